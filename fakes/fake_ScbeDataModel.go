@@ -4,8 +4,8 @@ package fakes
 import (
 	"sync"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/midoblgsm/ubiquity/local/scbe"
+	"github.com/midoblgsm/ubiquity/resources"
 )
 
 type FakeScbeDataModel struct {
@@ -82,6 +82,107 @@ type FakeScbeDataModel struct {
 	updateVolumeAttachToReturnsOnCall map[int]struct {
 		result1 error
 	}
+	InsertVolumesStub        func(volumes []scbe.ScbeVolume) error
+	insertVolumesMutex       sync.RWMutex
+	insertVolumesArgsForCall []struct {
+		volumes []scbe.ScbeVolume
+	}
+	insertVolumesReturns struct {
+		result1 error
+	}
+	insertVolumesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateVolumeWWNStub        func(volumeName string, wwn string) error
+	updateVolumeWWNMutex       sync.RWMutex
+	updateVolumeWWNArgsForCall []struct {
+		volumeName string
+		wwn        string
+	}
+	updateVolumeWWNReturns struct {
+		result1 error
+	}
+	updateVolumeWWNReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateVolumeStateStub        func(volumeName string, state string) error
+	updateVolumeStateMutex       sync.RWMutex
+	updateVolumeStateArgsForCall []struct {
+		volumeName string
+		state      string
+	}
+	updateVolumeStateReturns struct {
+		result1 error
+	}
+	updateVolumeStateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateVolumeQosStub        func(volumeName string, maxIops int, maxMbps int) error
+	updateVolumeQosMutex       sync.RWMutex
+	updateVolumeQosArgsForCall []struct {
+		volumeName string
+		maxIops    int
+		maxMbps    int
+	}
+	updateVolumeQosReturns struct {
+		result1 error
+	}
+	updateVolumeQosReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetVolumeAttachmentsStub        func(volumeName string) ([]resources.VolumeAttachment, error)
+	getVolumeAttachmentsMutex       sync.RWMutex
+	getVolumeAttachmentsArgsForCall []struct {
+		volumeName string
+	}
+	getVolumeAttachmentsReturns struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}
+	getVolumeAttachmentsReturnsOnCall map[int]struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}
+	AddVolumeAttachmentStub        func(volumeName string, scbeVolume scbe.ScbeVolume, host string, readOnly bool) error
+	addVolumeAttachmentMutex       sync.RWMutex
+	addVolumeAttachmentArgsForCall []struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		host       string
+		readOnly   bool
+	}
+	addVolumeAttachmentReturns struct {
+		result1 error
+	}
+	addVolumeAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveVolumeAttachmentStub        func(volumeName string, scbeVolume scbe.ScbeVolume, host string) error
+	removeVolumeAttachmentMutex       sync.RWMutex
+	removeVolumeAttachmentArgsForCall []struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		host       string
+	}
+	removeVolumeAttachmentReturns struct {
+		result1 error
+	}
+	removeVolumeAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetVolumeAttachmentsStub        func(volumeName string, scbeVolume scbe.ScbeVolume, hosts []string) error
+	setVolumeAttachmentsMutex       sync.RWMutex
+	setVolumeAttachmentsArgsForCall []struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		hosts      []string
+	}
+	setVolumeAttachmentsReturns struct {
+		result1 error
+	}
+	setVolumeAttachmentsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -372,6 +473,414 @@ func (fake *FakeScbeDataModel) UpdateVolumeAttachToReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeScbeDataModel) InsertVolumes(volumes []scbe.ScbeVolume) error {
+	var volumesCopy []scbe.ScbeVolume
+	if volumes != nil {
+		volumesCopy = make([]scbe.ScbeVolume, len(volumes))
+		copy(volumesCopy, volumes)
+	}
+	fake.insertVolumesMutex.Lock()
+	ret, specificReturn := fake.insertVolumesReturnsOnCall[len(fake.insertVolumesArgsForCall)]
+	fake.insertVolumesArgsForCall = append(fake.insertVolumesArgsForCall, struct {
+		volumes []scbe.ScbeVolume
+	}{volumesCopy})
+	fake.recordInvocation("InsertVolumes", []interface{}{volumesCopy})
+	fake.insertVolumesMutex.Unlock()
+	if fake.InsertVolumesStub != nil {
+		return fake.InsertVolumesStub(volumes)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.insertVolumesReturns.result1
+}
+
+func (fake *FakeScbeDataModel) InsertVolumesCallCount() int {
+	fake.insertVolumesMutex.RLock()
+	defer fake.insertVolumesMutex.RUnlock()
+	return len(fake.insertVolumesArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) InsertVolumesArgsForCall(i int) []scbe.ScbeVolume {
+	fake.insertVolumesMutex.RLock()
+	defer fake.insertVolumesMutex.RUnlock()
+	return fake.insertVolumesArgsForCall[i].volumes
+}
+
+func (fake *FakeScbeDataModel) InsertVolumesReturns(result1 error) {
+	fake.InsertVolumesStub = nil
+	fake.insertVolumesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) InsertVolumesReturnsOnCall(i int, result1 error) {
+	fake.InsertVolumesStub = nil
+	if fake.insertVolumesReturnsOnCall == nil {
+		fake.insertVolumesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.insertVolumesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeWWN(volumeName string, wwn string) error {
+	fake.updateVolumeWWNMutex.Lock()
+	ret, specificReturn := fake.updateVolumeWWNReturnsOnCall[len(fake.updateVolumeWWNArgsForCall)]
+	fake.updateVolumeWWNArgsForCall = append(fake.updateVolumeWWNArgsForCall, struct {
+		volumeName string
+		wwn        string
+	}{volumeName, wwn})
+	fake.recordInvocation("UpdateVolumeWWN", []interface{}{volumeName, wwn})
+	fake.updateVolumeWWNMutex.Unlock()
+	if fake.UpdateVolumeWWNStub != nil {
+		return fake.UpdateVolumeWWNStub(volumeName, wwn)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.updateVolumeWWNReturns.result1
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeWWNCallCount() int {
+	fake.updateVolumeWWNMutex.RLock()
+	defer fake.updateVolumeWWNMutex.RUnlock()
+	return len(fake.updateVolumeWWNArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeWWNArgsForCall(i int) (string, string) {
+	fake.updateVolumeWWNMutex.RLock()
+	defer fake.updateVolumeWWNMutex.RUnlock()
+	return fake.updateVolumeWWNArgsForCall[i].volumeName, fake.updateVolumeWWNArgsForCall[i].wwn
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeWWNReturns(result1 error) {
+	fake.UpdateVolumeWWNStub = nil
+	fake.updateVolumeWWNReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeWWNReturnsOnCall(i int, result1 error) {
+	fake.UpdateVolumeWWNStub = nil
+	if fake.updateVolumeWWNReturnsOnCall == nil {
+		fake.updateVolumeWWNReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateVolumeWWNReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeState(volumeName string, state string) error {
+	fake.updateVolumeStateMutex.Lock()
+	ret, specificReturn := fake.updateVolumeStateReturnsOnCall[len(fake.updateVolumeStateArgsForCall)]
+	fake.updateVolumeStateArgsForCall = append(fake.updateVolumeStateArgsForCall, struct {
+		volumeName string
+		state      string
+	}{volumeName, state})
+	fake.recordInvocation("UpdateVolumeState", []interface{}{volumeName, state})
+	fake.updateVolumeStateMutex.Unlock()
+	if fake.UpdateVolumeStateStub != nil {
+		return fake.UpdateVolumeStateStub(volumeName, state)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.updateVolumeStateReturns.result1
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeStateCallCount() int {
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	return len(fake.updateVolumeStateArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeStateArgsForCall(i int) (string, string) {
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	return fake.updateVolumeStateArgsForCall[i].volumeName, fake.updateVolumeStateArgsForCall[i].state
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeStateReturns(result1 error) {
+	fake.UpdateVolumeStateStub = nil
+	fake.updateVolumeStateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeStateReturnsOnCall(i int, result1 error) {
+	fake.UpdateVolumeStateStub = nil
+	if fake.updateVolumeStateReturnsOnCall == nil {
+		fake.updateVolumeStateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateVolumeStateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeQos(volumeName string, maxIops int, maxMbps int) error {
+	fake.updateVolumeQosMutex.Lock()
+	ret, specificReturn := fake.updateVolumeQosReturnsOnCall[len(fake.updateVolumeQosArgsForCall)]
+	fake.updateVolumeQosArgsForCall = append(fake.updateVolumeQosArgsForCall, struct {
+		volumeName string
+		maxIops    int
+		maxMbps    int
+	}{volumeName, maxIops, maxMbps})
+	fake.recordInvocation("UpdateVolumeQos", []interface{}{volumeName, maxIops, maxMbps})
+	fake.updateVolumeQosMutex.Unlock()
+	if fake.UpdateVolumeQosStub != nil {
+		return fake.UpdateVolumeQosStub(volumeName, maxIops, maxMbps)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.updateVolumeQosReturns.result1
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeQosCallCount() int {
+	fake.updateVolumeQosMutex.RLock()
+	defer fake.updateVolumeQosMutex.RUnlock()
+	return len(fake.updateVolumeQosArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeQosArgsForCall(i int) (string, int, int) {
+	fake.updateVolumeQosMutex.RLock()
+	defer fake.updateVolumeQosMutex.RUnlock()
+	return fake.updateVolumeQosArgsForCall[i].volumeName, fake.updateVolumeQosArgsForCall[i].maxIops, fake.updateVolumeQosArgsForCall[i].maxMbps
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeQosReturns(result1 error) {
+	fake.UpdateVolumeQosStub = nil
+	fake.updateVolumeQosReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) UpdateVolumeQosReturnsOnCall(i int, result1 error) {
+	fake.UpdateVolumeQosStub = nil
+	if fake.updateVolumeQosReturnsOnCall == nil {
+		fake.updateVolumeQosReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateVolumeQosReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) GetVolumeAttachments(volumeName string) ([]resources.VolumeAttachment, error) {
+	fake.getVolumeAttachmentsMutex.Lock()
+	ret, specificReturn := fake.getVolumeAttachmentsReturnsOnCall[len(fake.getVolumeAttachmentsArgsForCall)]
+	fake.getVolumeAttachmentsArgsForCall = append(fake.getVolumeAttachmentsArgsForCall, struct {
+		volumeName string
+	}{volumeName})
+	fake.recordInvocation("GetVolumeAttachments", []interface{}{volumeName})
+	fake.getVolumeAttachmentsMutex.Unlock()
+	if fake.GetVolumeAttachmentsStub != nil {
+		return fake.GetVolumeAttachmentsStub(volumeName)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getVolumeAttachmentsReturns.result1, fake.getVolumeAttachmentsReturns.result2
+}
+
+func (fake *FakeScbeDataModel) GetVolumeAttachmentsCallCount() int {
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	return len(fake.getVolumeAttachmentsArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) GetVolumeAttachmentsArgsForCall(i int) string {
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	return fake.getVolumeAttachmentsArgsForCall[i].volumeName
+}
+
+func (fake *FakeScbeDataModel) GetVolumeAttachmentsReturns(result1 []resources.VolumeAttachment, result2 error) {
+	fake.GetVolumeAttachmentsStub = nil
+	fake.getVolumeAttachmentsReturns = struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScbeDataModel) GetVolumeAttachmentsReturnsOnCall(i int, result1 []resources.VolumeAttachment, result2 error) {
+	fake.GetVolumeAttachmentsStub = nil
+	if fake.getVolumeAttachmentsReturnsOnCall == nil {
+		fake.getVolumeAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []resources.VolumeAttachment
+			result2 error
+		})
+	}
+	fake.getVolumeAttachmentsReturnsOnCall[i] = struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScbeDataModel) AddVolumeAttachment(volumeName string, scbeVolume scbe.ScbeVolume, host string, readOnly bool) error {
+	fake.addVolumeAttachmentMutex.Lock()
+	ret, specificReturn := fake.addVolumeAttachmentReturnsOnCall[len(fake.addVolumeAttachmentArgsForCall)]
+	fake.addVolumeAttachmentArgsForCall = append(fake.addVolumeAttachmentArgsForCall, struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		host       string
+		readOnly   bool
+	}{volumeName, scbeVolume, host, readOnly})
+	fake.recordInvocation("AddVolumeAttachment", []interface{}{volumeName, scbeVolume, host, readOnly})
+	fake.addVolumeAttachmentMutex.Unlock()
+	if fake.AddVolumeAttachmentStub != nil {
+		return fake.AddVolumeAttachmentStub(volumeName, scbeVolume, host, readOnly)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.addVolumeAttachmentReturns.result1
+}
+
+func (fake *FakeScbeDataModel) AddVolumeAttachmentCallCount() int {
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	return len(fake.addVolumeAttachmentArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) AddVolumeAttachmentArgsForCall(i int) (string, scbe.ScbeVolume, string, bool) {
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	return fake.addVolumeAttachmentArgsForCall[i].volumeName, fake.addVolumeAttachmentArgsForCall[i].scbeVolume, fake.addVolumeAttachmentArgsForCall[i].host, fake.addVolumeAttachmentArgsForCall[i].readOnly
+}
+
+func (fake *FakeScbeDataModel) AddVolumeAttachmentReturns(result1 error) {
+	fake.AddVolumeAttachmentStub = nil
+	fake.addVolumeAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) AddVolumeAttachmentReturnsOnCall(i int, result1 error) {
+	fake.AddVolumeAttachmentStub = nil
+	if fake.addVolumeAttachmentReturnsOnCall == nil {
+		fake.addVolumeAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addVolumeAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) RemoveVolumeAttachment(volumeName string, scbeVolume scbe.ScbeVolume, host string) error {
+	fake.removeVolumeAttachmentMutex.Lock()
+	ret, specificReturn := fake.removeVolumeAttachmentReturnsOnCall[len(fake.removeVolumeAttachmentArgsForCall)]
+	fake.removeVolumeAttachmentArgsForCall = append(fake.removeVolumeAttachmentArgsForCall, struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		host       string
+	}{volumeName, scbeVolume, host})
+	fake.recordInvocation("RemoveVolumeAttachment", []interface{}{volumeName, scbeVolume, host})
+	fake.removeVolumeAttachmentMutex.Unlock()
+	if fake.RemoveVolumeAttachmentStub != nil {
+		return fake.RemoveVolumeAttachmentStub(volumeName, scbeVolume, host)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.removeVolumeAttachmentReturns.result1
+}
+
+func (fake *FakeScbeDataModel) RemoveVolumeAttachmentCallCount() int {
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
+	return len(fake.removeVolumeAttachmentArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) RemoveVolumeAttachmentArgsForCall(i int) (string, scbe.ScbeVolume, string) {
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
+	return fake.removeVolumeAttachmentArgsForCall[i].volumeName, fake.removeVolumeAttachmentArgsForCall[i].scbeVolume, fake.removeVolumeAttachmentArgsForCall[i].host
+}
+
+func (fake *FakeScbeDataModel) RemoveVolumeAttachmentReturns(result1 error) {
+	fake.RemoveVolumeAttachmentStub = nil
+	fake.removeVolumeAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) RemoveVolumeAttachmentReturnsOnCall(i int, result1 error) {
+	fake.RemoveVolumeAttachmentStub = nil
+	if fake.removeVolumeAttachmentReturnsOnCall == nil {
+		fake.removeVolumeAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeVolumeAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) SetVolumeAttachments(volumeName string, scbeVolume scbe.ScbeVolume, hosts []string) error {
+	var hostsCopy []string
+	if hosts != nil {
+		hostsCopy = make([]string, len(hosts))
+		copy(hostsCopy, hosts)
+	}
+	fake.setVolumeAttachmentsMutex.Lock()
+	ret, specificReturn := fake.setVolumeAttachmentsReturnsOnCall[len(fake.setVolumeAttachmentsArgsForCall)]
+	fake.setVolumeAttachmentsArgsForCall = append(fake.setVolumeAttachmentsArgsForCall, struct {
+		volumeName string
+		scbeVolume scbe.ScbeVolume
+		hosts      []string
+	}{volumeName, scbeVolume, hostsCopy})
+	fake.recordInvocation("SetVolumeAttachments", []interface{}{volumeName, scbeVolume, hostsCopy})
+	fake.setVolumeAttachmentsMutex.Unlock()
+	if fake.SetVolumeAttachmentsStub != nil {
+		return fake.SetVolumeAttachmentsStub(volumeName, scbeVolume, hosts)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.setVolumeAttachmentsReturns.result1
+}
+
+func (fake *FakeScbeDataModel) SetVolumeAttachmentsCallCount() int {
+	fake.setVolumeAttachmentsMutex.RLock()
+	defer fake.setVolumeAttachmentsMutex.RUnlock()
+	return len(fake.setVolumeAttachmentsArgsForCall)
+}
+
+func (fake *FakeScbeDataModel) SetVolumeAttachmentsArgsForCall(i int) (string, scbe.ScbeVolume, []string) {
+	fake.setVolumeAttachmentsMutex.RLock()
+	defer fake.setVolumeAttachmentsMutex.RUnlock()
+	return fake.setVolumeAttachmentsArgsForCall[i].volumeName, fake.setVolumeAttachmentsArgsForCall[i].scbeVolume, fake.setVolumeAttachmentsArgsForCall[i].hosts
+}
+
+func (fake *FakeScbeDataModel) SetVolumeAttachmentsReturns(result1 error) {
+	fake.SetVolumeAttachmentsStub = nil
+	fake.setVolumeAttachmentsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeDataModel) SetVolumeAttachmentsReturnsOnCall(i int, result1 error) {
+	fake.SetVolumeAttachmentsStub = nil
+	if fake.setVolumeAttachmentsReturnsOnCall == nil {
+		fake.setVolumeAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setVolumeAttachmentsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeScbeDataModel) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -387,6 +896,22 @@ func (fake *FakeScbeDataModel) Invocations() map[string][][]interface{} {
 	defer fake.listVolumesMutex.RUnlock()
 	fake.updateVolumeAttachToMutex.RLock()
 	defer fake.updateVolumeAttachToMutex.RUnlock()
+	fake.insertVolumesMutex.RLock()
+	defer fake.insertVolumesMutex.RUnlock()
+	fake.updateVolumeWWNMutex.RLock()
+	defer fake.updateVolumeWWNMutex.RUnlock()
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	fake.updateVolumeQosMutex.RLock()
+	defer fake.updateVolumeQosMutex.RUnlock()
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
+	fake.setVolumeAttachmentsMutex.RLock()
+	defer fake.setVolumeAttachmentsMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -4,7 +4,6 @@ package fakes
 import (
 	"sync"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/midoblgsm/ubiquity/local/spectrumscale"
 	"github.com/midoblgsm/ubiquity/resources"
 )
@@ -129,6 +128,69 @@ type FakeSpectrumDataModel struct {
 	updateVolumeMountpointReturnsOnCall map[int]struct {
 		result1 error
 	}
+	InsertPreexistingFilesetVolumesStub        func(filesets []string, filesystem string, opts map[string]string) error
+	insertPreexistingFilesetVolumesMutex       sync.RWMutex
+	insertPreexistingFilesetVolumesArgsForCall []struct {
+		filesets   []string
+		filesystem string
+		opts       map[string]string
+	}
+	insertPreexistingFilesetVolumesReturns struct {
+		result1 error
+	}
+	insertPreexistingFilesetVolumesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateVolumeStateStub        func(name string, state string) error
+	updateVolumeStateMutex       sync.RWMutex
+	updateVolumeStateArgsForCall []struct {
+		name  string
+		state string
+	}
+	updateVolumeStateReturns struct {
+		result1 error
+	}
+	updateVolumeStateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetVolumeAttachmentsStub        func(name string) ([]resources.VolumeAttachment, error)
+	getVolumeAttachmentsMutex       sync.RWMutex
+	getVolumeAttachmentsArgsForCall []struct {
+		name string
+	}
+	getVolumeAttachmentsReturns struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}
+	getVolumeAttachmentsReturnsOnCall map[int]struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}
+	AddVolumeAttachmentStub        func(name string, host string, readOnly bool) error
+	addVolumeAttachmentMutex       sync.RWMutex
+	addVolumeAttachmentArgsForCall []struct {
+		name     string
+		host     string
+		readOnly bool
+	}
+	addVolumeAttachmentReturns struct {
+		result1 error
+	}
+	addVolumeAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveVolumeAttachmentStub        func(name string, host string) error
+	removeVolumeAttachmentMutex       sync.RWMutex
+	removeVolumeAttachmentArgsForCall []struct {
+		name string
+		host string
+	}
+	removeVolumeAttachmentReturns struct {
+		result1 error
+	}
+	removeVolumeAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -589,6 +651,260 @@ func (fake *FakeSpectrumDataModel) UpdateVolumeMountpointReturnsOnCall(i int, re
 	}{result1}
 }
 
+func (fake *FakeSpectrumDataModel) InsertPreexistingFilesetVolumes(filesets []string, filesystem string, opts map[string]string) error {
+	var filesetsCopy []string
+	if filesets != nil {
+		filesetsCopy = make([]string, len(filesets))
+		copy(filesetsCopy, filesets)
+	}
+	fake.insertPreexistingFilesetVolumesMutex.Lock()
+	ret, specificReturn := fake.insertPreexistingFilesetVolumesReturnsOnCall[len(fake.insertPreexistingFilesetVolumesArgsForCall)]
+	fake.insertPreexistingFilesetVolumesArgsForCall = append(fake.insertPreexistingFilesetVolumesArgsForCall, struct {
+		filesets   []string
+		filesystem string
+		opts       map[string]string
+	}{filesetsCopy, filesystem, opts})
+	fake.recordInvocation("InsertPreexistingFilesetVolumes", []interface{}{filesetsCopy, filesystem, opts})
+	fake.insertPreexistingFilesetVolumesMutex.Unlock()
+	if fake.InsertPreexistingFilesetVolumesStub != nil {
+		return fake.InsertPreexistingFilesetVolumesStub(filesets, filesystem, opts)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.insertPreexistingFilesetVolumesReturns.result1
+}
+
+func (fake *FakeSpectrumDataModel) InsertPreexistingFilesetVolumesCallCount() int {
+	fake.insertPreexistingFilesetVolumesMutex.RLock()
+	defer fake.insertPreexistingFilesetVolumesMutex.RUnlock()
+	return len(fake.insertPreexistingFilesetVolumesArgsForCall)
+}
+
+func (fake *FakeSpectrumDataModel) InsertPreexistingFilesetVolumesArgsForCall(i int) ([]string, string, map[string]string) {
+	fake.insertPreexistingFilesetVolumesMutex.RLock()
+	defer fake.insertPreexistingFilesetVolumesMutex.RUnlock()
+	return fake.insertPreexistingFilesetVolumesArgsForCall[i].filesets, fake.insertPreexistingFilesetVolumesArgsForCall[i].filesystem, fake.insertPreexistingFilesetVolumesArgsForCall[i].opts
+}
+
+func (fake *FakeSpectrumDataModel) InsertPreexistingFilesetVolumesReturns(result1 error) {
+	fake.InsertPreexistingFilesetVolumesStub = nil
+	fake.insertPreexistingFilesetVolumesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) InsertPreexistingFilesetVolumesReturnsOnCall(i int, result1 error) {
+	fake.InsertPreexistingFilesetVolumesStub = nil
+	if fake.insertPreexistingFilesetVolumesReturnsOnCall == nil {
+		fake.insertPreexistingFilesetVolumesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.insertPreexistingFilesetVolumesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) UpdateVolumeState(name string, state string) error {
+	fake.updateVolumeStateMutex.Lock()
+	ret, specificReturn := fake.updateVolumeStateReturnsOnCall[len(fake.updateVolumeStateArgsForCall)]
+	fake.updateVolumeStateArgsForCall = append(fake.updateVolumeStateArgsForCall, struct {
+		name  string
+		state string
+	}{name, state})
+	fake.recordInvocation("UpdateVolumeState", []interface{}{name, state})
+	fake.updateVolumeStateMutex.Unlock()
+	if fake.UpdateVolumeStateStub != nil {
+		return fake.UpdateVolumeStateStub(name, state)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.updateVolumeStateReturns.result1
+}
+
+func (fake *FakeSpectrumDataModel) UpdateVolumeStateCallCount() int {
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	return len(fake.updateVolumeStateArgsForCall)
+}
+
+func (fake *FakeSpectrumDataModel) UpdateVolumeStateArgsForCall(i int) (string, string) {
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	return fake.updateVolumeStateArgsForCall[i].name, fake.updateVolumeStateArgsForCall[i].state
+}
+
+func (fake *FakeSpectrumDataModel) UpdateVolumeStateReturns(result1 error) {
+	fake.UpdateVolumeStateStub = nil
+	fake.updateVolumeStateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) UpdateVolumeStateReturnsOnCall(i int, result1 error) {
+	fake.UpdateVolumeStateStub = nil
+	if fake.updateVolumeStateReturnsOnCall == nil {
+		fake.updateVolumeStateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateVolumeStateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) GetVolumeAttachments(name string) ([]resources.VolumeAttachment, error) {
+	fake.getVolumeAttachmentsMutex.Lock()
+	ret, specificReturn := fake.getVolumeAttachmentsReturnsOnCall[len(fake.getVolumeAttachmentsArgsForCall)]
+	fake.getVolumeAttachmentsArgsForCall = append(fake.getVolumeAttachmentsArgsForCall, struct {
+		name string
+	}{name})
+	fake.recordInvocation("GetVolumeAttachments", []interface{}{name})
+	fake.getVolumeAttachmentsMutex.Unlock()
+	if fake.GetVolumeAttachmentsStub != nil {
+		return fake.GetVolumeAttachmentsStub(name)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getVolumeAttachmentsReturns.result1, fake.getVolumeAttachmentsReturns.result2
+}
+
+func (fake *FakeSpectrumDataModel) GetVolumeAttachmentsCallCount() int {
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	return len(fake.getVolumeAttachmentsArgsForCall)
+}
+
+func (fake *FakeSpectrumDataModel) GetVolumeAttachmentsArgsForCall(i int) string {
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	return fake.getVolumeAttachmentsArgsForCall[i].name
+}
+
+func (fake *FakeSpectrumDataModel) GetVolumeAttachmentsReturns(result1 []resources.VolumeAttachment, result2 error) {
+	fake.GetVolumeAttachmentsStub = nil
+	fake.getVolumeAttachmentsReturns = struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpectrumDataModel) GetVolumeAttachmentsReturnsOnCall(i int, result1 []resources.VolumeAttachment, result2 error) {
+	fake.GetVolumeAttachmentsStub = nil
+	if fake.getVolumeAttachmentsReturnsOnCall == nil {
+		fake.getVolumeAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []resources.VolumeAttachment
+			result2 error
+		})
+	}
+	fake.getVolumeAttachmentsReturnsOnCall[i] = struct {
+		result1 []resources.VolumeAttachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpectrumDataModel) AddVolumeAttachment(name string, host string, readOnly bool) error {
+	fake.addVolumeAttachmentMutex.Lock()
+	ret, specificReturn := fake.addVolumeAttachmentReturnsOnCall[len(fake.addVolumeAttachmentArgsForCall)]
+	fake.addVolumeAttachmentArgsForCall = append(fake.addVolumeAttachmentArgsForCall, struct {
+		name     string
+		host     string
+		readOnly bool
+	}{name, host, readOnly})
+	fake.recordInvocation("AddVolumeAttachment", []interface{}{name, host, readOnly})
+	fake.addVolumeAttachmentMutex.Unlock()
+	if fake.AddVolumeAttachmentStub != nil {
+		return fake.AddVolumeAttachmentStub(name, host, readOnly)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.addVolumeAttachmentReturns.result1
+}
+
+func (fake *FakeSpectrumDataModel) AddVolumeAttachmentCallCount() int {
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	return len(fake.addVolumeAttachmentArgsForCall)
+}
+
+func (fake *FakeSpectrumDataModel) AddVolumeAttachmentArgsForCall(i int) (string, string, bool) {
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	return fake.addVolumeAttachmentArgsForCall[i].name, fake.addVolumeAttachmentArgsForCall[i].host, fake.addVolumeAttachmentArgsForCall[i].readOnly
+}
+
+func (fake *FakeSpectrumDataModel) AddVolumeAttachmentReturns(result1 error) {
+	fake.AddVolumeAttachmentStub = nil
+	fake.addVolumeAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) AddVolumeAttachmentReturnsOnCall(i int, result1 error) {
+	fake.AddVolumeAttachmentStub = nil
+	if fake.addVolumeAttachmentReturnsOnCall == nil {
+		fake.addVolumeAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addVolumeAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) RemoveVolumeAttachment(name string, host string) error {
+	fake.removeVolumeAttachmentMutex.Lock()
+	ret, specificReturn := fake.removeVolumeAttachmentReturnsOnCall[len(fake.removeVolumeAttachmentArgsForCall)]
+	fake.removeVolumeAttachmentArgsForCall = append(fake.removeVolumeAttachmentArgsForCall, struct {
+		name string
+		host string
+	}{name, host})
+	fake.recordInvocation("RemoveVolumeAttachment", []interface{}{name, host})
+	fake.removeVolumeAttachmentMutex.Unlock()
+	if fake.RemoveVolumeAttachmentStub != nil {
+		return fake.RemoveVolumeAttachmentStub(name, host)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.removeVolumeAttachmentReturns.result1
+}
+
+func (fake *FakeSpectrumDataModel) RemoveVolumeAttachmentCallCount() int {
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
+	return len(fake.removeVolumeAttachmentArgsForCall)
+}
+
+func (fake *FakeSpectrumDataModel) RemoveVolumeAttachmentArgsForCall(i int) (string, string) {
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
+	return fake.removeVolumeAttachmentArgsForCall[i].name, fake.removeVolumeAttachmentArgsForCall[i].host
+}
+
+func (fake *FakeSpectrumDataModel) RemoveVolumeAttachmentReturns(result1 error) {
+	fake.RemoveVolumeAttachmentStub = nil
+	fake.removeVolumeAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSpectrumDataModel) RemoveVolumeAttachmentReturnsOnCall(i int, result1 error) {
+	fake.RemoveVolumeAttachmentStub = nil
+	if fake.removeVolumeAttachmentReturnsOnCall == nil {
+		fake.removeVolumeAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeVolumeAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeSpectrumDataModel) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -612,6 +928,16 @@ func (fake *FakeSpectrumDataModel) Invocations() map[string][][]interface{} {
 	defer fake.listVolumesMutex.RUnlock()
 	fake.updateVolumeMountpointMutex.RLock()
 	defer fake.updateVolumeMountpointMutex.RUnlock()
+	fake.insertPreexistingFilesetVolumesMutex.RLock()
+	defer fake.insertPreexistingFilesetVolumesMutex.RUnlock()
+	fake.updateVolumeStateMutex.RLock()
+	defer fake.updateVolumeStateMutex.RUnlock()
+	fake.getVolumeAttachmentsMutex.RLock()
+	defer fake.getVolumeAttachmentsMutex.RUnlock()
+	fake.addVolumeAttachmentMutex.RLock()
+	defer fake.addVolumeAttachmentMutex.RUnlock()
+	fake.removeVolumeAttachmentMutex.RLock()
+	defer fake.removeVolumeAttachmentMutex.RUnlock()
 	return fake.invocations
 }
 
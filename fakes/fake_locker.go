@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/midoblgsm/ubiquity/utils"
@@ -28,6 +29,52 @@ type FakeLocker struct {
 	readUnlockArgsForCall []struct {
 		name string
 	}
+	WriteLockContextStub        func(ctx context.Context, name string) error
+	writeLockContextMutex       sync.RWMutex
+	writeLockContextArgsForCall []struct {
+		ctx  context.Context
+		name string
+	}
+	writeLockContextReturns struct {
+		result1 error
+	}
+	writeLockContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReadLockContextStub        func(ctx context.Context, name string) error
+	readLockContextMutex       sync.RWMutex
+	readLockContextArgsForCall []struct {
+		ctx  context.Context
+		name string
+	}
+	readLockContextReturns struct {
+		result1 error
+	}
+	readLockContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	TryWriteLockStub        func(name string) bool
+	tryWriteLockMutex       sync.RWMutex
+	tryWriteLockArgsForCall []struct {
+		name string
+	}
+	tryWriteLockReturns struct {
+		result1 bool
+	}
+	tryWriteLockReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	TryReadLockStub        func(name string) bool
+	tryReadLockMutex       sync.RWMutex
+	tryReadLockArgsForCall []struct {
+		name string
+	}
+	tryReadLockReturns struct {
+		result1 bool
+	}
+	tryReadLockReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -128,6 +175,200 @@ func (fake *FakeLocker) ReadUnlockArgsForCall(i int) string {
 	return fake.readUnlockArgsForCall[i].name
 }
 
+func (fake *FakeLocker) WriteLockContext(ctx context.Context, name string) error {
+	fake.writeLockContextMutex.Lock()
+	ret, specificReturn := fake.writeLockContextReturnsOnCall[len(fake.writeLockContextArgsForCall)]
+	fake.writeLockContextArgsForCall = append(fake.writeLockContextArgsForCall, struct {
+		ctx  context.Context
+		name string
+	}{ctx, name})
+	fake.recordInvocation("WriteLockContext", []interface{}{ctx, name})
+	fake.writeLockContextMutex.Unlock()
+	if fake.WriteLockContextStub != nil {
+		return fake.WriteLockContextStub(ctx, name)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.writeLockContextReturns.result1
+}
+
+func (fake *FakeLocker) WriteLockContextCallCount() int {
+	fake.writeLockContextMutex.RLock()
+	defer fake.writeLockContextMutex.RUnlock()
+	return len(fake.writeLockContextArgsForCall)
+}
+
+func (fake *FakeLocker) WriteLockContextArgsForCall(i int) (context.Context, string) {
+	fake.writeLockContextMutex.RLock()
+	defer fake.writeLockContextMutex.RUnlock()
+	return fake.writeLockContextArgsForCall[i].ctx, fake.writeLockContextArgsForCall[i].name
+}
+
+func (fake *FakeLocker) WriteLockContextReturns(result1 error) {
+	fake.WriteLockContextStub = nil
+	fake.writeLockContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocker) WriteLockContextReturnsOnCall(i int, result1 error) {
+	fake.WriteLockContextStub = nil
+	if fake.writeLockContextReturnsOnCall == nil {
+		fake.writeLockContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeLockContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocker) ReadLockContext(ctx context.Context, name string) error {
+	fake.readLockContextMutex.Lock()
+	ret, specificReturn := fake.readLockContextReturnsOnCall[len(fake.readLockContextArgsForCall)]
+	fake.readLockContextArgsForCall = append(fake.readLockContextArgsForCall, struct {
+		ctx  context.Context
+		name string
+	}{ctx, name})
+	fake.recordInvocation("ReadLockContext", []interface{}{ctx, name})
+	fake.readLockContextMutex.Unlock()
+	if fake.ReadLockContextStub != nil {
+		return fake.ReadLockContextStub(ctx, name)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.readLockContextReturns.result1
+}
+
+func (fake *FakeLocker) ReadLockContextCallCount() int {
+	fake.readLockContextMutex.RLock()
+	defer fake.readLockContextMutex.RUnlock()
+	return len(fake.readLockContextArgsForCall)
+}
+
+func (fake *FakeLocker) ReadLockContextArgsForCall(i int) (context.Context, string) {
+	fake.readLockContextMutex.RLock()
+	defer fake.readLockContextMutex.RUnlock()
+	return fake.readLockContextArgsForCall[i].ctx, fake.readLockContextArgsForCall[i].name
+}
+
+func (fake *FakeLocker) ReadLockContextReturns(result1 error) {
+	fake.ReadLockContextStub = nil
+	fake.readLockContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocker) ReadLockContextReturnsOnCall(i int, result1 error) {
+	fake.ReadLockContextStub = nil
+	if fake.readLockContextReturnsOnCall == nil {
+		fake.readLockContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.readLockContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocker) TryWriteLock(name string) bool {
+	fake.tryWriteLockMutex.Lock()
+	ret, specificReturn := fake.tryWriteLockReturnsOnCall[len(fake.tryWriteLockArgsForCall)]
+	fake.tryWriteLockArgsForCall = append(fake.tryWriteLockArgsForCall, struct {
+		name string
+	}{name})
+	fake.recordInvocation("TryWriteLock", []interface{}{name})
+	fake.tryWriteLockMutex.Unlock()
+	if fake.TryWriteLockStub != nil {
+		return fake.TryWriteLockStub(name)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.tryWriteLockReturns.result1
+}
+
+func (fake *FakeLocker) TryWriteLockCallCount() int {
+	fake.tryWriteLockMutex.RLock()
+	defer fake.tryWriteLockMutex.RUnlock()
+	return len(fake.tryWriteLockArgsForCall)
+}
+
+func (fake *FakeLocker) TryWriteLockArgsForCall(i int) string {
+	fake.tryWriteLockMutex.RLock()
+	defer fake.tryWriteLockMutex.RUnlock()
+	return fake.tryWriteLockArgsForCall[i].name
+}
+
+func (fake *FakeLocker) TryWriteLockReturns(result1 bool) {
+	fake.TryWriteLockStub = nil
+	fake.tryWriteLockReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocker) TryWriteLockReturnsOnCall(i int, result1 bool) {
+	fake.TryWriteLockStub = nil
+	if fake.tryWriteLockReturnsOnCall == nil {
+		fake.tryWriteLockReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.tryWriteLockReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocker) TryReadLock(name string) bool {
+	fake.tryReadLockMutex.Lock()
+	ret, specificReturn := fake.tryReadLockReturnsOnCall[len(fake.tryReadLockArgsForCall)]
+	fake.tryReadLockArgsForCall = append(fake.tryReadLockArgsForCall, struct {
+		name string
+	}{name})
+	fake.recordInvocation("TryReadLock", []interface{}{name})
+	fake.tryReadLockMutex.Unlock()
+	if fake.TryReadLockStub != nil {
+		return fake.TryReadLockStub(name)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.tryReadLockReturns.result1
+}
+
+func (fake *FakeLocker) TryReadLockCallCount() int {
+	fake.tryReadLockMutex.RLock()
+	defer fake.tryReadLockMutex.RUnlock()
+	return len(fake.tryReadLockArgsForCall)
+}
+
+func (fake *FakeLocker) TryReadLockArgsForCall(i int) string {
+	fake.tryReadLockMutex.RLock()
+	defer fake.tryReadLockMutex.RUnlock()
+	return fake.tryReadLockArgsForCall[i].name
+}
+
+func (fake *FakeLocker) TryReadLockReturns(result1 bool) {
+	fake.TryReadLockStub = nil
+	fake.tryReadLockReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeLocker) TryReadLockReturnsOnCall(i int, result1 bool) {
+	fake.TryReadLockStub = nil
+	if fake.tryReadLockReturnsOnCall == nil {
+		fake.tryReadLockReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.tryReadLockReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeLocker) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -139,6 +380,14 @@ func (fake *FakeLocker) Invocations() map[string][][]interface{} {
 	defer fake.readLockMutex.RUnlock()
 	fake.readUnlockMutex.RLock()
 	defer fake.readUnlockMutex.RUnlock()
+	fake.writeLockContextMutex.RLock()
+	defer fake.writeLockContextMutex.RUnlock()
+	fake.readLockContextMutex.RLock()
+	defer fake.readLockContextMutex.RUnlock()
+	fake.tryWriteLockMutex.RLock()
+	defer fake.tryWriteLockMutex.RUnlock()
+	fake.tryReadLockMutex.RLock()
+	defer fake.tryReadLockMutex.RUnlock()
 	return fake.invocations
 }
 
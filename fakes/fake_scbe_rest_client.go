@@ -108,6 +108,43 @@ type FakeScbeRestClient struct {
 		result1 bool
 		result2 error
 	}
+	ListServicesStub        func() ([]scbe.ScbeStorageService, error)
+	listServicesMutex       sync.RWMutex
+	listServicesArgsForCall []struct{}
+	listServicesReturns     struct {
+		result1 []scbe.ScbeStorageService
+		result2 error
+	}
+	listServicesReturnsOnCall map[int]struct {
+		result1 []scbe.ScbeStorageService
+		result2 error
+	}
+	SetVolumeQosStub        func(wwn string, maxIops int, maxMbps int) error
+	setVolumeQosMutex       sync.RWMutex
+	setVolumeQosArgsForCall []struct {
+		wwn     string
+		maxIops int
+		maxMbps int
+	}
+	setVolumeQosReturns struct {
+		result1 error
+	}
+	setVolumeQosReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetVolMappingsStub        func(wwn string) ([]string, error)
+	getVolMappingsMutex       sync.RWMutex
+	getVolMappingsArgsForCall []struct {
+		wwn string
+	}
+	getVolMappingsReturns struct {
+		result1 []string
+		result2 error
+	}
+	getVolMappingsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -507,6 +544,150 @@ func (fake *FakeScbeRestClient) ServiceExistReturnsOnCall(i int, result1 bool, r
 	}{result1, result2}
 }
 
+func (fake *FakeScbeRestClient) ListServices() ([]scbe.ScbeStorageService, error) {
+	fake.listServicesMutex.Lock()
+	ret, specificReturn := fake.listServicesReturnsOnCall[len(fake.listServicesArgsForCall)]
+	fake.listServicesArgsForCall = append(fake.listServicesArgsForCall, struct{}{})
+	fake.recordInvocation("ListServices", []interface{}{})
+	fake.listServicesMutex.Unlock()
+	if fake.ListServicesStub != nil {
+		return fake.ListServicesStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.listServicesReturns.result1, fake.listServicesReturns.result2
+}
+
+func (fake *FakeScbeRestClient) ListServicesCallCount() int {
+	fake.listServicesMutex.RLock()
+	defer fake.listServicesMutex.RUnlock()
+	return len(fake.listServicesArgsForCall)
+}
+
+func (fake *FakeScbeRestClient) ListServicesReturns(result1 []scbe.ScbeStorageService, result2 error) {
+	fake.ListServicesStub = nil
+	fake.listServicesReturns = struct {
+		result1 []scbe.ScbeStorageService
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScbeRestClient) ListServicesReturnsOnCall(i int, result1 []scbe.ScbeStorageService, result2 error) {
+	fake.ListServicesStub = nil
+	if fake.listServicesReturnsOnCall == nil {
+		fake.listServicesReturnsOnCall = make(map[int]struct {
+			result1 []scbe.ScbeStorageService
+			result2 error
+		})
+	}
+	fake.listServicesReturnsOnCall[i] = struct {
+		result1 []scbe.ScbeStorageService
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScbeRestClient) SetVolumeQos(wwn string, maxIops int, maxMbps int) error {
+	fake.setVolumeQosMutex.Lock()
+	ret, specificReturn := fake.setVolumeQosReturnsOnCall[len(fake.setVolumeQosArgsForCall)]
+	fake.setVolumeQosArgsForCall = append(fake.setVolumeQosArgsForCall, struct {
+		wwn     string
+		maxIops int
+		maxMbps int
+	}{wwn, maxIops, maxMbps})
+	fake.recordInvocation("SetVolumeQos", []interface{}{wwn, maxIops, maxMbps})
+	fake.setVolumeQosMutex.Unlock()
+	if fake.SetVolumeQosStub != nil {
+		return fake.SetVolumeQosStub(wwn, maxIops, maxMbps)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.setVolumeQosReturns.result1
+}
+
+func (fake *FakeScbeRestClient) SetVolumeQosCallCount() int {
+	fake.setVolumeQosMutex.RLock()
+	defer fake.setVolumeQosMutex.RUnlock()
+	return len(fake.setVolumeQosArgsForCall)
+}
+
+func (fake *FakeScbeRestClient) SetVolumeQosArgsForCall(i int) (string, int, int) {
+	fake.setVolumeQosMutex.RLock()
+	defer fake.setVolumeQosMutex.RUnlock()
+	return fake.setVolumeQosArgsForCall[i].wwn, fake.setVolumeQosArgsForCall[i].maxIops, fake.setVolumeQosArgsForCall[i].maxMbps
+}
+
+func (fake *FakeScbeRestClient) SetVolumeQosReturns(result1 error) {
+	fake.SetVolumeQosStub = nil
+	fake.setVolumeQosReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeRestClient) SetVolumeQosReturnsOnCall(i int, result1 error) {
+	fake.SetVolumeQosStub = nil
+	if fake.setVolumeQosReturnsOnCall == nil {
+		fake.setVolumeQosReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setVolumeQosReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScbeRestClient) GetVolMappings(wwn string) ([]string, error) {
+	fake.getVolMappingsMutex.Lock()
+	ret, specificReturn := fake.getVolMappingsReturnsOnCall[len(fake.getVolMappingsArgsForCall)]
+	fake.getVolMappingsArgsForCall = append(fake.getVolMappingsArgsForCall, struct {
+		wwn string
+	}{wwn})
+	fake.recordInvocation("GetVolMappings", []interface{}{wwn})
+	fake.getVolMappingsMutex.Unlock()
+	if fake.GetVolMappingsStub != nil {
+		return fake.GetVolMappingsStub(wwn)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getVolMappingsReturns.result1, fake.getVolMappingsReturns.result2
+}
+
+func (fake *FakeScbeRestClient) GetVolMappingsCallCount() int {
+	fake.getVolMappingsMutex.RLock()
+	defer fake.getVolMappingsMutex.RUnlock()
+	return len(fake.getVolMappingsArgsForCall)
+}
+
+func (fake *FakeScbeRestClient) GetVolMappingsArgsForCall(i int) string {
+	fake.getVolMappingsMutex.RLock()
+	defer fake.getVolMappingsMutex.RUnlock()
+	return fake.getVolMappingsArgsForCall[i].wwn
+}
+
+func (fake *FakeScbeRestClient) GetVolMappingsReturns(result1 []string, result2 error) {
+	fake.GetVolMappingsStub = nil
+	fake.getVolMappingsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScbeRestClient) GetVolMappingsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.GetVolMappingsStub = nil
+	if fake.getVolMappingsReturnsOnCall == nil {
+		fake.getVolMappingsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.getVolMappingsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeScbeRestClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -526,6 +707,12 @@ func (fake *FakeScbeRestClient) Invocations() map[string][][]interface{} {
 	defer fake.getVolMappingMutex.RUnlock()
 	fake.serviceExistMutex.RLock()
 	defer fake.serviceExistMutex.RUnlock()
+	fake.listServicesMutex.RLock()
+	defer fake.listServicesMutex.RUnlock()
+	fake.setVolumeQosMutex.RLock()
+	defer fake.setVolumeQosMutex.RUnlock()
+	fake.getVolMappingsMutex.RLock()
+	defer fake.getVolMappingsMutex.RUnlock()
 	return fake.invocations
 }
 
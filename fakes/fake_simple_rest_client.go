@@ -58,6 +58,20 @@ type FakeSimpleRestClient struct {
 	deleteReturnsOnCall map[int]struct {
 		result1 error
 	}
+	PutStub        func(resource_url string, payload []byte, exitStatus int, v interface{}) error
+	putMutex       sync.RWMutex
+	putArgsForCall []struct {
+		resource_url string
+		payload      []byte
+		exitStatus   int
+		v            interface{}
+	}
+	putReturns struct {
+		result1 error
+	}
+	putReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -264,6 +278,62 @@ func (fake *FakeSimpleRestClient) DeleteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeSimpleRestClient) Put(resource_url string, payload []byte, exitStatus int, v interface{}) error {
+	var payloadCopy []byte
+	if payload != nil {
+		payloadCopy = make([]byte, len(payload))
+		copy(payloadCopy, payload)
+	}
+	fake.putMutex.Lock()
+	ret, specificReturn := fake.putReturnsOnCall[len(fake.putArgsForCall)]
+	fake.putArgsForCall = append(fake.putArgsForCall, struct {
+		resource_url string
+		payload      []byte
+		exitStatus   int
+		v            interface{}
+	}{resource_url, payloadCopy, exitStatus, v})
+	fake.recordInvocation("Put", []interface{}{resource_url, payloadCopy, exitStatus, v})
+	fake.putMutex.Unlock()
+	if fake.PutStub != nil {
+		return fake.PutStub(resource_url, payload, exitStatus, v)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.putReturns.result1
+}
+
+func (fake *FakeSimpleRestClient) PutCallCount() int {
+	fake.putMutex.RLock()
+	defer fake.putMutex.RUnlock()
+	return len(fake.putArgsForCall)
+}
+
+func (fake *FakeSimpleRestClient) PutArgsForCall(i int) (string, []byte, int, interface{}) {
+	fake.putMutex.RLock()
+	defer fake.putMutex.RUnlock()
+	return fake.putArgsForCall[i].resource_url, fake.putArgsForCall[i].payload, fake.putArgsForCall[i].exitStatus, fake.putArgsForCall[i].v
+}
+
+func (fake *FakeSimpleRestClient) PutReturns(result1 error) {
+	fake.PutStub = nil
+	fake.putReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSimpleRestClient) PutReturnsOnCall(i int, result1 error) {
+	fake.PutStub = nil
+	if fake.putReturnsOnCall == nil {
+		fake.putReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.putReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeSimpleRestClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -275,6 +345,8 @@ func (fake *FakeSimpleRestClient) Invocations() map[string][][]interface{} {
 	defer fake.getMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
+	fake.putMutex.RLock()
+	defer fake.putMutex.RUnlock()
 	return fake.invocations
 }
 
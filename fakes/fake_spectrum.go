@@ -68,6 +68,19 @@ type FakeSpectrumScaleConnector struct {
 		result1 string
 		result2 error
 	}
+	GetFilesystemCapacityStub        func(filesystemName string) (connectors.FilesystemCapacity, error)
+	getFilesystemCapacityMutex       sync.RWMutex
+	getFilesystemCapacityArgsForCall []struct {
+		filesystemName string
+	}
+	getFilesystemCapacityReturns struct {
+		result1 connectors.FilesystemCapacity
+		result2 error
+	}
+	getFilesystemCapacityReturnsOnCall map[int]struct {
+		result1 connectors.FilesystemCapacity
+		result2 error
+	}
 	CreateFilesetStub        func(filesystemName string, filesetName string, opts map[string]string) error
 	createFilesetMutex       sync.RWMutex
 	createFilesetArgsForCall []struct {
@@ -448,6 +461,57 @@ func (fake *FakeSpectrumScaleConnector) GetFilesystemMountpointReturnsOnCall(i i
 	}{result1, result2}
 }
 
+func (fake *FakeSpectrumScaleConnector) GetFilesystemCapacity(filesystemName string) (connectors.FilesystemCapacity, error) {
+	fake.getFilesystemCapacityMutex.Lock()
+	ret, specificReturn := fake.getFilesystemCapacityReturnsOnCall[len(fake.getFilesystemCapacityArgsForCall)]
+	fake.getFilesystemCapacityArgsForCall = append(fake.getFilesystemCapacityArgsForCall, struct {
+		filesystemName string
+	}{filesystemName})
+	fake.recordInvocation("GetFilesystemCapacity", []interface{}{filesystemName})
+	fake.getFilesystemCapacityMutex.Unlock()
+	if fake.GetFilesystemCapacityStub != nil {
+		return fake.GetFilesystemCapacityStub(filesystemName)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.getFilesystemCapacityReturns.result1, fake.getFilesystemCapacityReturns.result2
+}
+
+func (fake *FakeSpectrumScaleConnector) GetFilesystemCapacityCallCount() int {
+	fake.getFilesystemCapacityMutex.RLock()
+	defer fake.getFilesystemCapacityMutex.RUnlock()
+	return len(fake.getFilesystemCapacityArgsForCall)
+}
+
+func (fake *FakeSpectrumScaleConnector) GetFilesystemCapacityArgsForCall(i int) string {
+	fake.getFilesystemCapacityMutex.RLock()
+	defer fake.getFilesystemCapacityMutex.RUnlock()
+	return fake.getFilesystemCapacityArgsForCall[i].filesystemName
+}
+
+func (fake *FakeSpectrumScaleConnector) GetFilesystemCapacityReturns(result1 connectors.FilesystemCapacity, result2 error) {
+	fake.GetFilesystemCapacityStub = nil
+	fake.getFilesystemCapacityReturns = struct {
+		result1 connectors.FilesystemCapacity
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpectrumScaleConnector) GetFilesystemCapacityReturnsOnCall(i int, result1 connectors.FilesystemCapacity, result2 error) {
+	fake.GetFilesystemCapacityStub = nil
+	if fake.getFilesystemCapacityReturnsOnCall == nil {
+		fake.getFilesystemCapacityReturnsOnCall = make(map[int]struct {
+			result1 connectors.FilesystemCapacity
+			result2 error
+		})
+	}
+	fake.getFilesystemCapacityReturnsOnCall[i] = struct {
+		result1 connectors.FilesystemCapacity
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSpectrumScaleConnector) CreateFileset(filesystemName string, filesetName string, opts map[string]string) error {
 	fake.createFilesetMutex.Lock()
 	ret, specificReturn := fake.createFilesetReturnsOnCall[len(fake.createFilesetArgsForCall)]
@@ -1012,6 +1076,8 @@ func (fake *FakeSpectrumScaleConnector) Invocations() map[string][][]interface{}
 	defer fake.listFilesystemsMutex.RUnlock()
 	fake.getFilesystemMountpointMutex.RLock()
 	defer fake.getFilesystemMountpointMutex.RUnlock()
+	fake.getFilesystemCapacityMutex.RLock()
+	defer fake.getFilesystemCapacityMutex.RUnlock()
 	fake.createFilesetMutex.RLock()
 	defer fake.createFilesetMutex.RUnlock()
 	fake.deleteFilesetMutex.RLock()
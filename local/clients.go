@@ -19,40 +19,108 @@ package local
 import (
 	"fmt"
 	"github.com/jinzhu/gorm"
-	"github.com/midoblgsm/ubiquity/local/localhost"
-	"github.com/midoblgsm/ubiquity/local/scbe"
-	"github.com/midoblgsm/ubiquity/local/spectrumscale"
+	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
-	"log"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+
+	// the backends register themselves, see resources.RegisterBackend
+	_ "github.com/midoblgsm/ubiquity/local/localhost"
+	_ "github.com/midoblgsm/ubiquity/local/scbe"
+	_ "github.com/midoblgsm/ubiquity/local/spectrumscale"
 )
 
-func GetLocalClients(logger *log.Logger, config resources.UbiquityServerConfig, database *gorm.DB) (map[string]resources.StorageClient, error) {
-	// TODO need to refactor and load all the existing clients automatically (instead of hardcore each one here)
-	clients := make(map[string]resources.StorageClient)
-	spectrumClient, err := spectrumscale.NewSpectrumLocalClient(logger, config, database)
+// GetLocalClients creates the clients of the configured backend instances, a backend that cannot be initialized is
+// logged and not served
+func GetLocalClients(logger logs.Logger, config resources.UbiquityServerConfig, database *gorm.DB) (map[string]resources.StorageClient, error) {
+	backends, err := GetBackendConfigs(config)
 	if err != nil {
-		logger.Printf("Not enough params to initialize '%s' client", resources.SpectrumScale)
-	} else {
-		clients[resources.SpectrumScale] = spectrumClient
+		return nil, logger.ErrorRet(err, "failed")
+	}
+	clients := make(map[string]resources.StorageClient)
+	for _, backend := range backends {
+		plugin, _ := resources.GetBackend(backend.Type)
+		settings, configured, err := decodeBackendConfig(plugin, backend)
+		if err != nil {
+			logger.Error("Cannot decode the backend config, the backend is not served", logs.Args{{"backend", backend.Name}, {"error", err}})
+			continue
+		}
+		if !configured {
+			logger.Info("The backend is not configured", logs.Args{{"backend", backend.Name}})
+			continue
+		}
+		client, err := plugin.NewClient(backend.Name, settings, config, database)
+		if err != nil {
+			logger.Error("Cannot initialize client, the backend is not served", logs.Args{{"backend", backend.Name}, {"error", err}})
+			continue
+		}
+		clients[backend.Name] = client
 	}
 
-	localHostClient, err := localhost.NewLocalhostLocalClient(logger, config, database)
-	if err != nil {
-		logger.Printf("Not enough params to initialize '%s' client", resources.LocalHost)
-	} else {
-		clients[resources.LocalHost] = localHostClient
+	if len(clients) == 0 {
+		return nil, logger.ErrorRet(fmt.Errorf("No client can be initialized....please check config file"), "failed")
+	}
+	var served []string
+	for name := range clients {
+		served = append(served, name)
+	}
+	if err := model.ValidateStorageClasses(config.StorageClasses, served); err != nil {
+		logger.Error("The volumes of a storage class cannot be created", logs.Args{{"error", err}})
 	}
+	return clients, nil
+}
 
-	ScbeClient, err := scbe.NewScbeLocalClient(config.ScbeConfig, database)
+// GetBackendConfigs returns the backend instances of config: one per registered type for the ScbeConfig,
+// SpectrumScaleConfig and LocalHostConfig sections, named after the type, then the named instances of config.Backends
+func GetBackendConfigs(config resources.UbiquityServerConfig) ([]resources.BackendConfig, error) {
+	var backends []resources.BackendConfig
+	names := make(map[string]bool)
+	for _, backendType := range resources.GetBackendTypes() {
+		backends = append(backends, resources.BackendConfig{Name: backendType, Type: backendType,
+			ScbeConfig: config.ScbeConfig, SpectrumScaleConfig: config.SpectrumScaleConfig, LocalHostConfig: config.LocalHostConfig})
+		names[backendType] = true
+	}
+	for _, backend := range config.Backends {
+		if backend.Name == "" {
+			return nil, fmt.Errorf("Error in config file. A backend has no Name")
+		}
+		if names[backend.Name] {
+			return nil, fmt.Errorf("Error in config file. The backend name [%s] is used twice or is the name of a backend type", backend.Name)
+		}
+		if _, ok := resources.GetBackend(backend.Type); !ok {
+			return nil, fmt.Errorf("Error in config file. The backend [%s] has an unknown Type [%s]", backend.Name, backend.Type)
+		}
+		names[backend.Name] = true
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// decodeBackendConfig returns the settings of backend, a named instance is always configured
+func decodeBackendConfig(plugin resources.BackendPlugin, backend resources.BackendConfig) (interface{}, bool, error) {
+	settings, configured, err := plugin.DecodeConfig(backend)
 	if err != nil {
-		logger.Printf("Not enough params to initialize '%s' client", resources.SCBE)
-	} else {
-		clients[resources.SCBE] = ScbeClient
+		return nil, false, err
 	}
+	return settings, configured || isNamedBackend(backend), nil
+}
 
-	if len(clients) == 0 {
-		log.Fatal("No client can be initialized....please check config file")
-		return nil, fmt.Errorf("No client can be initialized....please check config file")
+// isNamedBackend returns false for the instances of the ScbeConfig, SpectrumScaleConfig and LocalHostConfig sections
+func isNamedBackend(backend resources.BackendConfig) bool {
+	return backend.Name != backend.Type
+}
+
+// RecoverVolumes let every client that supports it finish the operations interrupted by a previous server stop,
+// failures are logged and do not prevent the server from starting.
+// volumeLocker must be the locker of the API handlers, so the operations running on the other servers are not recovered.
+func RecoverVolumes(logger logs.Logger, clients map[string]resources.StorageClient, volumeLocker utils.Locker) {
+	for backend, client := range clients {
+		recoverer, ok := client.(resources.VolumeRecoverer)
+		if !ok {
+			continue
+		}
+		if err := recoverer.RecoverVolumes(volumeLocker); err != nil {
+			logger.Error("Error recovering volumes", logs.Args{{"backend", backend}, {"error", err}})
+		}
 	}
-	return clients, nil
 }
@@ -1,14 +1,12 @@
 package localhost
 
 import (
-	"log"
-
 	"fmt"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 //go:generate counterfeiter -o ../../fakes/fake_SpectrumDataModel.go . SpectrumDataModel
@@ -19,31 +17,37 @@ type LocalhostDataModel interface {
 	GetVolume(name string) (resources.Volume, bool, error)
 	ListVolumes() ([]resources.Volume, error)
 	UpdateVolumeMountpoint(name string, mountpoint string) error
+	UpdateVolumeState(name string, state string) error
+	GetVolumeAttachments(name string) ([]resources.VolumeAttachment, error)
+	AddVolumeAttachment(name string, host string, readOnly bool) error
+	RemoveVolumeAttachment(name string, host string) error
 }
 
 type localhostDataModel struct {
-	log      *log.Logger
+	logger   logs.Logger
 	database *gorm.DB
 	backend  string
 }
 
-func NewLocalhostDataModel(log *log.Logger, db *gorm.DB, backend string) LocalhostDataModel {
-	return &localhostDataModel{log: log, database: db, backend: backend}
+func NewLocalhostDataModel(db *gorm.DB, backend string) LocalhostDataModel {
+	return &localhostDataModel{logger: logs.GetComponentLogger(logs.ComponentLocalhost), database: db, backend: backend}
 }
 
+// CreateVolumeTable creates the table if it does not exist, schema changes are done by migrations
 func (d *localhostDataModel) CreateVolumeTable() error {
-	d.log.Println("localhostDataModel: Create Volumes Table start")
-	defer d.log.Println("localhostDataModel: Create Volumes Table end")
+	defer d.logger.Trace(logs.DEBUG)()
 
-	if err := d.database.AutoMigrate(&resources.Volume{}).Error; err != nil {
+	if d.database.HasTable(&resources.Volume{}) {
+		return nil
+	}
+	if err := d.database.CreateTable(&resources.Volume{}).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
 func (d *localhostDataModel) DeleteVolume(name string) error {
-	d.log.Println("localhostDataModel: DeleteVolume start")
-	defer d.log.Println("localhostDataModel: DeleteVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, exists, err := d.GetVolume(name)
 
@@ -54,9 +58,6 @@ func (d *localhostDataModel) DeleteVolume(name string) error {
 		return fmt.Errorf("Volume : %s not found", name)
 	}
 
-	if err := d.database.Delete(&volume).Error; err != nil {
-		return err
-	}
 	if err := model.DeleteVolume(d.database, &volume).Error; err != nil {
 		return err
 	}
@@ -64,15 +65,14 @@ func (d *localhostDataModel) DeleteVolume(name string) error {
 }
 
 func (d *localhostDataModel) InsertVolume(volume resources.Volume) error {
-	d.log.Println("localhostDataModel: InsertFilesetVolume start")
-	defer d.log.Println("localhostDataModel: InsertFilesetVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	return d.insertVolume(volume)
 }
 
 func (d *localhostDataModel) insertVolume(volume resources.Volume) error {
-	d.log.Println("localhostDataModel: insertVolume start")
-	defer d.log.Println("localhostDataModel: insertVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
+	volume.BackendType = resources.LocalHost
 	if err := d.database.Create(&volume).Error; err != nil {
 		return err
 	}
@@ -80,8 +80,7 @@ func (d *localhostDataModel) insertVolume(volume resources.Volume) error {
 }
 
 func (d *localhostDataModel) GetVolume(name string) (resources.Volume, bool, error) {
-	d.log.Println("localhostDataModel: GetVolume start")
-	defer d.log.Println("localhostDataModel: GetVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, err := model.GetVolume(d.database, name, d.backend)
 	if err != nil {
@@ -90,20 +89,12 @@ func (d *localhostDataModel) GetVolume(name string) (resources.Volume, bool, err
 		}
 		return resources.Volume{}, false, err
 	}
-
-	var localhostVolume resources.Volume
-	if err := d.database.Where("volume_id = ?", volume.ID).First(&localhostVolume).Error; err != nil {
-		if err.Error() == "record not found" {
-			return resources.Volume{}, false, nil
-		}
-		return resources.Volume{}, false, err
-	}
-	return localhostVolume, true, nil
+	// localhost volumes have no backend specific table, the generic volumes row is the whole volume
+	return volume, true, nil
 }
 
 func (d *localhostDataModel) ListVolumes() ([]resources.Volume, error) {
-	d.log.Println("localhostDataModel: ListVolumes start")
-	defer d.log.Println("localhostDataModel: ListVolumes end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	var volumesInDb []resources.Volume
 
@@ -115,8 +106,7 @@ func (d *localhostDataModel) ListVolumes() ([]resources.Volume, error) {
 }
 
 func (d *localhostDataModel) UpdateVolumeMountpoint(name string, mountpoint string) error {
-	d.log.Println("localhostDataModel: UpdateVolumeMountpoint start")
-	defer d.log.Println("localhostDataModel: UpdateVolumeMountpoint end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, err := model.GetVolume(d.database, name, d.backend)
 	if err != nil {
@@ -128,3 +118,47 @@ func (d *localhostDataModel) UpdateVolumeMountpoint(name string, mountpoint stri
 	}
 	return nil
 }
+
+func (d *localhostDataModel) UpdateVolumeState(name string, state string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+
+	if err = model.UpdateVolumeState(d.database, &volume, state); err != nil {
+		return fmt.Errorf("Error updating state of volume %s to %s: %s", volume.Name, state, err.Error())
+	}
+	return nil
+}
+
+func (d *localhostDataModel) GetVolumeAttachments(name string) ([]resources.VolumeAttachment, error) {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return nil, err
+	}
+	return model.GetVolumeAttachments(d.database, volume.ID)
+}
+
+func (d *localhostDataModel) AddVolumeAttachment(name string, host string, readOnly bool) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+	return model.AddVolumeAttachment(d.database, volume.ID, host, readOnly)
+}
+
+func (d *localhostDataModel) RemoveVolumeAttachment(name string, host string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+	return model.RemoveVolumeAttachment(d.database, volume.ID, host)
+}
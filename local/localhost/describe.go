@@ -0,0 +1,28 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localhost
+
+func (s *localhostLocalClient) IsActivated() bool {
+	s.activationLock.RLock()
+	defer s.activationLock.RUnlock()
+	return s.isActivated
+}
+
+// Capabilities is empty, the volumes are directories of one host
+func (s *localhostLocalClient) Capabilities() []string {
+	return []string{}
+}
@@ -17,48 +17,83 @@
 package localhost
 
 import (
-	"log"
-
+	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"fmt"
 
 	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
 
 	"sync"
 
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type localhostLocalClient struct {
-	logger         *log.Logger
+	logger         logs.Logger
+	backend        string // name of the backend instance
 	dataModel      LocalhostDataModel
 	config         resources.LocalHostConfig
 	isActivated    bool
 	activationLock *sync.RWMutex
 }
 
-func NewLocalhostLocalClient(logger *log.Logger, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+func init() {
+	resources.RegisterBackend(resources.BackendPlugin{
+		Type: resources.LocalHost,
+		DecodeConfig: func(backend resources.BackendConfig) (interface{}, bool, error) {
+			return backend.LocalHostConfig, backend.LocalHostConfig.LocalhostPath != "", nil
+		},
+		NewClient: func(backend string, settings interface{}, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+			config.LocalHostConfig = settings.(resources.LocalHostConfig)
+			return NewLocalhostLocalClient(backend, config, database)
+		},
+		ValidateConfig: func(settings interface{}, config resources.UbiquityServerConfig) error {
+			return ValidateConfig(settings.(resources.LocalHostConfig))
+		},
+	})
+}
 
-	return newLocalhostLocalClient(logger, config.LocalHostConfig, database, resources.LocalHost)
+// NewLocalhostLocalClient creates the client of the localhost backend instance named backend, with the volumes under
+// config.LocalHostConfig.LocalhostPath
+func NewLocalhostLocalClient(backend string, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+	return newLocalhostLocalClient(config.LocalHostConfig, database, backend)
 }
 
-func newLocalhostLocalClient(logger *log.Logger, config resources.LocalHostConfig, database *gorm.DB, backend string) (*localhostLocalClient, error) {
-	logger.Println("localhostLocalClient: init start")
-	defer logger.Println("localhostLocalClient: init end")
+// ValidateConfig checks that the localhost path can hold the volumes, it creates the path if missing
+func ValidateConfig(config resources.LocalHostConfig) error {
+	if config.LocalhostPath == "" {
+		return fmt.Errorf("localhostLocalClient: init: missing required parameter 'localhostPath'")
+	}
+	if err := os.MkdirAll(config.LocalhostPath, 0777); err != nil {
+		return fmt.Errorf("cannot create the localhost path [%s]: %s", config.LocalhostPath, err.Error())
+	}
+	file, err := ioutil.TempFile(config.LocalhostPath, ".ubiquity-validate")
+	if err != nil {
+		return fmt.Errorf("the localhost path [%s] is not writable: %s", config.LocalhostPath, err.Error())
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
 
-	datamodel := NewLocalhostDataModel(logger, database, backend)
+func newLocalhostLocalClient(config resources.LocalHostConfig, database *gorm.DB, backend string) (*localhostLocalClient, error) {
+	logger := logs.GetComponentLogger(logs.ComponentLocalhost)
+	defer logger.Trace(logs.DEBUG)()
+
+	datamodel := NewLocalhostDataModel(database, backend)
 	err := datamodel.CreateVolumeTable()
 	if err != nil {
 		return &localhostLocalClient{}, err
 	}
-	return &localhostLocalClient{logger: logger, config: config, dataModel: datamodel, activationLock: &sync.RWMutex{}}, nil
+	return &localhostLocalClient{logger: logger, backend: backend, config: config, dataModel: datamodel, activationLock: &sync.RWMutex{}}, nil
 }
 
 func (s *localhostLocalClient) Activate(activateRequest resources.ActivateRequest) resources.ActivateResponse {
-	s.logger.Println("localhostLocalClient: Activate start")
-	defer s.logger.Println("localhostLocalClient: Activate end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	s.activationLock.RLock()
 	if s.isActivated {
@@ -72,7 +107,7 @@ func (s *localhostLocalClient) Activate(activateRequest resources.ActivateReques
 
 	err := os.MkdirAll(s.config.LocalhostPath, 0777)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.ActivateResponse{Error: err}
 	}
 	s.isActivated = true
@@ -80,13 +115,12 @@ func (s *localhostLocalClient) Activate(activateRequest resources.ActivateReques
 }
 
 func (s *localhostLocalClient) CreateVolume(createVolumeRequest resources.CreateVolumeRequest) resources.CreateVolumeResponse {
-	s.logger.Println("localhostLocalClient: create start")
-	defer s.logger.Println("localhostLocalClient: create end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(createVolumeRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.CreateVolumeResponse{Error: err}
 	}
 
@@ -94,28 +128,41 @@ func (s *localhostLocalClient) CreateVolume(createVolumeRequest resources.Create
 		return resources.CreateVolumeResponse{Volume: existingVolume}
 	}
 
-	s.logger.Printf("Opts for create: %#v\n", createVolumeRequest.Metadata)
+	s.logger.Debug("Opts for create", logs.Args{{"metadata", createVolumeRequest.Metadata}})
 	metadata := resources.VolumeMetadata{Values: createVolumeRequest.Metadata}
-	volume := resources.Volume{Name: createVolumeRequest.Name, Backend: createVolumeRequest.Backend, Metadata: metadata, CapacityBytes: createVolumeRequest.CapacityBytes}
+	volume := resources.Volume{Name: createVolumeRequest.Name, Backend: createVolumeRequest.Backend, Metadata: metadata, CapacityBytes: createVolumeRequest.CapacityBytes, State: resources.VolumeStateCreating}
+	err = s.dataModel.InsertVolume(volume)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.CreateVolumeResponse{Error: err}
+	}
+
 	volumePath := path.Join(s.config.LocalhostPath, volume.Name)
 	err = os.MkdirAll(volumePath, 0777)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		if deleteErr := s.dataModel.DeleteVolume(volume.Name); deleteErr != nil {
+			s.logger.Error("failed", logs.Args{{"error", deleteErr}})
+		}
 		return resources.CreateVolumeResponse{Error: err}
 	}
 
-	s.dataModel.InsertVolume(volume)
+	err = s.dataModel.UpdateVolumeState(volume.Name, resources.VolumeStateAvailable)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.CreateVolumeResponse{Error: err}
+	}
+	volume.State = resources.VolumeStateAvailable
 	return resources.CreateVolumeResponse{Volume: volume}
 }
 
 func (s *localhostLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveVolumeRequest) resources.RemoveVolumeResponse {
-	s.logger.Println("localhostLocalClient: remove start")
-	defer s.logger.Println("localhostLocalClient: remove end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(removeVolumeRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
@@ -123,23 +170,22 @@ func (s *localhostLocalClient) RemoveVolume(removeVolumeRequest resources.Remove
 		return resources.RemoveVolumeResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
-	pathToDel := path.Join(s.config.LocalhostPath, existingVolume.Name)
-	err = os.RemoveAll(pathToDel)
+	err = s.dataModel.UpdateVolumeState(removeVolumeRequest.Name, resources.VolumeStateDeleting)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
-	err = os.RemoveAll(existingVolume.Mountpoint)
+	err = s.removeVolumeDirectories(existingVolume)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
 	err = s.dataModel.DeleteVolume(removeVolumeRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
@@ -147,8 +193,7 @@ func (s *localhostLocalClient) RemoveVolume(removeVolumeRequest resources.Remove
 }
 
 func (s *localhostLocalClient) GetVolume(getVolumeRequest resources.GetVolumeRequest) resources.GetVolumeResponse {
-	s.logger.Println("localhostLocalClient: GetVolume start")
-	defer s.logger.Println("localhostLocalClient: GetVolume finish")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(getVolumeRequest.Name)
 	if err != nil {
@@ -162,13 +207,12 @@ func (s *localhostLocalClient) GetVolume(getVolumeRequest resources.GetVolumeReq
 }
 
 func (s *localhostLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.GetVolumeConfigRequest) resources.GetVolumeConfigResponse {
-	s.logger.Println("localhostLocalClient: GetVolumeConfig start")
-	defer s.logger.Println("localhostLocalClient: GetVolumeConfig finish")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(getVolumeConfigRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.GetVolumeConfigResponse{Error: err}
 	}
 
@@ -176,7 +220,7 @@ func (s *localhostLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.
 		volumeConfigDetails := make(map[string]interface{})
 		volumeConfigDetails["mountpoint"] = existingVolume.Mountpoint
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return resources.GetVolumeConfigResponse{Error: err}
 		}
 
@@ -186,13 +230,12 @@ func (s *localhostLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.
 }
 
 func (s *localhostLocalClient) Attach(attachRequest resources.AttachRequest) resources.AttachResponse {
-	s.logger.Println("localhostLocalClient: attach start")
-	defer s.logger.Println("localhostLocalClient: attach end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	_, volExists, err := s.dataModel.GetVolume(attachRequest.Name)
+	existingVolume, volExists, err := s.dataModel.GetVolume(attachRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: err}
 	}
 
@@ -200,17 +243,32 @@ func (s *localhostLocalClient) Attach(attachRequest resources.AttachRequest) res
 		return resources.AttachResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
+	attachments, err := s.dataModel.GetVolumeAttachments(attachRequest.Name)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AttachResponse{Error: err}
+	}
+
+	if err = model.CheckVolumeAttach(existingVolume, attachments, attachRequest.Host, attachRequest.ReadOnly); err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AttachResponse{Error: err}
+	}
+
+	if err = s.dataModel.AddVolumeAttachment(attachRequest.Name, attachRequest.Host, attachRequest.ReadOnly); err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AttachResponse{Error: err}
+	}
+
 	return resources.AttachResponse{}
 }
 
 func (s *localhostLocalClient) Detach(detachRequest resources.DetachRequest) resources.DetachResponse {
-	s.logger.Println("localhostLocalClient: detach start")
-	defer s.logger.Println("localhostLocalClient: detach end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	_, volExists, err := s.dataModel.GetVolume(detachRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: err}
 	}
 
@@ -218,20 +276,84 @@ func (s *localhostLocalClient) Detach(detachRequest resources.DetachRequest) res
 		return resources.DetachResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
+	if err = s.dataModel.RemoveVolumeAttachment(detachRequest.Name, detachRequest.Host); err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.DetachResponse{Error: err}
+	}
+
 	return resources.DetachResponse{}
 }
 
 func (s *localhostLocalClient) ListVolumes(listVolumesRequest resources.ListVolumesRequest) resources.ListVolumesResponse {
-	s.logger.Println("localhostLocalClient: list start")
-	defer s.logger.Println("localhostLocalClient: list end")
+	defer s.logger.Trace(logs.DEBUG)()
 	var err error
 
 	volumesInDb, err := s.dataModel.ListVolumes()
 
 	if err != nil {
-		s.logger.Printf("error retrieving volumes from db %#v\n", err)
+		s.logger.Error("error retrieving volumes from db", logs.Args{{"error", err}})
 		return resources.ListVolumesResponse{Error: err}
 	}
 
 	return resources.ListVolumesResponse{Volumes: volumesInDb}
 }
+
+// RecoverVolumes complete or roll back the creates and removes that were interrupted
+func (s *localhostLocalClient) RecoverVolumes(locker resources.VolumeLocker) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return err
+	}
+
+	var failed []string
+	for _, volume := range volumesInDb {
+		if volume.State != resources.VolumeStateCreating && volume.State != resources.VolumeStateDeleting {
+			continue
+		}
+		locker.WriteLock(volume.Name)
+		if err = s.recoverVolume(volume.Name); err != nil {
+			s.logger.Error("Error recovering volume", logs.Args{{"name", volume.Name}, {"error", err}})
+			s.dataModel.UpdateVolumeState(volume.Name, resources.VolumeStateError)
+			failed = append(failed, volume.Name)
+		}
+		locker.WriteUnlock(volume.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Recovery of volumes [%s] failed, they were moved to the error state", strings.Join(failed, ","))
+	}
+	return nil
+}
+
+// recoverVolume must be called with the volume lock held, it reads the volume again since another server may have completed the operation
+func (s *localhostLocalClient) recoverVolume(name string) error {
+	volume, exists, err := s.dataModel.GetVolume(name)
+	if err != nil || !exists {
+		return err
+	}
+	switch volume.State {
+	case resources.VolumeStateCreating:
+		if _, err = os.Stat(path.Join(s.config.LocalhostPath, volume.Name)); err == nil {
+			return s.dataModel.UpdateVolumeState(volume.Name, resources.VolumeStateAvailable)
+		} else if os.IsNotExist(err) {
+			return s.dataModel.DeleteVolume(volume.Name)
+		}
+		return err
+	case resources.VolumeStateDeleting:
+		if err = s.removeVolumeDirectories(volume); err != nil {
+			return err
+		}
+		return s.dataModel.DeleteVolume(volume.Name)
+	}
+	return nil
+}
+
+func (s *localhostLocalClient) removeVolumeDirectories(volume resources.Volume) error {
+	err := os.RemoveAll(path.Join(s.config.LocalhostPath, volume.Name))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(volume.Mountpoint)
+}
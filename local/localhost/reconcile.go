@@ -0,0 +1,106 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package localhost
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Reconcile compare the volumes in the DB with the directories under LocalhostPath
+func (s *localhostLocalClient) Reconcile() ([]resources.Drift, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(s.config.LocalhostPath)
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return nil, err
+	}
+	directories := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			directories[entry.Name()] = true
+		}
+	}
+
+	var drifts []resources.Drift
+	volumeNames := make(map[string]bool)
+	for _, volume := range volumesInDb {
+		volumeNames[volume.Name] = true
+		if !directories[volume.Name] {
+			drifts = append(drifts, s.newDrift(resources.DriftMissingStorage, volume.Name, path.Join(s.config.LocalhostPath, volume.Name)))
+		}
+	}
+	for directory := range directories {
+		if !volumeNames[directory] {
+			drifts = append(drifts, s.newDrift(resources.DriftOrphanStorage, "", path.Join(s.config.LocalhostPath, directory)))
+		}
+	}
+	s.logger.Info("Reconcile found drifts", logs.Args{{"drifts", len(drifts)}})
+	return drifts, nil
+}
+
+// RepairDrift delete the volume of a missing directory from the DB, or remove an orphan directory
+func (s *localhostLocalClient) RepairDrift(drift resources.Drift) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	switch drift.Kind {
+	case resources.DriftMissingStorage:
+		if _, err := os.Stat(path.Join(s.config.LocalhostPath, drift.Volume)); !os.IsNotExist(err) {
+			return fmt.Errorf("Directory of volume %s exists, skipping repair", drift.Volume)
+		}
+		if err := s.dataModel.DeleteVolume(drift.Volume); err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return err
+		}
+	case resources.DriftOrphanStorage:
+		name := path.Base(drift.Resource)
+		if path.Join(s.config.LocalhostPath, name) != path.Clean(drift.Resource) {
+			return fmt.Errorf("Orphan directory %s is not under %s", drift.Resource, s.config.LocalhostPath)
+		}
+		_, volExists, err := s.dataModel.GetVolume(name)
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return err
+		}
+		if volExists {
+			return fmt.Errorf("Directory %s belongs to volume %s, skipping repair", drift.Resource, name)
+		}
+		if err = os.RemoveAll(drift.Resource); err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return err
+		}
+	default:
+		return fmt.Errorf("Repair of drift kind %s is not supported", drift.Kind)
+	}
+	s.logger.Info("Repaired drift", logs.Args{{"drift", drift}})
+	return nil
+}
+
+func (s *localhostLocalClient) newDrift(kind string, volumeName string, resource string) resources.Drift {
+	return resources.Drift{Kind: kind, Backend: s.backend, Volume: volumeName, Resource: resource}
+}
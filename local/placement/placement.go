@@ -0,0 +1,138 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package placement
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Placement policies, they choose among the pools with enough free space for the volume
+const (
+	PolicyMostFree = "most-free" // the pool with the most free space
+	PolicyFirstFit = "first-fit" // the first pool by name
+	PolicyWeighted = "weighted"  // a random pool, with a probability proportional to its free space
+)
+
+var policies = []string{PolicyMostFree, PolicyFirstFit, PolicyWeighted}
+
+// Pool is where a backend can create a volume, e.g. an SCBE service or a Spectrum Scale filesystem
+type Pool struct {
+	Name       string
+	TotalBytes uint64
+	FreeBytes  uint64
+}
+
+// Placer picks the pool of a new volume
+type Placer interface {
+	Place(pools []Pool, sizeBytes uint64) (Pool, error)
+}
+
+type placer struct {
+	policy     string
+	random     *rand.Rand
+	randomLock *sync.Mutex // a rand.Rand is not safe for concurrent use, and the volumes are created concurrently
+}
+
+func NewPlacer(policy string) (Placer, error) {
+	return NewPlacerWithRand(policy, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+func NewPlacerWithRand(policy string, random *rand.Rand) (Placer, error) {
+	if err := ValidatePolicy(policy); err != nil {
+		return nil, err
+	}
+	return &placer{policy: policy, random: random, randomLock: &sync.Mutex{}}, nil
+}
+
+// ValidatePolicy checks a placement policy of the config, empty disables the placement
+func ValidatePolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	for _, known := range policies {
+		if policy == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown placement policy [%s], it must be one of [%s]", policy, strings.Join(policies, ", "))
+}
+
+// Place returns the pool of a volume of sizeBytes, or a NoCapacityError if it fits in none of pools
+func (p *placer) Place(pools []Pool, sizeBytes uint64) (Pool, error) {
+	var fitting []Pool
+	for _, pool := range pools {
+		if pool.FreeBytes >= sizeBytes {
+			fitting = append(fitting, pool)
+		}
+	}
+	if len(fitting) == 0 {
+		return Pool{}, &NoCapacityError{SizeBytes: sizeBytes, Pools: pools}
+	}
+	sort.SliceStable(fitting, func(i, j int) bool { return fitting[i].Name < fitting[j].Name })
+
+	switch p.policy {
+	case PolicyMostFree:
+		chosen := fitting[0]
+		for _, pool := range fitting[1:] {
+			if pool.FreeBytes > chosen.FreeBytes {
+				chosen = pool
+			}
+		}
+		return chosen, nil
+	case PolicyWeighted:
+		var totalFree uint64
+		for _, pool := range fitting {
+			totalFree += pool.FreeBytes
+		}
+		if totalFree == 0 {
+			return fitting[p.randomInt63n(int64(len(fitting)))], nil
+		}
+		point := uint64(p.randomInt63n(int64(totalFree)))
+		for _, pool := range fitting {
+			if point < pool.FreeBytes {
+				return pool, nil
+			}
+			point -= pool.FreeBytes
+		}
+	}
+	return fitting[0], nil
+}
+
+func (p *placer) randomInt63n(n int64) int64 {
+	p.randomLock.Lock()
+	defer p.randomLock.Unlock()
+	return p.random.Int63n(n)
+}
+
+// NoCapacityError is returned when a volume does not fit in any pool, before anything is provisioned
+type NoCapacityError struct {
+	SizeBytes uint64
+	Pools     []Pool
+}
+
+func (e *NoCapacityError) Error() string {
+	free := make([]string, 0, len(e.Pools))
+	for _, pool := range e.Pools {
+		free = append(free, fmt.Sprintf("%s=%d", pool.Name, pool.FreeBytes))
+	}
+	return fmt.Sprintf("no pool has %d free bytes for the volume, free bytes per pool [%s]", e.SizeBytes, strings.Join(free, ", "))
+}
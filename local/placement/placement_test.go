@@ -0,0 +1,102 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package placement_test
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/midoblgsm/ubiquity/local/placement"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Placer", func() {
+	var pools []placement.Pool
+	BeforeEach(func() {
+		pools = []placement.Pool{
+			{Name: "silver", TotalBytes: 1000, FreeBytes: 300},
+			{Name: "gold", TotalBytes: 1000, FreeBytes: 100},
+			{Name: "bronze", TotalBytes: 1000, FreeBytes: 900},
+		}
+	})
+	It("should pick the pool with the most free space", func() {
+		placer, err := placement.NewPlacer(placement.PolicyMostFree)
+		Expect(err).ToNot(HaveOccurred())
+		pool, err := placer.Place(pools, 200)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pool.Name).To(Equal("bronze"))
+	})
+	It("should pick the first pool by name with enough free space", func() {
+		placer, err := placement.NewPlacer(placement.PolicyFirstFit)
+		Expect(err).ToNot(HaveOccurred())
+		pool, err := placer.Place(pools, 50)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pool.Name).To(Equal("bronze"))
+		pool, err = placer.Place(pools[:2], 200)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pool.Name).To(Equal("silver"))
+	})
+	It("should pick the pools with a probability proportional to their free space", func() {
+		placer, err := placement.NewPlacerWithRand(placement.PolicyWeighted, rand.New(rand.NewSource(1)))
+		Expect(err).ToNot(HaveOccurred())
+		picked := make(map[string]int)
+		for i := 0; i < 1000; i++ {
+			pool, err := placer.Place(pools, 200)
+			Expect(err).ToNot(HaveOccurred())
+			picked[pool.Name]++
+		}
+		Expect(picked).ToNot(HaveKey("gold"))
+		Expect(picked["bronze"]).To(BeNumerically(">", 2*picked["silver"]))
+		Expect(picked["silver"]).To(BeNumerically(">", 0))
+	})
+	It("should place the volumes of concurrent creates", func() {
+		placer, err := placement.NewPlacer(placement.PolicyWeighted)
+		Expect(err).ToNot(HaveOccurred())
+		empty := []placement.Pool{{Name: "silver"}, {Name: "gold"}}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					_, err := placer.Place(pools, 200)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = placer.Place(empty, 0)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	It("should fail if the volume fits in no pool", func() {
+		placer, err := placement.NewPlacer(placement.PolicyMostFree)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = placer.Place(pools, 1000)
+		Expect(err).To(BeAssignableToTypeOf(&placement.NoCapacityError{}))
+		Expect(err.Error()).To(ContainSubstring("bronze=900"))
+		_, err = placer.Place(nil, 0)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should refuse an unknown policy", func() {
+		Expect(placement.ValidatePolicy("")).To(Succeed())
+		Expect(placement.ValidatePolicy("round-robin")).ToNot(Succeed())
+		_, err := placement.NewPlacer("round-robin")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scbe
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Adopt register as ubiquity volumes the SCBE volumes of a service whose name match the pattern.
+// The u_<instance>_ prefix is removed from the volume name, volumes already in the DB are skipped,
+// and each volume is looked up by WWN to record the host it is mapped to. All the volumes are inserted in one transaction.
+func (s *scbeLocalClient) Adopt(adoptRequest resources.AdoptRequest) resources.AdoptResponse {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	if adoptRequest.Pattern == "" {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(
+			&InValidRequestError{"adoptRequest", "Pattern", adoptRequest.Pattern, "none empty string"}, "failed")}
+	}
+	if _, err := path.Match(adoptRequest.Pattern, ""); err != nil {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(err, "path.Match failed", logs.Args{{"pattern", adoptRequest.Pattern}})}
+	}
+	service := adoptRequest.Service
+	if service == "" {
+		service = s.config.DefaultService
+	}
+	fstype := adoptRequest.Opts[resources.OptionNameForVolumeFsType]
+	if fstype == "" {
+		fstype = s.config.DefaultFilesystemType
+	}
+	if !utils.StringInSlice(fstype, SupportedFSTypes) {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(
+			&FsTypeNotSupportedError{adoptRequest.Pattern, fstype, strings.Join(SupportedFSTypes, ",")}, "failed")}
+	}
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(err, "dataModel.ListVolumes failed")}
+	}
+	wwnsInDb := make(map[string]bool)
+	for _, volume := range volumesInDb {
+		wwnsInDb[volume.WWN] = true
+	}
+
+	storageVolumes, err := s.scbeRestClient.GetVolumes("")
+	if err != nil {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.GetVolumes failed")}
+	}
+	instancePrefix := fmt.Sprintf(ComposeVolumeName, s.config.UbiquityInstanceName, "")
+	var toAdopt []ScbeVolume
+	var volumes []resources.Volume
+	for _, storageVolume := range storageVolumes {
+		if storageVolume.Profile != service || wwnsInDb[storageVolume.Wwn] {
+			continue
+		}
+		if matched, _ := path.Match(adoptRequest.Pattern, storageVolume.Name); !matched {
+			continue
+		}
+		name := strings.TrimPrefix(storageVolume.Name, instancePrefix)
+		host, err := s.scbeRestClient.GetVolMapping(storageVolume.Wwn)
+		if err != nil {
+			return resources.AdoptResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.GetVolMapping failed", logs.Args{{"wwn", storageVolume.Wwn}})}
+		}
+		volume := resources.Volume{Name: name, Backend: s.backend, BackendType: resources.SCBE, Metadata: resources.VolumeMetadata{Values: adoptRequest.Opts},
+			AccessMode: resources.GetDefaultAccessMode(resources.SCBE)}
+		toAdopt = append(toAdopt, ScbeVolume{Volume: volume, WWN: storageVolume.Wwn, AttachTo: host, FSType: fstype})
+		volumes = append(volumes, volume)
+	}
+
+	if adoptRequest.DryRun || len(toAdopt) == 0 {
+		return resources.AdoptResponse{Volumes: volumes}
+	}
+	if err = s.dataModel.InsertVolumes(toAdopt); err != nil {
+		return resources.AdoptResponse{Error: s.logger.ErrorRet(err, "dataModel.InsertVolumes failed")}
+	}
+	s.logger.Info("volumes adopted", logs.Args{{"service", service}, {"pattern", adoptRequest.Pattern}, {"volumes", len(toAdopt)}})
+	return resources.AdoptResponse{Volumes: volumes}
+}
@@ -17,9 +17,9 @@
 package scbe
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/jinzhu/gorm"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils/logs"
@@ -30,9 +30,17 @@ type ScbeDataModel interface {
 	CreateVolumeTable() error
 	DeleteVolume(name string) error
 	InsertVolume(volumeName string, wwn string, attachTo string, fstype string) error
+	InsertVolumes(volumes []ScbeVolume) error
 	GetVolume(name string) (ScbeVolume, bool, error)
 	ListVolumes() ([]ScbeVolume, error)
 	UpdateVolumeAttachTo(volumeName string, scbeVolume ScbeVolume, host2attach string) error
+	GetVolumeAttachments(volumeName string) ([]resources.VolumeAttachment, error)
+	AddVolumeAttachment(volumeName string, scbeVolume ScbeVolume, host string, readOnly bool) error
+	RemoveVolumeAttachment(volumeName string, scbeVolume ScbeVolume, host string) error
+	SetVolumeAttachments(volumeName string, scbeVolume ScbeVolume, hosts []string) error
+	UpdateVolumeWWN(volumeName string, wwn string) error
+	UpdateVolumeState(volumeName string, state string) error
+	UpdateVolumeQos(volumeName string, maxIops int, maxMbps int) error
 }
 
 type scbeDataModel struct {
@@ -48,17 +56,126 @@ type ScbeVolume struct {
 	WWN      string
 	AttachTo string
 	FSType   string
+	MaxIops  int // QoS limits set on the volume, 0 if unlimited
+	MaxMbps  int
 }
 
 func NewScbeDataModel(db *gorm.DB, backend string) ScbeDataModel {
-	return &scbeDataModel{logger: logs.GetLogger(), database: db, backend: backend}
+	return &scbeDataModel{logger: logs.GetComponentLogger(logs.ComponentScbe), database: db, backend: backend}
 }
 
-// CreateVolumeTable create the SCBE backend table
+// scbeVolumeInventoryRow is the inventory form of a ScbeVolume row
+type scbeVolumeInventoryRow struct {
+	ID       uint   `json:"id"`
+	VolumeID uint   `json:"volume_id"`
+	WWN      string `json:"wwn"`
+	AttachTo string `json:"attach_to"`
+	FSType   string `json:"fstype"`
+	MaxIops  int    `json:"max_iops"`
+	MaxMbps  int    `json:"max_mbps"`
+}
+
+func init() {
+	model.RegisterInventoryTable(model.InventoryTable{
+		Name: "scbe_volumes",
+		Export: func(db *gorm.DB) (interface{}, error) {
+			var volumes []ScbeVolume
+			if err := db.Order("id").Find(&volumes).Error; err != nil {
+				return nil, err
+			}
+			rows := make([]scbeVolumeInventoryRow, 0, len(volumes))
+			for _, volume := range volumes {
+				rows = append(rows, scbeVolumeInventoryRow{volume.ID, volume.VolumeID, volume.WWN, volume.AttachTo, volume.FSType, volume.MaxIops, volume.MaxMbps})
+			}
+			return rows, nil
+		},
+		Import: func(tx *gorm.DB, data json.RawMessage) error {
+			var rows []scbeVolumeInventoryRow
+			if err := json.Unmarshal(data, &rows); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				volume := ScbeVolume{ID: row.ID, VolumeID: row.VolumeID, WWN: row.WWN, AttachTo: row.AttachTo, FSType: row.FSType, MaxIops: row.MaxIops, MaxMbps: row.MaxMbps}
+				if err := tx.Set("gorm:save_associations", false).Create(&volume).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	model.RegisterMigrations(model.Migration{
+		Version:     2,
+		Description: "create scbe_volumes table",
+		Up: func(tx *gorm.DB) error {
+			type scbeVolume struct {
+				ID       uint
+				VolumeID uint
+				WWN      string
+				AttachTo string
+				FSType   string
+			}
+			return tx.Table("scbe_volumes").AutoMigrate(&scbeVolume{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("scbe_volumes").Error
+		},
+		DropsData: true,
+	}, model.Migration{
+		Version:     5,
+		Description: "set state of attached scbe volumes",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE volumes SET state = ? WHERE id IN (SELECT volume_id FROM scbe_volumes WHERE attach_to <> '')", resources.VolumeStateAttached).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	}, model.Migration{
+		Version:     10,
+		Description: "create attachments of attached scbe volumes",
+		Up: func(tx *gorm.DB) error {
+			var volumes []struct {
+				VolumeID uint
+				AttachTo string
+			}
+			if err := tx.Table("scbe_volumes").Select("volume_id, attach_to").Where("attach_to <> ''").Find(&volumes).Error; err != nil {
+				return err
+			}
+			for _, volume := range volumes {
+				if err := model.AddVolumeAttachment(tx, volume.VolumeID, volume.AttachTo, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// the volume_attachments table is dropped by the rollback of migration 9
+			return nil
+		},
+	}, model.Migration{
+		Version:     13,
+		Description: "add scbe_volumes max_iops and max_mbps columns",
+		Up: func(tx *gorm.DB) error {
+			return tx.Table("scbe_volumes").AutoMigrate(&struct {
+				MaxIops int
+				MaxMbps int
+			}{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the columns are kept, older versions ignore them
+			return nil
+		},
+	})
+}
+
+// CreateVolumeTable create the SCBE backend table if it does not exist.
+// It never alters an existing table, schema changes are done by the migrations in the model package.
 func (d *scbeDataModel) CreateVolumeTable() error {
 	defer d.logger.Trace(logs.DEBUG)()
 
-	if err := d.database.AutoMigrate(&ScbeVolume{}).Error; err != nil {
+	if d.database.HasTable(&ScbeVolume{}) {
+		return nil
+	}
+	if err := d.database.CreateTable(&ScbeVolume{}).Error; err != nil {
 		return d.logger.ErrorRet(err, "failed")
 	}
 	return nil
@@ -87,13 +204,16 @@ func (d *scbeDataModel) DeleteVolume(name string) error {
 	return nil
 }
 
-// InsertVolume volume name and its details given in opts
+// InsertVolume volume name and its details given in opts.
+// The volume is inserted in the creating state, UpdateVolumeWWN moves it to available once it is provisioned.
 func (d *scbeDataModel) InsertVolume(volumeName string, wwn string, attachTo string, fstype string) error {
 	defer d.logger.Trace(logs.DEBUG)()
 
 	volume := ScbeVolume{
 		Volume: resources.Volume{Name: volumeName,
-			Backend: fmt.Sprintf("%s", d.backend)},
+			Backend:     fmt.Sprintf("%s", d.backend),
+			BackendType: resources.SCBE,
+			State:       resources.VolumeStateCreating},
 		WWN:      wwn,
 		AttachTo: attachTo,
 		FSType:   fstype,
@@ -105,6 +225,47 @@ func (d *scbeDataModel) InsertVolume(volumeName string, wwn string, attachTo str
 	return nil
 }
 
+// InsertVolumes insert all the given volumes in one transaction, nothing is inserted if one of the names is already used
+func (d *scbeDataModel) InsertVolumes(volumes []ScbeVolume) error {
+	defer d.logger.Trace(logs.DEBUG, logs.Args{{"volumes", len(volumes)}})()
+
+	tx := d.database.Begin()
+	if tx.Error != nil {
+		return d.logger.ErrorRet(tx.Error, "database.Begin failed")
+	}
+	for _, volume := range volumes {
+		exists, err := model.VolumeExists(tx, volume.Volume.Name)
+		if err != nil {
+			tx.Rollback()
+			return d.logger.ErrorRet(err, "model.VolumeExists failed")
+		}
+		if exists {
+			tx.Rollback()
+			return d.logger.ErrorRet(&volAlreadyExistsError{volume.Volume.Name}, "failed")
+		}
+		volume.Volume.Backend = d.backend
+		volume.Volume.BackendType = resources.SCBE
+		volume.Volume.State = stateOfAttachTo(volume.AttachTo)
+		if volume.Volume.AccessMode == "" {
+			volume.Volume.AccessMode = resources.GetDefaultAccessMode(resources.SCBE)
+		}
+		if err := tx.Create(&volume).Error; err != nil {
+			tx.Rollback()
+			return d.logger.ErrorRet(err, "database.Create failed", logs.Args{{"volume", volume.Volume.Name}})
+		}
+		if volume.AttachTo != AttachedToNothing {
+			if err := model.AddVolumeAttachment(tx, volume.VolumeID, volume.AttachTo, false); err != nil {
+				tx.Rollback()
+				return d.logger.ErrorRet(err, "model.AddVolumeAttachment failed", logs.Args{{"volume", volume.Volume.Name}})
+			}
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return d.logger.ErrorRet(err, "database.Commit failed")
+	}
+	return nil
+}
+
 // GetVolume return ScbeVolume if exist in DB, else return false and err
 func (d *scbeDataModel) GetVolume(name string) (ScbeVolume, bool, error) {
 	defer d.logger.Trace(logs.DEBUG)()
@@ -145,12 +306,155 @@ func (d *scbeDataModel) ListVolumes() ([]ScbeVolume, error) {
 
 	return volumes, nil
 }
+
+// UpdateVolumeAttachTo set the host the volume is attached to, and the volume state to attached (or available if the host is empty).
+// Any other attachment of the volume is removed.
 func (d *scbeDataModel) UpdateVolumeAttachTo(volumeName string, scbeVolume ScbeVolume, host2attach string) error {
 	defer d.logger.Trace(logs.DEBUG)()
+	var hosts []string
+	if host2attach != EmptyHost {
+		hosts = []string{host2attach}
+	}
+	return d.SetVolumeAttachments(volumeName, scbeVolume, hosts)
+}
 
-	err := d.database.Table("scbe_volumes").Where("volume_id = ?", scbeVolume.ID).Update("attach_to", host2attach).Error
+func (d *scbeDataModel) GetVolumeAttachments(volumeName string) ([]resources.VolumeAttachment, error) {
+	defer d.logger.Trace(logs.DEBUG)()
+	scbeVolume, volExists, err := d.GetVolume(volumeName)
+	if err != nil {
+		return nil, d.logger.ErrorRet(err, "GetVolume failed")
+	}
+	if !volExists {
+		return nil, d.logger.ErrorRet(&volumeNotFoundError{volumeName}, "failed")
+	}
+	attachments, err := model.GetVolumeAttachments(d.database, scbeVolume.VolumeID)
 	if err != nil {
+		return nil, d.logger.ErrorRet(err, "model.GetVolumeAttachments failed", logs.Args{{"volumeName", volumeName}})
+	}
+	return attachments, nil
+}
+
+// AddVolumeAttachment records one more host the volume is mapped to
+func (d *scbeDataModel) AddVolumeAttachment(volumeName string, scbeVolume ScbeVolume, host string, readOnly bool) error {
+	defer d.logger.Trace(logs.DEBUG)()
+	return d.updateAttachments(volumeName, scbeVolume, func(tx *gorm.DB) error {
+		return model.AddVolumeAttachment(tx, scbeVolume.VolumeID, host, readOnly)
+	})
+}
+
+// RemoveVolumeAttachment removes a host the volume was unmapped from, the volume stays attached while other hosts remain
+func (d *scbeDataModel) RemoveVolumeAttachment(volumeName string, scbeVolume ScbeVolume, host string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+	return d.updateAttachments(volumeName, scbeVolume, func(tx *gorm.DB) error {
+		return model.RemoveVolumeAttachment(tx, scbeVolume.VolumeID, host)
+	})
+}
+
+// SetVolumeAttachments replaces the hosts the volume is attached to, e.g with the mappings found on SCBE
+func (d *scbeDataModel) SetVolumeAttachments(volumeName string, scbeVolume ScbeVolume, hosts []string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+	return d.updateAttachments(volumeName, scbeVolume, func(tx *gorm.DB) error {
+		return model.SetVolumeAttachmentHosts(tx, scbeVolume.VolumeID, hosts)
+	})
+}
+
+// updateAttachments runs update in a transaction, then sets attach_to to the first attached host and the volume state to match
+func (d *scbeDataModel) updateAttachments(volumeName string, scbeVolume ScbeVolume, update func(tx *gorm.DB) error) error {
+	tx := d.database.Begin()
+	if tx.Error != nil {
+		return d.logger.ErrorRet(tx.Error, "database.Begin failed")
+	}
+	if err := update(tx); err != nil {
+		tx.Rollback()
+		return d.logger.ErrorRet(err, "failed to update attachments", logs.Args{{"volumeName", volumeName}})
+	}
+	attachments, err := model.GetVolumeAttachments(tx, scbeVolume.VolumeID)
+	if err != nil {
+		tx.Rollback()
+		return d.logger.ErrorRet(err, "model.GetVolumeAttachments failed", logs.Args{{"volumeName", volumeName}})
+	}
+	attachTo := AttachedToNothing
+	if len(attachments) != 0 {
+		attachTo = attachments[0].Host
+	}
+	if err = tx.Table("scbe_volumes").Where("id = ?", scbeVolume.ID).Update("attach_to", attachTo).Error; err != nil {
+		tx.Rollback()
 		return d.logger.ErrorRet(err, "failed", logs.Args{{"volumeName", volumeName}})
 	}
+	if err = model.UpdateVolumeState(tx, &resources.Volume{Model: gorm.Model{ID: scbeVolume.VolumeID}}, stateOfAttachTo(attachTo)); err != nil {
+		tx.Rollback()
+		return d.logger.ErrorRet(err, "model.UpdateVolumeState failed", logs.Args{{"volumeName", volumeName}})
+	}
+	if err = tx.Commit().Error; err != nil {
+		return d.logger.ErrorRet(err, "database.Commit failed", logs.Args{{"volumeName", volumeName}})
+	}
 	return nil
 }
+
+// UpdateVolumeWWN set the WWN of a volume that was provisioned, and the volume state to available
+func (d *scbeDataModel) UpdateVolumeWWN(volumeName string, wwn string) error {
+	defer d.logger.Trace(logs.DEBUG, logs.Args{{"volumeName", volumeName}, {"wwn", wwn}})()
+
+	scbeVolume, volExists, err := d.GetVolume(volumeName)
+	if err != nil {
+		return d.logger.ErrorRet(err, "GetVolume failed")
+	}
+	if !volExists {
+		return d.logger.ErrorRet(&volumeNotFoundError{volumeName}, "failed")
+	}
+
+	tx := d.database.Begin()
+	if tx.Error != nil {
+		return d.logger.ErrorRet(tx.Error, "database.Begin failed")
+	}
+	if err = tx.Table("scbe_volumes").Where("id = ?", scbeVolume.ID).Update("wwn", wwn).Error; err != nil {
+		tx.Rollback()
+		return d.logger.ErrorRet(err, "failed", logs.Args{{"volumeName", volumeName}})
+	}
+	if err = model.UpdateVolumeState(tx, &scbeVolume.Volume, resources.VolumeStateAvailable); err != nil {
+		tx.Rollback()
+		return d.logger.ErrorRet(err, "model.UpdateVolumeState failed", logs.Args{{"volumeName", volumeName}})
+	}
+	if err = tx.Commit().Error; err != nil {
+		return d.logger.ErrorRet(err, "database.Commit failed", logs.Args{{"volumeName", volumeName}})
+	}
+	return nil
+}
+
+// UpdateVolumeQos records the QoS limits set on the SCBE volume
+func (d *scbeDataModel) UpdateVolumeQos(volumeName string, maxIops int, maxMbps int) error {
+	defer d.logger.Trace(logs.DEBUG, logs.Args{{"volumeName", volumeName}, {"maxIops", maxIops}, {"maxMbps", maxMbps}})()
+
+	scbeVolume, volExists, err := d.GetVolume(volumeName)
+	if err != nil {
+		return d.logger.ErrorRet(err, "GetVolume failed")
+	}
+	if !volExists {
+		return d.logger.ErrorRet(&volumeNotFoundError{volumeName}, "failed")
+	}
+	qos := map[string]interface{}{"max_iops": maxIops, "max_mbps": maxMbps}
+	if err = d.database.Table("scbe_volumes").Where("id = ?", scbeVolume.ID).Updates(qos).Error; err != nil {
+		return d.logger.ErrorRet(err, "failed", logs.Args{{"volumeName", volumeName}})
+	}
+	return nil
+}
+
+func (d *scbeDataModel) UpdateVolumeState(volumeName string, state string) error {
+	defer d.logger.Trace(logs.DEBUG, logs.Args{{"volumeName", volumeName}, {"state", state}})()
+
+	volume, err := model.GetVolume(d.database, volumeName, d.backend)
+	if err != nil {
+		return d.logger.ErrorRet(err, "model.GetVolume failed", logs.Args{{"volumeName", volumeName}})
+	}
+	if err = model.UpdateVolumeState(d.database, &volume, state); err != nil {
+		return d.logger.ErrorRet(err, "model.UpdateVolumeState failed", logs.Args{{"volumeName", volumeName}})
+	}
+	return nil
+}
+
+func stateOfAttachTo(attachTo string) string {
+	if attachTo == AttachedToNothing {
+		return resources.VolumeStateAvailable
+	}
+	return resources.VolumeStateAttached
+}
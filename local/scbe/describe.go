@@ -0,0 +1,59 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scbe
+
+import (
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+func (s *scbeLocalClient) IsActivated() bool {
+	s.activationLock.RLock()
+	defer s.activationLock.RUnlock()
+	return s.isActivated
+}
+
+func (s *scbeLocalClient) Capabilities() []string {
+	capabilities := []string{resources.CapabilityMultiWriter} // with a clustered fstype
+	if s.placer != nil {
+		capabilities = append(capabilities, resources.CapabilityPlacement)
+	}
+	return capabilities
+}
+
+// ListPools returns the SCBE services delegated to ubiquity, with their capacity, QoS limits and number of volumes
+func (s *scbeLocalClient) ListPools() ([]resources.StoragePool, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	services, err := s.scbeRestClient.ListServices()
+	if err != nil {
+		return nil, s.logger.ErrorRet(err, "scbeRestClient.ListServices failed")
+	}
+	pools := make([]resources.StoragePool, 0, len(services))
+	for _, service := range services {
+		pools = append(pools, resources.StoragePool{
+			Name:       service.Name,
+			TotalBytes: uint64(service.TotalCapacity),
+			UsedBytes:  uint64(service.UsedCapacity),
+			FreeBytes:  uint64(service.MaxResourceFreeSizeForProvisioning),
+			Volumes:    service.NumVolumes,
+			MaxIops:    service.QosMaxIops,
+			MaxMbps:    service.QosMaxMbps,
+		})
+	}
+	return pools, nil
+}
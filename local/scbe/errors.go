@@ -18,6 +18,8 @@ package scbe
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/midoblgsm/ubiquity/resources"
 )
 
@@ -106,6 +108,16 @@ func (e *provisionParamIsNotNumberError) Error() string {
 	return fmt.Sprintf("Fail to provision a volume [%s] because the [%s] option is not a number", e.volName, e.param)
 }
 
+type qosLimitIsNegativeError struct {
+	volName string
+	param   string
+	limit   int
+}
+
+func (e *qosLimitIsNegativeError) Error() string {
+	return fmt.Sprintf("Fail to set the QoS of volume [%s] because the [%s] option [%d] is negative", e.volName, e.param, e.limit)
+}
+
 type volAlreadyAttachedError struct {
 	volName  string
 	hostName string
@@ -132,6 +144,27 @@ func (e *volNotAttachedError) Error() string {
 	return fmt.Sprintf("Volume [%s] not attached", e.volName)
 }
 
+type volNotAttachedToHostError struct {
+	volName  string
+	hostName string
+}
+
+func (e *volNotAttachedToHostError) Error() string {
+	return fmt.Sprintf("Volume [%s] not attached to [%s]", e.volName, e.hostName)
+}
+
+type accessModeFsTypeMismatchError struct {
+	volName        string
+	accessMode     string
+	fstype         string
+	clusteredTypes string
+}
+
+func (e *accessModeFsTypeMismatchError) Error() string {
+	return fmt.Sprintf("Volume [%s] with access mode [%s] cannot use fstype [%s], only multi-writer volumes use the clustered fstypes [%s] and they must use one",
+		e.volName, e.accessMode, e.fstype, e.clusteredTypes)
+}
+
 type ConfigDefaultSizeNotNumError struct {
 	size string
 }
@@ -184,3 +217,64 @@ func (e *InValidRequestError) Error() string {
 		e.paramCurrentValue,
 		e.paramExpectedToBe)
 }
+
+type volumeMappedToManyHostsError struct {
+	volName  string
+	numHosts int
+}
+
+func (e *volumeMappedToManyHostsError) Error() string {
+	return fmt.Sprintf("Volume with WWN [%s] is mapped to [%d] hosts, but expected to be mapped to one host at most", e.volName, e.numHosts)
+}
+
+type hostIdNotFoundError struct {
+	volName string
+	hostId  int
+}
+
+func (e *hostIdNotFoundError) Error() string {
+	return fmt.Sprintf("Host id [%d] that the volume with WWN [%s] is mapped to was not found", e.hostId, e.volName)
+}
+
+type driftRepairNotSupportedError struct {
+	kind string
+}
+
+func (e *driftRepairNotSupportedError) Error() string {
+	return fmt.Sprintf("Repair of drift kind [%s] is not supported", e.kind)
+}
+
+type orphanVolumeIsMappedError struct {
+	wwn      string
+	hostName string
+}
+
+func (e *orphanVolumeIsMappedError) Error() string {
+	return fmt.Sprintf("Orphan volume with WWN [%s] is mapped to host [%s], unmap it manually before it can be deleted", e.wwn, e.hostName)
+}
+
+type orphanVolumeIsBeingCreatedError struct {
+	wwn     string
+	volName string
+}
+
+func (e *orphanVolumeIsBeingCreatedError) Error() string {
+	return fmt.Sprintf("Orphan volume with WWN [%s] is being created as [%s], skipping repair", e.wwn, e.volName)
+}
+
+type driftNoLongerExistsError struct {
+	kind   string
+	volume string
+}
+
+func (e *driftNoLongerExistsError) Error() string {
+	return fmt.Sprintf("Drift [%s] of [%s] no longer exists, skipping repair", e.kind, e.volume)
+}
+
+type volumesRecoveryFailedError struct {
+	volumes []string
+}
+
+func (e *volumesRecoveryFailedError) Error() string {
+	return fmt.Sprintf("Recovery of volumes [%s] failed, they were moved to the error state", strings.Join(e.volumes, ","))
+}
@@ -0,0 +1,238 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scbe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Reconcile compare the volumes in the DB with the SCBE volumes and their mappings, matching them by WWN since adopted volumes keep
+// their own names. Only the SCBE volumes of this ubiquity instance (u_<instance>_ prefix) can be orphans.
+func (s *scbeLocalClient) Reconcile() ([]resources.Drift, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		return nil, s.logger.ErrorRet(err, "dataModel.ListVolumes failed")
+	}
+	storageVolumes, err := s.listStorageVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []resources.Drift
+	wwnsInDb := make(map[string]bool)
+	creatingNames := s.getCreatingVolumeNames(volumesInDb)
+	for _, volume := range volumesInDb {
+		if isCreationInFlight(volume) {
+			continue // CreateVolume has not stored the WWN yet, or the recovery will finish the creation
+		}
+		wwnsInDb[volume.WWN] = true
+		if _, exists := storageVolumes[volume.WWN]; !exists {
+			drifts = append(drifts, s.newDrift(resources.DriftMissingStorage, volume.Volume.Name, volume.WWN))
+			continue
+		}
+		if model.IsMultiAttach(volume.Volume.AccessMode) {
+			inDB, onStorage, err := s.getAttachedHosts(volume)
+			if err != nil {
+				return nil, err
+			}
+			if inDB != onStorage {
+				drift := s.newDrift(resources.DriftAttachMismatch, volume.Volume.Name, volume.WWN)
+				drift.InDB = inDB
+				drift.OnStorage = onStorage
+				drifts = append(drifts, drift)
+			}
+			continue
+		}
+		host, err := s.scbeRestClient.GetVolMapping(volume.WWN)
+		if err != nil {
+			return nil, s.logger.ErrorRet(err, "scbeRestClient.GetVolMapping failed", logs.Args{{"volume", volume.Volume.Name}})
+		}
+		if host != volume.AttachTo {
+			drift := s.newDrift(resources.DriftAttachMismatch, volume.Volume.Name, volume.WWN)
+			drift.InDB = volume.AttachTo
+			drift.OnStorage = host
+			drifts = append(drifts, drift)
+		}
+	}
+	for wwn, storageVolume := range storageVolumes {
+		if !wwnsInDb[wwn] && s.isInstanceVolume(storageVolume.Name) && !creatingNames[storageVolume.Name] {
+			drifts = append(drifts, s.newDrift(resources.DriftOrphanStorage, "", wwn))
+		}
+	}
+
+	s.logger.Info("reconcile done", logs.Args{{"volumesInDb", len(volumesInDb)}, {"volumesOnStorage", len(storageVolumes)}, {"drifts", len(drifts)}})
+	return drifts, nil
+}
+
+// RepairDrift fix one drift found by Reconcile, after checking the storage again:
+//
+//	missing-storage - delete the volume from the DB
+//	attach-mismatch - update the DB to the host (or hosts, for multi-attach volumes) the volume is mapped to
+//	orphan-storage - delete the SCBE volume, only if it is not mapped to any host and no volume of the DB is
+//	                 (or is being created as) this SCBE volume
+func (s *scbeLocalClient) RepairDrift(drift resources.Drift) error {
+	defer s.logger.Trace(logs.DEBUG, logs.Args{{"drift", drift}})()
+
+	switch drift.Kind {
+	case resources.DriftMissingStorage:
+		volumes, err := s.scbeRestClient.GetVolumes(drift.Resource)
+		if err != nil {
+			return s.logger.ErrorRet(err, "scbeRestClient.GetVolumes failed")
+		}
+		if len(volumes) != 0 {
+			return s.logger.ErrorRet(&driftNoLongerExistsError{drift.Kind, drift.Volume}, "failed")
+		}
+		if err := s.dataModel.DeleteVolume(drift.Volume); err != nil {
+			return s.logger.ErrorRet(err, "dataModel.DeleteVolume failed")
+		}
+	case resources.DriftAttachMismatch:
+		existingVolume, volExists, err := s.dataModel.GetVolume(drift.Volume)
+		if err != nil {
+			return s.logger.ErrorRet(err, "dataModel.GetVolume failed")
+		}
+		if !volExists {
+			return s.logger.ErrorRet(&volumeNotFoundError{drift.Volume}, "failed")
+		}
+		if model.IsMultiAttach(existingVolume.Volume.AccessMode) {
+			inDB, onStorage, err := s.getAttachedHosts(existingVolume)
+			if err != nil {
+				return err
+			}
+			if inDB == onStorage {
+				return s.logger.ErrorRet(&driftNoLongerExistsError{drift.Kind, drift.Volume}, "failed")
+			}
+			var hosts []string
+			if onStorage != "" {
+				hosts = strings.Split(onStorage, ",")
+			}
+			if err = s.dataModel.SetVolumeAttachments(drift.Volume, existingVolume, hosts); err != nil {
+				return s.logger.ErrorRet(err, "dataModel.SetVolumeAttachments failed")
+			}
+			break
+		}
+		host, err := s.scbeRestClient.GetVolMapping(existingVolume.WWN)
+		if err != nil {
+			return s.logger.ErrorRet(err, "scbeRestClient.GetVolMapping failed")
+		}
+		if host == existingVolume.AttachTo {
+			return s.logger.ErrorRet(&driftNoLongerExistsError{drift.Kind, drift.Volume}, "failed")
+		}
+		if err = s.dataModel.UpdateVolumeAttachTo(drift.Volume, existingVolume, host); err != nil {
+			return s.logger.ErrorRet(err, "dataModel.UpdateVolumeAttachTo failed")
+		}
+	case resources.DriftOrphanStorage:
+		// the orphan drifts run without a volume lock, a CreateVolume may have provisioned it since Reconcile
+		storageVolumes, err := s.scbeRestClient.GetVolumes(drift.Resource)
+		if err != nil {
+			return s.logger.ErrorRet(err, "scbeRestClient.GetVolumes failed")
+		}
+		if len(storageVolumes) == 0 {
+			return s.logger.ErrorRet(&driftNoLongerExistsError{drift.Kind, drift.Resource}, "failed")
+		}
+		volumesInDb, err := s.dataModel.ListVolumes()
+		if err != nil {
+			return s.logger.ErrorRet(err, "dataModel.ListVolumes failed")
+		}
+		for _, volume := range volumesInDb {
+			if volume.WWN == drift.Resource {
+				return s.logger.ErrorRet(&driftNoLongerExistsError{drift.Kind, drift.Resource}, "failed")
+			}
+		}
+		if s.getCreatingVolumeNames(volumesInDb)[storageVolumes[0].Name] {
+			return s.logger.ErrorRet(&orphanVolumeIsBeingCreatedError{drift.Resource, storageVolumes[0].Name}, "failed")
+		}
+		host, err := s.scbeRestClient.GetVolMapping(drift.Resource)
+		if err != nil {
+			return s.logger.ErrorRet(err, "scbeRestClient.GetVolMapping failed")
+		}
+		if host != EmptyHost {
+			return s.logger.ErrorRet(&orphanVolumeIsMappedError{drift.Resource, host}, "failed")
+		}
+		if err = s.scbeRestClient.DeleteVolume(drift.Resource); err != nil {
+			return s.logger.ErrorRet(err, "scbeRestClient.DeleteVolume failed")
+		}
+	default:
+		return s.logger.ErrorRet(&driftRepairNotSupportedError{drift.Kind}, "failed")
+	}
+	s.logger.Info("drift repaired", logs.Args{{"drift", drift}})
+	return nil
+}
+
+// getAttachedHosts return the sorted, comma separated hosts a multi-attach volume is attached to in the DB and mapped to on SCBE
+func (s *scbeLocalClient) getAttachedHosts(volume ScbeVolume) (string, string, error) {
+	attachments, err := s.dataModel.GetVolumeAttachments(volume.Volume.Name)
+	if err != nil {
+		return "", "", s.logger.ErrorRet(err, "dataModel.GetVolumeAttachments failed", logs.Args{{"volume", volume.Volume.Name}})
+	}
+	var hostsInDB []string
+	for _, attachment := range attachments {
+		hostsInDB = append(hostsInDB, attachment.Host)
+	}
+	hostsOnStorage, err := s.scbeRestClient.GetVolMappings(volume.WWN)
+	if err != nil {
+		return "", "", s.logger.ErrorRet(err, "scbeRestClient.GetVolMappings failed", logs.Args{{"volume", volume.Volume.Name}})
+	}
+	sort.Strings(hostsInDB)
+	sort.Strings(hostsOnStorage)
+	return strings.Join(hostsInDB, ","), strings.Join(hostsOnStorage, ","), nil
+}
+
+// isCreationInFlight return true for a volume inserted in the DB by CreateVolume before it stored its WWN
+func isCreationInFlight(volume ScbeVolume) bool {
+	return volume.Volume.State == resources.VolumeStateCreating && volume.WWN == ""
+}
+
+// getCreatingVolumeNames return the SCBE names of the volumes whose creation is in flight
+func (s *scbeLocalClient) getCreatingVolumeNames(volumesInDb []ScbeVolume) map[string]bool {
+	names := make(map[string]bool)
+	for _, volume := range volumesInDb {
+		if isCreationInFlight(volume) {
+			names[fmt.Sprintf(ComposeVolumeName, s.config.UbiquityInstanceName, volume.Volume.Name)] = true
+		}
+	}
+	return names
+}
+
+// listStorageVolumes return the SCBE volumes, keyed by WWN
+func (s *scbeLocalClient) listStorageVolumes() (map[string]ScbeVolumeInfo, error) {
+	volumes, err := s.scbeRestClient.GetVolumes("")
+	if err != nil {
+		return nil, s.logger.ErrorRet(err, "scbeRestClient.GetVolumes failed")
+	}
+	storageVolumes := make(map[string]ScbeVolumeInfo)
+	for _, volume := range volumes {
+		storageVolumes[volume.Wwn] = volume
+	}
+	return storageVolumes, nil
+}
+
+// isInstanceVolume return true for the SCBE volumes created by this ubiquity instance
+func (s *scbeLocalClient) isInstanceVolume(name string) bool {
+	return strings.HasPrefix(name, fmt.Sprintf(ComposeVolumeName, s.config.UbiquityInstanceName, ""))
+}
+
+func (s *scbeLocalClient) newDrift(kind string, volumeName string, wwn string) resources.Drift {
+	return resources.Drift{Kind: kind, Backend: s.backend, Volume: volumeName, Resource: wwn}
+}
@@ -0,0 +1,135 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scbe
+
+import (
+	"fmt"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// RecoverVolumes complete or roll back the operations that were interrupted while the volumes were in a transitional state.
+// A volume that cannot be recovered is moved to the error state.
+func (s *scbeLocalClient) RecoverVolumes(locker resources.VolumeLocker) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		return s.logger.ErrorRet(err, "dataModel.ListVolumes failed")
+	}
+
+	var failed []string
+	for _, volume := range volumesInDb {
+		if !model.IsVolumeStateTransitional(volume.Volume.State) {
+			continue
+		}
+		if err := s.recoverLockedVolume(locker, volume.Volume.Name); err != nil {
+			failed = append(failed, volume.Volume.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return s.logger.ErrorRet(&volumesRecoveryFailedError{failed}, "failed")
+	}
+	return nil
+}
+
+// recoverLockedVolume reads the volume again under its lock, another server may have completed the operation meanwhile
+func (s *scbeLocalClient) recoverLockedVolume(locker resources.VolumeLocker, name string) error {
+	locker.WriteLock(name)
+	defer locker.WriteUnlock(name)
+	volume, exists, err := s.dataModel.GetVolume(name)
+	if err != nil {
+		return s.logger.ErrorRet(err, "dataModel.GetVolume failed", logs.Args{{"volume", name}})
+	}
+	if !exists || !model.IsVolumeStateTransitional(volume.Volume.State) {
+		return nil
+	}
+	s.logger.Info("recovering volume", logs.Args{{"volume", name}, {"state", volume.Volume.State}})
+	if err = s.recoverVolume(volume); err != nil {
+		s.logger.Error("volume recovery failed", logs.Args{{"volume", name}, {"error", err}})
+		s.restoreVolumeState(name, resources.VolumeStateError)
+	}
+	return err
+}
+
+func (s *scbeLocalClient) recoverVolume(volume ScbeVolume) error {
+	switch volume.Volume.State {
+	case resources.VolumeStateCreating:
+		wwn := volume.WWN
+		if wwn == "" {
+			storageVolumes, err := s.listStorageVolumes()
+			if err != nil {
+				return err
+			}
+			volNameOnStorage := fmt.Sprintf(ComposeVolumeName, s.config.UbiquityInstanceName, volume.Volume.Name)
+			for _, storageVolume := range storageVolumes {
+				if storageVolume.Name == volNameOnStorage {
+					wwn = storageVolume.Wwn
+					break
+				}
+			}
+		}
+		if wwn == "" {
+			// the volume was never provisioned
+			return s.dataModel.DeleteVolume(volume.Volume.Name)
+		}
+		// the limits recorded with the intent may not be set yet, setting them again is harmless
+		if volume.MaxIops > 0 || volume.MaxMbps > 0 {
+			if err := s.scbeRestClient.SetVolumeQos(wwn, volume.MaxIops, volume.MaxMbps); err != nil {
+				return err
+			}
+		}
+		return s.dataModel.UpdateVolumeWWN(volume.Volume.Name, wwn)
+	case resources.VolumeStateDeleting:
+		storageVolumes, err := s.scbeRestClient.GetVolumes(volume.WWN)
+		if err != nil {
+			return err
+		}
+		if len(storageVolumes) != 0 {
+			if err = s.scbeRestClient.DeleteVolume(volume.WWN); err != nil {
+				return err
+			}
+		}
+		return s.dataModel.DeleteVolume(volume.Volume.Name)
+	case resources.VolumeStateAttaching, resources.VolumeStateDetaching:
+		if model.IsMultiAttach(volume.Volume.AccessMode) {
+			hosts, err := s.scbeRestClient.GetVolMappings(volume.WWN)
+			if err != nil {
+				return err
+			}
+			return s.dataModel.SetVolumeAttachments(volume.Volume.Name, volume, hosts)
+		}
+		host, err := s.scbeRestClient.GetVolMapping(volume.WWN)
+		if err != nil {
+			return err
+		}
+		return s.dataModel.UpdateVolumeAttachTo(volume.Volume.Name, volume, host)
+	}
+	return nil
+}
+
+// restoreVolumeState move the volume back to the given state after a failed operation, the failure is only logged
+func (s *scbeLocalClient) restoreVolumeState(volumeName string, state string) {
+	if state == "" {
+		state = resources.VolumeStateAvailable
+	}
+	if err := s.dataModel.UpdateVolumeState(volumeName, state); err != nil {
+		s.logger.Error("dataModel.UpdateVolumeState failed", logs.Args{{"volume", volumeName}, {"state", state}, {"error", err}})
+	}
+}
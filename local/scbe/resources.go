@@ -178,6 +178,12 @@ type ScbeCreateVolumePostParams struct {
 	SizeUnit string `json:"size_unit"`
 }
 
+// ScbeVolumeQosPutParams sets the QoS limits of a volume, 0 removes a limit
+type ScbeVolumeQosPutParams struct {
+	IOPsLimit        int `json:"IOPs_limit"`
+	BandwidthLimitMB int `json:"bandwidth_limit_MB"`
+}
+
 type ScbeMapVolumePostParams struct {
 	VolumeId string `json:"volume_id"`
 	HostId   int    `json:"host_id"`
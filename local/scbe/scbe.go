@@ -21,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/local/placement"
+	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
 	"github.com/midoblgsm/ubiquity/utils/logs"
@@ -31,52 +33,102 @@ import (
 
 type scbeLocalClient struct {
 	logger         logs.Logger
+	backend        string // name of the backend instance
 	dataModel      ScbeDataModel
 	scbeRestClient ScbeRestClient
 	isActivated    bool
 	config         resources.ScbeConfig
 	activationLock *sync.RWMutex
 	locker         utils.Locker
+	placer         placement.Placer // nil if no placement policy is configured
 }
 
 const (
 	OptionNameForServiceName = "profile"
 	OptionNameForVolumeSize  = "size"
+	OptionNameForMaxIops     = "max-iops" // QoS limits of the volume, 0 or not given for unlimited
+	OptionNameForMaxMbps     = "max-mbps"
 	volumeNamePrefix         = "u_"
 	AttachedToNothing        = "" // during provisioning the volume is not attached to any host
 	EmptyHost                = ""
 	ComposeVolumeName        = volumeNamePrefix + "%s_%s" // e.g u_instance1_volName
 	MaxVolumeNameLength      = 63                         // IBM block storage max volume name cannot exceed this length
+	bytesInSizeUnit          = 1024 * 1024 * 1024         // the size option is in DefaultSizeUnit
 
-	GetVolumeConfigExtraParams = 1 // number of extra params added to the VolumeConfig beyond the scbe volume struct
+	GetVolumeConfigExtraParams = 3 // number of extra params added to the VolumeConfig beyond the scbe volume struct
 )
 
 var (
 	SupportedFSTypes = []string{"ext4", "xfs"}
+	// SupportedClusteredFSTypes can be mounted read write by many hosts, only multi-writer volumes use them
+	SupportedClusteredFSTypes = []string{"gfs2", "ocfs2"}
 )
 
-func NewScbeLocalClient(config resources.ScbeConfig, database *gorm.DB) (resources.StorageClient, error) {
-	logger := logs.GetLogger()
-	datamodel := NewScbeDataModel(database, resources.SCBE)
+func init() {
+	resources.RegisterBackend(resources.BackendPlugin{
+		Type: resources.SCBE,
+		DecodeConfig: func(backend resources.BackendConfig) (interface{}, bool, error) {
+			return backend.ScbeConfig, backend.ScbeConfig.ConnectionInfo.ManagementIP != "", nil
+		},
+		NewClient: func(backend string, settings interface{}, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+			hostLocker, err := model.NewLocker(config.LockConfig, database, hostLockerNamespace(backend))
+			if err != nil {
+				return nil, err
+			}
+			return NewScbeLocalClient(backend, settings.(resources.ScbeConfig), database, hostLocker)
+		},
+		ValidateConfig: func(settings interface{}, config resources.UbiquityServerConfig) error {
+			return ValidateConfig(settings.(resources.ScbeConfig))
+		},
+		DefaultAccessMode: resources.AccessModeSingleWriter, // a volume is mapped to one host
+	})
+}
+
+// hostLockerNamespace keeps the namespace of the single SCBE backend, so the servers that are not upgraded yet
+// still serialize their attachments with the upgraded ones
+func hostLockerNamespace(backend string) string {
+	if backend == resources.SCBE {
+		return "scbe-host"
+	}
+	return "scbe-host/" + backend
+}
+
+// NewScbeLocalClient creates the client of the SCBE backend instance named backend, hostLocker serializes the attachments
+// to the same host (it is shared between the servers when the database locks are used)
+func NewScbeLocalClient(backend string, config resources.ScbeConfig, database *gorm.DB, hostLocker utils.Locker) (resources.StorageClient, error) {
+	logger := logs.GetComponentLogger(logs.ComponentScbe)
+	datamodel := NewScbeDataModel(database, backend)
 	err := datamodel.CreateVolumeTable()
 	if err != nil {
 		return &scbeLocalClient{}, logger.ErrorRet(err, "failed")
 	}
 	scbeRestClient := NewScbeRestClient(config.ConnectionInfo)
-	return NewScbeLocalClientWithNewScbeRestClientAndDataModel(config, datamodel, scbeRestClient)
+	return newScbeLocalClient(backend, config, datamodel, scbeRestClient, hostLocker)
 }
 func NewScbeLocalClientWithNewScbeRestClientAndDataModel(config resources.ScbeConfig, dataModel ScbeDataModel, scbeRestClient ScbeRestClient) (resources.StorageClient, error) {
+	return newScbeLocalClient(resources.SCBE, config, dataModel, scbeRestClient, utils.NewLocker())
+}
+
+func newScbeLocalClient(backend string, config resources.ScbeConfig, dataModel ScbeDataModel, scbeRestClient ScbeRestClient, hostLocker utils.Locker) (resources.StorageClient, error) {
 	if err := validateScbeConfig(&config); err != nil {
 		return &scbeLocalClient{}, err
 	}
 
 	client := &scbeLocalClient{
-		logger:         logs.GetLogger(),
+		logger:         logs.GetComponentLogger(logs.ComponentScbe),
+		backend:        backend,
 		scbeRestClient: scbeRestClient, // TODO need to mock it in more advance way
 		dataModel:      dataModel,
 		config:         config,
 		activationLock: &sync.RWMutex{},
-		locker:         utils.NewLocker(),
+		locker:         hostLocker,
+	}
+	if config.PlacementPolicy != "" {
+		placer, err := placement.NewPlacer(config.PlacementPolicy)
+		if err != nil {
+			return &scbeLocalClient{}, err
+		}
+		client.placer = placer
 	}
 	if err := basicScbeLocalClientStartupAndValidation(client); err != nil {
 		return &scbeLocalClient{}, err
@@ -84,6 +136,20 @@ func NewScbeLocalClientWithNewScbeRestClientAndDataModel(config resources.ScbeCo
 	return client, nil
 }
 
+// ValidateConfig checks config like NewScbeLocalClient: the parameters, the login to SCBE and the default service,
+// without touching the database
+func ValidateConfig(config resources.ScbeConfig) error {
+	return ValidateConfigWithScbeRestClient(config, NewScbeRestClient(config.ConnectionInfo))
+}
+
+func ValidateConfigWithScbeRestClient(config resources.ScbeConfig, scbeRestClient ScbeRestClient) error {
+	if err := validateScbeConfig(&config); err != nil {
+		return err
+	}
+	client := &scbeLocalClient{logger: logs.GetComponentLogger(logs.ComponentScbe), backend: resources.SCBE, scbeRestClient: scbeRestClient, config: config}
+	return basicScbeLocalClientStartupAndValidation(client)
+}
+
 // basicScbeLocalClientStartup validate config params, login to SCBE and validate default exist
 func basicScbeLocalClientStartupAndValidation(s *scbeLocalClient) error {
 	if err := s.scbeRestClient.Login(); err != nil {
@@ -104,7 +170,7 @@ func basicScbeLocalClientStartupAndValidation(s *scbeLocalClient) error {
 }
 
 func validateScbeConfig(config *resources.ScbeConfig) error {
-	logger := logs.GetLogger()
+	logger := logs.GetComponentLogger(logs.ComponentScbe)
 
 	if config.DefaultVolumeSize == "" {
 		// means customer didn't configure the default
@@ -130,6 +196,10 @@ func validateScbeConfig(config *resources.ScbeConfig) error {
 	if len(config.UbiquityInstanceName) > resources.UbiquityInstanceNameMaxSize {
 		return logger.ErrorRet(&ConfigScbeUbiquityInstanceNameWrongSize{}, "failed")
 	}
+
+	if err := placement.ValidatePolicy(config.PlacementPolicy); err != nil {
+		return logger.ErrorRet(err, "failed")
+	}
 	// TODO add more verification on the config file.
 	return nil
 }
@@ -179,6 +249,16 @@ func (s *scbeLocalClient) CreateVolume(createVolumeRequest resources.CreateVolum
 		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(&provisionParamIsNotNumberError{createVolumeRequest.Name, OptionNameForVolumeSize}, "failed")}
 	}
 
+	// validate the QoS options are numbers
+	maxIops, err := s.getQosOption(createVolumeRequest.Name, createVolumeRequest.Metadata, OptionNameForMaxIops)
+	if err != nil {
+		return resources.CreateVolumeResponse{Error: err}
+	}
+	maxMbps, err := s.getQosOption(createVolumeRequest.Name, createVolumeRequest.Metadata, OptionNameForMaxMbps)
+	if err != nil {
+		return resources.CreateVolumeResponse{Error: err}
+	}
+
 	// validate fstype option given
 	fstypeInt, ok := createVolumeRequest.Metadata[resources.OptionNameForVolumeFsType]
 	var fstype string
@@ -189,17 +269,32 @@ func (s *scbeLocalClient) CreateVolume(createVolumeRequest resources.CreateVolum
 	} else {
 		fstype = fstypeInt
 	}
-	if !utils.StringInSlice(fstype, SupportedFSTypes) {
+	isClusteredFS := utils.StringInSlice(fstype, SupportedClusteredFSTypes)
+	if !utils.StringInSlice(fstype, SupportedFSTypes) && !isClusteredFS {
+		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(
+			&FsTypeNotSupportedError{createVolumeRequest.Name, fstype, strings.Join(append(SupportedFSTypes, SupportedClusteredFSTypes...), ",")}, "failed")}
+	}
+	// ext4 and xfs are corrupted when written from two hosts, only a clustered filesystem can be mapped to many writers
+	if (createVolumeRequest.AccessMode == resources.AccessModeMultiWriter) != isClusteredFS {
 		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(
-			&FsTypeNotSupportedError{createVolumeRequest.Name, fstype, strings.Join(SupportedFSTypes, ",")}, "failed")}
+			&accessModeFsTypeMismatchError{createVolumeRequest.Name, createVolumeRequest.AccessMode, fstype, strings.Join(SupportedClusteredFSTypes, ",")}, "failed")}
 	}
 
 	// Get the profile option
 	profile := s.config.DefaultService
+	profilePinned := createVolumeRequest.Metadata[OptionNameForServiceName] != ""
 	if createVolumeRequest.Metadata[OptionNameForServiceName] != "" && createVolumeRequest.Metadata[OptionNameForServiceName] != "" {
 		profile = createVolumeRequest.Metadata[OptionNameForServiceName]
 	}
 
+	// Reject the volume before anything is provisioned if it does not fit
+	if s.placer != nil {
+		profile, err = s.placeVolume(createVolumeRequest.Name, profile, profilePinned, uint64(size)*bytesInSizeUnit)
+		if err != nil {
+			return resources.CreateVolumeResponse{Error: err}
+		}
+	}
+
 	// Generate the designated volume name by template
 	volNameToCreate := fmt.Sprintf(ComposeVolumeName, s.config.UbiquityInstanceName, createVolumeRequest.Name)
 
@@ -213,22 +308,104 @@ func (s *scbeLocalClient) CreateVolume(createVolumeRequest resources.CreateVolum
 	//TODO: check this
 	metadata := resources.VolumeMetadata{Values: createVolumeRequest.Metadata}
 	volume := resources.Volume{Name: createVolumeRequest.Name, CapacityBytes: createVolumeRequest.CapacityBytes, Metadata: metadata, Backend: createVolumeRequest.Backend}
+	// Record the intent before provisioning, so a crash in between is found by RecoverVolumes
+	err = s.dataModel.InsertVolume(createVolumeRequest.Name, "", AttachedToNothing, fstype)
+	if err != nil {
+		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "dataModel.InsertVolume failed")}
+	}
+	// the requested QoS limits are part of the intent, so RecoverVolumes sets them too
+	if maxIops > 0 || maxMbps > 0 {
+		if err = s.dataModel.UpdateVolumeQos(createVolumeRequest.Name, maxIops, maxMbps); err != nil {
+			if deleteErr := s.dataModel.DeleteVolume(createVolumeRequest.Name); deleteErr != nil {
+				s.logger.Error("dataModel.DeleteVolume failed", logs.Args{{"volume", createVolumeRequest.Name}, {"error", deleteErr}})
+			}
+			return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeQos failed")}
+		}
+	}
+
 	// Provision the volume on SCBE service
 	volInfo := ScbeVolumeInfo{}
 	volInfo, err = s.scbeRestClient.CreateVolume(volNameToCreate, profile, size)
 	if err != nil {
+		if deleteErr := s.dataModel.DeleteVolume(createVolumeRequest.Name); deleteErr != nil {
+			s.logger.Error("dataModel.DeleteVolume failed", logs.Args{{"volume", createVolumeRequest.Name}, {"error", deleteErr}})
+		}
 		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.CreateVolume failed")}
 	}
 
-	err = s.dataModel.InsertVolume(createVolumeRequest.Name, volInfo.Wwn, AttachedToNothing, fstype)
+	// SCBE provisions the volume without limits, a volume without the requested limits is removed.
+	// The limits are set while the volume is still creating, so an interrupted create is completed with them
+	if maxIops > 0 || maxMbps > 0 {
+		if err = s.scbeRestClient.SetVolumeQos(volInfo.Wwn, maxIops, maxMbps); err != nil {
+			s.deleteCreatedVolume(createVolumeRequest.Name, volInfo.Wwn)
+			return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.SetVolumeQos failed")}
+		}
+	}
+
+	err = s.dataModel.UpdateVolumeWWN(createVolumeRequest.Name, volInfo.Wwn)
 	if err != nil {
-		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "dataModel.InsertVolume failed")}
+		return resources.CreateVolumeResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeWWN failed")}
 	}
 
 	s.logger.Info("succeeded", logs.Args{{"volume", createVolumeRequest.Name}, {"profile", profile}})
 	return resources.CreateVolumeResponse{Volume: volume}
 }
 
+// deleteCreatedVolume removes a provisioned volume and its row after the rest of the create failed, the failure is only logged.
+// The row is kept if the volume cannot be deleted on SCBE, so the volume is not lost
+func (s *scbeLocalClient) deleteCreatedVolume(volumeName string, wwn string) {
+	if err := s.scbeRestClient.DeleteVolume(wwn); err != nil {
+		s.logger.Error("scbeRestClient.DeleteVolume failed", logs.Args{{"volume", volumeName}, {"error", err}})
+	} else if err = s.dataModel.DeleteVolume(volumeName); err != nil {
+		s.logger.Error("dataModel.DeleteVolume failed", logs.Args{{"volume", volumeName}, {"error", err}})
+	}
+}
+
+// getQosOption returns the QoS limit given in the option of the create request, or 0 (unlimited) if it is not given
+func (s *scbeLocalClient) getQosOption(volName string, opts map[string]string, option string) (int, error) {
+	value, ok := opts[option]
+	if !ok {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, s.logger.ErrorRet(&provisionParamIsNotNumberError{volName, option}, "failed")
+	}
+	if limit < 0 {
+		return 0, s.logger.ErrorRet(&qosLimitIsNegativeError{volName, option, limit}, "failed")
+	}
+	return limit, nil
+}
+
+// placeVolume returns the service of a new volume of sizeBytes: the one the placement policy chooses among the services
+// with enough free space, or profile if it is pinned by the request and has enough free space
+func (s *scbeLocalClient) placeVolume(name string, profile string, pinned bool, sizeBytes uint64) (string, error) {
+	services, err := s.scbeRestClient.ListServices()
+	if err != nil {
+		return "", s.logger.ErrorRet(err, "scbeRestClient.ListServices failed")
+	}
+	var pools []placement.Pool
+	for _, service := range services {
+		if pinned && service.Name != profile {
+			continue
+		}
+		pools = append(pools, placement.Pool{
+			Name:       service.Name,
+			TotalBytes: uint64(service.TotalCapacity),
+			FreeBytes:  uint64(service.MaxResourceFreeSizeForProvisioning),
+		})
+	}
+	if pinned && len(pools) == 0 {
+		return "", s.logger.ErrorRet(&serviceDoesntExistError{name, profile, s.config.ConnectionInfo.ManagementIP}, "failed")
+	}
+	pool, err := s.placer.Place(pools, sizeBytes)
+	if err != nil {
+		return "", s.logger.ErrorRet(err, "placer.Place failed", logs.Args{{"volume", name}})
+	}
+	s.logger.Debug("Placed volume", logs.Args{{"volume", name}, {"service", pool.Name}, {"free_bytes", pool.FreeBytes}})
+	return pool.Name, nil
+}
+
 func (s *scbeLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveVolumeRequest) resources.RemoveVolumeResponse {
 	defer s.logger.Trace(logs.DEBUG)()
 
@@ -244,7 +421,12 @@ func (s *scbeLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveVolum
 		return resources.RemoveVolumeResponse{Error: s.logger.ErrorRet(&CannotDeleteVolWhichAttachedToHostError{removeVolumeRequest.Name, existingVolume.AttachTo}, "failed")}
 	}
 
+	if err = s.dataModel.UpdateVolumeState(removeVolumeRequest.Name, resources.VolumeStateDeleting); err != nil {
+		return resources.RemoveVolumeResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeState failed")}
+	}
+
 	if err = s.scbeRestClient.DeleteVolume(existingVolume.WWN); err != nil {
+		s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
 		return resources.RemoveVolumeResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.DeleteVolume failed")}
 	}
 
@@ -308,9 +490,39 @@ func (s *scbeLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.GetVo
 
 	// The ubiquity remote will use this extra info to determine the fstype needed to be created on this volume while attaching
 	volConfig[resources.OptionNameForVolumeFsType] = scbeVolume.FSType
+	volConfig[OptionNameForMaxIops] = scbeVolume.MaxIops
+	volConfig[OptionNameForMaxMbps] = scbeVolume.MaxMbps
 	return resources.GetVolumeConfigResponse{VolumeConfig: volConfig}
 }
 
+// UpdateVolumeQos sets the QoS limits of the volume on SCBE and records them, 0 removes a limit
+func (s *scbeLocalClient) UpdateVolumeQos(updateVolumeQosRequest resources.UpdateVolumeQosRequest) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	scbeVolume, volExists, err := s.dataModel.GetVolume(updateVolumeQosRequest.Name)
+	if err != nil {
+		return s.logger.ErrorRet(err, "dataModel.GetVolume failed")
+	}
+	if !volExists {
+		return s.logger.ErrorRet(&volumeNotFoundError{updateVolumeQosRequest.Name}, "failed")
+	}
+	if updateVolumeQosRequest.MaxIops < 0 {
+		return s.logger.ErrorRet(&qosLimitIsNegativeError{updateVolumeQosRequest.Name, OptionNameForMaxIops, updateVolumeQosRequest.MaxIops}, "failed")
+	}
+	if updateVolumeQosRequest.MaxMbps < 0 {
+		return s.logger.ErrorRet(&qosLimitIsNegativeError{updateVolumeQosRequest.Name, OptionNameForMaxMbps, updateVolumeQosRequest.MaxMbps}, "failed")
+	}
+
+	if err = s.scbeRestClient.SetVolumeQos(scbeVolume.WWN, updateVolumeQosRequest.MaxIops, updateVolumeQosRequest.MaxMbps); err != nil {
+		return s.logger.ErrorRet(err, "scbeRestClient.SetVolumeQos failed")
+	}
+	if err = s.dataModel.UpdateVolumeQos(updateVolumeQosRequest.Name, updateVolumeQosRequest.MaxIops, updateVolumeQosRequest.MaxMbps); err != nil {
+		return s.logger.ErrorRet(err, "dataModel.UpdateVolumeQos failed")
+	}
+	s.logger.Info("succeeded", logs.Args{{"volume", updateVolumeQosRequest.Name}, {"maxIops", updateVolumeQosRequest.MaxIops}, {"maxMbps", updateVolumeQosRequest.MaxMbps}})
+	return nil
+}
+
 func (s *scbeLocalClient) Attach(attachRequest resources.AttachRequest) resources.AttachResponse {
 	defer s.logger.Trace(logs.DEBUG)()
 
@@ -332,32 +544,48 @@ func (s *scbeLocalClient) Attach(attachRequest resources.AttachRequest) resource
 		return resources.AttachResponse{Error: s.logger.ErrorRet(&volumeNotFoundError{attachRequest.Name}, "failed")}
 	}
 
-	if existingVolume.AttachTo == attachRequest.Host {
+	attachments, err := s.getVolumeAttachments(existingVolume)
+	if err != nil {
+		return resources.AttachResponse{Error: err}
+	}
+	if model.FindVolumeAttachment(attachments, attachRequest.Host) != nil {
 		// if already map to the given host then just ignore and succeed to attach
 		s.logger.Info("Volume already attached, skip backend attach", logs.Args{{"volume", attachRequest.Name}, {"host", attachRequest.Host}})
 		volumeMountpoint := fmt.Sprintf(resources.PathToMountUbiquityBlockDevices, existingVolume.WWN)
 		return resources.AttachResponse{Mountpoint: volumeMountpoint}
-	} else if existingVolume.AttachTo != "" {
-		return resources.AttachResponse{Error: s.logger.ErrorRet(&volAlreadyAttachedError{attachRequest.Name, existingVolume.AttachTo}, "failed")}
+	}
+	if err = model.CheckVolumeAttach(existingVolume.Volume, attachments, attachRequest.Host, attachRequest.ReadOnly); err != nil {
+		if !model.IsMultiAttach(existingVolume.Volume.AccessMode) {
+			err = &volAlreadyAttachedError{attachRequest.Name, existingVolume.AttachTo}
+		}
+		return resources.AttachResponse{Error: s.logger.ErrorRet(err, "failed")}
 	}
 
+	// the state of a volume already attached to other hosts stays attached while one more host is mapped
+	if len(attachments) == 0 {
+		if err = s.dataModel.UpdateVolumeState(attachRequest.Name, resources.VolumeStateAttaching); err != nil {
+			return resources.AttachResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeState failed")}
+		}
+	}
 	// Lock will ensure no other caller attach a volume from the same host concurrently, Prevent SCBE race condition on get next available lun ID
 	s.locker.WriteLock(attachRequest.Host)
 	s.logger.Debug("Attaching", logs.Args{{"volume", existingVolume}})
 	if _, err = s.scbeRestClient.MapVolume(existingVolume.WWN, attachRequest.Host); err != nil {
 		s.locker.WriteUnlock(attachRequest.Host)
+		if len(attachments) == 0 {
+			s.restoreVolumeState(attachRequest.Name, resources.VolumeStateAvailable)
+		}
 		return resources.AttachResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.MapVolume failed")}
 	}
 	s.locker.WriteUnlock(attachRequest.Host)
 
-	if err = s.dataModel.UpdateVolumeAttachTo(attachRequest.Name, existingVolume, attachRequest.Host); err != nil {
-		return resources.AttachResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeAttachTo failed")}
+	if err = s.dataModel.AddVolumeAttachment(attachRequest.Name, existingVolume, attachRequest.Host, attachRequest.ReadOnly); err != nil {
+		return resources.AttachResponse{Error: s.logger.ErrorRet(err, "dataModel.AddVolumeAttachment failed")}
 	}
 
 	volumeMountpoint := fmt.Sprintf(resources.PathToMountUbiquityBlockDevices, existingVolume.WWN)
 	return resources.AttachResponse{Mountpoint: volumeMountpoint}
 }
-
 func (s *scbeLocalClient) Detach(detachRequest resources.DetachRequest) resources.DetachResponse {
 	defer s.logger.Trace(logs.DEBUG)()
 	host2detach := detachRequest.Host
@@ -375,14 +603,30 @@ func (s *scbeLocalClient) Detach(detachRequest resources.DetachRequest) resource
 	if existingVolume.AttachTo == EmptyHost {
 		return resources.DetachResponse{Error: s.logger.ErrorRet(&volNotAttachedError{detachRequest.Name}, "failed")}
 	}
-
+	attachments, err := s.getVolumeAttachments(existingVolume)
+	if err != nil {
+		return resources.DetachResponse{Error: err}
+	}
+	if model.FindVolumeAttachment(attachments, host2detach) == nil {
+		return resources.DetachResponse{Error: s.logger.ErrorRet(&volNotAttachedToHostError{detachRequest.Name, host2detach}, "failed")}
+	}
+	// the state of a volume that stays attached to other hosts is not changed
+	lastAttachment := len(attachments) <= 1
+	if lastAttachment {
+		if err = s.dataModel.UpdateVolumeState(detachRequest.Name, resources.VolumeStateDetaching); err != nil {
+			return resources.DetachResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeState failed")}
+		}
+	}
 	s.logger.Debug("Detaching", logs.Args{{"volume", existingVolume}})
 	if err = s.scbeRestClient.UnmapVolume(existingVolume.WWN, host2detach); err != nil {
+		if lastAttachment {
+			s.restoreVolumeState(detachRequest.Name, resources.VolumeStateAttached)
+		}
 		return resources.DetachResponse{Error: s.logger.ErrorRet(err, "scbeRestClient.UnmapVolume failed")}
 	}
 
-	if err = s.dataModel.UpdateVolumeAttachTo(detachRequest.Name, existingVolume, EmptyHost); err != nil {
-		return resources.DetachResponse{Error: s.logger.ErrorRet(err, "dataModel.UpdateVolumeAttachTo failed")}
+	if err = s.dataModel.RemoveVolumeAttachment(detachRequest.Name, existingVolume, host2detach); err != nil {
+		return resources.DetachResponse{Error: s.logger.ErrorRet(err, "dataModel.RemoveVolumeAttachment failed")}
 	}
 
 	return resources.DetachResponse{}
@@ -407,6 +651,19 @@ func (s *scbeLocalClient) ListVolumes(listVolumesRequest resources.ListVolumesRe
 	return resources.ListVolumesResponse{Volumes: volumes}
 }
 
+// getVolumeAttachments return the hosts the volume is attached to.
+// A volume attached before the attachments were recorded has only its AttachTo host.
+func (s *scbeLocalClient) getVolumeAttachments(volume ScbeVolume) ([]resources.VolumeAttachment, error) {
+	attachments, err := s.dataModel.GetVolumeAttachments(volume.Volume.Name)
+	if err != nil {
+		return nil, s.logger.ErrorRet(err, "dataModel.GetVolumeAttachments failed")
+	}
+	if len(attachments) == 0 && volume.AttachTo != EmptyHost {
+		attachments = []resources.VolumeAttachment{{VolumeID: volume.VolumeID, Host: volume.AttachTo}}
+	}
+	return attachments, nil
+}
+
 func (s *scbeLocalClient) getVolumeMountPoint(volume ScbeVolume) (string, error) {
 	defer s.logger.Trace(logs.DEBUG)()
 
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils/logs"
+	"strconv"
 )
 
 //go:generate counterfeiter -o ../../fakes/fake_scbe_rest_client.go . ScbeRestClient
@@ -32,7 +33,10 @@ type ScbeRestClient interface {
 	MapVolume(wwn string, host string) (ScbeResponseMapping, error)
 	UnmapVolume(wwn string, host string) error
 	GetVolMapping(wwn string) (string, error)
+	GetVolMappings(wwn string) ([]string, error)
 	ServiceExist(serviceName string) (bool, error)
+	ListServices() ([]ScbeStorageService, error)
+	SetVolumeQos(wwn string, maxIops int, maxMbps int) error
 }
 
 type scbeRestClient struct {
@@ -76,7 +80,7 @@ func newScbeRestClient(conInfo resources.ConnectionInfo, simpleClient SimpleRest
 		baseUrl := referrer + UrlScbeBaseSuffix
 		simpleClient = NewSimpleRestClient(conInfo, baseUrl, UrlScbeResourceGetAuth, referrer)
 	}
-	return &scbeRestClient{logs.GetLogger(), conInfo, simpleClient}
+	return &scbeRestClient{logs.GetComponentLogger(logs.ComponentScbe), conInfo, simpleClient}
 }
 
 func (s *scbeRestClient) Login() error {
@@ -182,9 +186,42 @@ func (s *scbeRestClient) UnmapVolume(wwn string, host string) error {
 	return nil
 }
 
+// GetVolMapping return the name of the host the volume(wwn) is mapped to, or EmptyHost if it is not mapped.
+// It fails if the volume is mapped to more than one host, use GetVolMappings for multi-attach volumes.
 func (s *scbeRestClient) GetVolMapping(wwn string) (string, error) {
 	defer s.logger.Trace(logs.DEBUG)()
-	return "", nil
+	hosts, err := s.GetVolMappings(wwn)
+	if err != nil {
+		return "", err
+	}
+	if len(hosts) == 0 {
+		return EmptyHost, nil
+	}
+	if len(hosts) > 1 {
+		return "", s.logger.ErrorRet(&volumeMappedToManyHostsError{wwn, len(hosts)}, "failed")
+	}
+	return hosts[0], nil
+}
+
+// GetVolMappings return the names of all the hosts the volume(wwn) is mapped to
+func (s *scbeRestClient) GetVolMappings(wwn string) ([]string, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+	var mappings []ScbeResponseMapping
+	if err := s.client.Get(UrlScbeResourceMapping, map[string]string{"volume": wwn}, -1, &mappings); err != nil {
+		return nil, s.logger.ErrorRet(err, "client.Get failed", logs.Args{{"wwn", wwn}})
+	}
+	hostNames := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		hosts, err := s.hostList(map[string]string{"id": strconv.Itoa(mapping.Host)})
+		if err != nil {
+			return nil, s.logger.ErrorRet(err, "hostList failed")
+		}
+		if len(hosts) != 1 {
+			return nil, s.logger.ErrorRet(&hostIdNotFoundError{wwn, mapping.Host}, "failed")
+		}
+		hostNames = append(hostNames, hosts[0].Name)
+	}
+	return hostNames, nil
 }
 
 func (s *scbeRestClient) ServiceExist(serviceName string) (exist bool, err error) {
@@ -197,6 +234,26 @@ func (s *scbeRestClient) ServiceExist(serviceName string) (exist bool, err error
 	return false, err
 }
 
+// SetVolumeQos sets the IOPS and MB/s limits of a volume, 0 removes a limit
+func (s *scbeRestClient) SetVolumeQos(wwn string, maxIops int, maxMbps int) error {
+	defer s.logger.Trace(logs.DEBUG)()
+	payload := ScbeVolumeQosPutParams{IOPsLimit: maxIops, BandwidthLimitMB: maxMbps}
+	payloadMarshaled, err := json.Marshal(payload)
+	if err != nil {
+		return s.logger.ErrorRet(err, "json.Marshal failed", logs.Args{{"payload", payload}})
+	}
+	urlToUpdate := fmt.Sprintf("%s/%s", UrlScbeResourceVolume, wwn)
+	if err = s.client.Put(urlToUpdate, payloadMarshaled, HTTP_SUCCEED, nil); err != nil {
+		return s.logger.ErrorRet(err, "client.Put failed", logs.Args{{"url", urlToUpdate}, {"payload", payload}})
+	}
+	return nil
+}
+
+func (s *scbeRestClient) ListServices() ([]ScbeStorageService, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+	return s.serviceList("")
+}
+
 func (s *scbeRestClient) serviceList(serviceName string) ([]ScbeStorageService, error) {
 	defer s.logger.Trace(logs.DEBUG)()
 	payload := map[string]string{}
@@ -19,6 +19,7 @@ package scbe_test
 import (
 	"fmt"
 	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/midoblgsm/ubiquity/local/scbe"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils/logs"
@@ -41,7 +42,7 @@ var _ = Describe("restClient integration testing with existing SCBE instance", f
 		if err != nil {
 			Skip(err.Error())
 		}
-		credentialInfo = resources.CredentialInfo{scbeUser, scbePassword, "flocker"}
+		credentialInfo = resources.CredentialInfo{UserName: scbeUser, Password: scbePassword, Group: "flocker"}
 		conInfo = resources.ConnectionInfo{credentialInfo, scbePort, scbeIP, true}
 		client = scbe.NewSimpleRestClient(
 			conInfo,
@@ -83,7 +84,7 @@ var _ = Describe("ScbeRestClient integration testing with existing SCBE instance
 		if err != nil {
 			Skip(err.Error())
 		}
-		credentialInfo = resources.CredentialInfo{scbeUser, scbePassword, "flocker"}
+		credentialInfo = resources.CredentialInfo{UserName: scbeUser, Password: scbePassword, Group: "flocker"}
 		conInfo = resources.ConnectionInfo{credentialInfo, scbePort, scbeIP, true}
 		scbeRestClient = scbe.NewScbeRestClient(conInfo)
 	})
@@ -124,7 +125,7 @@ var _ = Describe("ScbeRestClient volume operations integration testing with exis
 		if err != nil {
 			Skip(err.Error())
 		}
-		credentialInfo = resources.CredentialInfo{scbeUser, scbePassword, "flocker"}
+		credentialInfo = resources.CredentialInfo{UserName: scbeUser, Password: scbePassword, Group: "flocker"}
 		conInfo = resources.ConnectionInfo{credentialInfo, scbePort, scbeIP, true}
 		scbeRestClient = scbe.NewScbeRestClient(conInfo)
 
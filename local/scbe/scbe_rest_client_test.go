@@ -40,7 +40,7 @@ var _ = Describe("ScbeRestClient", func() {
 	)
 	BeforeEach(func() {
 		fakeSimpleRestClient = new(fakes.FakeSimpleRestClient)
-		credentialInfo := resources.CredentialInfo{"user", "password", "flocker"}
+		credentialInfo := resources.CredentialInfo{UserName: "user", Password: "password", Group: "flocker"}
 		conInfo := resources.ConnectionInfo{credentialInfo, 8440, "ip", true}
 		scbeRestClient = scbe.NewScbeRestClientWithSimpleRestClient(conInfo, fakeSimpleRestClient)
 	})
@@ -132,6 +132,24 @@ var _ = Describe("ScbeRestClient", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+	Context(".SetVolumeQos", func() {
+		It("succeed upon simple rest client success", func() {
+			err = scbeRestClient.SetVolumeQos(volIdentifier, 1000, 200)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSimpleRestClient.PutCallCount()).To(Equal(1))
+			url, payload, status, _ := fakeSimpleRestClient.PutArgsForCall(0)
+			Expect(url).To(Equal(scbe.UrlScbeResourceVolume + "/" + volIdentifier))
+			Expect(status).To(Equal(scbe.HTTP_SUCCEED))
+			var params scbe.ScbeVolumeQosPutParams
+			Expect(json.Unmarshal(payload, &params)).To(Succeed())
+			Expect(params).To(Equal(scbe.ScbeVolumeQosPutParams{IOPsLimit: 1000, BandwidthLimitMB: 200}))
+		})
+		It("fail upon simple rest client error", func() {
+			fakeSimpleRestClient.PutReturns(restErr)
+			err = scbeRestClient.SetVolumeQos(volIdentifier, 1000, 0)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 	Context(".GetVolumes", func() {
 		It("succeed and return a few ScbeVolumeInfo", func() {
 			volumes := []scbe.ScbeResponseVolume{
@@ -174,6 +192,61 @@ var _ = Describe("ScbeRestClient", func() {
 			Expect(err).To(MatchError(restErr))
 		})
 	})
+	Context(".GetVolMapping", func() {
+		It("return empty host if the volume is not mapped", func() {
+			fakeSimpleRestClient.GetStub = OverrideGetStub([]scbe.ScbeResponseMapping{})
+			host, err := scbeRestClient.GetVolMapping(volIdentifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host).To(Equal(scbe.EmptyHost))
+			resource, params, _, _ := fakeSimpleRestClient.GetArgsForCall(0)
+			Expect(resource).To(Equal(scbe.UrlScbeResourceMapping))
+			Expect(params).To(Equal(map[string]string{"volume": volIdentifier}))
+		})
+		It("return the name of the host the volume is mapped to", func() {
+			mappingsStub := OverrideGetStub([]scbe.ScbeResponseMapping{{Id: 1, Volume: volIdentifier, Host: 7}})
+			hostsStub := OverrideGetStub([]scbe.ScbeResponseHost{{Id: 7, Name: "host7"}})
+			fakeSimpleRestClient.GetStub = func(resource_url string, params map[string]string, exitStatus int, v interface{}) error {
+				if resource_url == scbe.UrlScbeResourceHost {
+					Expect(params).To(Equal(map[string]string{"id": "7"}))
+					return hostsStub(resource_url, params, exitStatus, v)
+				}
+				return mappingsStub(resource_url, params, exitStatus, v)
+			}
+			host, err := scbeRestClient.GetVolMapping(volIdentifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host).To(Equal("host7"))
+		})
+		It("fail if the volume is mapped to more than one host", func() {
+			fakeSimpleRestClient.GetStub = OverrideGetStub([]scbe.ScbeResponseMapping{{Host: 1}, {Host: 2}})
+			_, err := scbeRestClient.GetVolMapping(volIdentifier)
+			Expect(err).To(HaveOccurred())
+		})
+		It("fail upon SimpleRestClient error", func() {
+			fakeSimpleRestClient.GetReturns(restErr)
+			_, err := scbeRestClient.GetVolMapping(volIdentifier)
+			Expect(err).To(MatchError(restErr))
+		})
+	})
+	Context(".GetVolMappings", func() {
+		It("return the names of all the hosts the volume is mapped to", func() {
+			mappingsStub := OverrideGetStub([]scbe.ScbeResponseMapping{{Id: 1, Volume: volIdentifier, Host: 7}, {Id: 2, Volume: volIdentifier, Host: 8}})
+			fakeSimpleRestClient.GetStub = func(resource_url string, params map[string]string, exitStatus int, v interface{}) error {
+				if resource_url == scbe.UrlScbeResourceHost {
+					return OverrideGetStub([]scbe.ScbeResponseHost{{Name: "host" + params["id"]}})(resource_url, params, exitStatus, v)
+				}
+				return mappingsStub(resource_url, params, exitStatus, v)
+			}
+			hosts, err := scbeRestClient.GetVolMappings(volIdentifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hosts).To(Equal([]string{"host7", "host8"}))
+		})
+		It("return no host if the volume is not mapped", func() {
+			fakeSimpleRestClient.GetStub = OverrideGetStub([]scbe.ScbeResponseMapping{})
+			hosts, err := scbeRestClient.GetVolMappings(volIdentifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hosts).To(BeEmpty())
+		})
+	})
 })
 
 func OverrideGetStub(override interface{}) func(resource_url string, params map[string]string, exitStatus int, v interface{}) error {
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/midoblgsm/ubiquity/fakes"
+	"github.com/midoblgsm/ubiquity/local/placement"
 	"github.com/midoblgsm/ubiquity/local/scbe"
 	"github.com/midoblgsm/ubiquity/resources"
 	. "github.com/onsi/ginkgo"
@@ -136,6 +137,51 @@ var _ = Describe("scbeLocalClient init", func() {
 		})
 
 	})
+	Context(".ValidateConfig", func() {
+		BeforeEach(func() {
+			fakeConfig = resources.ScbeConfig{DefaultService: fakeDefaultProfile}
+		})
+		It("should fail without login if the config params are wrong", func() {
+			fakeConfig.DefaultVolumeSize = "badint"
+			err = scbe.ValidateConfigWithScbeRestClient(fakeConfig, fakeScbeRestClient)
+			_, ok := err.(*scbe.ConfigDefaultSizeNotNumError)
+			Expect(ok).To(Equal(true))
+			Expect(fakeScbeRestClient.LoginCallCount()).To(Equal(0))
+		})
+		It("should fail if the login fails", func() {
+			fakeScbeRestClient.LoginReturns(fmt.Errorf(fakeError))
+			err = scbe.ValidateConfigWithScbeRestClient(fakeConfig, fakeScbeRestClient)
+			Expect(err).To(MatchError(fakeError))
+			Expect(fakeScbeRestClient.ServiceExistCallCount()).To(Equal(0))
+		})
+		It("should fail if the default service does not exist", func() {
+			fakeScbeRestClient.ServiceExistReturns(false, nil)
+			err = scbe.ValidateConfigWithScbeRestClient(fakeConfig, fakeScbeRestClient)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeRestClient.ServiceExistArgsForCall(0)).To(Equal(fakeDefaultProfile))
+		})
+		It("should succeed if the default service exists", func() {
+			fakeScbeRestClient.ServiceExistReturns(true, nil)
+			err = scbe.ValidateConfigWithScbeRestClient(fakeConfig, fakeScbeRestClient)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+	Context("registered backend", func() {
+		It("should serve the instances with a management IP and map their volumes to one host", func() {
+			plugin, ok := resources.GetBackend(resources.SCBE)
+			Expect(ok).To(BeTrue())
+			Expect(plugin.DefaultAccessMode).To(Equal(resources.AccessModeSingleWriter))
+			_, configured, err := plugin.DecodeConfig(resources.BackendConfig{Name: resources.SCBE, Type: resources.SCBE})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configured).To(BeFalse())
+			backend := resources.BackendConfig{Name: "scbe-dc2", Type: resources.SCBE}
+			backend.ScbeConfig.ConnectionInfo.ManagementIP = "scbe-dc2.example.com"
+			settings, configured, err := plugin.DecodeConfig(backend)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configured).To(BeTrue())
+			Expect(settings).To(Equal(backend.ScbeConfig))
+		})
+	})
 })
 
 var _ = Describe("scbeLocalClient", func() {
@@ -278,6 +324,19 @@ var _ = Describe("scbeLocalClient", func() {
 			_, ok := createVolumeResponse.Error.(*scbe.FsTypeNotSupportedError)
 			Expect(ok).To(Equal(true))
 		})
+		It("should fail create volume if a multi-writer volume does not use a clustered fstype", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			opts := map[string]string{resources.OptionNameForVolumeFsType: "ext4"}
+			req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: opts, AccessMode: resources.AccessModeMultiWriter}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(HaveOccurred())
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+			req.Metadata = map[string]string{resources.OptionNameForVolumeFsType: "gfs2"}
+			req.AccessMode = resources.AccessModeSingleWriter
+			createVolumeResponse = client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(HaveOccurred())
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+		})
 
 		It("should fail create volume if vol len exeeded", func() {
 			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
@@ -365,9 +424,34 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(1))
 			name, wwn, host, fstype := fakeScbeDataModel.InsertVolumeArgsForCall(0)
 			Expect(name).To(Equal(volFake))
-			Expect(wwn).To(Equal("wwn1"))
+			Expect(wwn).To(Equal(""))
 			Expect(host).To(Equal(scbe.AttachedToNothing))
 			Expect(fstype).To(Equal("ext4"))
+			Expect(fakeScbeRestClient.CreateVolumeCallCount()).To(Equal(0))
+		})
+		It("should delete the vol from DB if vol creation failed", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeRestClient.CreateVolumeReturns(scbe.ScbeVolumeInfo{}, fmt.Errorf("error"))
+
+			volFake := "fakevol"
+			req := resources.CreateVolumeRequest{Name: volFake, Backend: resources.SCBE, Metadata: make(map[string]string)}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(HaveOccurred())
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeArgsForCall(0)).To(Equal(volFake))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(0))
+		})
+		It("should fail to update the vol WWN in DB after create it", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeRestClient.CreateVolumeReturns(scbe.ScbeVolumeInfo{Name: "v1", Wwn: "wwn1", Profile: "gold"}, nil)
+			fakeScbeDataModel.UpdateVolumeWWNReturns(fmt.Errorf("error"))
+
+			req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: make(map[string]string)}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(HaveOccurred())
+			Expect(createVolumeResponse.Error.Error()).To(Equal("error"))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
 		})
 
 		It("should succeed to insert vol to DB after create it", func() {
@@ -385,9 +469,66 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(1))
 			name, wwn, host, fstype := fakeScbeDataModel.InsertVolumeArgsForCall(0)
 			Expect(name).To(Equal(volFake))
-			Expect(wwn).To(Equal("wwn1"))
+			Expect(wwn).To(Equal(""))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+			updatedName, updatedWwn := fakeScbeDataModel.UpdateVolumeWWNArgsForCall(0)
+			Expect(updatedName).To(Equal(volFake))
+			Expect(updatedWwn).To(Equal("wwn1"))
 			Expect(fstype).To(Equal("ext4"))
 			Expect(host).To(Equal(scbe.AttachedToNothing))
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(0))
+		})
+		It("should set the QoS limits of the volume after creating it", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeRestClient.CreateVolumeReturns(scbe.ScbeVolumeInfo{Name: "v1", Wwn: "wwn1", Profile: "gold"}, nil)
+			opts := map[string]string{scbe.OptionNameForMaxIops: "1000", scbe.OptionNameForMaxMbps: "200"}
+			req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: opts}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).NotTo(HaveOccurred())
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(1))
+			wwn, maxIops, maxMbps := fakeScbeRestClient.SetVolumeQosArgsForCall(0)
+			Expect(wwn).To(Equal("wwn1"))
+			Expect(maxIops).To(Equal(1000))
+			Expect(maxMbps).To(Equal(200))
+			Expect(fakeScbeDataModel.UpdateVolumeQosCallCount()).To(Equal(1))
+			name, maxIops, maxMbps := fakeScbeDataModel.UpdateVolumeQosArgsForCall(0)
+			Expect(name).To(Equal("fakevol"))
+			Expect(maxIops).To(Equal(1000))
+			Expect(maxMbps).To(Equal(200))
+		})
+		It("should fail create volume if a QoS limit is not a positive number", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			for _, value := range []string{"fast", "-1"} {
+				req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: map[string]string{scbe.OptionNameForMaxMbps: value}}
+				createVolumeResponse := client.CreateVolume(req)
+				Expect(createVolumeResponse.Error).To(HaveOccurred())
+			}
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+			Expect(fakeScbeRestClient.CreateVolumeCallCount()).To(Equal(0))
+		})
+		It("should remove the volume if its QoS limits cannot be set", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeRestClient.CreateVolumeReturns(scbe.ScbeVolumeInfo{Name: "v1", Wwn: "wwn1", Profile: "gold"}, nil)
+			fakeScbeRestClient.SetVolumeQosReturns(fakeErr)
+			req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: map[string]string{scbe.OptionNameForMaxIops: "1000"}}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(MatchError(fakeErr))
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeRestClient.DeleteVolumeArgsForCall(0)).To(Equal("wwn1"))
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeArgsForCall(0)).To(Equal("fakevol"))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(0))
+		})
+		It("should not provision the volume if its QoS limits cannot be recorded", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeDataModel.UpdateVolumeQosReturns(fakeErr)
+			req := resources.CreateVolumeRequest{Name: "fakevol", Backend: resources.SCBE, Metadata: map[string]string{scbe.OptionNameForMaxIops: "1000"}}
+			createVolumeResponse := client.CreateVolume(req)
+			Expect(createVolumeResponse.Error).To(MatchError(fakeErr))
+			Expect(fakeScbeRestClient.CreateVolumeCallCount()).To(Equal(0))
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(0))
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeArgsForCall(0)).To(Equal("fakevol"))
 		})
 		It("should succeed to insert vol to DB even if size not provided", func() {
 			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
@@ -404,7 +545,11 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(1))
 			name, wwn, host, fstype := fakeScbeDataModel.InsertVolumeArgsForCall(0)
 			Expect(name).To(Equal(volFake))
-			Expect(wwn).To(Equal("wwn1"))
+			Expect(wwn).To(Equal(""))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+			updatedName, updatedWwn := fakeScbeDataModel.UpdateVolumeWWNArgsForCall(0)
+			Expect(updatedName).To(Equal(volFake))
+			Expect(updatedWwn).To(Equal("wwn1"))
 			Expect(fstype).To(Equal("ext4"))
 			Expect(host).To(Equal(scbe.AttachedToNothing))
 		})
@@ -423,12 +568,93 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(1))
 			name, wwn, host, fstype := fakeScbeDataModel.InsertVolumeArgsForCall(0)
 			Expect(name).To(Equal(volFake))
-			Expect(wwn).To(Equal("wwn1"))
+			Expect(wwn).To(Equal(""))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+			updatedName, updatedWwn := fakeScbeDataModel.UpdateVolumeWWNArgsForCall(0)
+			Expect(updatedName).To(Equal(volFake))
+			Expect(updatedWwn).To(Equal("wwn1"))
 			Expect(fstype).To(Equal("xfs"))
 			Expect(host).To(Equal(scbe.AttachedToNothing))
 		})
 
 	})
+	Context(".CreateVolume with a placement policy", func() {
+		BeforeEach(func() {
+			fakeConfig.PlacementPolicy = "most-free"
+			client, err = scbe.NewScbeLocalClientWithNewScbeRestClientAndDataModel(fakeConfig, fakeScbeDataModel, fakeScbeRestClient)
+			Expect(err).ToNot(HaveOccurred())
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			fakeScbeRestClient.CreateVolumeReturns(scbe.ScbeVolumeInfo{Name: "v1", Wwn: "wwn1"}, nil)
+			fakeScbeRestClient.ListServicesReturns([]scbe.ScbeStorageService{
+				{Name: "gold", MaxResourceFreeSizeForProvisioning: 20 * 1024 * 1024 * 1024},
+				{Name: "silver", MaxResourceFreeSizeForProvisioning: 50 * 1024 * 1024 * 1024},
+				{Name: "bronze", MaxResourceFreeSizeForProvisioning: 5 * 1024 * 1024 * 1024},
+			}, nil)
+		})
+		It("should fail to init with an unknown policy", func() {
+			fakeConfig.PlacementPolicy = "round-robin"
+			_, err = scbe.NewScbeLocalClientWithNewScbeRestClientAndDataModel(fakeConfig, fakeScbeDataModel, fakeScbeRestClient)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should create the volume on the service with the most free space if no profile is given", func() {
+			opts := map[string]string{scbe.OptionNameForVolumeSize: "10"}
+			createVolumeResponse := client.CreateVolume(resources.CreateVolumeRequest{Name: "fakevol", Metadata: opts})
+			Expect(createVolumeResponse.Error).NotTo(HaveOccurred())
+			_, profile, size := fakeScbeRestClient.CreateVolumeArgsForCall(0)
+			Expect(profile).To(Equal("silver"))
+			Expect(size).To(Equal(10))
+		})
+		It("should keep the given profile if it has enough free space", func() {
+			opts := map[string]string{scbe.OptionNameForVolumeSize: "10", scbe.OptionNameForServiceName: "gold"}
+			createVolumeResponse := client.CreateVolume(resources.CreateVolumeRequest{Name: "fakevol", Metadata: opts})
+			Expect(createVolumeResponse.Error).NotTo(HaveOccurred())
+			_, profile, _ := fakeScbeRestClient.CreateVolumeArgsForCall(0)
+			Expect(profile).To(Equal("gold"))
+		})
+		It("should reject the volume before inserting it if the given profile is too small", func() {
+			opts := map[string]string{scbe.OptionNameForVolumeSize: "10", scbe.OptionNameForServiceName: "bronze"}
+			createVolumeResponse := client.CreateVolume(resources.CreateVolumeRequest{Name: "fakevol", Metadata: opts})
+			Expect(createVolumeResponse.Error).To(BeAssignableToTypeOf(&placement.NoCapacityError{}))
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+			Expect(fakeScbeRestClient.CreateVolumeCallCount()).To(Equal(0))
+		})
+		It("should reject the volume before inserting it if no service has enough free space", func() {
+			opts := map[string]string{scbe.OptionNameForVolumeSize: "100"}
+			createVolumeResponse := client.CreateVolume(resources.CreateVolumeRequest{Name: "fakevol", Metadata: opts})
+			Expect(createVolumeResponse.Error).To(BeAssignableToTypeOf(&placement.NoCapacityError{}))
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+		})
+		It("should fail if the service list cannot be fetched", func() {
+			fakeScbeRestClient.ListServicesReturns(nil, fakeErr)
+			createVolumeResponse := client.CreateVolume(resources.CreateVolumeRequest{Name: "fakevol", Metadata: map[string]string{}})
+			Expect(createVolumeResponse.Error).To(Equal(fakeErr))
+			Expect(fakeScbeDataModel.InsertVolumeCallCount()).To(Equal(0))
+		})
+	})
+	Context(".ListPools", func() {
+		It("should list the services with their capacity, QoS limits and volumes", func() {
+			fakeScbeRestClient.ListServicesReturns([]scbe.ScbeStorageService{
+				{Name: "gold", TotalCapacity: 1000, UsedCapacity: 300, MaxResourceFreeSizeForProvisioning: 600, NumVolumes: 4, QosMaxIops: 5000, QosMaxMbps: 200},
+			}, nil)
+			pools, err := client.(resources.PoolLister).ListPools()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pools).To(Equal([]resources.StoragePool{
+				{Name: "gold", TotalBytes: 1000, UsedBytes: 300, FreeBytes: 600, Volumes: 4, MaxIops: 5000, MaxMbps: 200},
+			}))
+		})
+		It("should fail if the service list cannot be fetched", func() {
+			fakeScbeRestClient.ListServicesReturns(nil, fakeErr)
+			_, err := client.(resources.PoolLister).ListPools()
+			Expect(err).To(Equal(fakeErr))
+		})
+		It("should report the activation and the placement", func() {
+			describer := client.(resources.BackendDescriber)
+			Expect(describer.IsActivated()).To(BeFalse())
+			Expect(describer.Capabilities()).ToNot(ContainElement(resources.CapabilityPlacement))
+			Expect(client.Activate(resources.ActivateRequest{}).Error).ToNot(HaveOccurred())
+			Expect(describer.IsActivated()).To(BeTrue())
+		})
+	})
 })
 
 var _ = Describe("scbeLocalClient", func() {
@@ -499,27 +725,58 @@ var _ = Describe("scbeLocalClient", func() {
 		It("should fail to attach the volume if update the vol in the DB failed", func() {
 			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{AttachTo: ""}, true, nil)
 			fakeScbeRestClient.MapVolumeReturns(scbe.ScbeResponseMapping{}, nil)
-			fakeScbeDataModel.UpdateVolumeAttachToReturns(fakeErr)
+			fakeScbeDataModel.AddVolumeAttachmentReturns(fakeErr)
 			attachResponse := client.Attach(fakeAttachRequest)
 			Expect(attachResponse.Error).To(HaveOccurred())
 			Expect(attachResponse.Error).To(MatchError(fakeErr))
-			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.AddVolumeAttachmentCallCount()).To(Equal(1))
 		})
 		It("should succeed to attach the volume when everything is cool", func() {
 			fakeScbeDataModel.GetVolumeReturns(
 				scbe.ScbeVolume{AttachTo: ""}, true, nil)
 			fakeScbeRestClient.MapVolumeReturns(scbe.ScbeResponseMapping{}, nil)
-			fakeScbeDataModel.UpdateVolumeAttachToReturns(nil)
+			fakeScbeDataModel.AddVolumeAttachmentReturns(nil)
 			attachResponse := client.Attach(fakeAttachRequest)
 			Expect(attachResponse.Error).NotTo(HaveOccurred())
-			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.AddVolumeAttachmentCallCount()).To(Equal(1))
 		})
 		It("should succeed to attach the volume if vol already attach to this host", func() {
 			fakeScbeDataModel.GetVolumeReturns(
 				scbe.ScbeVolume{AttachTo: fakeHost}, true, nil)
 			attachResponse := client.Attach(fakeAttachRequest)
 			Expect(attachResponse.Error).NotTo(HaveOccurred())
-			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(0))
+			Expect(fakeScbeDataModel.AddVolumeAttachmentCallCount()).To(Equal(0))
+		})
+		It("should fail to attach a single-writer volume attached to another host", func() {
+			fakeScbeDataModel.GetVolumeReturns(
+				scbe.ScbeVolume{Volume: resources.Volume{AccessMode: resources.AccessModeSingleWriter}, AttachTo: fakeHost2}, true, nil)
+			fakeScbeDataModel.GetVolumeAttachmentsReturns([]resources.VolumeAttachment{{Host: fakeHost2}}, nil)
+			attachResponse := client.Attach(fakeAttachRequest)
+			Expect(attachResponse.Error).To(HaveOccurred())
+			Expect(fakeScbeRestClient.MapVolumeCallCount()).To(Equal(0))
+		})
+		It("should map a multi-writer volume to one more host without changing its state", func() {
+			fakeScbeDataModel.GetVolumeReturns(
+				scbe.ScbeVolume{Volume: resources.Volume{AccessMode: resources.AccessModeMultiWriter}, AttachTo: fakeHost2}, true, nil)
+			fakeScbeDataModel.GetVolumeAttachmentsReturns([]resources.VolumeAttachment{{Host: fakeHost2}}, nil)
+			attachResponse := client.Attach(fakeAttachRequest)
+			Expect(attachResponse.Error).NotTo(HaveOccurred())
+			Expect(fakeScbeRestClient.MapVolumeCallCount()).To(Equal(1))
+			_, host := fakeScbeRestClient.MapVolumeArgsForCall(0)
+			Expect(host).To(Equal(fakeHost))
+			Expect(fakeScbeDataModel.UpdateVolumeStateCallCount()).To(Equal(0))
+			Expect(fakeScbeDataModel.AddVolumeAttachmentCallCount()).To(Equal(1))
+		})
+		It("should allow only read only hosts next to the writer of a multi-reader volume", func() {
+			fakeScbeDataModel.GetVolumeReturns(
+				scbe.ScbeVolume{Volume: resources.Volume{AccessMode: resources.AccessModeMultiReader}, AttachTo: fakeHost2}, true, nil)
+			fakeScbeDataModel.GetVolumeAttachmentsReturns([]resources.VolumeAttachment{{Host: fakeHost2}}, nil)
+			attachResponse := client.Attach(fakeAttachRequest)
+			Expect(attachResponse.Error).To(HaveOccurred())
+			attachResponse = client.Attach(resources.AttachRequest{Name: fakeVol, Host: fakeHost, ReadOnly: true})
+			Expect(attachResponse.Error).NotTo(HaveOccurred())
+			_, _, _, readOnly := fakeScbeDataModel.AddVolumeAttachmentArgsForCall(0)
+			Expect(readOnly).To(BeTrue())
 		})
 
 	})
@@ -552,20 +809,36 @@ var _ = Describe("scbeLocalClient", func() {
 		It("should fail to detach the volume if update the vol in the DB failed", func() {
 			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{AttachTo: fakeHost}, true, nil)
 			fakeScbeRestClient.UnmapVolumeReturns(nil)
-			fakeScbeDataModel.UpdateVolumeAttachToReturns(fakeErr)
+			fakeScbeDataModel.RemoveVolumeAttachmentReturns(fakeErr)
 			detachResponse := client.Detach(fakeDetachRequest)
 			Expect(detachResponse.Error).To(HaveOccurred())
 			Expect(detachResponse.Error).To(MatchError(fakeErr))
-			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.RemoveVolumeAttachmentCallCount()).To(Equal(1))
 		})
 		It("should succeed to detach the volume when everything is cool", func() {
 			fakeScbeDataModel.GetVolumeReturns(
 				scbe.ScbeVolume{AttachTo: fakeHost}, true, nil)
 			fakeScbeRestClient.UnmapVolumeReturns(nil)
-			fakeScbeDataModel.UpdateVolumeAttachToReturns(nil)
+			fakeScbeDataModel.RemoveVolumeAttachmentReturns(nil)
 			detachResponse := client.Detach(fakeDetachRequest)
 			Expect(detachResponse.Error).NotTo(HaveOccurred())
-			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.RemoveVolumeAttachmentCallCount()).To(Equal(1))
+		})
+		It("should fail to detach the volume from a host it is not attached to", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{AttachTo: fakeHost2}, true, nil)
+			detachResponse := client.Detach(fakeDetachRequest)
+			Expect(detachResponse.Error).To(HaveOccurred())
+			Expect(fakeScbeRestClient.UnmapVolumeCallCount()).To(Equal(0))
+		})
+		It("should unmap one host of a multi-attach volume and keep it attached", func() {
+			fakeScbeDataModel.GetVolumeReturns(
+				scbe.ScbeVolume{Volume: resources.Volume{AccessMode: resources.AccessModeMultiWriter}, AttachTo: fakeHost2}, true, nil)
+			fakeScbeDataModel.GetVolumeAttachmentsReturns([]resources.VolumeAttachment{{Host: fakeHost2}, {Host: fakeHost}}, nil)
+			detachResponse := client.Detach(fakeDetachRequest)
+			Expect(detachResponse.Error).NotTo(HaveOccurred())
+			Expect(fakeScbeDataModel.UpdateVolumeStateCallCount()).To(Equal(0))
+			_, _, host := fakeScbeDataModel.RemoveVolumeAttachmentArgsForCall(0)
+			Expect(host).To(Equal(fakeHost))
 		})
 
 	})
@@ -577,7 +850,7 @@ var _ = Describe("scbeLocalClient", func() {
 			for i := 0; i < val.Type().NumField(); i++ {
 				reflect.ValueOf(vol).Elem().Field(i).SetString(val.Type().Field(i).Name)
 			}
-			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn", FSType: "ext4"}, true, nil)
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn", FSType: "ext4", MaxIops: 500}, true, nil)
 			fakeScbeRestClient.GetVolumesReturns(volumes, nil)
 			getVolumeConfigResponse := client.GetVolumeConfig(resources.GetVolumeConfigRequest{"name"})
 			Expect(getVolumeConfigResponse.Error).To(Not(HaveOccurred()))
@@ -585,9 +858,11 @@ var _ = Describe("scbeLocalClient", func() {
 			fstype, ok := getVolumeConfigResponse.VolumeConfig[resources.OptionNameForVolumeFsType]
 			Expect(ok).To(Equal(true))
 			Expect(fstype).To(Equal("ext4"))
+			Expect(getVolumeConfigResponse.VolumeConfig[scbe.OptionNameForMaxIops]).To(Equal(500))
+			Expect(getVolumeConfigResponse.VolumeConfig[scbe.OptionNameForMaxMbps]).To(Equal(0))
 
 			for k, v := range getVolumeConfigResponse.VolumeConfig {
-				if k == resources.OptionNameForVolumeFsType {
+				if k == resources.OptionNameForVolumeFsType || k == scbe.OptionNameForMaxIops || k == scbe.OptionNameForMaxMbps {
 					continue
 				}
 				Expect(k).To(Not(Equal("")))
@@ -620,6 +895,46 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(getVolumeConfigResponse.Error).To(HaveOccurred())
 		})
 	})
+	Context(".UpdateVolumeQos", func() {
+		var updater resources.VolumeQosUpdater
+		BeforeEach(func() {
+			updater = client.(resources.VolumeQosUpdater)
+		})
+		It("should set the limits on SCBE and record them", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn1"}, true, nil)
+			err = updater.UpdateVolumeQos(resources.UpdateVolumeQosRequest{Name: "vol1", MaxIops: 1000, MaxMbps: 0})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(1))
+			wwn, maxIops, maxMbps := fakeScbeRestClient.SetVolumeQosArgsForCall(0)
+			Expect(wwn).To(Equal("wwn1"))
+			Expect(maxIops).To(Equal(1000))
+			Expect(maxMbps).To(Equal(0))
+			Expect(fakeScbeDataModel.UpdateVolumeQosCallCount()).To(Equal(1))
+			name, maxIops, maxMbps := fakeScbeDataModel.UpdateVolumeQosArgsForCall(0)
+			Expect(name).To(Equal("vol1"))
+			Expect(maxIops).To(Equal(1000))
+			Expect(maxMbps).To(Equal(0))
+		})
+		It("should fail if the volume does not exist", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, nil)
+			err = updater.UpdateVolumeQos(resources.UpdateVolumeQosRequest{Name: "vol1", MaxIops: 1000})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(0))
+		})
+		It("should fail if a limit is negative", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn1"}, true, nil)
+			err = updater.UpdateVolumeQos(resources.UpdateVolumeQosRequest{Name: "vol1", MaxMbps: -1})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(0))
+		})
+		It("should not record the limits if SCBE fails to set them", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn1"}, true, nil)
+			fakeScbeRestClient.SetVolumeQosReturns(fakeErr)
+			err = updater.UpdateVolumeQos(resources.UpdateVolumeQosRequest{Name: "vol1", MaxIops: 1000})
+			Expect(err).To(MatchError(fakeErr))
+			Expect(fakeScbeDataModel.UpdateVolumeQosCallCount()).To(Equal(0))
+		})
+	})
 	Context(".Remove", func() {
 		It("should fail to remove the volume if GetVolume failed", func() {
 			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{}, false, fakeErr)
@@ -649,6 +964,19 @@ var _ = Describe("scbeLocalClient", func() {
 			Expect(removeVolumeResponse.Error).To(HaveOccurred())
 			Expect(removeVolumeResponse.Error).To(MatchError(fakeErr))
 			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.UpdateVolumeStateCallCount()).To(Equal(2))
+			_, state := fakeScbeDataModel.UpdateVolumeStateArgsForCall(0)
+			Expect(state).To(Equal(resources.VolumeStateDeleting))
+			_, state = fakeScbeDataModel.UpdateVolumeStateArgsForCall(1)
+			Expect(state).To(Equal(resources.VolumeStateAvailable))
+		})
+		It("should fail to remove the volume if fail to mark it as deleting", func() {
+			fakeScbeDataModel.GetVolumeReturns(
+				scbe.ScbeVolume{AttachTo: scbe.EmptyHost}, true, nil)
+			fakeScbeDataModel.UpdateVolumeStateReturns(fakeErr)
+			removeVolumeResponse := client.RemoveVolume(fakeRemoveRequest)
+			Expect(removeVolumeResponse.Error).To(MatchError(fakeErr))
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(0))
 		})
 		It("should fail to remove the volume if fail to delete from DB", func() {
 			fakeScbeDataModel.GetVolumeReturns(
@@ -674,3 +1002,324 @@ var _ = Describe("scbeLocalClient", func() {
 	})
 
 })
+
+var _ = Describe("scbeLocalClient", func() {
+	var (
+		client             resources.StorageClient
+		reconciler         resources.BackendReconciler
+		fakeScbeDataModel  *fakes.FakeScbeDataModel
+		fakeScbeRestClient *fakes.FakeScbeRestClient
+		fakeConfig         resources.ScbeConfig
+		fakeErr            error = errors.New("fake error")
+		err                error
+	)
+	BeforeEach(func() {
+		fakeScbeDataModel = new(fakes.FakeScbeDataModel)
+		fakeScbeRestClient = new(fakes.FakeScbeRestClient)
+		fakeConfig = resources.ScbeConfig{
+			ConfigPath:           "/tmp",
+			DefaultService:       fakeDefaultProfile,
+			UbiquityInstanceName: "inst1"}
+
+		fakeScbeRestClient.LoginReturns(nil)
+		fakeScbeRestClient.ServiceExistReturns(true, nil)
+		client, err = scbe.NewScbeLocalClientWithNewScbeRestClientAndDataModel(
+			fakeConfig,
+			fakeScbeDataModel,
+			fakeScbeRestClient)
+		Expect(err).ToNot(HaveOccurred())
+		reconciler = client.(resources.BackendReconciler)
+	})
+
+	Context(".Reconcile", func() {
+		It("should fail if fail to list the storage volumes", func() {
+			fakeScbeRestClient.GetVolumesReturns(nil, fakeErr)
+			_, err := reconciler.Reconcile()
+			Expect(err).To(MatchError(fakeErr))
+		})
+		It("should report missing, orphan and mismatched volumes and ignore volumes of other instances", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1"}, WWN: "wwn1", AttachTo: fakeHost},
+				{Volume: resources.Volume{Name: "vol2"}, WWN: "wwn2", AttachTo: fakeHost},
+				{Volume: resources.Volume{Name: "vol3"}, WWN: "wwn3"},
+			}, nil)
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{
+				{Name: "u_inst1_vol1", Wwn: "wwn1"},
+				{Name: "u_inst1_vol2", Wwn: "wwn2"},
+				{Name: "u_inst1_orphan", Wwn: "wwn4"},
+				{Name: "u_inst2_vol5", Wwn: "wwn5"},
+			}, nil)
+			fakeScbeRestClient.GetVolMappingReturnsOnCall(0, fakeHost, nil)
+			fakeScbeRestClient.GetVolMappingReturnsOnCall(1, fakeHost2, nil)
+
+			drifts, err := reconciler.Reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeScbeRestClient.GetVolumesArgsForCall(0)).To(Equal(""))
+			Expect(drifts).To(ConsistOf(
+				resources.Drift{Kind: resources.DriftAttachMismatch, Backend: resources.SCBE, Volume: "vol2", Resource: "wwn2", InDB: fakeHost, OnStorage: fakeHost2},
+				resources.Drift{Kind: resources.DriftMissingStorage, Backend: resources.SCBE, Volume: "vol3", Resource: "wwn3"},
+				resources.Drift{Kind: resources.DriftOrphanStorage, Backend: resources.SCBE, Resource: "wwn4"},
+			))
+		})
+		It("should not report a volume being created as missing nor its SCBE volume as orphan", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}},
+			}, nil)
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_vol1", Wwn: "wwn1"}}, nil)
+			drifts, err := reconciler.Reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifts).To(BeEmpty())
+		})
+	})
+	Context(".RepairDrift", func() {
+		It("should delete the volume from the DB if it is still missing on the storage", func() {
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{}, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftMissingStorage, Volume: "vol3", Resource: "wwn3"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeArgsForCall(0)).To(Equal("vol3"))
+		})
+		It("should not delete the volume from the DB if it showed up on the storage", func() {
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Wwn: "wwn3"}}, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftMissingStorage, Volume: "vol3", Resource: "wwn3"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(0))
+		})
+		It("should update the DB to the host the volume is mapped to", func() {
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{WWN: "wwn2", AttachTo: fakeHost}, true, nil)
+			fakeScbeRestClient.GetVolMappingReturns(fakeHost2, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftAttachMismatch, Volume: "vol2", Resource: "wwn2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			_, _, host := fakeScbeDataModel.UpdateVolumeAttachToArgsForCall(0)
+			Expect(host).To(Equal(fakeHost2))
+		})
+		It("should not delete an orphan volume that is mapped", func() {
+			fakeScbeRestClient.GetVolMappingReturns(fakeHost, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftOrphanStorage, Resource: "wwn4"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(0))
+		})
+		It("should delete an orphan volume that is not mapped", func() {
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_orphan", Wwn: "wwn4"}}, nil)
+			fakeScbeRestClient.GetVolMappingReturns(scbe.EmptyHost, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftOrphanStorage, Resource: "wwn4"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeScbeRestClient.GetVolumesArgsForCall(0)).To(Equal("wwn4"))
+			Expect(fakeScbeRestClient.DeleteVolumeArgsForCall(0)).To(Equal("wwn4"))
+		})
+		It("should not delete an orphan volume that a CreateVolume provisioned since the reconcile", func() {
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_vol1", Wwn: "wwn1"}}, nil)
+			fakeScbeRestClient.GetVolMappingReturns(scbe.EmptyHost, nil)
+			// the WWN is not stored yet
+			fakeScbeDataModel.ListVolumesReturnsOnCall(0, []scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}},
+			}, nil)
+			// the WWN is stored
+			fakeScbeDataModel.ListVolumesReturnsOnCall(1, []scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAvailable}, WWN: "wwn1"},
+			}, nil)
+			for i := 0; i < 2; i++ {
+				err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftOrphanStorage, Resource: "wwn1"})
+				Expect(err).To(HaveOccurred())
+			}
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(0))
+		})
+		It("should skip an orphan volume that was already deleted", func() {
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{}, nil)
+			err := reconciler.RepairDrift(resources.Drift{Kind: resources.DriftOrphanStorage, Resource: "wwn4"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("scbeLocalClient", func() {
+	var (
+		client             resources.StorageClient
+		adopter            resources.BackendAdopter
+		fakeScbeDataModel  *fakes.FakeScbeDataModel
+		fakeScbeRestClient *fakes.FakeScbeRestClient
+		fakeConfig         resources.ScbeConfig
+		fakeErr            error = errors.New("fake error")
+	)
+	BeforeEach(func() {
+		fakeScbeDataModel = new(fakes.FakeScbeDataModel)
+		fakeScbeRestClient = new(fakes.FakeScbeRestClient)
+		fakeConfig = resources.ScbeConfig{
+			ConfigPath:           "/tmp",
+			DefaultService:       fakeDefaultProfile,
+			UbiquityInstanceName: "inst1"}
+
+		fakeScbeRestClient.LoginReturns(nil)
+		fakeScbeRestClient.ServiceExistReturns(true, nil)
+		var err error
+		client, err = scbe.NewScbeLocalClientWithNewScbeRestClientAndDataModel(
+			fakeConfig,
+			fakeScbeDataModel,
+			fakeScbeRestClient)
+		Expect(err).ToNot(HaveOccurred())
+		adopter = client.(resources.BackendAdopter)
+		fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{{Volume: resources.Volume{Name: "known"}, WWN: "wwn0"}}, nil)
+		fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{
+			{Name: "legacy_known", Wwn: "wwn0", Profile: fakeDefaultProfile},
+			{Name: "legacy_1", Wwn: "wwn1", Profile: fakeDefaultProfile},
+			{Name: "u_inst1_legacy_2", Wwn: "wwn2", Profile: fakeDefaultProfile},
+			{Name: "legacy_3", Wwn: "wwn3", Profile: "otherProfile"},
+			{Name: "other_4", Wwn: "wwn4", Profile: fakeDefaultProfile},
+		}, nil)
+	})
+
+	Context(".RecoverVolumes", func() {
+		var (
+			recoverer resources.VolumeRecoverer
+			locker    *fakes.FakeLocker
+		)
+		BeforeEach(func() {
+			recoverer = client.(resources.VolumeRecoverer)
+			locker = new(fakes.FakeLocker)
+		})
+		It("should skip the volumes that are not in a transitional state", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAvailable}, WWN: "wwn1"},
+				{Volume: resources.Volume{Name: "vol2", State: resources.VolumeStateError}, WWN: "wwn2"},
+			}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeScbeRestClient.GetVolumesCallCount()).To(Equal(0))
+			Expect(fakeScbeDataModel.UpdateVolumeStateCallCount()).To(Equal(0))
+			Expect(locker.WriteLockCallCount()).To(Equal(0))
+		})
+		It("should set the WWN of a created volume found on the storage and delete a volume never provisioned", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}},
+				{Volume: resources.Volume{Name: "vol2", State: resources.VolumeStateCreating}},
+			}, nil)
+			fakeScbeDataModel.GetVolumeReturnsOnCall(0, scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}}, true, nil)
+			fakeScbeDataModel.GetVolumeReturnsOnCall(1, scbe.ScbeVolume{Volume: resources.Volume{Name: "vol2", State: resources.VolumeStateCreating}}, true, nil)
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_vol1", Wwn: "wwn1"}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+			name, wwn := fakeScbeDataModel.UpdateVolumeWWNArgsForCall(0)
+			Expect(name).To(Equal("vol1"))
+			Expect(wwn).To(Equal("wwn1"))
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeDataModel.DeleteVolumeArgsForCall(0)).To(Equal("vol2"))
+		})
+		It("should set the QoS limits recorded with a created volume", func() {
+			volume := scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}, MaxIops: 1000, MaxMbps: 200}
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{volume}, nil)
+			fakeScbeDataModel.GetVolumeReturns(volume, true, nil)
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_vol1", Wwn: "wwn1"}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeScbeRestClient.SetVolumeQosCallCount()).To(Equal(1))
+			wwn, maxIops, maxMbps := fakeScbeRestClient.SetVolumeQosArgsForCall(0)
+			Expect(wwn).To(Equal("wwn1"))
+			Expect(maxIops).To(Equal(1000))
+			Expect(maxMbps).To(Equal(200))
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+
+			fakeScbeRestClient.SetVolumeQosReturns(fakeErr)
+			Expect(recoverer.RecoverVolumes(locker)).ToNot(Succeed())
+			Expect(fakeScbeDataModel.UpdateVolumeWWNCallCount()).To(Equal(1))
+			_, state := fakeScbeDataModel.UpdateVolumeStateArgsForCall(0)
+			Expect(state).To(Equal(resources.VolumeStateError))
+		})
+		It("should resume the deletion of a volume that still exists on the storage", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDeleting}, WWN: "wwn1"},
+			}, nil)
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDeleting}, WWN: "wwn1"}, true, nil)
+			fakeScbeRestClient.GetVolumesReturns([]scbe.ScbeVolumeInfo{{Name: "u_inst1_vol1", Wwn: "wwn1"}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeScbeRestClient.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeScbeRestClient.DeleteVolumeArgsForCall(0)).To(Equal("wwn1"))
+			Expect(fakeScbeDataModel.DeleteVolumeCallCount()).To(Equal(1))
+		})
+		It("should set the attach state from the storage mapping", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAttaching}, WWN: "wwn1"},
+			}, nil)
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAttaching}, WWN: "wwn1"}, true, nil)
+			fakeScbeRestClient.GetVolMappingReturns(fakeHost, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeScbeDataModel.UpdateVolumeAttachToCallCount()).To(Equal(1))
+			name, _, host := fakeScbeDataModel.UpdateVolumeAttachToArgsForCall(0)
+			Expect(name).To(Equal("vol1"))
+			Expect(host).To(Equal(fakeHost))
+		})
+		It("should move a volume that cannot be recovered to the error state", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDetaching}, WWN: "wwn1"},
+			}, nil)
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDetaching}, WWN: "wwn1"}, true, nil)
+			fakeScbeRestClient.GetVolMappingReturns("", fakeErr)
+			Expect(recoverer.RecoverVolumes(locker)).NotTo(Succeed())
+			Expect(fakeScbeDataModel.UpdateVolumeStateCallCount()).To(Equal(1))
+			name, state := fakeScbeDataModel.UpdateVolumeStateArgsForCall(0)
+			Expect(name).To(Equal("vol1"))
+			Expect(state).To(Equal(resources.VolumeStateError))
+		})
+		It("should skip a volume completed by another server while waiting for its lock", func() {
+			fakeScbeDataModel.ListVolumesReturns([]scbe.ScbeVolume{
+				{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAttaching}, WWN: "wwn1"},
+			}, nil)
+			fakeScbeDataModel.GetVolumeReturns(scbe.ScbeVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAttached}, WWN: "wwn1"}, true, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(locker.WriteLockCallCount()).To(Equal(1))
+			Expect(locker.WriteLockArgsForCall(0)).To(Equal("vol1"))
+			Expect(locker.WriteUnlockCallCount()).To(Equal(1))
+			Expect(fakeScbeRestClient.GetVolMappingCallCount()).To(Equal(0))
+		})
+	})
+	Context(".Adopt", func() {
+		It("should fail if the pattern is empty", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{})
+			Expect(adoptResponse.Error).To(HaveOccurred())
+			_, ok := adoptResponse.Error.(*scbe.InValidRequestError)
+			Expect(ok).To(Equal(true))
+		})
+		It("should adopt the unknown volumes of the service that match the pattern in one insert", func() {
+			fakeScbeRestClient.GetVolMappingReturnsOnCall(0, scbe.EmptyHost, nil)
+			fakeScbeRestClient.GetVolMappingReturnsOnCall(1, fakeHost, nil)
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "*legacy_*"})
+			Expect(adoptResponse.Error).NotTo(HaveOccurred())
+			Expect(len(adoptResponse.Volumes)).To(Equal(2))
+			Expect(fakeScbeRestClient.GetVolMappingArgsForCall(0)).To(Equal("wwn1"))
+			Expect(fakeScbeRestClient.GetVolMappingArgsForCall(1)).To(Equal("wwn2"))
+			Expect(fakeScbeDataModel.InsertVolumesCallCount()).To(Equal(1))
+			adopted := fakeScbeDataModel.InsertVolumesArgsForCall(0)
+			Expect(adopted[0].Volume.Name).To(Equal("legacy_1"))
+			Expect(adopted[0].Volume.Backend).To(Equal(resources.SCBE))
+			Expect(adopted[0].Volume.BackendType).To(Equal(resources.SCBE))
+			Expect(adopted[0].Volume.AccessMode).To(Equal(resources.AccessModeSingleWriter))
+			Expect(adopted[0].WWN).To(Equal("wwn1"))
+			Expect(adopted[0].AttachTo).To(Equal(scbe.EmptyHost))
+			Expect(adopted[1].Volume.Name).To(Equal("legacy_2"))
+			Expect(adopted[1].AttachTo).To(Equal(fakeHost))
+		})
+		It("should not insert anything on dry run", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "legacy_*", Service: "otherProfile", DryRun: true})
+			Expect(adoptResponse.Error).NotTo(HaveOccurred())
+			Expect(len(adoptResponse.Volumes)).To(Equal(1))
+			Expect(adoptResponse.Volumes[0].Name).To(Equal("legacy_3"))
+			Expect(fakeScbeDataModel.InsertVolumesCallCount()).To(Equal(0))
+		})
+		It("should fail if the insert fails", func() {
+			fakeScbeDataModel.InsertVolumesReturns(fakeErr)
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "legacy_*"})
+			Expect(adoptResponse.Error).To(MatchError(fakeErr))
+		})
+		It("should not report the adopted volumes as drifts", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "*legacy_*"})
+			Expect(adoptResponse.Error).NotTo(HaveOccurred())
+			adopted := fakeScbeDataModel.InsertVolumesArgsForCall(0)
+			Expect(adopted[0].Volume.Name).To(Equal("legacy_1"))
+			fakeScbeDataModel.ListVolumesReturns(append([]scbe.ScbeVolume{{Volume: resources.Volume{Name: "known"}, WWN: "wwn0"}}, adopted...), nil)
+			fakeScbeRestClient.GetVolMappingReturns(scbe.EmptyHost, nil)
+
+			drifts, err := client.(resources.BackendReconciler).Reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifts).To(BeEmpty())
+		})
+	})
+})
@@ -42,6 +42,9 @@ type SimpleRestClient interface {
 
 	// send DELETE request with optional payload and check expected status of response
 	Delete(resource_url string, payload []byte, exitStatus int) error
+
+	// send PUT request with payload and check expected status of response
+	Put(resource_url string, payload []byte, exitStatus int, v interface{}) error
 }
 
 const (
@@ -70,7 +73,7 @@ func NewSimpleRestClient(conInfo resources.ConnectionInfo, baseURL string, authU
 	if conInfo.SkipVerifySSL {
 		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
 	}
-	return &simpleRestClient{logger: logs.GetLogger(), connectionInfo: conInfo, baseURL: baseURL, authURL: authURL, referrer: referrer, httpClient: client, headers: headers}
+	return &simpleRestClient{logger: logs.GetComponentLogger(logs.ComponentScbe), connectionInfo: conInfo, baseURL: baseURL, authURL: authURL, referrer: referrer, httpClient: client, headers: headers}
 }
 
 func (s *simpleRestClient) Login() error {
@@ -135,8 +138,7 @@ func (s *simpleRestClient) genericActionInternal(actionName string, resource_url
 	for key, value := range s.headers {
 		request.Header.Add(key, value)
 	}
-
-	response, err := s.httpClient.Do(request)
+	response, err := utils.HttpDo(s.httpClient, request)
 	if err != nil {
 		return s.logger.ErrorRet(err, "httpClient.Do failed", logs.Args{{actionName, request.URL}})
 	}
@@ -159,7 +161,12 @@ func (s *simpleRestClient) genericActionInternal(actionName string, resource_url
 		return s.logger.ErrorRet(err, "ioutil.ReadAll failed")
 	}
 
-	s.logger.Debug(actionName+" "+url, logs.Args{{"data", string(data[:])}})
+	if resource_url == s.authURL {
+		// the login response holds the token
+		s.logger.Debug(actionName+" "+url, logs.Args{{"data", resources.RedactedSecret}})
+	} else {
+		s.logger.Debug(actionName+" "+url, logs.Args{{"data", string(data[:])}})
+	}
 	if response.StatusCode != exitStatus {
 		return s.logger.ErrorRet(errors.New("bad status code "+response.Status), "failed", logs.Args{{actionName, url}})
 	}
@@ -199,3 +206,12 @@ func (s *simpleRestClient) Delete(resource_url string, payload []byte, exitStatu
 	}
 	return s.genericAction("DELETE", resource_url, payload, nil, exitStatus, nil)
 }
+
+// Put http request
+func (s *simpleRestClient) Put(resource_url string, payload []byte, exitStatus int, v interface{}) error {
+	defer s.logger.Trace(logs.DEBUG)()
+	if exitStatus < 0 {
+		exitStatus = HTTP_SUCCEED // Default value
+	}
+	return s.genericAction("PUT", resource_url, payload, nil, exitStatus, v)
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spectrumscale
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Adopt register as ubiquity volumes the filesets of a filesystem whose name match the pattern.
+// Each volume is named after its fileset, filesets already used by a volume are skipped, and all the volumes are inserted in one transaction.
+func (s *spectrumLocalClient) Adopt(adoptRequest resources.AdoptRequest) resources.AdoptResponse {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	if adoptRequest.Pattern == "" {
+		return resources.AdoptResponse{Error: fmt.Errorf("Adopt requires a fileset name pattern")}
+	}
+	if _, err := path.Match(adoptRequest.Pattern, ""); err != nil {
+		return resources.AdoptResponse{Error: fmt.Errorf("Invalid fileset name pattern %s: %s", adoptRequest.Pattern, err.Error())}
+	}
+	filesystem := adoptRequest.Filesystem
+	if filesystem == "" {
+		filesystem = s.config.DefaultFilesystemName
+	}
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AdoptResponse{Error: err}
+	}
+	usedFilesets := make(map[string]bool)
+	for _, volume := range volumesInDb {
+		existingVolume, volExists, err := s.dataModel.GetVolume(volume.Name)
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return resources.AdoptResponse{Error: err}
+		}
+		if volExists && existingVolume.FileSystem == filesystem {
+			usedFilesets[existingVolume.Fileset] = true
+		}
+	}
+
+	filesets, err := s.connector.ListFilesets(filesystem)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AdoptResponse{Error: err}
+	}
+	var toAdopt []string
+	var volumes []resources.Volume
+	for _, fileset := range filesets {
+		if fileset.Name == rootFileset || usedFilesets[fileset.Name] {
+			continue
+		}
+		if matched, _ := path.Match(adoptRequest.Pattern, fileset.Name); !matched {
+			continue
+		}
+		toAdopt = append(toAdopt, fileset.Name)
+		volumes = append(volumes, resources.Volume{Name: fileset.Name, Backend: s.backend, BackendType: resources.SpectrumScale, Metadata: resources.VolumeMetadata{Values: adoptRequest.Opts},
+			AccessMode: resources.GetDefaultAccessMode(resources.SpectrumScale)})
+	}
+
+	if adoptRequest.DryRun || len(toAdopt) == 0 {
+		return resources.AdoptResponse{Volumes: volumes}
+	}
+	err = s.dataModel.InsertPreexistingFilesetVolumes(toAdopt, filesystem, adoptRequest.Opts)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AdoptResponse{Error: err}
+	}
+	s.logger.Info("Adopted filesets", logs.Args{{"filesets", len(toAdopt)}, {"filesystem", filesystem}, {"pattern", adoptRequest.Pattern}})
+	return resources.AdoptResponse{Volumes: volumes}
+}
@@ -17,9 +17,8 @@
 package connectors
 
 import (
-	"log"
-
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 //go:generate counterfeiter -o ../../../fakes/fake_spectrum.go . SpectrumScaleConnector
@@ -31,6 +30,7 @@ type SpectrumScaleConnector interface {
 	MountFileSystem(filesystemName string) error
 	ListFilesystems() ([]string, error)
 	GetFilesystemMountpoint(filesystemName string) (string, error)
+	GetFilesystemCapacity(filesystemName string) (FilesystemCapacity, error)
 	//Fileset operations
 	CreateFileset(filesystemName string, filesetName string, opts map[string]string) error
 	DeleteFileset(filesystemName string, filesetName string) error
@@ -46,6 +46,16 @@ type SpectrumScaleConnector interface {
 	UnexportNfs(volumeMountpoint string) error
 }
 
+// FilesystemCapacity is the block and inode capacity of a filesystem, the fields the connector cannot query are 0
+type FilesystemCapacity struct {
+	TotalBytes uint64
+	FreeBytes  uint64
+	BlockSize  uint64
+	UsedInodes uint64
+	FreeInodes uint64
+	MaxInodes  uint64
+}
+
 const (
 	UserSpecifiedFilesetType string = "fileset-type"
 	UserSpecifiedInodeLimit  string = "inode-limit"
@@ -53,18 +63,37 @@ const (
 	UserSpecifiedGid         string = "gid"
 )
 
-func GetSpectrumScaleConnector(logger *log.Logger, config resources.SpectrumScaleConfig) (SpectrumScaleConnector, error) {
+const (
+	ConnectorTypeRest  = "rest"
+	ConnectorTypeSsh   = "ssh"
+	ConnectorTypeMmcli = "mmcli"
+)
+
+// GetSpectrumScaleConnectorType returns the connector that GetSpectrumScaleConnector selects for config:
+// REST if an endpoint is set, SSH if a user and a host are set, the local mm commands otherwise
+func GetSpectrumScaleConnectorType(config resources.SpectrumScaleConfig) string {
 	if config.RestConfig.Endpoint != "" {
-		logger.Printf("Initializing SpectrumScale REST connector\n")
-		return NewSpectrumRestV2(logger, config.RestConfig)
+		return ConnectorTypeRest
 	}
 	if config.SshConfig.User != "" && config.SshConfig.Host != "" {
+		return ConnectorTypeSsh
+	}
+	return ConnectorTypeMmcli
+}
+
+func GetSpectrumScaleConnector(config resources.SpectrumScaleConfig) (SpectrumScaleConnector, error) {
+	logger := logs.GetComponentLogger(logs.ComponentSpectrum)
+	switch GetSpectrumScaleConnectorType(config) {
+	case ConnectorTypeRest:
+		logger.Info("Initializing SpectrumScale REST connector")
+		return NewSpectrumRestV2(config.RestConfig)
+	case ConnectorTypeSsh:
 		if config.SshConfig.Port == "" || config.SshConfig.Port == "0" {
 			config.SshConfig.Port = "22"
 		}
-		logger.Printf("Initializing SpectrumScale SSH connector with sshConfig: %+v\n", config.SshConfig)
-		return NewSpectrumSSH(logger, config.SshConfig)
+		logger.Info("Initializing SpectrumScale SSH connector with sshConfig", logs.Args{{"sshConfig", config.SshConfig}})
+		return NewSpectrumSSH(config.SshConfig)
 	}
-	logger.Println("Initializing SpectrumScale MMCLI Connector")
-	return NewSpectrumMMCLI(logger)
+	logger.Info("Initializing SpectrumScale MMCLI Connector")
+	return NewSpectrumMMCLI()
 }
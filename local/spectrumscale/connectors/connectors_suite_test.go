@@ -20,12 +20,14 @@ import (
 	"testing"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
 func TestConnectors(t *testing.T) {
 	RegisterFailHandler(Fail)
+	defer logs.InitStdoutLogger(logs.DEBUG)()
 	RunSpecs(t, "Connectors Suite")
 }
 
@@ -18,27 +18,28 @@ package connectors
 
 import (
 	"fmt"
-	"log"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 	"net/url"
 )
 
 type spectrum_mmcli struct {
-	logger    *log.Logger
+	logger    logs.Logger
 	executor  utils.Executor
 	isMounted bool
 }
 
-func NewSpectrumMMCLI(logger *log.Logger) (SpectrumScaleConnector, error) {
-	return &spectrum_mmcli{logger: logger, executor: utils.NewExecutor()}, nil
+func NewSpectrumMMCLI() (SpectrumScaleConnector, error) {
+	return &spectrum_mmcli{logger: logs.GetComponentLogger(logs.ComponentSpectrum), executor: utils.NewExecutor()}, nil
 }
 
-func NewSpectrumMMCLIWithExecutor(logger *log.Logger, executor utils.Executor) (SpectrumScaleConnector, error) {
-	return &spectrum_mmcli{logger: logger, executor: executor}, nil
+func NewSpectrumMMCLIWithExecutor(executor utils.Executor) (SpectrumScaleConnector, error) {
+	return &spectrum_mmcli{logger: logs.GetComponentLogger(logs.ComponentSpectrum), executor: executor}, nil
 }
 
 func (s *spectrum_mmcli) GetClusterId() (string, error) {
@@ -46,19 +47,19 @@ func (s *spectrum_mmcli) GetClusterId() (string, error) {
 	args := []string{spectrumCommand}
 	return GetClusterIdInternal(s.logger, s.executor, "sudo", args)
 }
-func GetClusterIdInternal(logger *log.Logger, executor utils.Executor, command string, args []string) (string, error) {
+func GetClusterIdInternal(logger logs.Logger, executor utils.Executor, command string, args []string) (string, error) {
 	var clusterId string
 
 	outputBytes, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Error running command: %v", err)
+		logger.Error("Error running command", logs.Args{{"error", err}})
 		return "", err
 	}
 	spectrumOutput := string(outputBytes)
 
 	lines := strings.Split(spectrumOutput, "\n")
 	if len(lines) < 4 {
-		logger.Println("Error determining cluster id")
+		logger.Error("Error determining cluster id")
 		return "", fmt.Errorf("Error determining cluster id")
 	}
 	tokens := strings.Split(lines[4], ":")
@@ -73,8 +74,7 @@ func GetClusterIdInternal(logger *log.Logger, executor utils.Executor, command s
 }
 
 func (s *spectrum_mmcli) IsFilesystemMounted(filesystemName string) (bool, error) {
-	s.logger.Println("spectrumLocalClient: isMounted start")
-	defer s.logger.Println("spectrumLocalClient: isMounted end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.isMounted == true {
 		s.isMounted = true
@@ -86,10 +86,10 @@ func (s *spectrum_mmcli) IsFilesystemMounted(filesystemName string) (bool, error
 	s.isMounted = isMounted
 	return s.isMounted, err
 }
-func IsFilesystemMountedInternal(logger *log.Logger, executor utils.Executor, filesystemName string, command string, args []string) (bool, error) {
+func IsFilesystemMountedInternal(logger logs.Logger, executor utils.Executor, filesystemName string, command string, args []string) (bool, error) {
 	outputBytes, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Error running command %v\n", err)
+		logger.Error("Error running command", logs.Args{{"error", err}})
 		return false, err
 	}
 	mountedNodes := extractMountedNodes(string(outputBytes))
@@ -100,10 +100,10 @@ func IsFilesystemMountedInternal(logger *log.Logger, executor utils.Executor, fi
 		// checkif mounted on current node -- compare node name
 		currentNode, err := executor.Hostname()
 		if err != nil {
-			logger.Printf("error in getting hostname %v", err)
+			logger.Error("error in getting hostname", logs.Args{{"error", err}})
 			return false, err
 		}
-		logger.Printf("spectrumLocalClient: node name: %s\n", currentNode)
+		logger.Debug("spectrumLocalClient: node name", logs.Args{{"currentNode", currentNode}})
 		for _, node := range mountedNodes {
 			if node == currentNode {
 				return true, nil
@@ -132,8 +132,7 @@ func extractMountedNodes(spectrumOutput string) []string {
 }
 
 func (s *spectrum_mmcli) MountFileSystem(filesystemName string) error {
-	s.logger.Println("spectrumLocalClient: mount start")
-	defer s.logger.Println("spectrumLocalClient: mount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.isMounted == true {
 		return nil
@@ -144,28 +143,111 @@ func (s *spectrum_mmcli) MountFileSystem(filesystemName string) error {
 
 	err := MountFileSystemInternal(s.logger, s.executor, filesystemName, "sudo", args)
 	if err != nil {
-		s.logger.Printf("error mounting filesystem %v", err)
+		s.logger.Error("error mounting filesystem", logs.Args{{"error", err}})
 		return err
 	}
 	s.isMounted = true
 	return nil
 }
 
-func MountFileSystemInternal(logger *log.Logger, executor utils.Executor, filesystemName string, command string, args []string) error {
+func MountFileSystemInternal(logger logs.Logger, executor utils.Executor, filesystemName string, command string, args []string) error {
 
 	output, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Failed to mount filesystem %v", err)
+		logger.Error("Failed to mount filesystem", logs.Args{{"error", err}})
 		return err
 	}
 
-	logger.Println(output)
+	logger.Debug("filesystem mounted", logs.Args{{"output", string(output)}})
 	return nil
 }
 
 func (s *spectrum_mmcli) ListFilesystems() ([]string, error) {
-	//TODO not yet implemented
-	return nil, nil
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfs"
+	args := []string{spectrumCommand, "all", "-T", "-Y"}
+	return ListFilesystemsInternal(s.logger, s.executor, "sudo", args)
+}
+
+func ListFilesystemsInternal(logger logs.Logger, executor utils.Executor, command string, args []string) ([]string, error) {
+	outputBytes, err := executor.Execute(command, args)
+	if err != nil {
+		logger.Error("Error running command", logs.Args{{"error", err}})
+		return nil, err
+	}
+	var filesystems []string
+	for _, line := range strings.Split(string(outputBytes), "\n") {
+		tokens := strings.Split(line, ":")
+		if len(tokens) < 7 || tokens[2] == "HEADER" {
+			continue
+		}
+		filesystem := strings.TrimSpace(tokens[6])
+		if filesystem != "" && !utils.StringInSlice(filesystem, filesystems) {
+			filesystems = append(filesystems, filesystem)
+		}
+	}
+	return filesystems, nil
+}
+
+func (s *spectrum_mmcli) GetFilesystemCapacity(filesystemName string) (FilesystemCapacity, error) {
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmdf"
+	args := []string{spectrumCommand, filesystemName, "-Y"}
+	return GetFilesystemCapacityInternal(s.logger, s.executor, filesystemName, "sudo", args)
+}
+
+// GetFilesystemCapacityInternal reads the fsTotal and inode records of the mmdf -Y output, the sizes are in KB
+func GetFilesystemCapacityInternal(logger logs.Logger, executor utils.Executor, filesystemName string, command string, args []string) (FilesystemCapacity, error) {
+	outputBytes, err := executor.Execute(command, args)
+	if err != nil {
+		logger.Error("Error running command", logs.Args{{"error", err}})
+		return FilesystemCapacity{}, err
+	}
+	records := parseMmYOutput(string(outputBytes))
+	fsTotal, ok := records["fsTotal"]
+	if !ok {
+		return FilesystemCapacity{}, fmt.Errorf("Cannot determine the capacity of filesystem %s", filesystemName)
+	}
+	values := make(map[string]uint64)
+	for _, field := range []string{"fsSize", "freeBlocks"} {
+		if values[field], err = strconv.ParseUint(fsTotal[field], 10, 64); err != nil {
+			return FilesystemCapacity{}, fmt.Errorf("Cannot determine the capacity of filesystem %s: %s", filesystemName, err.Error())
+		}
+	}
+	capacity := FilesystemCapacity{TotalBytes: values["fsSize"] * 1024, FreeBytes: values["freeBlocks"] * 1024}
+	if inode, ok := records["inode"]; ok {
+		capacity.UsedInodes, _ = strconv.ParseUint(inode["usedInodes"], 10, 64)
+		capacity.FreeInodes, _ = strconv.ParseUint(inode["freeInodes"], 10, 64)
+		capacity.MaxInodes, _ = strconv.ParseUint(inode["maxInodes"], 10, 64)
+	}
+	logger.Debug("Filesystem capacity", logs.Args{{"filesystem", filesystemName}, {"capacity", capacity}})
+	return capacity, nil
+}
+
+// parseMmYOutput returns the fields of the first line of every record of the -Y output of an mm command, keyed by the
+// names of the HEADER line of the record
+func parseMmYOutput(output string) map[string]map[string]string {
+	headers := make(map[string][]string)
+	records := make(map[string]map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		tokens := strings.Split(strings.TrimSpace(line), ":")
+		if len(tokens) < 3 {
+			continue
+		}
+		record := tokens[1]
+		if tokens[2] == "HEADER" {
+			headers[record] = tokens
+			continue
+		}
+		header, ok := headers[record]
+		if !ok || records[record] != nil {
+			continue
+		}
+		fields := make(map[string]string)
+		for i := 0; i < len(header) && i < len(tokens); i++ {
+			fields[header[i]] = tokens[i]
+		}
+		records[record] = fields
+	}
+	return records
 }
 func (s *spectrum_mmcli) GetFilesystemMountpoint(filesystemName string) (string, error) {
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfs"
@@ -173,10 +255,10 @@ func (s *spectrum_mmcli) GetFilesystemMountpoint(filesystemName string) (string,
 	return GetFilesystemMountpointInternal(s.logger, s.executor, filesystemName, "sudo", args)
 }
 
-func GetFilesystemMountpointInternal(logger *log.Logger, executor utils.Executor, filesystemName string, command string, args []string) (string, error) {
+func GetFilesystemMountpointInternal(logger logs.Logger, executor utils.Executor, filesystemName string, command string, args []string) (string, error) {
 	outputBytes, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Error running command: %s", err.Error())
+		logger.Error("Error running command", logs.Args{{"error", err}})
 		return "", err
 	}
 	spectrumOutput := string(outputBytes)
@@ -194,9 +276,9 @@ func GetFilesystemMountpointInternal(logger *log.Logger, executor utils.Executor
 			//Todo this should be changed to url.PathUnescape when available
 			mountpoint, err := url.PathUnescape(mountpoint)
 			if err != nil {
-				logger.Printf("Error decoding mountpoint: %s\n", err)
+				logger.Error("Error decoding mountpoint", logs.Args{{"error", err}})
 			} else {
-				logger.Printf("Returning mountpoint: %s\n", mountpoint)
+				logger.Debug("Returning mountpoint", logs.Args{{"mountpoint", mountpoint}})
 			}
 			return mountpoint, nil
 		}
@@ -206,10 +288,9 @@ func GetFilesystemMountpointInternal(logger *log.Logger, executor utils.Executor
 }
 
 func (s *spectrum_mmcli) CreateFileset(filesystemName string, filesetName string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient: createFileset start")
-	defer s.logger.Println("spectrumLocalClient: createFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("creating a new fileset: %s\n", filesetName)
+	s.logger.Info("creating a new fileset", logs.Args{{"filesetName", filesetName}})
 
 	// create fileset
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmcrfileset"
@@ -229,48 +310,46 @@ func (s *spectrum_mmcli) CreateFileset(filesystemName string, filesetName string
 	return CreateFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
 
-func CreateFilesetInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
+func CreateFilesetInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
 	output, err := executor.Execute(command, args)
 
 	if err != nil {
-		logger.Printf("Error creating fileset: %#v, %#v\n", output, err)
+		logger.Error("Error creating fileset", logs.Args{{"output", output}, {"error", err}})
 		return fmt.Errorf("Failed to create fileset %s on filesystem %s. Please check that filesystem specified is correct and healthy", filesetName, filesystemName)
 	}
-	logger.Printf("Createfileset output: %s\n", string(output))
+	logger.Debug("Createfileset output", logs.Args{{"output", string(output)}})
 	return nil
 }
 func (s *spectrum_mmcli) DeleteFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: deleteFileset start")
-	defer s.logger.Println("spectrumLocalClient: deleteFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmdelfileset"
 	args := []string{spectrumCommand, filesystemName, filesetName, "-f"}
 	return DeleteFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
 
-func DeleteFilesetInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
+func DeleteFilesetInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
 	output, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Failed to remove fileset %s: %s ", filesetName, err.Error())
+		logger.Error("Failed to remove fileset", logs.Args{{"filesetName", filesetName}, {"error", err}})
 		return fmt.Errorf("Failed to remove fileset %s: %s ", filesetName, err.Error())
 	}
-	logger.Printf("spectrumLocalClient: deleteFileset output: %s\n", string(output))
+	logger.Debug("deleteFileset output", logs.Args{{"output", string(output)}})
 	return nil
 }
 func (s *spectrum_mmcli) IsFilesetLinked(filesystemName string, filesetName string) (bool, error) {
-	s.logger.Println("spectrumLocalClient: isFilesetLinked start")
-	defer s.logger.Println("spectrumLocalClient: isFilesetLinked end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
 	args := []string{spectrumCommand, filesystemName, filesetName, "-Y"}
-	s.logger.Printf("%#v\n", args)
+	s.logger.Debug("executing", logs.Args{{"args", args}})
 	return IsFilesetLinkedInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
 
-func IsFilesetLinkedInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (bool, error) {
+func IsFilesetLinkedInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (bool, error) {
 	outputBytes, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Printf("Error in mmlsfileset invocation\n")
+		logger.Error("Error in mmlsfileset invocation")
 		return false, err
 	}
 
@@ -278,7 +357,7 @@ func IsFilesetLinkedInternal(logger *log.Logger, executor utils.Executor, filesy
 	lines := strings.Split(spectrumOutput, "\n")
 
 	if len(lines) == 1 {
-		logger.Printf("Error in listing fileset\n")
+		logger.Error("Error in listing fileset")
 		return false, fmt.Errorf("Error listing fileset %s", filesetName)
 	}
 
@@ -290,77 +369,106 @@ func IsFilesetLinkedInternal(logger *log.Logger, executor utils.Executor, filesy
 			return false, nil
 		}
 	}
-	logger.Printf("Error listing fileset %s after parsing", filesetName)
+	logger.Error("Error listing fileset after parsing", logs.Args{{"filesetName", filesetName}})
 	return false, fmt.Errorf("Error listing fileset %s after parsing", filesetName)
 
 }
 
 func (s *spectrum_mmcli) LinkFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: linkFileset start")
-	defer s.logger.Println("spectrumLocalClient: linkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("Trying to link: %s,%s", filesystemName, filesetName)
+	s.logger.Debug("Trying to link", logs.Args{{"filesystemName", filesystemName}, {"filesetName", filesetName}})
 
 	mountpoint, err := s.GetFilesystemMountpoint(filesystemName)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlinkfileset"
 	filesetPath := path.Join(mountpoint, filesetName)
 	args := []string{spectrumCommand, filesystemName, filesetName, "-J", filesetPath}
-	s.logger.Printf("Args for link fileset%#v", args)
+	s.logger.Debug("Args for link fileset", logs.Args{{"args", args}})
 	err = LinkFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 	if err != nil {
-		s.logger.Printf("error linking fileset %v", err)
+		s.logger.Error("error linking fileset", logs.Args{{"error", err}})
 		return err
 	}
 	return nil
 }
 
-func LinkFilesetInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
+func LinkFilesetInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
 	_, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Println("Failed to link fileset %v\n", err)
+		logger.Error("Failed to link fileset", logs.Args{{"error", err}})
 		return fmt.Errorf("Failed to link fileset: %s", err.Error())
 	}
 	return nil
 }
 
 func (s *spectrum_mmcli) UnlinkFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: unlinkFileset start")
-	defer s.logger.Println("spectrumLocalClient: unlinkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmunlinkfileset"
 	args := []string{spectrumCommand, filesystemName, filesetName}
 	return UnlinkFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
 
-func UnlinkFilesetInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
+func UnlinkFilesetInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) error {
 	output, err := executor.Execute(command, args)
 	if err != nil {
 		return fmt.Errorf("Failed to unlink fileset %s: %s", filesetName, err.Error())
 	}
-	logger.Printf("spectrumLocalClient: unLinkfileset output: %s\n", string(output))
+	logger.Debug("unLinkfileset output", logs.Args{{"output", string(output)}})
 	return nil
 }
 
 func (s *spectrum_mmcli) ListFilesets(filesystemName string) ([]resources.Volume, error) {
-	return nil, nil
+	defer s.logger.Trace(logs.DEBUG)()
+
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
+	args := []string{spectrumCommand, filesystemName, "-Y"}
+	return ListFilesetsInternal(s.logger, s.executor, filesystemName, "sudo", args)
+}
+
+// ListFilesetsInternal parses the mmlsfileset -Y output, the volume mountpoint is the fileset junction path or empty if the fileset is not linked
+func ListFilesetsInternal(logger logs.Logger, executor utils.Executor, filesystemName string, command string, args []string) ([]resources.Volume, error) {
+	outputBytes, err := executor.Execute(command, args)
+	if err != nil {
+		logger.Error("Error in mmlsfileset invocation")
+		return nil, err
+	}
+
+	var filesets []resources.Volume
+	lines := strings.Split(string(outputBytes), "\n")
+	for _, line := range lines[1:] {
+		tokens := strings.Split(strings.TrimSpace(line), ":")
+		if len(tokens) < 12 || tokens[2] == "HEADER" {
+			continue
+		}
+		mountpoint := ""
+		if tokens[10] == "Linked" {
+			mountpoint, err = url.QueryUnescape(tokens[11])
+			if err != nil {
+				logger.Error("Error decoding path of fileset", logs.Args{{"path", tokens[7]}, {"error", err}})
+				return nil, err
+			}
+		}
+		filesets = append(filesets, resources.Volume{Name: tokens[7], Mountpoint: mountpoint})
+	}
+	return filesets, nil
 }
 func (s *spectrum_mmcli) ListFileset(filesystemName string, filesetName string) (resources.Volume, error) {
-	s.logger.Println("spectrumLocalClient: ListFileset start")
-	defer s.logger.Println("spectrumLocalClient: ListFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
 	args := []string{spectrumCommand, filesystemName, filesetName, "-Y"}
 	return ListFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
-func ListFilesetInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (resources.Volume, error) {
+func ListFilesetInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (resources.Volume, error) {
 	_, err := executor.Execute(command, args)
 	if err != nil {
-		logger.Println(err)
+		logger.Error("failed", logs.Args{{"error", err}})
 		return resources.Volume{}, err
 	}
 	//TODO check what we need to return
@@ -369,19 +477,18 @@ func ListFilesetInternal(logger *log.Logger, executor utils.Executor, filesystem
 
 //TODO modify quota from string to Capacity (see kubernetes)
 func (s *spectrum_mmcli) ListFilesetQuota(filesystemName string, filesetName string) (string, error) {
-	s.logger.Println("spectrumLocalClient: verifyFilesetQuota start")
-	defer s.logger.Println("spectrumLocalClient: verifyFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsquota"
 	args := []string{spectrumCommand, "-j", filesetName, filesystemName, "--block-size", "auto"}
 	return ListFilesetQuotaInternal(s.logger, s.executor, filesystemName, filesetName, "sudo", args)
 }
 
-func ListFilesetQuotaInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (string, error) {
+func ListFilesetQuotaInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, command string, args []string) (string, error) {
 	outputBytes, err := executor.Execute(command, args)
 
 	if err != nil {
-		logger.Printf("failed to list quota for fileset %s: %v", filesetName, err)
+		logger.Error("failed to list quota for fileset", logs.Args{{"filesetName", filesetName}, {"error", err}})
 		return "", fmt.Errorf("Failed to list quota for fileset %s: %s", filesetName, err.Error())
 	}
 
@@ -397,7 +504,7 @@ func ListFilesetQuotaInternal(logger *log.Logger, executor utils.Executor, files
 				return tokens[3], nil
 			}
 		} else {
-			logger.Printf("error parsing tokens while listing quota for fileset %s: %v", filesetName, err)
+			logger.Error("error parsing tokens while listing quota for fileset", logs.Args{{"filesetName", filesetName}, {"error", err}})
 			return "", fmt.Errorf("Error parsing tokens while listing quota for fileset %s", filesetName)
 		}
 	}
@@ -405,31 +512,29 @@ func ListFilesetQuotaInternal(logger *log.Logger, executor utils.Executor, files
 }
 
 func (s *spectrum_mmcli) SetFilesetQuota(filesystemName string, filesetName string, quota string) error {
-	s.logger.Println("spectrumLocalClient: setFilesetQuota start")
-	defer s.logger.Println("spectrumLocalClient: setFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("setting quota to %s for fileset %s\n", quota, filesetName)
+	s.logger.Info("setting quota for fileset", logs.Args{{"quota", quota}, {"filesetName", filesetName}})
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmsetquota"
 	args := []string{spectrumCommand, filesystemName + ":" + filesetName, "--block", quota + ":" + quota}
 	return SetFilesetQuotaInternal(s.logger, s.executor, filesystemName, filesetName, quota, "sudo", args)
 }
 
-func SetFilesetQuotaInternal(logger *log.Logger, executor utils.Executor, filesystemName string, filesetName string, quota string, command string, args []string) error {
+func SetFilesetQuotaInternal(logger logs.Logger, executor utils.Executor, filesystemName string, filesetName string, quota string, command string, args []string) error {
 	output, err := executor.Execute(command, args)
 
 	if err != nil {
-		logger.Printf("Failed to set quota '%s' for fileset '%s': %s", quota, filesetName, err.Error())
+		logger.Error("Failed to set quota for fileset", logs.Args{{"quota", quota}, {"filesetName", filesetName}, {"error", err}})
 		return fmt.Errorf("Failed to set quota '%s' for fileset '%s': %s", quota, filesetName, err.Error())
 	}
 
-	logger.Printf("setFilesetQuota output: %s\n", string(output))
+	logger.Debug("setFilesetQuota output", logs.Args{{"output", string(output)}})
 	return nil
 }
 
 func (s *spectrum_mmcli) ExportNfs(volumeMountpoint string, clientConfig string) error {
-	s.logger.Println("spectrumLocalClient: ExportNfs start")
-	defer s.logger.Println("spectrumLocalClient: ExportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmnfs"
 	args := []string{spectrumCommand, "export", "add", volumeMountpoint, "--client", clientConfig}
@@ -437,22 +542,21 @@ func (s *spectrum_mmcli) ExportNfs(volumeMountpoint string, clientConfig string)
 	return ExportNfsInternal(s.logger, s.executor, "sudo", args)
 }
 
-func ExportNfsInternal(logger *log.Logger, executor utils.Executor, command string, args []string) error {
+func ExportNfsInternal(logger logs.Logger, executor utils.Executor, command string, args []string) error {
 
 	output, err := executor.Execute(command, args)
 
 	if err != nil {
-		logger.Printf("Failed to export fileset via Nfs: error %#v ExportNfs output: %#v\n", err, output)
+		logger.Error("Failed to export fileset via Nfs", logs.Args{{"output", string(output)}, {"error", err}})
 		return fmt.Errorf("Failed to export fileset via Nfs: %s", err.Error())
 	}
 
-	logger.Printf("ExportNfs output: %s\n", string(output))
+	logger.Debug("ExportNfs output", logs.Args{{"output", string(output)}})
 	return nil
 }
 
 func (s *spectrum_mmcli) UnexportNfs(volumeMountpoint string) error {
-	s.logger.Println("spectrumLocalClient: UnexportNfs start")
-	defer s.logger.Println("spectrumLocalClient: UnexportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmnfs"
 	args := []string{spectrumCommand, "export", "remove", volumeMountpoint, "--force"}
@@ -460,15 +564,15 @@ func (s *spectrum_mmcli) UnexportNfs(volumeMountpoint string) error {
 	return UnexportNfsInternal(s.logger, s.executor, "sudo", args)
 }
 
-func UnexportNfsInternal(logger *log.Logger, executor utils.Executor, command string, args []string) error {
+func UnexportNfsInternal(logger logs.Logger, executor utils.Executor, command string, args []string) error {
 
 	output, err := executor.Execute(command, args)
 
 	if err != nil {
-		logger.Printf("Failed to unexport fileset via Nfs: error %#v UnexportNfs output: %#v \n", err, output)
+		logger.Error("Failed to unexport fileset via Nfs", logs.Args{{"output", string(output)}, {"error", err}})
 		return fmt.Errorf("Failed to unexport fileset via Nfs: %s", err.Error())
 	}
 
-	logger.Printf("UnexportNfs output: %s\n", string(output))
+	logger.Debug("UnexportNfs output", logs.Args{{"output", string(output)}})
 	return nil
 }
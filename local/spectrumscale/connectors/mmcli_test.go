@@ -18,8 +18,6 @@ package connectors_test
 
 import (
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/midoblgsm/ubiquity/fakes"
 	"github.com/midoblgsm/ubiquity/local/spectrumscale/connectors"
@@ -31,7 +29,6 @@ import (
 var _ = Describe("spectrum_mmcli", func() {
 	var (
 		spectrumMMCLI connectors.SpectrumScaleConnector
-		logger        *log.Logger
 		fakeExec      *fakes.FakeExecutor
 		opts          map[string]string
 		err           error
@@ -40,9 +37,8 @@ var _ = Describe("spectrum_mmcli", func() {
 	)
 
 	BeforeEach(func() {
-		logger = log.New(os.Stdout, "spectrum: ", log.Lshortfile|log.LstdFlags)
 		fakeExec = new(fakes.FakeExecutor)
-		spectrumMMCLI, err = connectors.NewSpectrumMMCLIWithExecutor(logger, fakeExec)
+		spectrumMMCLI, err = connectors.NewSpectrumMMCLIWithExecutor(fakeExec)
 		Expect(err).ToNot(HaveOccurred())
 		fileset = "fake-fileset"
 		filesystem = "fake-filesystem"
@@ -146,9 +142,65 @@ var _ = Describe("spectrum_mmcli", func() {
 		})
 	})
 
-	//TODO when listfilesystems is implemented
 	Context(".ListFileSystems", func() {
-		It("", func() {})
+		It("should fail when execute command errors", func() {
+			fakeExec.ExecuteReturns(nil, fmt.Errorf("failed to execute command"))
+
+			filesystems, err := spectrumMMCLI.ListFilesystems()
+			Expect(err).To(HaveOccurred())
+			Expect(filesystems).To(BeEmpty())
+		})
+
+		It("should return every filesystem once", func() {
+			stringOutput := "mmlsfs::HEADER:version:reserved:reserved:deviceName:fieldName:data:remarks:\n" +
+				"mmlsfs::0:1:::gpfs1:minFragmentSize:8192::\n" +
+				"mmlsfs::0:1:::gpfs1:inodeSize:4096::\n" +
+				"mmlsfs::0:1:::gpfs2:minFragmentSize:8192::\n"
+			fakeExec.ExecuteReturns([]byte(stringOutput), nil)
+
+			filesystems, err := spectrumMMCLI.ListFilesystems()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filesystems).To(Equal([]string{"gpfs1", "gpfs2"}))
+		})
+	})
+
+	Context(".GetFilesystemCapacity", func() {
+		It("should fail when execute command errors", func() {
+			fakeExec.ExecuteReturns(nil, fmt.Errorf("failed to execute command"))
+
+			_, err := spectrumMMCLI.GetFilesystemCapacity(filesystem)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when the output has no total", func() {
+			fakeExec.ExecuteReturns([]byte("mmdf:inode:HEADER:version:reserved:reserved:usedInodes:freeInodes:allocatedInodes:maxInodes:\n"), nil)
+
+			_, err := spectrumMMCLI.GetFilesystemCapacity(filesystem)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(filesystem))
+		})
+
+		It("should convert the KB of the total and read the inodes", func() {
+			stringOutput := "mmdf:nsd:HEADER:version:reserved:reserved:nsdName:storagePool:diskSize:\n" +
+				"mmdf:nsd:0:1:::nsd1:system:10485760:\n" +
+				"mmdf:fsTotal:HEADER:version:reserved:reserved:fsSize:freeBlocks:freeBlocksPct:freeFragments:freeFragmentsPct:\n" +
+				"mmdf:fsTotal:0:1:::10485760:8388608:80:1000:0:\n" +
+				"mmdf:inode:HEADER:version:reserved:reserved:usedInodes:freeInodes:allocatedInodes:maxInodes:\n" +
+				"mmdf:inode:0:1:::4038:61498:65536:100000:\n"
+			fakeExec.ExecuteReturns([]byte(stringOutput), nil)
+
+			capacity, err := spectrumMMCLI.GetFilesystemCapacity(filesystem)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(capacity).To(Equal(connectors.FilesystemCapacity{
+				TotalBytes: 10485760 * 1024,
+				FreeBytes:  8388608 * 1024,
+				UsedInodes: 4038,
+				FreeInodes: 61498,
+				MaxInodes:  100000,
+			}))
+			_, args := fakeExec.ExecuteArgsForCall(0)
+			Expect(args).To(ContainElement(filesystem))
+		})
 	})
 
 	Context(".GetFileSystemMountpoint", func() {
@@ -315,9 +367,27 @@ var _ = Describe("spectrum_mmcli", func() {
 		})
 	})
 
-	//TODO when listfilesets is implemented
 	Context(".ListFilesets", func() {
-		It("", func() {})
+		It("should fail when execute command errors", func() {
+			errorMsg := fmt.Sprintf("error executing command")
+			fakeExec.ExecuteReturns(nil, fmt.Errorf(errorMsg))
+
+			volumes, err := spectrumMMCLI.ListFilesets(filesystem)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(errorMsg))
+			Expect(volumes).To(BeNil())
+		})
+
+		It("should succeed and return linked and unlinked filesets", func() {
+			stringOutput := "mmlsfileset::HEADER:version:reserved:reserved:filesystemName:filesetName:id:rootInode:status:path:parentId\n" +
+				"mmlsfileset::0:1:::fake-filesystem:fileset1:1:3:Linked:%2Fgpfs%2Ffileset1:0\n" +
+				"mmlsfileset::0:1:::fake-filesystem:fileset2:2:4:Unlinked:--:0\n"
+			fakeExec.ExecuteReturns([]byte(stringOutput), nil)
+
+			volumes, err := spectrumMMCLI.ListFilesets(filesystem)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volumes).To(Equal([]resources.Volume{{Name: "fileset1", Mountpoint: "/gpfs/fileset1"}, {Name: "fileset2"}}))
+		})
 	})
 
 	Context(".ListFileset", func() {
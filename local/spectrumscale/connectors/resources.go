@@ -538,3 +538,18 @@ type CreateFilesetRequest struct {
 	AfmRPO                       int    `json:"afmRPO,omitempty"`
 	AfmShowHomeSnapshots         string `json:"afmShowHomeSnapshots,omitempty"`
 }
+
+type GetDisksResponse_v2 struct {
+	Disks  []Disk_v2 `json:"disks,omitempty"`
+	Status Status    `json:"status,omitempty"`
+	Paging Pages     `json:"paging,omitempty"`
+}
+
+type Disk_v2 struct {
+	Name            string `json:"name,omitempty"`
+	FileSystem      string `json:"fileSystem,omitempty"`
+	StoragePool     string `json:"storagePool,omitempty"`
+	Usage           string `json:"usage,omitempty"`
+	Size            uint64 `json:"size,omitempty"`
+	AvailableBlocks uint64 `json:"availableBlocks,omitempty"`
+}
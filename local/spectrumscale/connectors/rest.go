@@ -21,21 +21,21 @@ import (
 	"fmt"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
-	"log"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 	"net/http"
 	"os"
 	"path"
 )
 
 type spectrum_rest struct {
-	logger     *log.Logger
+	logger     logs.Logger
 	httpClient *http.Client
 	endpoint   string
 	user       string
 	password   string
 }
 
-func NewSpectrumRest(logger *log.Logger, restConfig resources.RestConfig) (SpectrumScaleConnector, error) {
+func NewSpectrumRest(restConfig resources.RestConfig) (SpectrumScaleConnector, error) {
 	endpoint := restConfig.Endpoint
 	user := restConfig.User
 	password := restConfig.Password
@@ -44,12 +44,12 @@ func NewSpectrumRest(logger *log.Logger, restConfig resources.RestConfig) (Spect
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
 
-	return &spectrum_rest{logger: logger, httpClient: &http.Client{Transport: tr}, endpoint: endpoint, user: user, password: password}, nil
+	return &spectrum_rest{logger: logs.GetComponentLogger(logs.ComponentSpectrum), httpClient: &http.Client{Transport: tr}, endpoint: endpoint, user: user, password: password}, nil
 }
 
-func NewSpectrumRestWithClient(logger *log.Logger, restConfig resources.RestConfig, client *http.Client) (SpectrumScaleConnector, error) {
+func NewSpectrumRestWithClient(restConfig resources.RestConfig, client *http.Client) (SpectrumScaleConnector, error) {
 	endpoint := restConfig.Endpoint
-	return &spectrum_rest{logger: logger, httpClient: client, endpoint: endpoint}, nil
+	return &spectrum_rest{logger: logs.GetComponentLogger(logs.ComponentSpectrum), httpClient: client, endpoint: endpoint}, nil
 }
 
 func (s *spectrum_rest) ExportNfs(volumeMountpoint string, clientConfig string) error {
@@ -65,7 +65,7 @@ func (s *spectrum_rest) GetClusterId() (string, error) {
 	getClusterResponse := GetClusterResponse{}
 	cidResponse, err := s.doHTTP(getClusterURL, "GET", getClusterResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return "", err
 	}
 
@@ -81,14 +81,14 @@ func (s *spectrum_rest) IsFilesystemMounted(filesystemName string) (bool, error)
 	getNodesResponse := GetNodesResponse{}
 	nodesResponse, err := s.doHTTP(getNodesURL, "GET", getNodesResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return false, err
 	}
 
 	getNodesResponse = nodesResponse.(GetNodesResponse)
 
 	currentNode, _ := os.Hostname()
-	s.logger.Printf("spectrum rest Client: node name: %s\n", currentNode)
+	s.logger.Debug("spectrum rest Client: node name", logs.Args{{"currentNode", currentNode}})
 	for _, node := range getNodesResponse.Nodes {
 		if node.NodeName == currentNode {
 			return true, nil
@@ -108,7 +108,7 @@ func (s *spectrum_rest) ListFilesystems() ([]string, error) {
 	getFilesystemResponse := GetFilesystemResponse{}
 	fsResponse, err := s.doHTTP(listFilesystemsURL, "GET", getFilesystemResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return nil, err
 	}
 
@@ -128,7 +128,7 @@ func (s *spectrum_rest) GetFilesystemMountpoint(filesystemName string) (string,
 
 	fsResponse, err := s.doHTTP(getFilesystemURL, "GET", getFilesystemResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return "", err
 	}
 
@@ -136,6 +136,10 @@ func (s *spectrum_rest) GetFilesystemMountpoint(filesystemName string) (string,
 	return getFilesystemResponse.FileSystems[0].DefaultMountPoint, nil
 }
 
+func (s *spectrum_rest) GetFilesystemCapacity(filesystemName string) (FilesystemCapacity, error) {
+	return FilesystemCapacity{}, fmt.Errorf("The capacity of filesystem %s cannot be queried with the v1 REST API", filesystemName)
+}
+
 func (s *spectrum_rest) CreateFileset(filesystemName string, filesetName string, opts map[string]string) error {
 	filesetConfig := FilesetConfig{}
 	filesetConfig.Comment = "fileset for container volume"
@@ -158,7 +162,7 @@ func (s *spectrum_rest) CreateFileset(filesystemName string, filesetName string,
 	createFilesetResponse := GenericResponse{}
 	response, err := s.doHTTP(createFilesetURL, "POST", createFilesetResponse, fileset)
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return err
 	}
 	createFilesetResponse = response.(GenericResponse)
@@ -174,7 +178,7 @@ func (s *spectrum_rest) DeleteFileset(filesystemName string, filesetName string)
 	deleteFilesetResponse := GenericResponse{}
 	response, err := s.doHTTP(deleteFilesetURL, "DELETE", deleteFilesetResponse, nil)
 	if err != nil {
-		s.logger.Printf("Error in delete remote call")
+		s.logger.Error("Error in delete remote call")
 		return err
 	}
 
@@ -193,7 +197,7 @@ func (s *spectrum_rest) LinkFileset(filesystemName string, filesetName string) e
 	filesetConfig.FilesystemName = filesystemName
 	fsMountpoint, err := s.GetFilesystemMountpoint(filesystemName)
 	if err != nil {
-		s.logger.Printf("error in linking fileset")
+		s.logger.Error("error in linking fileset")
 	}
 	filesetConfig.Path = path.Join(fsMountpoint, filesetName)
 	fileset := Fileset{Config: filesetConfig}
@@ -201,7 +205,7 @@ func (s *spectrum_rest) LinkFileset(filesystemName string, filesetName string) e
 	linkFilesetResponse := GenericResponse{}
 	response, err := s.doHTTP(linkFilesetURL, "PUT", linkFilesetResponse, fileset)
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return err
 	}
 
@@ -223,7 +227,7 @@ func (s *spectrum_rest) UnlinkFileset(filesystemName string, filesetName string)
 	linkFilesetResponse := GenericResponse{}
 	response, err := s.doHTTP(linkFilesetURL, "PUT", linkFilesetResponse, fileset)
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return err
 	}
 
@@ -239,7 +243,7 @@ func (s *spectrum_rest) ListFilesets(filesystemName string) ([]resources.Volume,
 	listFilesetResponse := GetFilesetResponse{}
 	lfsResponse, err := s.doHTTP(listFilesetURL, "GET", listFilesetResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in processing remote call %v", err)
+		s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 		return nil, err
 	}
 
@@ -260,7 +264,7 @@ func (s *spectrum_rest) ListFileset(filesystemName string, filesetName string) (
 	getFilesetResponse := GetFilesetResponse{}
 	gfsResponse, err := s.doHTTP(getFilesetURL, "GET", getFilesetResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in processing remote call %v", err)
+		s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 		return resources.Volume{}, err
 	}
 
@@ -274,7 +278,7 @@ func (s *spectrum_rest) ListFileset(filesystemName string, filesetName string) (
 func (s *spectrum_rest) IsFilesetLinked(filesystemName string, filesetName string) (bool, error) {
 	fileset, err := s.ListFileset(filesystemName, filesetName)
 	if err != nil {
-		s.logger.Printf("error retrieving fileset data")
+		s.logger.Error("error retrieving fileset data")
 		return false, err
 	}
 
@@ -290,7 +294,7 @@ func (s *spectrum_rest) ListFilesetQuota(filesystemName string, filesetName stri
 	listQuotaResponse := GetQuotaResponse{}
 	gqResponse, err := s.doHTTP(listQuotaURL, "GET", listQuotaResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in processing remote call %v", err)
+		s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 		return "", err
 	}
 
@@ -311,7 +315,7 @@ func (s *spectrum_rest) SetFilesetQuota(filesystemName string, filesetName strin
 	setQuotaResponse := GenericResponse{}
 	sqResponse, err := s.doHTTP(setQuotaURL, "POST", setQuotaResponse, quotaRequest)
 	if err != nil {
-		s.logger.Printf("error setting quota for fileset %v", err)
+		s.logger.Error("error setting quota for fileset", logs.Args{{"error", err}})
 		return err
 	}
 	setQuotaResponse = sqResponse.(GenericResponse)
@@ -324,17 +328,17 @@ func (s *spectrum_rest) SetFilesetQuota(filesystemName string, filesetName strin
 func (s *spectrum_rest) doHTTP(endpoint string, method string, responseObject interface{}, param interface{}) (interface{}, error) {
 	response, err := utils.HttpExecuteUserAuth(s.httpClient, s.logger, method, endpoint, s.user, s.password, param)
 	if err != nil {
-		s.logger.Printf("Error in %s: %s remote call %#v", method, endpoint, err)
+		s.logger.Error("Error in remote call", logs.Args{{"method", method}, {"endpoint", endpoint}, {"error", err}})
 		return nil, fmt.Errorf("Error in get filesystem remote call")
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in get filesystem remote call %#v\n", response)
+		s.logger.Error("Error in get filesystem remote call", logs.Args{{"response", response}})
 		return nil, utils.ExtractErrorResponse(response)
 	}
 	err = utils.UnmarshalResponse(response, &responseObject)
 	if err != nil {
-		s.logger.Printf("Error in unmarshalling response for get remote call %#v for response %#v", err, response)
+		s.logger.Error("Error in unmarshalling response for get remote call", logs.Args{{"response", response}, {"error", err}})
 		return nil, fmt.Errorf("Error in unmarshalling response for get remote call")
 	}
 
@@ -19,7 +19,6 @@ package connectors
 import (
 	"crypto/tls"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -28,10 +27,11 @@ import (
 
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type spectrumRestV2 struct {
-	logger     *log.Logger
+	logger     logs.Logger
 	httpClient *http.Client
 	endpoint   string
 	user       string
@@ -40,8 +40,7 @@ type spectrumRestV2 struct {
 }
 
 func (s *spectrumRestV2) isStatusOK(statusCode int) bool {
-	s.logger.Println("spectrumRestConnector: isStatusOK")
-	defer s.logger.Println("spectrumRestConnector: isStatusOK end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if (statusCode == http.StatusOK) ||
 		(statusCode == http.StatusCreated) ||
@@ -52,8 +51,7 @@ func (s *spectrumRestV2) isStatusOK(statusCode int) bool {
 }
 
 func (s *spectrumRestV2) checkAsynchronousJob(statusCode int) bool {
-	s.logger.Println("spectrumRestConnector: checkAsynchronousJob")
-	defer s.logger.Println("spectrumRestConnector: checkAsynchronousJob end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if (statusCode == http.StatusAccepted) ||
 		(statusCode == http.StatusCreated) {
@@ -63,8 +61,7 @@ func (s *spectrumRestV2) checkAsynchronousJob(statusCode int) bool {
 }
 
 func (s *spectrumRestV2) isRequestAccepted(response GenericResponse, url string) error {
-	s.logger.Println("spectrumRestConnector: isRequestAccepted")
-	defer s.logger.Println("spectrumRestConnector: isRequestAccepted end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if !s.isStatusOK(response.Status.Code) {
 		return fmt.Errorf("error %v for url %v", response, url)
@@ -77,15 +74,14 @@ func (s *spectrumRestV2) isRequestAccepted(response GenericResponse, url string)
 }
 
 func (s *spectrumRestV2) waitForJobCompletion(statusCode int, jobID uint64) error {
-	s.logger.Println("spectrumRestConnector: waitForJobCompletion")
-	defer s.logger.Println("spectrumRestConnector: waitForJobCompletion end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.checkAsynchronousJob(statusCode) {
 		jobURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/jobs?filter=jobId=%d&fields=:all:", jobID))
-		s.logger.Println("Job URL: ", jobURL)
+		s.logger.Debug("Job URL", logs.Args{{"url", jobURL}})
 		err := s.AsyncJobCompletion(jobURL)
 		if err != nil {
-			s.logger.Printf("%v\n", err)
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return err
 		}
 	}
@@ -93,12 +89,11 @@ func (s *spectrumRestV2) waitForJobCompletion(statusCode int, jobID uint64) erro
 }
 
 func (s *spectrumRestV2) AsyncJobCompletion(jobURL string) error {
-	s.logger.Println("spectrumRestConnector: AsyncJobCompletion")
-	defer s.logger.Println("spectrumRestConnector: AsyncJobCompletion end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	jobQueryResponse := GenericResponse{}
 	for {
-		s.logger.Printf("jobUrl  %v", jobURL)
+		s.logger.Debug("jobUrl", logs.Args{{"jobURL", jobURL}})
 		err := s.doHTTP(jobURL, "GET", &jobQueryResponse, nil)
 		if err != nil {
 			return err
@@ -114,14 +109,14 @@ func (s *spectrumRestV2) AsyncJobCompletion(jobURL string) error {
 		break
 	}
 	if jobQueryResponse.Jobs[0].Status == "COMPLETED" {
-		s.logger.Printf("Job %v Completed Successfully: %v\n", jobURL, jobQueryResponse.Jobs[0].Result)
+		s.logger.Info("Job completed successfully", logs.Args{{"jobURL", jobURL}, {"result", jobQueryResponse.Jobs[0].Result}})
 		return nil
 	} else {
 		return fmt.Errorf("%v", jobQueryResponse.Jobs[0].Result.Stderr)
 	}
 }
 
-func NewSpectrumRestV2(logger *log.Logger, restConfig resources.RestConfig) (SpectrumScaleConnector, error) {
+func NewSpectrumRestV2(restConfig resources.RestConfig) (SpectrumScaleConnector, error) {
 
 	endpoint := restConfig.Endpoint
 	user := restConfig.User
@@ -131,10 +126,10 @@ func NewSpectrumRestV2(logger *log.Logger, restConfig resources.RestConfig) (Spe
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	return &spectrumRestV2{logger: logger, httpClient: &http.Client{Transport: tr}, endpoint: endpoint, user: user, password: password, hostname: hostname}, nil
+	return &spectrumRestV2{logger: logs.GetComponentLogger(logs.ComponentSpectrum), httpClient: &http.Client{Transport: tr}, endpoint: endpoint, user: user, password: password, hostname: hostname}, nil
 }
 
-func NewspectrumRestV2WithClient(logger *log.Logger, restConfig resources.RestConfig) (SpectrumScaleConnector, *http.Client, error) {
+func NewspectrumRestV2WithClient(restConfig resources.RestConfig) (SpectrumScaleConnector, *http.Client, error) {
 	endpoint := restConfig.Endpoint
 	user := restConfig.User
 	password := restConfig.Password
@@ -144,22 +139,21 @@ func NewspectrumRestV2WithClient(logger *log.Logger, restConfig resources.RestCo
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
 	client := &http.Client{Transport: tr}
-	return &spectrumRestV2{logger: logger, httpClient: client, endpoint: endpoint, user: user, password: password, hostname: hostname}, client, nil
+	return &spectrumRestV2{logger: logs.GetComponentLogger(logs.ComponentSpectrum), httpClient: client, endpoint: endpoint, user: user, password: password, hostname: hostname}, client, nil
 
 }
 
 func (s *spectrumRestV2) GetClusterId() (string, error) {
-	s.logger.Println("spectrumRestConnector: GetClusterId")
-	defer s.logger.Println("spectrumRestConnector: GetClusterId end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getClusterURL := utils.FormatURL(s.endpoint, "scalemgmt/v2/cluster")
 	getClusterResponse := GetClusterResponse{}
 
-	s.logger.Println("Get Cluster URL : %s", getClusterURL)
+	s.logger.Debug("Get Cluster URL", logs.Args{{"url", getClusterURL}})
 
 	err := s.doHTTP(getClusterURL, "GET", &getClusterResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return "", fmt.Errorf("Unable to get cluster id. Please refer Ubiquity server logs for more details")
 	}
 	cid_str := fmt.Sprintf("%v", getClusterResponse.Cluster.ClusterSummary.ClusterID)
@@ -167,29 +161,28 @@ func (s *spectrumRestV2) GetClusterId() (string, error) {
 }
 
 func (s *spectrumRestV2) IsFilesystemMounted(filesystemName string) (bool, error) {
-	s.logger.Println("spectrumRestConnector: IsFilesystemMounted")
-	defer s.logger.Println("spectrumRestConnector: IsFilesystemMounted end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	var currentNode string
 	getNodesURL := utils.FormatURL(s.endpoint, "scalemgmt/v2/nodes")
 	getNodesResponse := GetNodesResponse_v2{}
 
-	s.logger.Println("Get Nodes URL %s", getNodesURL)
+	s.logger.Debug("Get Nodes URL", logs.Args{{"url", getNodesURL}})
 
 	for {
 		err := s.doHTTP(getNodesURL, "GET", &getNodesResponse, nil)
 		if err != nil {
-			s.logger.Printf("error in executing remote call: %v", err)
+			s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 			return false, fmt.Errorf("Unable to fetch nodes for %v. Please refer Ubiquity server logs for more details", filesystemName)
 		}
 
 		if s.hostname != "" {
-			s.logger.Printf("Got hostname from config %v", s.hostname)
+			s.logger.Info("Got hostname from config", logs.Args{{"hostname", s.hostname}})
 			currentNode = s.hostname
 		} else {
 			currentNode, _ = os.Hostname()
 		}
-		s.logger.Printf("spectrum rest Client: node name: %s\n", currentNode)
+		s.logger.Debug("spectrum rest Client: node name", logs.Args{{"currentNode", currentNode}})
 		for _, node := range getNodesResponse.Nodes {
 			if node.AdminNodename == currentNode {
 				return true, nil
@@ -211,17 +204,16 @@ func (s *spectrumRestV2) MountFileSystem(filesystemName string) error {
 
 func (s *spectrumRestV2) ListFilesystems() ([]string, error) {
 
-	s.logger.Println("spectrumRestConnector: ListFilesystems")
-	defer s.logger.Println("spectrumRestConnector: ListFilesystems end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	listFilesystemsURL := utils.FormatURL(s.endpoint, "scalemgmt/v2/filesystems")
 	getFilesystemResponse := GetFilesystemResponse_v2{}
 
-	s.logger.Println("List Filesystem URL: ", listFilesystemsURL)
+	s.logger.Debug("List Filesystem URL", logs.Args{{"url", listFilesystemsURL}})
 
 	err := s.doHTTP(listFilesystemsURL, "GET", &getFilesystemResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return nil, fmt.Errorf("Unable to list filesystems. Please refer Ubiquity server logs for more details")
 	}
 	fsNumber := len(getFilesystemResponse.FileSystems)
@@ -234,17 +226,16 @@ func (s *spectrumRestV2) ListFilesystems() ([]string, error) {
 
 func (s *spectrumRestV2) GetFilesystemMountpoint(filesystemName string) (string, error) {
 
-	s.logger.Println("spectrumRestConnector: GetFilesystemMountpoint")
-	defer s.logger.Println("spectrumRestConnector: GetFilesystemMountpoint end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getFilesystemURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s", filesystemName))
 	getFilesystemResponse := GetFilesystemResponse_v2{}
 
-	s.logger.Println("Get Filesystem Mount URL: ", getFilesystemURL)
+	s.logger.Debug("Get Filesystem Mount URL", logs.Args{{"url", getFilesystemURL}})
 
 	err := s.doHTTP(getFilesystemURL, "GET", &getFilesystemResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in executing remote call: %v", err)
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
 		return "", fmt.Errorf("Unable to fetch mount point for %v. Please refer Ubiquity server logs for more details", filesystemName)
 	}
 
@@ -255,10 +246,50 @@ func (s *spectrumRestV2) GetFilesystemMountpoint(filesystemName string) (string,
 	}
 }
 
+func (s *spectrumRestV2) GetFilesystemCapacity(filesystemName string) (FilesystemCapacity, error) {
+
+	defer s.logger.Trace(logs.DEBUG)()
+
+	getDisksURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/disks?fields=:all:", filesystemName))
+	getDisksResponse := GetDisksResponse_v2{}
+
+	s.logger.Debug("Get Filesystem Disks URL", logs.Args{{"url", getDisksURL}})
+
+	err := s.doHTTP(getDisksURL, "GET", &getDisksResponse, nil)
+	if err != nil {
+		s.logger.Error("error in executing remote call", logs.Args{{"error", err}})
+		return FilesystemCapacity{}, fmt.Errorf("Unable to fetch capacity of %v. Please refer Ubiquity server logs for more details", filesystemName)
+	}
+
+	// the disk sizes are in KB, the disks that only hold metadata do not add to the capacity of the volumes
+	capacity := FilesystemCapacity{}
+	for _, disk := range getDisksResponse.Disks {
+		if disk.Usage == "metadataOnly" {
+			continue
+		}
+		capacity.TotalBytes += disk.Size * 1024
+		capacity.FreeBytes += disk.AvailableBlocks * 1024
+	}
+	if capacity.TotalBytes == 0 {
+		return FilesystemCapacity{}, fmt.Errorf("Unable to fetch capacity of %v. Please refer Ubiquity server logs for more details", filesystemName)
+	}
+
+	// the block size and the inode limit are only informative, the capacity is returned without them if they are missing
+	getFilesystemURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s", filesystemName))
+	getFilesystemResponse := GetFilesystemResponse_v2{}
+	err = s.doHTTP(getFilesystemURL, "GET", &getFilesystemResponse, nil)
+	if err != nil || len(getFilesystemResponse.FileSystems) == 0 {
+		s.logger.Debug("Unable to fetch the block size of the filesystem", logs.Args{{"filesystem", filesystemName}, {"error", err}})
+		return capacity, nil
+	}
+	capacity.BlockSize = uint64(getFilesystemResponse.FileSystems[0].Block.BlockSize)
+	capacity.MaxInodes = uint64(getFilesystemResponse.FileSystems[0].Settings.MaxNumberOfInodes)
+	return capacity, nil
+}
+
 func (s *spectrumRestV2) CreateFileset(filesystemName string, filesetName string, opts map[string]string) error {
 
-	s.logger.Println("spectrumRestConnector: CreateFileset")
-	defer s.logger.Println("spectrumRestConnector: CreateFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	filesetreq := CreateFilesetRequest{}
 	filesetreq.FilesetName = filesetName
@@ -276,15 +307,15 @@ func (s *spectrumRestV2) CreateFileset(filesystemName string, filesetName string
 		filesetreq.InodeSpace = "root"
 	}
 
-	s.logger.Printf("filesetreq %v\n", filesetreq)
+	s.logger.Debug("filesetreq", logs.Args{{"filesetreq", filesetreq}})
 	createFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets", filesystemName))
 	createFilesetResponse := GenericResponse{}
 
-	s.logger.Println("Create Fileset URL: ", createFilesetURL)
+	s.logger.Debug("Create Fileset URL", logs.Args{{"url", createFilesetURL}})
 
 	err := s.doHTTP(createFilesetURL, "POST", &createFilesetResponse, filesetreq)
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to create fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -302,17 +333,16 @@ func (s *spectrumRestV2) CreateFileset(filesystemName string, filesetName string
 
 func (s *spectrumRestV2) DeleteFileset(filesystemName string, filesetName string) error {
 
-	s.logger.Println("spectrumRestConnector: DeleteFileset")
-	defer s.logger.Println("spectrumRestConnector: DeleteFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	deleteFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s", filesystemName, filesetName))
 	deleteFilesetResponse := GenericResponse{}
 
-	s.logger.Println("Delete Fileset URL: ", deleteFilesetURL)
+	s.logger.Debug("Delete Fileset URL", logs.Args{{"url", deleteFilesetURL}})
 
 	err := s.doHTTP(deleteFilesetURL, "DELETE", &deleteFilesetResponse, nil)
 	if err != nil {
-		s.logger.Printf("Error in delete remote call")
+		s.logger.Error("Error in delete remote call")
 		return fmt.Errorf("Unable to delete fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -331,13 +361,12 @@ func (s *spectrumRestV2) DeleteFileset(filesystemName string, filesetName string
 
 func (s *spectrumRestV2) LinkFileset(filesystemName string, filesetName string) error {
 
-	s.logger.Println("spectrumRestConnector: LinkFileset")
-	defer s.logger.Println("spectrumRestConnector: LinkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	linkReq := LinkFilesetRequest{}
 	fsMountpoint, err := s.GetFilesystemMountpoint(filesystemName)
 	if err != nil {
-		s.logger.Printf("error in linking fileset")
+		s.logger.Error("error in linking fileset")
 		return err
 	}
 
@@ -345,11 +374,11 @@ func (s *spectrumRestV2) LinkFileset(filesystemName string, filesetName string)
 	linkFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/link", filesystemName, filesetName))
 	linkFilesetResponse := GenericResponse{}
 
-	s.logger.Println("Link Fileset URL: ", linkFilesetURL)
+	s.logger.Debug("Link Fileset URL", logs.Args{{"url", linkFilesetURL}})
 
 	err = s.doHTTP(linkFilesetURL, "POST", &linkFilesetResponse, linkReq)
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to link fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -367,18 +396,17 @@ func (s *spectrumRestV2) LinkFileset(filesystemName string, filesetName string)
 
 func (s *spectrumRestV2) UnlinkFileset(filesystemName string, filesetName string) error {
 
-	s.logger.Println("spectrumRestConnector: UnlinkFileset")
-	defer s.logger.Println("spectrumRestConnector: UnlinkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	unlinkFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/link?force=True", filesystemName, filesetName))
 	unlinkFilesetResponse := GenericResponse{}
 
-	s.logger.Println("Unlink Fileset URL: ", unlinkFilesetURL)
+	s.logger.Debug("Unlink Fileset URL", logs.Args{{"url", unlinkFilesetURL}})
 
 	err := s.doHTTP(unlinkFilesetURL, "DELETE", &unlinkFilesetResponse, nil)
 
 	if err != nil {
-		s.logger.Printf("error in remote call %v", err)
+		s.logger.Error("error in remote call", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to unlink fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -397,17 +425,16 @@ func (s *spectrumRestV2) UnlinkFileset(filesystemName string, filesetName string
 
 func (s *spectrumRestV2) ListFileset(filesystemName string, filesetName string) (resources.Volume, error) {
 
-	s.logger.Println("spectrumRestConnector: ListFileset")
-	defer s.logger.Println("spectrumRestConnector: ListFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s", filesystemName, filesetName))
 	getFilesetResponse := GetFilesetResponse_v2{}
 
-	s.logger.Println("List Fileset URL: ", getFilesetURL)
+	s.logger.Debug("List Fileset URL", logs.Args{{"url", getFilesetURL}})
 
 	err := s.doHTTP(getFilesetURL, "GET", &getFilesetResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in processing remote call %v", err)
+		s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 		return resources.Volume{}, fmt.Errorf("Unable to list fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -423,20 +450,19 @@ func (s *spectrumRestV2) ListFileset(filesystemName string, filesetName string)
 
 func (s *spectrumRestV2) ListFilesets(filesystemName string) ([]resources.Volume, error) {
 
-	s.logger.Println("spectrumRestConnector: ListFilesets")
-	defer s.logger.Println("spectrumRestConnector: ListFilesets end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	listFilesetURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets", filesystemName))
 	listFilesetResponse := GetFilesetResponse_v2{}
 
-	s.logger.Println("List Filesets URL: ", listFilesetURL)
+	s.logger.Debug("List Filesets URL", logs.Args{{"url", listFilesetURL}})
 
 	var response []resources.Volume
 	var responseSize int
 	for {
 		err := s.doHTTP(listFilesetURL, "GET", &listFilesetResponse, nil)
 		if err != nil {
-			s.logger.Printf("error in processing remote call %v", err)
+			s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 			return nil, fmt.Errorf("Unable to list filesets for %v. Please refer Ubiquity server logs for more details", filesystemName)
 		}
 		responseSize = len(listFilesetResponse.Filesets)
@@ -457,12 +483,11 @@ func (s *spectrumRestV2) ListFilesets(filesystemName string) ([]resources.Volume
 
 func (s *spectrumRestV2) IsFilesetLinked(filesystemName string, filesetName string) (bool, error) {
 
-	s.logger.Println("spectrumRestConnector: IsFilesetLinked")
-	defer s.logger.Println("spectrumRestConnector: IsFilesetLinked end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	fileset, err := s.ListFileset(filesystemName, filesetName)
 	if err != nil {
-		s.logger.Printf("error retrieving fileset data")
+		s.logger.Error("error retrieving fileset data")
 		return false, err
 	}
 
@@ -475,13 +500,12 @@ func (s *spectrumRestV2) IsFilesetLinked(filesystemName string, filesetName stri
 
 func (s *spectrumRestV2) SetFilesetQuota(filesystemName string, filesetName string, quota string) error {
 
-	s.logger.Println("spectrumRestConnector: SetFilesetQuota")
-	defer s.logger.Println("spectrumRestConnector: SetFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	setQuotaURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/filesets/%s/quotas", filesystemName, filesetName))
 	quotaRequest := SetQuotaRequest_v2{}
 
-	s.logger.Println("Set Quota URL: ", setQuotaURL)
+	s.logger.Debug("Set Quota URL", logs.Args{{"url", setQuotaURL}})
 
 	quotaRequest.BlockHardLimit = quota
 	quotaRequest.BlockSoftLimit = quota
@@ -493,7 +517,7 @@ func (s *spectrumRestV2) SetFilesetQuota(filesystemName string, filesetName stri
 
 	err := s.doHTTP(setQuotaURL, "POST", &setQuotaResponse, quotaRequest)
 	if err != nil {
-		s.logger.Printf("error setting quota for fileset %v", err)
+		s.logger.Error("error setting quota for fileset", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to set quota for fileset %v. Please refer Ubiquity server logs for more details", filesetName)
 	}
 
@@ -511,17 +535,16 @@ func (s *spectrumRestV2) SetFilesetQuota(filesystemName string, filesetName stri
 
 func (s *spectrumRestV2) ListFilesetQuota(filesystemName string, filesetName string) (string, error) {
 
-	s.logger.Println("spectrumRestConnector: ListFilesetQuota")
-	defer s.logger.Println("spectrumRestConnector: ListFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	listQuotaURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/filesystems/%s/quotas?filter=objectName=%s", filesystemName, filesetName))
 	listQuotaResponse := GetQuotaResponse_v2{}
 
-	s.logger.Println("List Quota URL: ", listQuotaURL)
+	s.logger.Debug("List Quota URL", logs.Args{{"url", listQuotaURL}})
 
 	err := s.doHTTP(listQuotaURL, "GET", &listQuotaResponse, nil)
 	if err != nil {
-		s.logger.Printf("error in processing remote call %v", err)
+		s.logger.Error("error in processing remote call", logs.Args{{"error", err}})
 		return "", fmt.Errorf("Unable to fetch quota information %v. Please refer Ubiquity server logs for more details", filesystemName)
 	}
 
@@ -535,21 +558,20 @@ func (s *spectrumRestV2) ListFilesetQuota(filesystemName string, filesetName str
 
 func (s *spectrumRestV2) ExportNfs(volumeMountpoint string, clientConfig string) error {
 
-	s.logger.Println("spectrumRestConnector: ExportNfs")
-	defer s.logger.Println("spectrumRestConnector: ExportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	exportNfsURL := utils.FormatURL(s.endpoint, fmt.Sprintf("scalemgmt/v2/nfs/exports"))
 	nfsExportReq := nfsExportRequest{}
 	nfsExportReq.Path = volumeMountpoint
 	nfsExportReq.ClientDetail = append(nfsExportReq.ClientDetail, clientConfig)
 
-	s.logger.Println("Export NFS URL: ", exportNfsURL)
-	s.logger.Printf("volumemount %s clientdetail %s\n", nfsExportReq.Path, nfsExportReq.ClientDetail)
+	s.logger.Debug("Export NFS URL", logs.Args{{"url", exportNfsURL}})
+	s.logger.Debug("nfs export", logs.Args{{"path", nfsExportReq.Path}, {"clientDetail", nfsExportReq.ClientDetail}})
 
 	nfsExportResp := GenericResponse{}
 	err := s.doHTTP(exportNfsURL, "POST", &nfsExportResp, nfsExportReq)
 	if err != nil {
-		s.logger.Printf("error during NFS export %v", err)
+		s.logger.Error("error during NFS export", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to export %v. Please refer Ubiquity server logs for more details", volumeMountpoint)
 	}
 
@@ -567,18 +589,17 @@ func (s *spectrumRestV2) ExportNfs(volumeMountpoint string, clientConfig string)
 
 func (s *spectrumRestV2) UnexportNfs(volumeMountpoint string) error {
 
-	s.logger.Println("spectrumRestConnector: UnexportNfs")
-	defer s.logger.Println("spectrumRestConnector: UnexportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	volumeMountpoint = url.QueryEscape(volumeMountpoint)
 	unexportNfsURL := utils.FormatURL(s.endpoint, "scalemgmt/v2/nfs/exports/", volumeMountpoint)
 	unexportNfsResp := GenericResponse{}
 
-	s.logger.Printf("NFS export DELETE URL: \n", unexportNfsURL)
+	s.logger.Debug("NFS export DELETE URL", logs.Args{{"url", unexportNfsURL}})
 
 	err := s.doHTTP(unexportNfsURL, "DELETE", &unexportNfsResp, nil)
 	if err != nil {
-		s.logger.Printf("Error while deleting NFS export %v", err)
+		s.logger.Error("Error while deleting NFS export", logs.Args{{"error", err}})
 		return fmt.Errorf("Unable to remove export %v. Please refer Ubiquity server logs for more details", volumeMountpoint)
 	}
 
@@ -598,18 +619,18 @@ func (s *spectrumRestV2) UnexportNfs(volumeMountpoint string) error {
 func (s *spectrumRestV2) doHTTP(endpoint string, method string, responseObject interface{}, param interface{}) error {
 	response, err := utils.HttpExecuteUserAuth(s.httpClient, s.logger, method, endpoint, s.user, s.password, param)
 	if err != nil {
-		s.logger.Printf("Error in %s: %s remote call %#v", method, endpoint, err)
+		s.logger.Error("Error in remote call", logs.Args{{"method", method}, {"endpoint", endpoint}, {"error", err}})
 
 		return err
 	}
 
 	if !s.isStatusOK(response.StatusCode) {
-		s.logger.Printf("Remote call completed with error %#v\n", response)
+		s.logger.Error("Remote call completed with error", logs.Args{{"response", response}})
 		return fmt.Errorf("Remote call completed with error")
 	}
 	err = utils.UnmarshalResponse(response, responseObject)
 	if err != nil {
-		s.logger.Printf("Error in unmarshalling response for get remote call %#v for response %#v", err, response)
+		s.logger.Error("Error in unmarshalling response for get remote call", logs.Args{{"response", response}, {"error", err}})
 		return err
 
 	}
@@ -18,7 +18,6 @@ package connectors_test
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"os"
 
@@ -32,7 +31,6 @@ import (
 var _ = Describe("spectrumRestV2", func() {
 	var (
 		spectrumRestV2 connectors.SpectrumScaleConnector
-		logger         *log.Logger
 		fakeurl        string
 		restConfig     resources.RestConfig
 		opts           map[string]string
@@ -43,14 +41,13 @@ var _ = Describe("spectrumRestV2", func() {
 	)
 
 	BeforeEach(func() {
-		logger = log.New(os.Stdout, "spectrum: ", log.Lshortfile|log.LstdFlags)
 		httpmock.Activate()
 		fakeurl = "http://1.1.1.1:443"
 		restConfig.Endpoint = fakeurl
 		restConfig.User = "fakeuser"
 		restConfig.Password = "fakepassword"
 		restConfig.Hostname = "fakehostname"
-		spectrumRestV2, client, err = connectors.NewspectrumRestV2WithClient(logger, restConfig)
+		spectrumRestV2, client, err = connectors.NewspectrumRestV2WithClient(restConfig)
 		Expect(err).ToNot(HaveOccurred())
 		httpmock.ActivateNonDefault(client)
 		fileset = "fake-fileset"
@@ -166,7 +163,7 @@ var _ = Describe("spectrumRestV2", func() {
 
 			restConfig.Hostname = ""
 
-			nspectrumRestV2, nclient, err := connectors.NewspectrumRestV2WithClient(logger, restConfig)
+			nspectrumRestV2, nclient, err := connectors.NewspectrumRestV2WithClient(restConfig)
 			Expect(err).ToNot(HaveOccurred())
 			httpmock.ActivateNonDefault(nclient)
 
@@ -274,6 +271,79 @@ var _ = Describe("spectrumRestV2", func() {
 		})
 	})
 
+	Context(".GetFilesystemCapacity", func() {
+		It("Should sum the disks that hold data", func() {
+			getDisksResp := connectors.GetDisksResponse_v2{}
+			getDisksResp.Disks = []connectors.Disk_v2{
+				{Name: "nsd1", Usage: "dataAndMetadata", Size: 1024, AvailableBlocks: 512},
+				{Name: "nsd2", Usage: "dataOnly", Size: 2048, AvailableBlocks: 2048},
+				{Name: "nsd3", Usage: "metadataOnly", Size: 4096, AvailableBlocks: 4096},
+			}
+			getDisksResp.Status.Code = 200
+			marshalledResponse, err := json.Marshal(getDisksResp)
+			Expect(err).ToNot(HaveOccurred())
+			registerurl := fakeurl + "/scalemgmt/v2/filesystems/" + filesystem + "/disks?fields=:all:"
+			httpmock.RegisterResponder(
+				"GET",
+				registerurl,
+				httpmock.NewStringResponder(200, string(marshalledResponse)),
+			)
+			capacity, err := spectrumRestV2.GetFilesystemCapacity(filesystem)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(capacity.TotalBytes).To(Equal(uint64(3072 * 1024)))
+			Expect(capacity.FreeBytes).To(Equal(uint64(2560 * 1024)))
+			Expect(capacity.BlockSize).To(Equal(uint64(0)))
+		})
+
+		It("Should add the block size and the inode limit of the filesystem", func() {
+			getDisksResp := connectors.GetDisksResponse_v2{}
+			getDisksResp.Disks = []connectors.Disk_v2{{Name: "nsd1", Size: 1024, AvailableBlocks: 512}}
+			getDisksResp.Status.Code = 200
+			marshalledResponse, err := json.Marshal(getDisksResp)
+			Expect(err).ToNot(HaveOccurred())
+			httpmock.RegisterResponder(
+				"GET",
+				fakeurl+"/scalemgmt/v2/filesystems/"+filesystem+"/disks?fields=:all:",
+				httpmock.NewStringResponder(200, string(marshalledResponse)),
+			)
+			getfilesysResp := connectors.GetFilesystemResponse_v2{}
+			getfilesysResp.FileSystems = make([]connectors.FileSystem_v2, 1)
+			getfilesysResp.FileSystems[0].Block.BlockSize = 4194304
+			getfilesysResp.FileSystems[0].Settings.MaxNumberOfInodes = 100000
+			getfilesysResp.Status.Code = 200
+			marshalledResponse, err = json.Marshal(getfilesysResp)
+			Expect(err).ToNot(HaveOccurred())
+			httpmock.RegisterResponder(
+				"GET",
+				fakeurl+"/scalemgmt/v2/filesystems/"+filesystem,
+				httpmock.NewStringResponder(200, string(marshalledResponse)),
+			)
+			capacity, err := spectrumRestV2.GetFilesystemCapacity(filesystem)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(capacity.TotalBytes).To(Equal(uint64(1024 * 1024)))
+			Expect(capacity.BlockSize).To(Equal(uint64(4194304)))
+			Expect(capacity.MaxInodes).To(Equal(uint64(100000)))
+		})
+
+		It("Should fail with http error", func() {
+			getDisksResp := connectors.GetDisksResponse_v2{}
+			getDisksResp.Status.Code = 500
+			marshalledResponse, err := json.Marshal(getDisksResp)
+			Expect(err).ToNot(HaveOccurred())
+			registerurl := fakeurl + "/scalemgmt/v2/filesystems/" + filesystem + "/disks?fields=:all:"
+			httpmock.RegisterResponder(
+				"GET",
+				registerurl,
+				httpmock.NewStringResponder(500, string(marshalledResponse)),
+			)
+			_, err = spectrumRestV2.GetFilesystemCapacity(filesystem)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context(".CreateFileset", func() {
 		var (
 			createFilesetResp connectors.GenericResponse
@@ -1349,8 +1419,8 @@ var _ = Describe("spectrumRestV2", func() {
 			restConfig.User = ""
 			restConfig.Password = "fakepassword"
 			restConfig.Hostname = "fakehostname"
-			spectrumRestV2, err = connectors.NewSpectrumRestV2(logger, restConfig)
-			spectrumRestV2, client, err = connectors.NewspectrumRestV2WithClient(logger, restConfig)
+			spectrumRestV2, err = connectors.NewSpectrumRestV2(restConfig)
+			spectrumRestV2, client, err = connectors.NewspectrumRestV2WithClient(restConfig)
 			err = spectrumRestV2.UnexportNfs(fileset)
 			Expect(err).To(HaveOccurred())
 
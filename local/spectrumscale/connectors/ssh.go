@@ -18,15 +18,15 @@ package connectors
 
 import (
 	"fmt"
-	"log"
 	"path"
 
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type spectrum_ssh struct {
-	logger    *log.Logger
+	logger    logs.Logger
 	executor  utils.Executor
 	user      string
 	host      string
@@ -34,17 +34,17 @@ type spectrum_ssh struct {
 	isMounted bool
 }
 
-func NewSpectrumSSH(logger *log.Logger, sshConfig resources.SshConfig) (SpectrumScaleConnector, error) {
+func NewSpectrumSSH(sshConfig resources.SshConfig) (SpectrumScaleConnector, error) {
 	user := sshConfig.User
 	host := sshConfig.Host
 	port := sshConfig.Port
-	return &spectrum_ssh{logger: logger, executor: utils.NewExecutor(), user: user, host: host, port: port}, nil
+	return &spectrum_ssh{logger: logs.GetComponentLogger(logs.ComponentSpectrum), executor: utils.NewExecutor(), user: user, host: host, port: port}, nil
 }
-func NewSpectrumSSHWithExecutor(logger *log.Logger, sshConfig resources.SshConfig, executor utils.Executor) (SpectrumScaleConnector, error) {
+func NewSpectrumSSHWithExecutor(sshConfig resources.SshConfig, executor utils.Executor) (SpectrumScaleConnector, error) {
 	user := sshConfig.User
 	host := sshConfig.Host
 	port := sshConfig.Port
-	return &spectrum_ssh{logger: logger, executor: executor, user: user, host: host, port: port}, nil
+	return &spectrum_ssh{logger: logs.GetComponentLogger(logs.ComponentSpectrum), executor: executor, user: user, host: host, port: port}, nil
 }
 
 func (s *spectrum_ssh) GetClusterId() (string, error) {
@@ -54,8 +54,7 @@ func (s *spectrum_ssh) GetClusterId() (string, error) {
 	return GetClusterIdInternal(s.logger, s.executor, "ssh", args)
 }
 func (s *spectrum_ssh) IsFilesystemMounted(filesystemName string) (bool, error) {
-	s.logger.Println("spectrumLocalClient: isMounted start")
-	defer s.logger.Println("spectrumLocalClient: isMounted end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.isMounted == true {
 		s.isMounted = true
@@ -70,8 +69,7 @@ func (s *spectrum_ssh) IsFilesystemMounted(filesystemName string) (bool, error)
 
 }
 func (s *spectrum_ssh) MountFileSystem(filesystemName string) error {
-	s.logger.Println("spectrumLocalClient: mount start")
-	defer s.logger.Println("spectrumLocalClient: mount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.isMounted == true {
 		return nil
@@ -83,7 +81,7 @@ func (s *spectrum_ssh) MountFileSystem(filesystemName string) error {
 
 	err := MountFileSystemInternal(s.logger, s.executor, filesystemName, "ssh", args)
 	if err != nil {
-		s.logger.Printf("error mounting filesystem %v", err)
+		s.logger.Error("error mounting filesystem", logs.Args{{"error", err}})
 		return err
 	}
 	s.isMounted = true
@@ -91,7 +89,16 @@ func (s *spectrum_ssh) MountFileSystem(filesystemName string) error {
 }
 
 func (s *spectrum_ssh) ListFilesystems() ([]string, error) {
-	return nil, nil
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfs"
+	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
+	args := []string{userAndHost, "-p", s.port, "sudo", spectrumCommand, "all", "-T", "-Y"}
+	return ListFilesystemsInternal(s.logger, s.executor, "ssh", args)
+}
+func (s *spectrum_ssh) GetFilesystemCapacity(filesystemName string) (FilesystemCapacity, error) {
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmdf"
+	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
+	args := []string{userAndHost, "-p", s.port, "sudo", spectrumCommand, filesystemName, "-Y"}
+	return GetFilesystemCapacityInternal(s.logger, s.executor, filesystemName, "ssh", args)
 }
 func (s *spectrum_ssh) GetFilesystemMountpoint(filesystemName string) (string, error) {
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfs"
@@ -101,10 +108,9 @@ func (s *spectrum_ssh) GetFilesystemMountpoint(filesystemName string) (string, e
 }
 
 func (s *spectrum_ssh) CreateFileset(filesystemName string, filesetName string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient: createFileset start")
-	defer s.logger.Println("spectrumLocalClient: createFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("creating a new fileset: %s\n", filesetName)
+	s.logger.Info("creating a new fileset", logs.Args{{"filesetName", filesetName}})
 
 	// create fileset
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmcrfileset"
@@ -126,8 +132,7 @@ func (s *spectrum_ssh) CreateFileset(filesystemName string, filesetName string,
 }
 
 func (s *spectrum_ssh) DeleteFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: deleteFileset start")
-	defer s.logger.Println("spectrumLocalClient: deleteFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmdelfileset"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -136,25 +141,23 @@ func (s *spectrum_ssh) DeleteFileset(filesystemName string, filesetName string)
 }
 
 func (s *spectrum_ssh) IsFilesetLinked(filesystemName string, filesetName string) (bool, error) {
-	s.logger.Println("spectrumLocalClient: isFilesetLinked start")
-	defer s.logger.Println("spectrumLocalClient: isFilesetLinked end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
 	args := []string{userAndHost, "-p", s.port, "sudo", spectrumCommand, filesystemName, filesetName, "-Y"}
-	s.logger.Printf("%#v\n", args)
+	s.logger.Debug("executing", logs.Args{{"args", args}})
 	return IsFilesetLinkedInternal(s.logger, s.executor, filesystemName, filesetName, "ssh", args)
 }
 
 func (s *spectrum_ssh) LinkFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: linkFileset start")
-	defer s.logger.Println("spectrumLocalClient: linkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("Trying to link: %s,%s", filesystemName, filesetName)
+	s.logger.Debug("Trying to link", logs.Args{{"filesystemName", filesystemName}, {"filesetName", filesetName}})
 
 	mountpoint, err := s.GetFilesystemMountpoint(filesystemName)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 
@@ -162,18 +165,17 @@ func (s *spectrum_ssh) LinkFileset(filesystemName string, filesetName string) er
 	filesetPath := path.Join(mountpoint, filesetName)
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
 	args := []string{userAndHost, "-p", s.port, "sudo", spectrumCommand, filesystemName, filesetName, "-J", filesetPath}
-	s.logger.Printf("Args for link fileset%#v", args)
+	s.logger.Debug("Args for link fileset", logs.Args{{"args", args}})
 	err = LinkFilesetInternal(s.logger, s.executor, filesystemName, filesetName, "ssh", args)
 	if err != nil {
-		s.logger.Printf("error linking fileset %v", err)
+		s.logger.Error("error linking fileset", logs.Args{{"error", err}})
 		return err
 	}
 	return nil
 }
 
 func (s *spectrum_ssh) UnlinkFileset(filesystemName string, filesetName string) error {
-	s.logger.Println("spectrumLocalClient: unlinkFileset start")
-	defer s.logger.Println("spectrumLocalClient: unlinkFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmunlinkfileset"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -182,12 +184,16 @@ func (s *spectrum_ssh) UnlinkFileset(filesystemName string, filesetName string)
 }
 
 func (s *spectrum_ssh) ListFilesets(filesystemName string) ([]resources.Volume, error) {
-	return nil, nil
+	defer s.logger.Trace(logs.DEBUG)()
+
+	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
+	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
+	args := []string{userAndHost, "-p", s.port, "sudo", spectrumCommand, filesystemName, "-Y"}
+	return ListFilesetsInternal(s.logger, s.executor, filesystemName, "ssh", args)
 }
 
 func (s *spectrum_ssh) ListFileset(filesystemName string, filesetName string) (resources.Volume, error) {
-	s.logger.Println("spectrumLocalClient: ListFileset start")
-	defer s.logger.Println("spectrumLocalClient: ListFileset end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsfileset"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -197,8 +203,7 @@ func (s *spectrum_ssh) ListFileset(filesystemName string, filesetName string) (r
 
 //TODO modify quota from string to Capacity (see kubernetes)
 func (s *spectrum_ssh) ListFilesetQuota(filesystemName string, filesetName string) (string, error) {
-	s.logger.Println("spectrumLocalClient: verifyFilesetQuota start")
-	defer s.logger.Println("spectrumLocalClient: verifyFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmlsquota"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -207,10 +212,9 @@ func (s *spectrum_ssh) ListFilesetQuota(filesystemName string, filesetName strin
 }
 
 func (s *spectrum_ssh) SetFilesetQuota(filesystemName string, filesetName string, quota string) error {
-	s.logger.Println("spectrumLocalClient: setFilesetQuota start")
-	defer s.logger.Println("spectrumLocalClient: setFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
-	s.logger.Printf("setting quota to %s for fileset %s\n", quota, filesetName)
+	s.logger.Info("setting quota for fileset", logs.Args{{"quota", quota}, {"filesetName", filesetName}})
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmsetquota"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -220,8 +224,7 @@ func (s *spectrum_ssh) SetFilesetQuota(filesystemName string, filesetName string
 
 func (s *spectrum_ssh) ExportNfs(volumeMountpoint string, clientConfig string) error {
 
-	s.logger.Println("spectrumLocalClient: ExportNfs start")
-	defer s.logger.Println("spectrumLocalClient: ExportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmnfs"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -231,8 +234,7 @@ func (s *spectrum_ssh) ExportNfs(volumeMountpoint string, clientConfig string) e
 
 func (s *spectrum_ssh) UnexportNfs(volumeMountpoint string) error {
 
-	s.logger.Println("spectrumLocalClient: UnexportNfs start")
-	defer s.logger.Println("spectrumLocalClient: UnexportNfs end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	spectrumCommand := "/usr/lpp/mmfs/bin/mmnfs"
 	userAndHost := fmt.Sprintf("%s@%s", s.user, s.host)
@@ -17,14 +17,14 @@
 package spectrumscale
 
 import (
-	"log"
+	"encoding/json"
 
 	"fmt"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 //go:generate counterfeiter -o ../../fakes/fake_SpectrumDataModel.go . SpectrumDataModel
@@ -36,13 +36,18 @@ type SpectrumDataModel interface {
 	InsertFilesetVolume(fileset, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error
 	InsertLightweightVolume(fileset, directory, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error
 	InsertFilesetQuotaVolume(fileset, quota, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error
+	InsertPreexistingFilesetVolumes(filesets []string, filesystem string, opts map[string]string) error
 	GetVolume(name string) (SpectrumScaleVolume, bool, error)
 	ListVolumes() ([]resources.Volume, error)
 	UpdateVolumeMountpoint(name string, mountpoint string) error
+	UpdateVolumeState(name string, state string) error
+	GetVolumeAttachments(name string) ([]resources.VolumeAttachment, error)
+	AddVolumeAttachment(name string, host string, readOnly bool) error
+	RemoveVolumeAttachment(name string, host string) error
 }
 
 type spectrumDataModel struct {
-	log       *log.Logger
+	logger    logs.Logger
 	database  *gorm.DB
 	clusterId string
 	backend   string
@@ -76,8 +81,8 @@ type SpectrumScaleVolume struct {
 	IsPreexisting bool
 }
 
-func NewSpectrumDataModel(log *log.Logger, db *gorm.DB, backend string) SpectrumDataModel {
-	return &spectrumDataModel{log: log, database: db, backend: backend}
+func NewSpectrumDataModel(db *gorm.DB, backend string) SpectrumDataModel {
+	return &spectrumDataModel{logger: logs.GetComponentLogger(logs.ComponentSpectrum), database: db, backend: backend}
 }
 
 func (d *spectrumDataModel) SetClusterId(id string) {
@@ -86,19 +91,115 @@ func (d *spectrumDataModel) SetClusterId(id string) {
 func (d *spectrumDataModel) GetClusterId() string {
 	return d.clusterId
 }
+
+// spectrumScaleVolumeInventoryRow is the inventory form of a SpectrumScaleVolume row
+type spectrumScaleVolumeInventoryRow struct {
+	ID            uint       `json:"id"`
+	VolumeID      uint       `json:"volume_id"`
+	Type          VolumeType `json:"type"`
+	ClusterId     string     `json:"cluster_id"`
+	FileSystem    string     `json:"filesystem"`
+	Fileset       string     `json:"fileset"`
+	Directory     string     `json:"directory"`
+	UID           string     `json:"uid"`
+	GID           string     `json:"gid"`
+	Quota         string     `json:"quota"`
+	IsPreexisting bool       `json:"is_preexisting"`
+}
+
+func init() {
+	model.RegisterInventoryTable(model.InventoryTable{
+		Name: "spectrum_scale_volumes",
+		Export: func(db *gorm.DB) (interface{}, error) {
+			var volumes []SpectrumScaleVolume
+			if err := db.Order("id").Find(&volumes).Error; err != nil {
+				return nil, err
+			}
+			rows := make([]spectrumScaleVolumeInventoryRow, 0, len(volumes))
+			for _, volume := range volumes {
+				rows = append(rows, spectrumScaleVolumeInventoryRow{
+					ID:            volume.ID,
+					VolumeID:      volume.VolumeID,
+					Type:          volume.Type,
+					ClusterId:     volume.ClusterId,
+					FileSystem:    volume.FileSystem,
+					Fileset:       volume.Fileset,
+					Directory:     volume.Directory,
+					UID:           volume.UID,
+					GID:           volume.GID,
+					Quota:         volume.Quota,
+					IsPreexisting: volume.IsPreexisting,
+				})
+			}
+			return rows, nil
+		},
+		Import: func(tx *gorm.DB, data json.RawMessage) error {
+			var rows []spectrumScaleVolumeInventoryRow
+			if err := json.Unmarshal(data, &rows); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				volume := SpectrumScaleVolume{
+					ID:            row.ID,
+					VolumeID:      row.VolumeID,
+					Type:          row.Type,
+					ClusterId:     row.ClusterId,
+					FileSystem:    row.FileSystem,
+					Fileset:       row.Fileset,
+					Directory:     row.Directory,
+					UID:           row.UID,
+					GID:           row.GID,
+					Quota:         row.Quota,
+					IsPreexisting: row.IsPreexisting,
+				}
+				if err := tx.Set("gorm:save_associations", false).Create(&volume).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	model.RegisterMigrations(model.Migration{
+		Version:     3,
+		Description: "create spectrum_scale_volumes table",
+		Up: func(tx *gorm.DB) error {
+			type spectrumScaleVolume struct {
+				ID            uint
+				VolumeID      uint
+				Type          int
+				ClusterId     string
+				FileSystem    string
+				Fileset       string
+				Directory     string
+				UID           string
+				GID           string
+				Quota         string
+				IsPreexisting bool
+			}
+			return tx.Table("spectrum_scale_volumes").AutoMigrate(&spectrumScaleVolume{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("spectrum_scale_volumes").Error
+		},
+		DropsData: true,
+	})
+}
+
+// CreateVolumeTable creates the table if it does not exist, schema changes are done by migrations
 func (d *spectrumDataModel) CreateVolumeTable() error {
-	d.log.Println("SpectrumDataModel: Create Volumes Table start")
-	defer d.log.Println("SpectrumDataModel: Create Volumes Table end")
+	defer d.logger.Trace(logs.DEBUG)()
 
-	if err := d.database.AutoMigrate(&SpectrumScaleVolume{}).Error; err != nil {
+	if d.database.HasTable(&SpectrumScaleVolume{}) {
+		return nil
+	}
+	if err := d.database.CreateTable(&SpectrumScaleVolume{}).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
 func (d *spectrumDataModel) DeleteVolume(name string) error {
-	d.log.Println("SpectrumDataModel: DeleteVolume start")
-	defer d.log.Println("SpectrumDataModel: DeleteVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, exists, err := d.GetVolume(name)
 
@@ -119,10 +220,9 @@ func (d *spectrumDataModel) DeleteVolume(name string) error {
 }
 
 func (d *spectrumDataModel) InsertFilesetVolume(fileset, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error {
-	d.log.Println("SpectrumDataModel: InsertFilesetVolume start")
-	defer d.log.Println("SpectrumDataModel: InsertFilesetVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
-	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend}, Type: Fileset, ClusterId: d.clusterId, FileSystem: filesystem,
+	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend, BackendType: resources.SpectrumScale, State: initialState(isPreexisting)}, Type: Fileset, ClusterId: d.clusterId, FileSystem: filesystem,
 		Fileset: fileset, IsPreexisting: isPreexisting}
 
 	addPermissionsForVolume(&volume, opts)
@@ -131,10 +231,9 @@ func (d *spectrumDataModel) InsertFilesetVolume(fileset, volumeName string, file
 }
 
 func (d *spectrumDataModel) InsertLightweightVolume(fileset, directory, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error {
-	d.log.Println("SpectrumDataModel: InsertLightweightVolume start")
-	defer d.log.Println("SpectrumDataModel: InsertLightweightVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
-	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend}, Type: Lightweight, ClusterId: d.clusterId, FileSystem: filesystem,
+	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend, BackendType: resources.SpectrumScale, State: initialState(isPreexisting)}, Type: Lightweight, ClusterId: d.clusterId, FileSystem: filesystem,
 		Fileset: fileset, Directory: directory, IsPreexisting: isPreexisting}
 
 	addPermissionsForVolume(&volume, opts)
@@ -143,10 +242,9 @@ func (d *spectrumDataModel) InsertLightweightVolume(fileset, directory, volumeNa
 }
 
 func (d *spectrumDataModel) InsertFilesetQuotaVolume(fileset, quota, volumeName string, filesystem string, isPreexisting bool, opts map[string]string) error {
-	d.log.Println("SpectrumDataModel: InsertFilesetQuotaVolume start")
-	defer d.log.Println("SpectrumDataModel: InsertFilesetQuotaVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
-	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend}, Type: FilesetWithQuota, ClusterId: d.clusterId, FileSystem: filesystem,
+	volume := SpectrumScaleVolume{Volume: resources.Volume{Name: volumeName, Backend: d.backend, BackendType: resources.SpectrumScale, State: initialState(isPreexisting)}, Type: FilesetWithQuota, ClusterId: d.clusterId, FileSystem: filesystem,
 		Fileset: fileset, Quota: quota, IsPreexisting: isPreexisting}
 
 	addPermissionsForVolume(&volume, opts)
@@ -154,9 +252,39 @@ func (d *spectrumDataModel) InsertFilesetQuotaVolume(fileset, quota, volumeName
 	return d.insertVolume(volume)
 }
 
+// InsertPreexistingFilesetVolumes adds a volume named after each fileset in one transaction,
+// nothing is inserted if one of the names is already used
+func (d *spectrumDataModel) InsertPreexistingFilesetVolumes(filesets []string, filesystem string, opts map[string]string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	tx := d.database.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	for _, fileset := range filesets {
+		exists, err := model.VolumeExists(tx, fileset)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if exists {
+			tx.Rollback()
+			return fmt.Errorf("Volume %s already exists", fileset)
+		}
+		volume := SpectrumScaleVolume{Volume: resources.Volume{Name: fileset, Backend: d.backend, BackendType: resources.SpectrumScale, State: resources.VolumeStateAvailable,
+			AccessMode: resources.GetDefaultAccessMode(resources.SpectrumScale)}, Type: Fileset, ClusterId: d.clusterId, FileSystem: filesystem,
+			Fileset: fileset, IsPreexisting: true}
+		addPermissionsForVolume(&volume, opts)
+		if err := tx.Create(&volume).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
 func (d *spectrumDataModel) insertVolume(volume SpectrumScaleVolume) error {
-	d.log.Println("SpectrumDataModel: insertVolume start")
-	defer d.log.Println("SpectrumDataModel: insertVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 	if err := d.database.Create(&volume).Error; err != nil {
 		return err
 	}
@@ -164,8 +292,7 @@ func (d *spectrumDataModel) insertVolume(volume SpectrumScaleVolume) error {
 }
 
 func (d *spectrumDataModel) GetVolume(name string) (SpectrumScaleVolume, bool, error) {
-	d.log.Println("SpectrumDataModel: GetVolume start")
-	defer d.log.Println("SpectrumDataModel: GetVolume end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, err := model.GetVolume(d.database, name, d.backend)
 	if err != nil {
@@ -186,8 +313,7 @@ func (d *spectrumDataModel) GetVolume(name string) (SpectrumScaleVolume, bool, e
 }
 
 func (d *spectrumDataModel) ListVolumes() ([]resources.Volume, error) {
-	d.log.Println("SpectrumDataModel: ListVolumes start")
-	defer d.log.Println("SpectrumDataModel: ListVolumes end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	var volumesInDb []SpectrumScaleVolume
 	if err := d.database.Preload("Volume").Find(&volumesInDb).Error; err != nil {
@@ -195,12 +321,12 @@ func (d *spectrumDataModel) ListVolumes() ([]resources.Volume, error) {
 	}
 	// hack: to be replaced by proper DB filtering (joins)
 	var volumes []resources.Volume
-	d.log.Println("backend ", d.backend)
+	d.logger.Debug("backend", logs.Args{{"backend", d.backend}})
 	for _, volume := range volumesInDb {
-		d.log.Println("volume %#v", volume)
+		d.logger.Debug("volume", logs.Args{{"volume", volume}})
 		if volume.Volume.Backend == d.backend {
-			d.log.Println("volume %#v", volume)
-			d.log.Println("backend for vol %#v", volume.Volume.Backend)
+			d.logger.Debug("volume", logs.Args{{"volume", volume}})
+			d.logger.Debug("backend for vol", logs.Args{{"backend", volume.Volume.Backend}})
 			volumes = append(volumes, volume.Volume)
 		}
 	}
@@ -209,8 +335,7 @@ func (d *spectrumDataModel) ListVolumes() ([]resources.Volume, error) {
 }
 
 func (d *spectrumDataModel) UpdateVolumeMountpoint(name string, mountpoint string) error {
-	d.log.Println("SpectrumDataModel: UpdateVolumeMountpoint start")
-	defer d.log.Println("SpectrumDataModel: UpdateVolumeMountpoint end")
+	defer d.logger.Trace(logs.DEBUG)()
 
 	volume, err := model.GetVolume(d.database, name, d.backend)
 	if err != nil {
@@ -223,6 +348,65 @@ func (d *spectrumDataModel) UpdateVolumeMountpoint(name string, mountpoint strin
 	return nil
 }
 
+func (d *spectrumDataModel) UpdateVolumeState(name string, state string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+
+	if err = model.UpdateVolumeState(d.database, &volume, state); err != nil {
+		return fmt.Errorf("Error updating state of volume %s to %s: %s", volume.Name, state, err.Error())
+	}
+	return nil
+}
+
+func (d *spectrumDataModel) GetVolumeAttachments(name string) ([]resources.VolumeAttachment, error) {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return nil, err
+	}
+	return model.GetVolumeAttachments(d.database, volume.ID)
+}
+
+func (d *spectrumDataModel) AddVolumeAttachment(name string, host string, readOnly bool) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+	if err = model.AddVolumeAttachment(d.database, volume.ID, host, readOnly); err != nil {
+		return fmt.Errorf("Error attaching volume %s to host %s: %s", name, host, err.Error())
+	}
+	return nil
+}
+
+func (d *spectrumDataModel) RemoveVolumeAttachment(name string, host string) error {
+	defer d.logger.Trace(logs.DEBUG)()
+
+	volume, err := model.GetVolume(d.database, name, d.backend)
+	if err != nil {
+		return err
+	}
+	if err = model.RemoveVolumeAttachment(d.database, volume.ID, host); err != nil {
+		return fmt.Errorf("Error detaching volume %s from host %s: %s", name, host, err.Error())
+	}
+	return nil
+}
+
+// initialState is creating for a volume whose fileset or directory is still to be created,
+// the caller moves it to available once the storage is ready
+func initialState(isPreexisting bool) string {
+	if isPreexisting {
+		return resources.VolumeStateAvailable
+	}
+	return resources.VolumeStateCreating
+}
+
 func addPermissionsForVolume(volume *SpectrumScaleVolume, opts map[string]string) {
 
 	if len(opts) > 0 {
@@ -0,0 +1,70 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spectrumscale
+
+import (
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+func (s *spectrumLocalClient) IsActivated() bool {
+	s.activationLock.RLock()
+	defer s.activationLock.RUnlock()
+	return s.isActivated
+}
+
+func (s *spectrumLocalClient) Capabilities() []string {
+	capabilities := []string{resources.CapabilityMultiWriter, resources.CapabilityQuota}
+	if s.placer != nil {
+		capabilities = append(capabilities, resources.CapabilityPlacement)
+	}
+	return capabilities
+}
+
+// ListPools returns the filesystems of the cluster with their block and inode capacity. A filesystem whose capacity
+// cannot be queried is listed with the error, so one unmounted filesystem does not hide the others.
+func (s *spectrumLocalClient) ListPools() ([]resources.StoragePool, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	filesystems, err := s.connector.ListFilesystems()
+	if err != nil {
+		return nil, s.logger.ErrorRet(err, "connector.ListFilesystems failed")
+	}
+	pools := make([]resources.StoragePool, 0, len(filesystems))
+	for _, filesystem := range filesystems {
+		capacity, err := s.connector.GetFilesystemCapacity(filesystem)
+		if err != nil {
+			s.logger.Error("connector.GetFilesystemCapacity failed", logs.Args{{"filesystem", filesystem}, {"error", err}})
+			pools = append(pools, resources.StoragePool{Name: filesystem, Err: err.Error()})
+			continue
+		}
+		pool := resources.StoragePool{
+			Name:       filesystem,
+			TotalBytes: capacity.TotalBytes,
+			FreeBytes:  capacity.FreeBytes,
+			BlockSize:  capacity.BlockSize,
+			UsedInodes: capacity.UsedInodes,
+			FreeInodes: capacity.FreeInodes,
+			MaxInodes:  capacity.MaxInodes,
+		}
+		if capacity.TotalBytes > capacity.FreeBytes {
+			pool.UsedBytes = capacity.TotalBytes - capacity.FreeBytes
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
@@ -0,0 +1,190 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spectrumscale
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// rootFileset is created with every filesystem, it is never a ubiquity volume
+const rootFileset = "root"
+
+// Reconcile compare the volumes in the DB with the filesets of their filesystems and with the filesets link state.
+// Filesets of the default filesystem that no volume uses are reported as orphans.
+func (s *spectrumLocalClient) Reconcile() ([]resources.Drift, error) {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return nil, err
+	}
+
+	defaultFilesets, err := s.listFilesets(s.config.DefaultFilesystemName)
+	if err != nil {
+		return nil, err
+	}
+	filesetsByFilesystem := map[string]map[string]bool{s.config.DefaultFilesystemName: defaultFilesets}
+	usedFilesets := make(map[string]bool)
+
+	var drifts []resources.Drift
+	for _, volume := range volumesInDb {
+		existingVolume, volExists, err := s.dataModel.GetVolume(volume.Name)
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return nil, err
+		}
+		if !volExists {
+			continue // removed meanwhile
+		}
+		filesets, listed := filesetsByFilesystem[existingVolume.FileSystem]
+		if !listed {
+			if filesets, err = s.listFilesets(existingVolume.FileSystem); err != nil {
+				return nil, err
+			}
+			filesetsByFilesystem[existingVolume.FileSystem] = filesets
+		}
+		if existingVolume.FileSystem == s.config.DefaultFilesystemName {
+			usedFilesets[existingVolume.Fileset] = true
+		}
+
+		if !filesets[existingVolume.Fileset] {
+			drifts = append(drifts, s.newDrift(resources.DriftMissingStorage, volume.Name, path.Join(existingVolume.FileSystem, existingVolume.Fileset)))
+			continue
+		}
+
+		isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return nil, err
+		}
+		if existingVolume.Type == Lightweight && isFilesetLinked {
+			volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
+			if err != nil {
+				s.logger.Error("failed", logs.Args{{"error", err}})
+				return nil, err
+			}
+			if _, err := s.executor.Stat(volumeMountpoint); os.IsNotExist(err) {
+				drifts = append(drifts, s.newDrift(resources.DriftMissingStorage, volume.Name, volumeMountpoint))
+				continue
+			}
+		}
+		// Detach keeps the fileset linked, so only an attached volume on an unlinked fileset is a drift
+		if existingVolume.Volume.Mountpoint != "" && !isFilesetLinked {
+			drift := s.newDrift(resources.DriftAttachMismatch, volume.Name, path.Join(existingVolume.FileSystem, existingVolume.Fileset))
+			drift.InDB = existingVolume.Volume.Mountpoint
+			drifts = append(drifts, drift)
+		}
+	}
+
+	for fileset := range defaultFilesets {
+		if fileset != rootFileset && !usedFilesets[fileset] {
+			drifts = append(drifts, s.newDrift(resources.DriftOrphanStorage, "", path.Join(s.config.DefaultFilesystemName, fileset)))
+		}
+	}
+	s.logger.Info("Reconcile found drifts", logs.Args{{"drifts", len(drifts)}})
+	return drifts, nil
+}
+
+// RepairDrift fix one drift found by Reconcile, after checking the storage again:
+//
+//	missing-storage - delete the volume from the DB
+//	attach-mismatch - clear the volume mountpoint in the DB
+//
+// Orphan filesets are only reported, the filesets carry no mark that proves ubiquity created them.
+func (s *spectrumLocalClient) RepairDrift(drift resources.Drift) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	if drift.Kind == resources.DriftOrphanStorage {
+		return fmt.Errorf("Orphan fileset %s is not removed automatically, delete it manually if it is not used", drift.Resource)
+	}
+	if drift.Kind != resources.DriftMissingStorage && drift.Kind != resources.DriftAttachMismatch {
+		return fmt.Errorf("Repair of drift kind %s is not supported", drift.Kind)
+	}
+
+	existingVolume, volExists, err := s.dataModel.GetVolume(drift.Volume)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return err
+	}
+	if !volExists {
+		return fmt.Errorf("Volume %s not found", drift.Volume)
+	}
+
+	if drift.Kind == resources.DriftMissingStorage {
+		filesets, err := s.listFilesets(existingVolume.FileSystem)
+		if err != nil {
+			return err
+		}
+		if filesets[existingVolume.Fileset] && existingVolume.Type != Lightweight {
+			return fmt.Errorf("Fileset %s of volume %s exists, skipping repair", existingVolume.Fileset, drift.Volume)
+		}
+		if existingVolume.Type == Lightweight && filesets[existingVolume.Fileset] {
+			volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
+			if err != nil {
+				s.logger.Error("failed", logs.Args{{"error", err}})
+				return err
+			}
+			if _, err := s.executor.Stat(volumeMountpoint); !os.IsNotExist(err) {
+				return fmt.Errorf("Directory %s of volume %s exists, skipping repair", volumeMountpoint, drift.Volume)
+			}
+		}
+		if err = s.dataModel.DeleteVolume(drift.Volume); err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return err
+		}
+		s.logger.Info("Repaired drift", logs.Args{{"drift", drift}})
+		return nil
+	}
+
+	isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return err
+	}
+	if isFilesetLinked || existingVolume.Volume.Mountpoint == "" {
+		return fmt.Errorf("Volume %s is no longer in attach mismatch, skipping repair", drift.Volume)
+	}
+	if err = s.dataModel.UpdateVolumeMountpoint(drift.Volume, ""); err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return err
+	}
+	s.logger.Info("Repaired drift", logs.Args{{"drift", drift}})
+	return nil
+}
+
+func (s *spectrumLocalClient) listFilesets(filesystem string) (map[string]bool, error) {
+	filesets, err := s.connector.ListFilesets(filesystem)
+	if err != nil {
+		s.logger.Error("Error listing filesets of filesystem", logs.Args{{"filesystem", filesystem}, {"error", err}})
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, fileset := range filesets {
+		names[fileset.Name] = true
+	}
+	return names, nil
+}
+
+func (s *spectrumLocalClient) newDrift(kind string, volumeName string, resource string) resources.Drift {
+	return resources.Drift{Kind: kind, Backend: s.backend, Volume: volumeName, Resource: resource}
+}
@@ -0,0 +1,180 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spectrumscale
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// RecoverVolumes complete or roll back the operations that were interrupted while the volumes were in a transitional state.
+// A volume that cannot be recovered is moved to the error state.
+func (s *spectrumLocalClient) RecoverVolumes(locker resources.VolumeLocker) error {
+	defer s.logger.Trace(logs.DEBUG)()
+
+	volumesInDb, err := s.dataModel.ListVolumes()
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return err
+	}
+
+	var failed []string
+	for _, volume := range volumesInDb {
+		if !model.IsVolumeStateTransitional(volume.State) {
+			continue
+		}
+		locker.WriteLock(volume.Name)
+		if err := s.recoverVolume(volume.Name); err != nil {
+			s.logger.Error("Error recovering volume", logs.Args{{"volume", volume.Name}, {"error", err}})
+			s.restoreVolumeState(volume.Name, resources.VolumeStateError)
+			failed = append(failed, volume.Name)
+		}
+		locker.WriteUnlock(volume.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Recovery of volumes [%s] failed, they were moved to the error state", strings.Join(failed, ","))
+	}
+	return nil
+}
+
+// recoverVolume must be called with the volume lock held, it reads the volume again since another server may have completed the operation
+func (s *spectrumLocalClient) recoverVolume(name string) error {
+	existingVolume, volExists, err := s.dataModel.GetVolume(name)
+	if err != nil {
+		return err
+	}
+	if !volExists || !model.IsVolumeStateTransitional(existingVolume.Volume.State) {
+		return nil // removed or completed meanwhile
+	}
+	s.logger.Info("Recovering volume", logs.Args{{"volume", name}, {"state", existingVolume.Volume.State}})
+
+	switch existingVolume.Volume.State {
+	case resources.VolumeStateCreating:
+		exists, err := s.volumeStorageExists(existingVolume)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// the fileset or directory was never created
+			return s.dataModel.DeleteVolume(name)
+		}
+		if existingVolume.Type == FilesetWithQuota {
+			if err = s.connector.SetFilesetQuota(existingVolume.FileSystem, existingVolume.Fileset, existingVolume.Quota); err != nil {
+				return err
+			}
+		}
+		return s.dataModel.UpdateVolumeState(name, resources.VolumeStateAvailable)
+	case resources.VolumeStateDeleting:
+		return s.resumeRemoveVolume(existingVolume)
+	case resources.VolumeStateAttaching, resources.VolumeStateDetaching:
+		isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
+		if err != nil {
+			return err
+		}
+		if existingVolume.Volume.State == resources.VolumeStateDetaching || !isFilesetLinked {
+			return s.dataModel.UpdateVolumeMountpoint(name, "")
+		}
+		volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
+		if err != nil {
+			return err
+		}
+		return s.dataModel.UpdateVolumeMountpoint(name, volumeMountpoint)
+	}
+	return nil
+}
+
+// volumeStorageExists check if the fileset of the volume (or the directory of a lightweight volume) exists
+func (s *spectrumLocalClient) volumeStorageExists(existingVolume SpectrumScaleVolume) (bool, error) {
+	filesets, err := s.listFilesets(existingVolume.FileSystem)
+	if err != nil {
+		return false, err
+	}
+	if !filesets[existingVolume.Fileset] {
+		return false, nil
+	}
+	if existingVolume.Type != Lightweight {
+		return true, nil
+	}
+	volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
+	if err != nil {
+		return false, err
+	}
+	if _, err = s.executor.Stat(volumeMountpoint); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// resumeRemoveVolume repeat the steps of RemoveVolume, skipping the ones that were already done
+func (s *spectrumLocalClient) resumeRemoveVolume(existingVolume SpectrumScaleVolume) error {
+	exists, err := s.volumeStorageExists(existingVolume)
+	if err != nil {
+		return err
+	}
+	if exists && existingVolume.Type != Lightweight {
+		isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
+		if err != nil {
+			return err
+		}
+		if isFilesetLinked {
+			if err = s.connector.UnlinkFileset(existingVolume.FileSystem, existingVolume.Fileset); err != nil {
+				return err
+			}
+		}
+	}
+	if exists && s.config.ForceDelete == true && existingVolume.IsPreexisting == false {
+		if existingVolume.Type == Lightweight {
+			mountpoint, err := s.connector.GetFilesystemMountpoint(existingVolume.FileSystem)
+			if err != nil {
+				return err
+			}
+			err = s.executor.RemoveAll(path.Join(mountpoint, existingVolume.Fileset, existingVolume.Directory))
+			if err != nil {
+				return err
+			}
+		} else if err = s.connector.DeleteFileset(existingVolume.FileSystem, existingVolume.Fileset); err != nil {
+			return err
+		}
+	}
+	return s.dataModel.DeleteVolume(existingVolume.Volume.Name)
+}
+
+// rollbackInsertVolume delete the volume inserted before its storage failed to be created, the failure is only logged
+func (s *spectrumLocalClient) rollbackInsertVolume(name string) {
+	if err := s.dataModel.DeleteVolume(name); err != nil {
+		s.logger.Error("Error deleting volume after failed create", logs.Args{{"volume", name}, {"error", err}})
+	}
+}
+
+// restoreVolumeState move the volume back to the given state after a failed operation, the failure is only logged
+func (s *spectrumLocalClient) restoreVolumeState(name string, state string) {
+	if state == "" {
+		state = resources.VolumeStateAvailable
+	}
+	if err := s.dataModel.UpdateVolumeState(name, state); err != nil {
+		s.logger.Error("Error restoring state of volume", logs.Args{{"volume", name}, {"state", state}, {"error", err}})
+	}
+}
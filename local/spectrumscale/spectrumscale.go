@@ -17,8 +17,6 @@
 package spectrumscale
 
 import (
-	"log"
-
 	"github.com/midoblgsm/ubiquity/utils"
 
 	"os"
@@ -27,15 +25,19 @@ import (
 	"fmt"
 
 	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/local/placement"
 	"github.com/midoblgsm/ubiquity/local/spectrumscale/connectors"
+	"github.com/midoblgsm/ubiquity/model"
 
 	"sync"
 
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type spectrumLocalClient struct {
-	logger         *log.Logger
+	logger         logs.Logger
+	backend        string // name of the backend instance
 	connector      connectors.SpectrumScaleConnector
 	dataModel      SpectrumDataModel
 	executor       utils.Executor
@@ -43,6 +45,7 @@ type spectrumLocalClient struct {
 	isMounted      bool
 	config         resources.SpectrumScaleConfig
 	activationLock *sync.RWMutex
+	placer         placement.Placer // nil if no placement policy is configured
 }
 
 const (
@@ -61,43 +64,111 @@ const (
 	Cluster string = "clusterId"
 )
 
-func NewSpectrumLocalClient(logger *log.Logger, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+func init() {
+	resources.RegisterBackend(resources.BackendPlugin{
+		Type: resources.SpectrumScale,
+		DecodeConfig: func(backend resources.BackendConfig) (interface{}, bool, error) {
+			spectrumConfig := backend.SpectrumScaleConfig
+			return spectrumConfig, spectrumConfig.DefaultFilesystemName != "" || spectrumConfig.RestConfig.Endpoint != "" || spectrumConfig.SshConfig.Host != "", nil
+		},
+		NewClient: func(backend string, settings interface{}, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+			config.SpectrumScaleConfig = settings.(resources.SpectrumScaleConfig)
+			return NewSpectrumLocalClient(backend, config, database)
+		},
+		ValidateConfig: func(settings interface{}, config resources.UbiquityServerConfig) error {
+			config.SpectrumScaleConfig = settings.(resources.SpectrumScaleConfig)
+			return ValidateConfig(config)
+		},
+	})
+}
+
+// NewSpectrumLocalClient creates the client of the Spectrum Scale backend instance named backend, of the cluster of
+// config.SpectrumScaleConfig
+func NewSpectrumLocalClient(backend string, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+	if err := validateSpectrumConfig(config); err != nil {
+		return nil, err
+	}
+	return newSpectrumLocalClient(config.SpectrumScaleConfig, database, backend)
+}
+
+func validateSpectrumConfig(config resources.UbiquityServerConfig) error {
 	if config.ConfigPath == "" {
-		return nil, fmt.Errorf("spectrumLocalClient: init: missing required parameter 'spectrumConfigPath'")
+		return fmt.Errorf("spectrumLocalClient: init: missing required parameter 'spectrumConfigPath'")
 	}
 	if config.SpectrumScaleConfig.DefaultFilesystemName == "" {
-		return nil, fmt.Errorf("spectrumLocalClient: init: missing required parameter 'spectrumDefaultFileSystem'")
+		return fmt.Errorf("spectrumLocalClient: init: missing required parameter 'spectrumDefaultFileSystem'")
+	}
+	if err := placement.ValidatePolicy(config.SpectrumScaleConfig.PlacementPolicy); err != nil {
+		return fmt.Errorf("spectrumLocalClient: init: %s", err.Error())
+	}
+	return nil
+}
+
+// newPlacer returns the placer of the placement policy of config, nil if there is none
+func newPlacer(config resources.SpectrumScaleConfig) (placement.Placer, error) {
+	if config.PlacementPolicy == "" {
+		return nil, nil
+	}
+	return placement.NewPlacer(config.PlacementPolicy)
+}
+
+// ValidateConfig checks config like NewSpectrumLocalClient, then that the cluster answers through the selected connector
+// and that the default filesystem exists, without touching the database
+func ValidateConfig(config resources.UbiquityServerConfig) error {
+	if err := validateSpectrumConfig(config); err != nil {
+		return err
+	}
+	connector, err := connectors.GetSpectrumScaleConnector(config.SpectrumScaleConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get the Spectrum Scale %s connector: %s", connectors.GetSpectrumScaleConnectorType(config.SpectrumScaleConfig), err.Error())
+	}
+	return ValidateConfigWithConnector(config.SpectrumScaleConfig, connector)
+}
+
+func ValidateConfigWithConnector(config resources.SpectrumScaleConfig, connector connectors.SpectrumScaleConnector) error {
+	connectorType := connectors.GetSpectrumScaleConnectorType(config)
+	if _, err := connector.GetClusterId(); err != nil {
+		return fmt.Errorf("the Spectrum Scale cluster is not reachable with the %s connector: %s", connectorType, err.Error())
 	}
-	return newSpectrumLocalClient(logger, config.SpectrumScaleConfig, database, resources.SpectrumScale)
+	if _, err := connector.GetFilesystemMountpoint(config.DefaultFilesystemName); err != nil {
+		return fmt.Errorf("the default filesystem [%s] is not found with the %s connector: %s", config.DefaultFilesystemName, connectorType, err.Error())
+	}
+	return nil
 }
 
-func NewSpectrumLocalClientWithConnectors(logger *log.Logger, connector connectors.SpectrumScaleConnector, spectrumExecutor utils.Executor, config resources.SpectrumScaleConfig, datamodel SpectrumDataModel) (resources.StorageClient, error) {
+func NewSpectrumLocalClientWithConnectors(connector connectors.SpectrumScaleConnector, spectrumExecutor utils.Executor, config resources.SpectrumScaleConfig, datamodel SpectrumDataModel) (resources.StorageClient, error) {
 	err := datamodel.CreateVolumeTable()
 	if err != nil {
 		return &spectrumLocalClient{}, err
 	}
-	return &spectrumLocalClient{logger: logger, connector: connector, dataModel: datamodel, executor: spectrumExecutor, config: config, activationLock: &sync.RWMutex{}}, nil
+	placer, err := newPlacer(config)
+	if err != nil {
+		return &spectrumLocalClient{}, err
+	}
+	return &spectrumLocalClient{logger: logs.GetComponentLogger(logs.ComponentSpectrum), backend: resources.SpectrumScale, connector: connector, dataModel: datamodel, executor: spectrumExecutor, config: config, activationLock: &sync.RWMutex{}, placer: placer}, nil
 }
 
-func newSpectrumLocalClient(logger *log.Logger, config resources.SpectrumScaleConfig, database *gorm.DB, backend string) (*spectrumLocalClient, error) {
-	logger.Println("spectrumLocalClient: init start")
-	defer logger.Println("spectrumLocalClient: init end")
-	client, err := connectors.GetSpectrumScaleConnector(logger, config)
+func newSpectrumLocalClient(config resources.SpectrumScaleConfig, database *gorm.DB, backend string) (*spectrumLocalClient, error) {
+	logger := logs.GetComponentLogger(logs.ComponentSpectrum)
+	defer logger.Trace(logs.DEBUG)()
+	client, err := connectors.GetSpectrumScaleConnector(config)
 	if err != nil {
-		logger.Fatalln(err.Error())
-		return &spectrumLocalClient{}, err
+		return &spectrumLocalClient{}, logger.ErrorRet(err, "failed to get the Spectrum Scale connector")
 	}
-	datamodel := NewSpectrumDataModel(logger, database, backend)
+	datamodel := NewSpectrumDataModel(database, backend)
 	err = datamodel.CreateVolumeTable()
 	if err != nil {
 		return &spectrumLocalClient{}, err
 	}
-	return &spectrumLocalClient{logger: logger, connector: client, dataModel: datamodel, config: config, executor: utils.NewExecutor(), activationLock: &sync.RWMutex{}}, nil
+	placer, err := newPlacer(config)
+	if err != nil {
+		return &spectrumLocalClient{}, err
+	}
+	return &spectrumLocalClient{logger: logger, backend: backend, connector: client, dataModel: datamodel, config: config, executor: utils.NewExecutor(), activationLock: &sync.RWMutex{}, placer: placer}, nil
 }
 
 func (s *spectrumLocalClient) Activate(activateRequest resources.ActivateRequest) resources.ActivateResponse {
-	s.logger.Println("spectrumLocalClient: Activate start")
-	defer s.logger.Println("spectrumLocalClient: Activate end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	s.activationLock.RLock()
 	if s.isActivated {
@@ -113,7 +184,7 @@ func (s *spectrumLocalClient) Activate(activateRequest resources.ActivateRequest
 	mounted, err := s.connector.IsFilesystemMounted(s.config.DefaultFilesystemName)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.ActivateResponse{Error: err}
 	}
 
@@ -121,7 +192,7 @@ func (s *spectrumLocalClient) Activate(activateRequest resources.ActivateRequest
 		err = s.connector.MountFileSystem(s.config.DefaultFilesystemName)
 
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return resources.ActivateResponse{Error: err}
 		}
 	}
@@ -129,13 +200,13 @@ func (s *spectrumLocalClient) Activate(activateRequest resources.ActivateRequest
 	clusterId, err := s.connector.GetClusterId()
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.ActivateResponse{Error: err}
 	}
 
 	if len(clusterId) == 0 {
 		clusterIdErr := fmt.Errorf("Unable to retrieve clusterId: clusterId is empty")
-		s.logger.Println(clusterIdErr.Error())
+		s.logger.Error("failed", logs.Args{{"error", clusterIdErr}})
 		return resources.ActivateResponse{Error: clusterIdErr}
 	}
 
@@ -146,13 +217,12 @@ func (s *spectrumLocalClient) Activate(activateRequest resources.ActivateRequest
 }
 
 func (s *spectrumLocalClient) CreateVolume(createVolumeRequest resources.CreateVolumeRequest) resources.CreateVolumeResponse {
-	s.logger.Println("spectrumLocalClient: create start")
-	defer s.logger.Println("spectrumLocalClient: create end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	_, volExists, err := s.dataModel.GetVolume(createVolumeRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.CreateVolumeResponse{Error: err}
 	}
 
@@ -160,28 +230,32 @@ func (s *spectrumLocalClient) CreateVolume(createVolumeRequest resources.CreateV
 		return resources.CreateVolumeResponse{Error: fmt.Errorf("Volume already exists")}
 	}
 
-	s.logger.Printf("Opts for create: %#v\n", createVolumeRequest.Metadata)
+	s.logger.Debug("Opts for create", logs.Args{{"metadata", createVolumeRequest.Metadata}})
 	metadata := resources.VolumeMetadata{Values: createVolumeRequest.Metadata}
 	volume := resources.Volume{Name: createVolumeRequest.Name, Backend: createVolumeRequest.Backend, Metadata: metadata, CapacityBytes: createVolumeRequest.CapacityBytes}
 
 	if len(createVolumeRequest.Metadata) == 0 {
 		//fileset
-		return resources.CreateVolumeResponse{Volume: volume, Error: s.createFilesetVolume(s.config.DefaultFilesystemName, createVolumeRequest.Name, createVolumeRequest.Metadata)}
+		filesystem, err := s.placeVolume(createVolumeRequest, s.config.DefaultFilesystemName, false)
+		if err != nil {
+			return resources.CreateVolumeResponse{Error: err}
+		}
+		return resources.CreateVolumeResponse{Volume: volume, Error: s.createFilesetVolume(filesystem, createVolumeRequest.Name, createVolumeRequest.Metadata)}
 	}
-	s.logger.Printf("Trying to determine type for request\n")
+	s.logger.Debug("Trying to determine type for request")
 	userSpecifiedType, err := determineTypeFromRequest(s.logger, createVolumeRequest.Metadata)
 	if err != nil {
-		s.logger.Printf("Error determining type: %s\n", err.Error())
+		s.logger.Error("Error determining type", logs.Args{{"error", err}})
 		return resources.CreateVolumeResponse{Error: err}
 	}
-	s.logger.Printf("Volume type requested: %s", userSpecifiedType)
+	s.logger.Debug("Volume type requested", logs.Args{{"userSpecifiedType", userSpecifiedType}})
 	isExistingVolume, filesystem, existingFileset, existingLightWeightDir, err := s.validateAndParseParams(s.logger, createVolumeRequest.Metadata)
 	if err != nil {
-		s.logger.Printf("Error in validate params: %s\n", err.Error())
+		s.logger.Error("Error in validate params", logs.Args{{"error", err}})
 		return resources.CreateVolumeResponse{Error: err}
 	}
 
-	s.logger.Printf("Params for create: %s,%s,%s,%s\n", isExistingVolume, filesystem, existingFileset, existingLightWeightDir)
+	s.logger.Debug("Params for create", logs.Args{{"isExistingVolume", isExistingVolume}, {"filesystem", filesystem}, {"existingFileset", existingFileset}, {"existingLightWeightDir", existingLightWeightDir}})
 
 	if isExistingVolume && userSpecifiedType == TypeFileset {
 		quota, quotaSpecified := createVolumeRequest.Metadata[Quota]
@@ -196,6 +270,11 @@ func (s *spectrumLocalClient) CreateVolume(createVolumeRequest resources.CreateV
 	}
 
 	if userSpecifiedType == TypeFileset {
+		_, filesystemSpecified := createVolumeRequest.Metadata[Filesystem]
+		filesystem, err = s.placeVolume(createVolumeRequest, filesystem, filesystemSpecified)
+		if err != nil {
+			return resources.CreateVolumeResponse{Error: err}
+		}
 		quota, quotaSpecified := createVolumeRequest.Metadata[Quota]
 		if quotaSpecified {
 			return resources.CreateVolumeResponse{Volume: volume, Error: s.createFilesetQuotaVolume(filesystem, createVolumeRequest.Name, quota, createVolumeRequest.Metadata)}
@@ -208,14 +287,60 @@ func (s *spectrumLocalClient) CreateVolume(createVolumeRequest resources.CreateV
 	return resources.CreateVolumeResponse{Error: fmt.Errorf("Internal error")}
 }
 
+// placeVolume returns the filesystem of a new fileset volume: the one the placement policy chooses among the
+// filesystems with enough free space for its quota, or filesystem if it is pinned by the request and has enough free
+// space. The filesystems whose capacity cannot be queried are skipped, if none is left filesystem is used unchecked.
+func (s *spectrumLocalClient) placeVolume(createVolumeRequest resources.CreateVolumeRequest, filesystem string, pinned bool) (string, error) {
+	if s.placer == nil {
+		return filesystem, nil
+	}
+	sizeBytes := createVolumeRequest.CapacityBytes
+	if quota, quotaSpecified := createVolumeRequest.Metadata[Quota]; quotaSpecified {
+		// a quota in a unit only Spectrum Scale knows is left for it to check
+		if quotaBytes, err := utils.ConvertToBytes(s.logger, quota); err == nil {
+			sizeBytes = quotaBytes
+		} else {
+			s.logger.Debug("The quota is not used to place the volume", logs.Args{{"quota", quota}, {"error", err}})
+		}
+	}
+
+	filesystems := []string{filesystem}
+	if !pinned {
+		listed, err := s.connector.ListFilesystems()
+		if err != nil {
+			s.logger.Info("connector.ListFilesystems failed, the volume is not placed", logs.Args{{"error", err}})
+			return filesystem, nil
+		}
+		filesystems = listed
+	}
+	var pools []placement.Pool
+	for _, name := range filesystems {
+		capacity, err := s.connector.GetFilesystemCapacity(name)
+		if err != nil {
+			s.logger.Info("connector.GetFilesystemCapacity failed, the filesystem is skipped", logs.Args{{"filesystem", name}, {"error", err}})
+			continue
+		}
+		pools = append(pools, placement.Pool{Name: name, TotalBytes: capacity.TotalBytes, FreeBytes: capacity.FreeBytes})
+	}
+	if len(pools) == 0 {
+		s.logger.Info("The capacity of the filesystems is unknown, the volume is not placed", logs.Args{{"volume", createVolumeRequest.Name}, {"filesystem", filesystem}})
+		return filesystem, nil
+	}
+	pool, err := s.placer.Place(pools, sizeBytes)
+	if err != nil {
+		return "", s.logger.ErrorRet(err, "placer.Place failed", logs.Args{{"volume", createVolumeRequest.Name}})
+	}
+	s.logger.Debug("Placed volume", logs.Args{{"volume", createVolumeRequest.Name}, {"filesystem", pool.Name}, {"free_bytes", pool.FreeBytes}})
+	return pool.Name, nil
+}
+
 func (s *spectrumLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveVolumeRequest) resources.RemoveVolumeResponse {
-	s.logger.Println("spectrumLocalClient: remove start")
-	defer s.logger.Println("spectrumLocalClient: remove end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(removeVolumeRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
@@ -223,17 +348,21 @@ func (s *spectrumLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveV
 		return resources.RemoveVolumeResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
-	if existingVolume.Type == Lightweight {
-		err = s.dataModel.DeleteVolume(removeVolumeRequest.Name)
-		if err != nil {
-			s.logger.Println(err.Error())
-			return resources.RemoveVolumeResponse{Error: err}
-		}
+	err = s.dataModel.UpdateVolumeState(removeVolumeRequest.Name, resources.VolumeStateDeleting)
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.RemoveVolumeResponse{Error: err}
+	}
+
+	// the volume stays in the deleting state until its storage is deleted, so that an interrupted remove is resumed by the recovery
+	deleteStorage := s.config.ForceDelete == true && existingVolume.IsPreexisting == false
 
-		if s.config.ForceDelete == true && existingVolume.IsPreexisting == false {
+	if existingVolume.Type == Lightweight {
+		if deleteStorage {
 			mountpoint, err := s.connector.GetFilesystemMountpoint(existingVolume.FileSystem)
 			if err != nil {
-				s.logger.Println(err.Error())
+				s.logger.Error("failed", logs.Args{{"error", err}})
+				s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
 				return resources.RemoveVolumeResponse{Error: err}
 			}
 			lightweightVolumePath := path.Join(mountpoint, existingVolume.Fileset, existingVolume.Directory)
@@ -241,17 +370,28 @@ func (s *spectrumLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveV
 			err = s.executor.RemoveAll(lightweightVolumePath)
 
 			if err != nil {
-				s.logger.Println(err.Error())
+				s.logger.Error("failed", logs.Args{{"error", err}})
+				s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
 				return resources.RemoveVolumeResponse{Error: err}
 			}
 		}
+
+		err = s.dataModel.DeleteVolume(removeVolumeRequest.Name)
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			if !deleteStorage {
+				s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
+			}
+			return resources.RemoveVolumeResponse{Error: err}
+		}
 		return resources.RemoveVolumeResponse{}
 	}
 
 	isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
 		return resources.RemoveVolumeResponse{Error: err}
 	}
 
@@ -259,31 +399,44 @@ func (s *spectrumLocalClient) RemoveVolume(removeVolumeRequest resources.RemoveV
 		err := s.connector.UnlinkFileset(existingVolume.FileSystem, existingVolume.Fileset)
 
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			s.restoreVolumeState(removeVolumeRequest.Name, existingVolume.Volume.State)
 			return resources.RemoveVolumeResponse{Error: err}
 		}
 	}
-	err = s.dataModel.DeleteVolume(removeVolumeRequest.Name)
 
-	if err != nil {
-		s.logger.Println(err.Error())
-		return resources.RemoveVolumeResponse{Error: err}
+	// the fileset is unlinked now, so the volume is no longer attached
+	restoredState := resources.VolumeStateAvailable
+	if existingVolume.Volume.State == resources.VolumeStateDeleted {
+		restoredState = resources.VolumeStateDeleted
 	}
-	if s.config.ForceDelete == true && existingVolume.IsPreexisting == false {
+
+	if deleteStorage {
 		err = s.connector.DeleteFileset(existingVolume.FileSystem, existingVolume.Fileset)
 
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			s.restoreVolumeState(removeVolumeRequest.Name, restoredState)
 			return resources.RemoveVolumeResponse{Error: err}
 		}
 	}
 
+	err = s.dataModel.DeleteVolume(removeVolumeRequest.Name)
+
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		// once the fileset is deleted the volume is left in the deleting state, the recovery deletes it
+		if !deleteStorage {
+			s.restoreVolumeState(removeVolumeRequest.Name, restoredState)
+		}
+		return resources.RemoveVolumeResponse{Error: err}
+	}
+
 	return resources.RemoveVolumeResponse{}
 }
 
 func (s *spectrumLocalClient) GetVolume(getVolumeRequest resources.GetVolumeRequest) resources.GetVolumeResponse {
-	s.logger.Println("spectrumLocalClient: GetVolume start")
-	defer s.logger.Println("spectrumLocalClient: GetVolume finish")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(getVolumeRequest.Name)
 	if err != nil {
@@ -293,17 +446,16 @@ func (s *spectrumLocalClient) GetVolume(getVolumeRequest resources.GetVolumeRequ
 		return resources.GetVolumeResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
-	return resources.GetVolumeResponse{Volume: resources.Volume{Name: existingVolume.Volume.Name, Backend: existingVolume.Volume.Backend, Mountpoint: existingVolume.Volume.Mountpoint}}
+	return resources.GetVolumeResponse{Volume: resources.Volume{Name: existingVolume.Volume.Name, Backend: existingVolume.Volume.Backend, Mountpoint: existingVolume.Volume.Mountpoint, State: existingVolume.Volume.State}}
 }
 
 func (s *spectrumLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.GetVolumeConfigRequest) resources.GetVolumeConfigResponse {
-	s.logger.Println("spectrumLocalClient: GetVolumeConfig start")
-	defer s.logger.Println("spectrumLocalClient: GetVolumeConfig finish")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(getVolumeConfigRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.GetVolumeConfigResponse{Error: err}
 	}
 
@@ -311,13 +463,13 @@ func (s *spectrumLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.G
 		volumeConfigDetails := make(map[string]interface{})
 		volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return resources.GetVolumeConfigResponse{Error: err}
 		}
 
 		isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return resources.GetVolumeConfigResponse{Error: err}
 		}
 		if isFilesetLinked {
@@ -345,13 +497,12 @@ func (s *spectrumLocalClient) GetVolumeConfig(getVolumeConfigRequest resources.G
 }
 
 func (s *spectrumLocalClient) Attach(attachRequest resources.AttachRequest) resources.AttachResponse {
-	s.logger.Println("spectrumLocalClient: attach start")
-	defer s.logger.Println("spectrumLocalClient: attach end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(attachRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: err}
 	}
 
@@ -359,25 +510,47 @@ func (s *spectrumLocalClient) Attach(attachRequest resources.AttachRequest) reso
 		return resources.AttachResponse{Error: fmt.Errorf("Volume not found")}
 	}
 
+	attachments, err := s.dataModel.GetVolumeAttachments(attachRequest.Name)
+
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AttachResponse{Error: err}
+	}
+
+	err = model.CheckVolumeAttach(existingVolume.Volume, attachments, attachRequest.Host, attachRequest.ReadOnly)
+
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.AttachResponse{Error: err}
+	}
+
 	volumeMountpoint, err := s.getVolumeMountPoint(existingVolume)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: err}
 	}
 
 	isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: err}
 	}
 
 	if isFilesetLinked == false {
+		err = s.dataModel.UpdateVolumeState(attachRequest.Name, resources.VolumeStateAttaching)
+
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return resources.AttachResponse{Error: err}
+		}
+
 		err = s.connector.LinkFileset(existingVolume.FileSystem, existingVolume.Fileset)
 
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			s.restoreVolumeState(attachRequest.Name, existingVolume.Volume.State)
 			return resources.AttachResponse{Error: err}
 		}
 	}
@@ -387,21 +560,29 @@ func (s *spectrumLocalClient) Attach(attachRequest resources.AttachRequest) reso
 	err = s.dataModel.UpdateVolumeMountpoint(attachRequest.Name, volumeMountpoint)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: err}
 	}
 
+	if model.FindVolumeAttachment(attachments, attachRequest.Host) == nil {
+		err = s.dataModel.AddVolumeAttachment(attachRequest.Name, attachRequest.Host, attachRequest.ReadOnly)
+
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return resources.AttachResponse{Error: err}
+		}
+	}
+
 	return resources.AttachResponse{Mountpoint: volumeMountpoint}
 }
 
 func (s *spectrumLocalClient) Detach(detachRequest resources.DetachRequest) resources.DetachResponse {
-	s.logger.Println("spectrumLocalClient: detach start")
-	defer s.logger.Println("spectrumLocalClient: detach end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	existingVolume, volExists, err := s.dataModel.GetVolume(detachRequest.Name)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: err}
 	}
 
@@ -416,16 +597,42 @@ func (s *spectrumLocalClient) Detach(detachRequest resources.DetachRequest) reso
 	isFilesetLinked, err := s.connector.IsFilesetLinked(existingVolume.FileSystem, existingVolume.Fileset)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: err}
 	}
 	if isFilesetLinked == false {
 		return resources.DetachResponse{Error: fmt.Errorf("volume not attached")}
 	}
 
+	// volumes attached before the attachments were recorded have none, they are detached as a whole
+	attachments, err := s.dataModel.GetVolumeAttachments(detachRequest.Name)
+
+	if err != nil {
+		s.logger.Error("failed", logs.Args{{"error", err}})
+		return resources.DetachResponse{Error: err}
+	}
+
+	if len(attachments) != 0 {
+		if model.FindVolumeAttachment(attachments, detachRequest.Host) == nil {
+			return resources.DetachResponse{Error: fmt.Errorf("volume not attached to host %s", detachRequest.Host)}
+		}
+
+		err = s.dataModel.RemoveVolumeAttachment(detachRequest.Name, detachRequest.Host)
+
+		if err != nil {
+			s.logger.Error("failed", logs.Args{{"error", err}})
+			return resources.DetachResponse{Error: err}
+		}
+
+		if len(attachments) > 1 {
+			// still attached to other hosts, keep the mountpoint
+			return resources.DetachResponse{}
+		}
+	}
+
 	err = s.dataModel.UpdateVolumeMountpoint(detachRequest.Name, "")
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: err}
 	}
 
@@ -433,14 +640,13 @@ func (s *spectrumLocalClient) Detach(detachRequest resources.DetachRequest) reso
 }
 
 func (s *spectrumLocalClient) ListVolumes(listVolumesRequest resources.ListVolumesRequest) resources.ListVolumesResponse {
-	s.logger.Println("spectrumLocalClient: list start")
-	defer s.logger.Println("spectrumLocalClient: list end")
+	defer s.logger.Trace(logs.DEBUG)()
 	var err error
 
 	volumesInDb, err := s.dataModel.ListVolumes()
 
 	if err != nil {
-		s.logger.Printf("error retrieving volumes from db %#v\n", err)
+		s.logger.Error("error retrieving volumes from db", logs.Args{{"error", err}})
 		return resources.ListVolumesResponse{Error: err}
 	}
 
@@ -448,38 +654,51 @@ func (s *spectrumLocalClient) ListVolumes(listVolumesRequest resources.ListVolum
 }
 
 func (s *spectrumLocalClient) createFilesetVolume(filesystem, name string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient: createFilesetVolume start")
-	defer s.logger.Println("spectrumLocalClient: createFilesetVolume end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	filesetName := generateFilesetName(name)
 
-	err := s.connector.CreateFileset(filesystem, filesetName, opts)
+	err := s.dataModel.InsertFilesetVolume(filesetName, name, filesystem, false, opts)
+
+	if err != nil {
+		s.logger.Error("Error inserting fileset", logs.Args{{"error", err}})
+		return err
+	}
+
+	err = s.connector.CreateFileset(filesystem, filesetName, opts)
 
 	if err != nil {
-		s.logger.Printf("Error creating fileset %v", err)
+		s.logger.Error("Error creating fileset", logs.Args{{"error", err}})
+		s.rollbackInsertVolume(name)
 		return err
 	}
 
-	err = s.dataModel.InsertFilesetVolume(filesetName, name, filesystem, false, opts)
+	err = s.dataModel.UpdateVolumeState(name, resources.VolumeStateAvailable)
 
 	if err != nil {
-		s.logger.Printf("Error inserting fileset %v", err)
+		s.logger.Error("Error updating volume state", logs.Args{{"error", err}})
 		return err
 	}
 
-	s.logger.Printf("Created fileset volume with fileset %s\n", filesetName)
+	s.logger.Info("Created fileset volume with fileset", logs.Args{{"filesetName", filesetName}})
 	return nil
 }
 
 func (s *spectrumLocalClient) createFilesetQuotaVolume(filesystem, name, quota string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient: createFilesetQuotaVolume start")
-	defer s.logger.Println("spectrumLocalClient: createFilesetQuotaVolume end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	filesetName := generateFilesetName(name)
 
-	err := s.connector.CreateFileset(filesystem, filesetName, opts)
+	err := s.dataModel.InsertFilesetQuotaVolume(filesetName, quota, name, filesystem, false, opts)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.connector.CreateFileset(filesystem, filesetName, opts)
 
 	if err != nil {
+		s.rollbackInsertVolume(name)
 		return err
 	}
 
@@ -488,29 +707,30 @@ func (s *spectrumLocalClient) createFilesetQuotaVolume(filesystem, name, quota s
 	if err != nil {
 		deleteErr := s.connector.DeleteFileset(filesystem, filesetName)
 		if deleteErr != nil {
+			// the volume stays in the creating state, so the startup recovery retries the quota
 			return fmt.Errorf("Error setting quota (rollback error on delete fileset %s - manual cleanup needed)", filesetName)
 		}
+		s.rollbackInsertVolume(name)
 		return err
 	}
 
-	err = s.dataModel.InsertFilesetQuotaVolume(filesetName, quota, name, filesystem, false, opts)
+	err = s.dataModel.UpdateVolumeState(name, resources.VolumeStateAvailable)
 
 	if err != nil {
 		return err
 	}
 
-	s.logger.Printf("Created fileset volume with fileset %s, quota %s\n", filesetName, quota)
+	s.logger.Info("Created fileset volume", logs.Args{{"fileset", filesetName}, {"quota", quota}})
 	return nil
 }
 
 func (s *spectrumLocalClient) createLightweightVolume(filesystem, name, fileset string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient: createLightweightVolume start")
-	defer s.logger.Println("spectrumLocalClient: createLightweightVolume end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	filesetLinked, err := s.connector.IsFilesetLinked(filesystem, fileset)
 
 	if err != nil {
-		s.logger.Println("error finding fileset in the filesystem %s", err.Error())
+		s.logger.Error("error finding fileset in the filesystem", logs.Args{{"error", err}})
 		return err
 	}
 
@@ -518,7 +738,7 @@ func (s *spectrumLocalClient) createLightweightVolume(filesystem, name, fileset
 		err = s.connector.LinkFileset(filesystem, fileset)
 
 		if err != nil {
-			s.logger.Println(err.Error())
+			s.logger.Error("failed", logs.Args{{"error", err}})
 			return err
 		}
 	}
@@ -527,7 +747,7 @@ func (s *spectrumLocalClient) createLightweightVolume(filesystem, name, fileset
 
 	mountpoint, err := s.connector.GetFilesystemMountpoint(filesystem)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 	//open permissions on enclosing fileset
@@ -535,7 +755,13 @@ func (s *spectrumLocalClient) createLightweightVolume(filesystem, name, fileset
 	_, err = s.executor.Execute("sudo", args)
 
 	if err != nil {
-		s.logger.Printf("Failed update permissions of fileset %s containing LTW volumes with error: %s", fileset, err.Error())
+		s.logger.Error("Failed update permissions of fileset containing LTW volumes", logs.Args{{"fileset", fileset}, {"error", err}})
+		return err
+	}
+
+	err = s.dataModel.InsertLightweightVolume(fileset, lightweightVolumeName, name, filesystem, false, opts)
+
+	if err != nil {
 		return err
 	}
 
@@ -544,18 +770,19 @@ func (s *spectrumLocalClient) createLightweightVolume(filesystem, name, fileset
 	_, err = s.executor.Execute("sudo", args)
 
 	if err != nil {
-		s.logger.Printf("Failed to create directory path %s : %s", lightweightVolumePath, err.Error())
+		s.logger.Error("Failed to create directory path", logs.Args{{"lightweightVolumePath", lightweightVolumePath}, {"error", err}})
+		s.rollbackInsertVolume(name)
 		return err
 	}
-	s.logger.Printf("creating directory for lwv: %s\n", lightweightVolumePath)
+	s.logger.Info("creating directory for lwv", logs.Args{{"lightweightVolumePath", lightweightVolumePath}})
 
-	err = s.dataModel.InsertLightweightVolume(fileset, lightweightVolumeName, name, filesystem, false, opts)
+	err = s.dataModel.UpdateVolumeState(name, resources.VolumeStateAvailable)
 
 	if err != nil {
 		return err
 	}
 
-	s.logger.Printf("Created LightWeight volume at directory path: %s\n", lightweightVolumePath)
+	s.logger.Info("Created LightWeight volume at directory path", logs.Args{{"lightweightVolumePath", lightweightVolumePath}})
 	return nil
 }
 
@@ -572,33 +799,31 @@ func generateFilesetName(name string) string {
 //TODO move updates to DB file
 
 func (s *spectrumLocalClient) updateDBWithExistingFileset(filesystem, name, userSpecifiedFileset string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient:  updateDBWithExistingFileset start")
-	defer s.logger.Println("spectrumLocalClient: updateDBWithExistingFileset end")
-	s.logger.Printf("User specified fileset: %s\n", userSpecifiedFileset)
+	defer s.logger.Trace(logs.DEBUG)()
+	s.logger.Info("User specified fileset", logs.Args{{"userSpecifiedFileset", userSpecifiedFileset}})
 
 	_, err := s.connector.ListFileset(filesystem, userSpecifiedFileset)
 	if err != nil {
-		s.logger.Printf("Fileset does not exist %v", err.Error())
+		s.logger.Error("Fileset does not exist", logs.Args{{"error", err}})
 		return err
 	}
 
 	err = s.dataModel.InsertFilesetVolume(userSpecifiedFileset, name, filesystem, true, opts)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 	return nil
 }
 
 func (s *spectrumLocalClient) checkIfVolumeExistsInDB(name, userSpecifiedFileset string) error {
-	s.logger.Println("spectrumLocalClient:  checkIfVolumeExistsIbDB start")
-	defer s.logger.Println("spectrumLocalClient: checkIfVolumeExistsIbDB end")
+	defer s.logger.Trace(logs.DEBUG)()
 	getVolumeConfigRequest := resources.GetVolumeConfigRequest{Name: name}
 	getVolumeConfigResponse := s.GetVolumeConfig(getVolumeConfigRequest)
 
 	if getVolumeConfigResponse.Error != nil {
-		s.logger.Println(getVolumeConfigResponse.Error.Error())
+		s.logger.Error("failed", logs.Args{{"error", getVolumeConfigResponse.Error}})
 		return getVolumeConfigResponse.Error
 	}
 
@@ -609,37 +834,36 @@ func (s *spectrumLocalClient) checkIfVolumeExistsInDB(name, userSpecifiedFileset
 }
 
 func (s *spectrumLocalClient) updateDBWithExistingFilesetQuota(filesystem, name, userSpecifiedFileset, quota string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient:  updateDBWithExistingFilesetQuota start")
-	defer s.logger.Println("spectrumLocalClient: updateDBWithExistingFilesetQuota end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	filesetQuota, err := s.connector.ListFilesetQuota(filesystem, userSpecifiedFileset)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 
 	if s.config.RestConfig.Endpoint != "" {
-		s.logger.Printf("For REST connector converting quotas to bytes\n")
+		s.logger.Debug("For REST connector converting quotas to bytes")
 		filesetQuotaBytes, err := utils.ConvertToBytes(s.logger, filesetQuota)
 		if err != nil {
-			s.logger.Printf("utils.ConvertToBytes failed %v", err)
+			s.logger.Error("utils.ConvertToBytes failed", logs.Args{{"error", err}})
 			return err
 		}
 
 		quotasBytes, err := utils.ConvertToBytes(s.logger, quota)
 		if err != nil {
-			s.logger.Printf("utils.ConvertToBytes failed %v", err)
+			s.logger.Error("utils.ConvertToBytes failed", logs.Args{{"error", err}})
 			return err
 		}
 
 		if filesetQuotaBytes != quotasBytes {
-			s.logger.Printf("Mismatch between user-specified %v and listed quota %v for fileset %s", quotasBytes, filesetQuotaBytes, userSpecifiedFileset)
+			s.logger.Info("Mismatch between user-specified and listed quota for fileset", logs.Args{{"quota", quotasBytes}, {"filesetQuota", filesetQuotaBytes}, {"fileset", userSpecifiedFileset}})
 			return fmt.Errorf("Mismatch between user-specified %v and listed quota %v for fileset %s", quotasBytes, filesetQuotaBytes, userSpecifiedFileset)
 		}
 	} else {
 		if filesetQuota != quota {
-			s.logger.Printf("Mismatch between user-specified and listed quota for fileset %s", userSpecifiedFileset)
+			s.logger.Info("Mismatch between user-specified and listed quota for fileset", logs.Args{{"userSpecifiedFileset", userSpecifiedFileset}})
 			return fmt.Errorf("Mismatch between user-specified and listed quota for fileset %s", userSpecifiedFileset)
 
 		}
@@ -648,16 +872,15 @@ func (s *spectrumLocalClient) updateDBWithExistingFilesetQuota(filesystem, name,
 	err = s.dataModel.InsertFilesetQuotaVolume(userSpecifiedFileset, quota, name, filesystem, true, opts)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 	return nil
 }
 
 func (s *spectrumLocalClient) updateDBWithExistingDirectory(filesystem, name, userSpecifiedFileset, userSpecifiedDirectory string, opts map[string]string) error {
-	s.logger.Println("spectrumLocalClient:  updateDBWithExistingDirectory start")
-	defer s.logger.Println("spectrumLocalClient: updateDBWithExistingDirectory end")
-	s.logger.Printf("User specified fileset: %s, User specified directory: %s\n", userSpecifiedFileset, userSpecifiedDirectory)
+	defer s.logger.Trace(logs.DEBUG)()
+	s.logger.Debug("User specified fileset and directory", logs.Args{{"fileset", userSpecifiedFileset}, {"directory", userSpecifiedDirectory}})
 
 	linked, err := s.connector.IsFilesetLinked(filesystem, userSpecifiedFileset)
 	if err != nil {
@@ -672,7 +895,7 @@ func (s *spectrumLocalClient) updateDBWithExistingDirectory(filesystem, name, us
 
 	mountpoint, err := s.connector.GetFilesystemMountpoint(filesystem)
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 
@@ -682,26 +905,25 @@ func (s *spectrumLocalClient) updateDBWithExistingDirectory(filesystem, name, us
 
 	if err != nil {
 		if os.IsNotExist(err) {
-			s.logger.Printf("directory path %s doesn't exist", directoryPath)
+			s.logger.Info("directory path doesn't exist", logs.Args{{"directoryPath", directoryPath}})
 			return err
 		}
 
-		s.logger.Printf("Error stating directoryPath %s: %s", directoryPath, err.Error())
+		s.logger.Error("Error stating directoryPath", logs.Args{{"directoryPath", directoryPath}, {"error", err}})
 		return err
 	}
 
 	err = s.dataModel.InsertLightweightVolume(userSpecifiedFileset, userSpecifiedDirectory, name, filesystem, true, opts)
 
 	if err != nil {
-		s.logger.Println(err.Error())
+		s.logger.Error("failed", logs.Args{{"error", err}})
 		return err
 	}
 	return nil
 }
 
-func determineTypeFromRequest(logger *log.Logger, opts map[string]string) (string, error) {
-	logger.Print("determineTypeFromRequest start\n")
-	defer logger.Printf("determineTypeFromRequest end\n")
+func determineTypeFromRequest(logger logs.Logger, opts map[string]string) (string, error) {
+	defer logger.Trace(logs.DEBUG)()
 	userSpecifiedType, exists := opts[Type]
 	if exists == false {
 		_, exists := opts[Directory]
@@ -718,9 +940,8 @@ func determineTypeFromRequest(logger *log.Logger, opts map[string]string) (strin
 	return userSpecifiedType, nil
 }
 
-func (s *spectrumLocalClient) validateAndParseParams(logger *log.Logger, opts map[string]string) (bool, string, string, string, error) {
-	logger.Println("validateAndParseParams start")
-	defer logger.Println("validateAndParseParams end")
+func (s *spectrumLocalClient) validateAndParseParams(logger logs.Logger, opts map[string]string) (bool, string, string, string, error) {
+	defer logger.Trace(logs.DEBUG)()
 	existingFileset, existingFilesetSpecified := opts[TypeFileset]
 	existingLightWeightDir, existingLightWeightDirSpecified := opts[Directory]
 	filesystem, filesystemSpecified := opts[Filesystem]
@@ -729,7 +950,7 @@ func (s *spectrumLocalClient) validateAndParseParams(logger *log.Logger, opts ma
 
 	userSpecifiedType, err := determineTypeFromRequest(logger, opts)
 	if err != nil {
-		logger.Printf("%s", err.Error())
+		logger.Error("failed", logs.Args{{"error", err}})
 		return false, "", "", "", err
 	}
 
@@ -744,23 +965,23 @@ func (s *spectrumLocalClient) validateAndParseParams(logger *log.Logger, opts ma
 
 	if (userSpecifiedType == TypeFileset && existingFilesetSpecified) || (userSpecifiedType == TypeLightweight && existingLightWeightDirSpecified) {
 		if filesystemSpecified == false {
-			logger.Println("'filesystem' is a required opt for using existing volumes")
+			logger.Info("'filesystem' is a required opt for using existing volumes")
 			return true, filesystem, existingFileset, existingLightWeightDir, fmt.Errorf("'filesystem' is a required opt for using existing volumes")
 		}
 		if existingLightWeightDirSpecified && !existingFilesetSpecified {
-			logger.Println("'fileset' is a required opt for using existing lightweight volumes")
+			logger.Info("'fileset' is a required opt for using existing lightweight volumes")
 			return true, filesystem, existingFileset, existingLightWeightDir, fmt.Errorf("'fileset' is a required opt for using existing lightweight volumes")
 		}
 		if userSpecifiedType == TypeLightweight && existingLightWeightDir != "" {
 			_, quotaSpecified := opts[Quota]
 			if quotaSpecified {
-				logger.Println("'quota' is not supported for lightweight volumes")
+				logger.Info("'quota' is not supported for lightweight volumes")
 				return true, "", "", "", fmt.Errorf("'quota' is not supported for lightweight volumes")
 			}
-			logger.Println("Valid: existing LTWT")
+			logger.Debug("Valid: existing LTWT")
 			return true, filesystem, existingFileset, existingLightWeightDir, nil
 		} else {
-			logger.Println("Valid: existing FILESET")
+			logger.Debug("Valid: existing FILESET")
 			return true, filesystem, existingFileset, "", nil
 		}
 
@@ -770,7 +991,7 @@ func (s *spectrumLocalClient) validateAndParseParams(logger *log.Logger, opts ma
 
 			_, quotaSpecified := opts[Quota]
 			if quotaSpecified {
-				logger.Println("'quota' is not supported for lightweight volumes")
+				logger.Info("'quota' is not supported for lightweight volumes")
 				return false, "", "", "", fmt.Errorf("'quota' is not supported for lightweight volumes")
 			}
 
@@ -787,8 +1008,7 @@ func (s *spectrumLocalClient) validateAndParseParams(logger *log.Logger, opts ma
 }
 
 func (s *spectrumLocalClient) getVolumeMountPoint(volume SpectrumScaleVolume) (string, error) {
-	s.logger.Println("getVolumeMountPoint start")
-	defer s.logger.Println("getVolumeMountPoint end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	fsMountpoint, err := s.connector.GetFilesystemMountpoint(volume.FileSystem)
 	if err != nil {
@@ -810,8 +1030,7 @@ func (s *spectrumLocalClient) getVolumeMountPoint(volume SpectrumScaleVolume) (s
 
 }
 func (s *spectrumLocalClient) updatePermissions(name string) error {
-	s.logger.Println("spectrumLocalClient: updatePermissions-start")
-	defer s.logger.Println("spectrumLocalClient: updatePermissions-end")
+	defer s.logger.Trace(logs.DEBUG)()
 	getVolumeConfigRequest := resources.GetVolumeConfigRequest{Name: name}
 	getVolumeConfigResponse := s.GetVolumeConfig(getVolumeConfigRequest)
 	if getVolumeConfigResponse.Error != nil {
@@ -839,7 +1058,7 @@ func (s *spectrumLocalClient) updatePermissions(name string) error {
 	args := []string{"chmod", "777", filesetPath}
 	_, err = s.executor.Execute("sudo", args)
 	if err != nil {
-		s.logger.Printf("Failed to change permissions of filesetpath %s: %s", filesetPath, err.Error())
+		s.logger.Error("Failed to change permissions of filesetpath", logs.Args{{"filesetPath", filesetPath}, {"error", err}})
 		return err
 	}
 	if volumeType == Lightweight {
@@ -851,7 +1070,7 @@ func (s *spectrumLocalClient) updatePermissions(name string) error {
 		args := []string{"chmod", "777", directoryPath}
 		_, err = s.executor.Execute("sudo", args)
 		if err != nil {
-			s.logger.Printf("Failed to change permissions of directorypath %s: %s", directoryPath, err.Error())
+			s.logger.Error("Failed to change permissions of directorypath", logs.Args{{"directoryPath", directoryPath}, {"error", err}})
 			return err
 		}
 	}
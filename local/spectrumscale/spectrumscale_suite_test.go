@@ -17,6 +17,7 @@
 package spectrumscale_test
 
 import (
+	"github.com/midoblgsm/ubiquity/utils/logs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"testing"
@@ -24,5 +25,6 @@ import (
 
 func TestSpectrum(t *testing.T) {
 	RegisterFailHandler(Fail)
+	defer logs.InitStdoutLogger(logs.DEBUG)()
 	RunSpecs(t, "Spectrum Test Suite")
 }
@@ -18,7 +18,7 @@ package spectrumscale_test
 
 import (
 	"fmt"
-	"log"
+	"io/ioutil"
 	"os"
 
 	. "github.com/onsi/ginkgo"
@@ -26,14 +26,17 @@ import (
 
 	"github.com/midoblgsm/ubiquity/local/spectrumscale"
 
+	"github.com/jinzhu/gorm"
 	"github.com/midoblgsm/ubiquity/fakes"
+	"github.com/midoblgsm/ubiquity/local/placement"
+	"github.com/midoblgsm/ubiquity/local/spectrumscale/connectors"
+	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
 )
 
 var _ = Describe("local-client", func() {
 	var (
 		client                     resources.StorageClient
-		logger                     *log.Logger
 		fakeSpectrumScaleConnector *fakes.FakeSpectrumScaleConnector
 		fakeSpectrumDataModel      *fakes.FakeSpectrumDataModel
 		fakeExec                   *fakes.FakeExecutor
@@ -50,14 +53,13 @@ var _ = Describe("local-client", func() {
 		err                error
 	)
 	BeforeEach(func() {
-		logger = log.New(os.Stdout, "ubiquity: ", log.Lshortfile|log.LstdFlags)
 		fakeSpectrumScaleConnector = new(fakes.FakeSpectrumScaleConnector)
 		backends = []string{resources.SpectrumScale}
 		fakeExec = new(fakes.FakeExecutor)
 		fakeSpectrumDataModel = new(fakes.FakeSpectrumDataModel)
 		fakeConfig = resources.SpectrumScaleConfig{}
 		activateRequest = resources.ActivateRequest{Backends: backends}
-		client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(logger, fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+		client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
 		Expect(err).ToNot(HaveOccurred())
 
 	})
@@ -153,7 +155,7 @@ var _ = Describe("local-client", func() {
 			opts map[string]string
 		)
 		BeforeEach(func() {
-			client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(logger, fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+			client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
 			Expect(err).ToNot(HaveOccurred())
 			fakeSpectrumScaleConnector.IsFilesystemMountedReturns(false, nil)
 			fakeSpectrumScaleConnector.MountFileSystemReturns(nil)
@@ -196,7 +198,9 @@ var _ = Describe("local-client", func() {
 				Expect(createVolumeResponse.Error).To(HaveOccurred())
 				Expect(createVolumeResponse.Error.Error()).To(Equal("error creating fileset"))
 				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(1))
-				Expect(fakeSpectrumDataModel.InsertFilesetVolumeCallCount()).To(Equal(0))
+				Expect(fakeSpectrumDataModel.InsertFilesetVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(0))
 			})
 
 			It("should fail when dbClient fails to insert fileset record", func() {
@@ -206,7 +210,7 @@ var _ = Describe("local-client", func() {
 				createVolumeResponse := client.CreateVolume(createVolumeRequest)
 				Expect(createVolumeResponse.Error).To(HaveOccurred())
 				Expect(createVolumeResponse.Error.Error()).To(Equal("error inserting fileset"))
-				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(1))
+				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(0))
 				Expect(fakeSpectrumDataModel.InsertFilesetVolumeCallCount()).To(Equal(1))
 			})
 
@@ -218,8 +222,75 @@ var _ = Describe("local-client", func() {
 				Expect(createVolumeResponse.Error).ToNot(HaveOccurred())
 				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(1))
 				Expect(fakeSpectrumDataModel.InsertFilesetVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(1))
+				name, state := fakeSpectrumDataModel.UpdateVolumeStateArgsForCall(0)
+				Expect(name).To(Equal("fake-fileset"))
+				Expect(state).To(Equal(resources.VolumeStateAvailable))
+			})
+
+		})
+
+		Context(".WithPlacementPolicy", func() {
+			BeforeEach(func() {
+				fakeConfig.DefaultFilesystemName = "gpfs0"
+				fakeConfig.PlacementPolicy = "most-free"
+				client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.Activate(activateRequest).Error).ToNot(HaveOccurred())
+				fakeSpectrumScaleConnector.ListFilesystemsReturns([]string{"gpfs0", "gpfs1", "gpfs2"}, nil)
+				fakeSpectrumScaleConnector.GetFilesystemCapacityStub = func(filesystem string) (connectors.FilesystemCapacity, error) {
+					switch filesystem {
+					case "gpfs0":
+						return connectors.FilesystemCapacity{TotalBytes: 8 << 30, FreeBytes: 1 << 30}, nil
+					case "gpfs1":
+						return connectors.FilesystemCapacity{TotalBytes: 8 << 30, FreeBytes: 4 << 30}, nil
+					}
+					return connectors.FilesystemCapacity{}, fmt.Errorf("error in mmdf")
+				}
+				fakeSpectrumScaleConnector.CreateFilesetReturns(nil)
+				fakeSpectrumDataModel.InsertFilesetVolumeReturns(nil)
+				fakeSpectrumDataModel.InsertFilesetQuotaVolumeReturns(nil)
 			})
 
+			It("should fail to create the client with an unknown policy", func() {
+				fakeConfig.PlacementPolicy = "round-robin"
+				_, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should create the fileset on the filesystem with the most free space", func() {
+				createVolumeRequest = resources.CreateVolumeRequest{Name: "fake-fileset", Metadata: map[string]string{}}
+				createVolumeResponse := client.CreateVolume(createVolumeRequest)
+				Expect(createVolumeResponse.Error).ToNot(HaveOccurred())
+				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(1))
+				filesystem, _, _ := fakeSpectrumScaleConnector.CreateFilesetArgsForCall(0)
+				Expect(filesystem).To(Equal("gpfs1"))
+			})
+
+			It("should reject a quota that fits in no filesystem before creating anything", func() {
+				createVolumeRequest = resources.CreateVolumeRequest{Name: "fake-fileset", Metadata: map[string]string{"quota": "8G"}}
+				createVolumeResponse := client.CreateVolume(createVolumeRequest)
+				Expect(createVolumeResponse.Error).To(BeAssignableToTypeOf(&placement.NoCapacityError{}))
+				Expect(fakeSpectrumDataModel.InsertFilesetQuotaVolumeCallCount()).To(Equal(0))
+				Expect(fakeSpectrumScaleConnector.CreateFilesetCallCount()).To(Equal(0))
+			})
+
+			It("should only check the capacity of the given filesystem", func() {
+				createVolumeRequest = resources.CreateVolumeRequest{Name: "fake-fileset", Metadata: map[string]string{"filesystem": "gpfs0", "quota": "2G"}}
+				createVolumeResponse := client.CreateVolume(createVolumeRequest)
+				Expect(createVolumeResponse.Error).To(BeAssignableToTypeOf(&placement.NoCapacityError{}))
+				Expect(fakeSpectrumScaleConnector.ListFilesystemsCallCount()).To(Equal(0))
+				Expect(fakeSpectrumScaleConnector.GetFilesystemCapacityCallCount()).To(Equal(1))
+			})
+
+			It("should use the default filesystem if the filesystems cannot be listed", func() {
+				fakeSpectrumScaleConnector.ListFilesystemsReturns(nil, fmt.Errorf("error in list filesystems"))
+				createVolumeRequest = resources.CreateVolumeRequest{Name: "fake-fileset", Metadata: map[string]string{}}
+				createVolumeResponse := client.CreateVolume(createVolumeRequest)
+				Expect(createVolumeResponse.Error).ToNot(HaveOccurred())
+				filesystem, _, _ := fakeSpectrumScaleConnector.CreateFilesetArgsForCall(0)
+				Expect(filesystem).To(Equal("gpfs0"))
+			})
 		})
 
 		Context(".FilesetVolume", func() {
@@ -356,6 +427,29 @@ var _ = Describe("local-client", func() {
 		})
 	})
 
+	Context(".ListPools", func() {
+		It("should fail when spectrum client fails to list the filesystems", func() {
+			fakeSpectrumScaleConnector.ListFilesystemsReturns(nil, fmt.Errorf("error in list filesystems"))
+			_, err := client.(resources.PoolLister).ListPools()
+			Expect(err).To(HaveOccurred())
+		})
+		It("should list the filesystems with their capacity and the errors of the others", func() {
+			fakeSpectrumScaleConnector.ListFilesystemsReturns([]string{"gpfs0", "gpfs1"}, nil)
+			fakeSpectrumScaleConnector.GetFilesystemCapacityStub = func(filesystem string) (connectors.FilesystemCapacity, error) {
+				if filesystem == "gpfs0" {
+					return connectors.FilesystemCapacity{TotalBytes: 1000, FreeBytes: 400, BlockSize: 4096, UsedInodes: 10, FreeInodes: 90, MaxInodes: 100}, nil
+				}
+				return connectors.FilesystemCapacity{}, fmt.Errorf("error in mmdf")
+			}
+			pools, err := client.(resources.PoolLister).ListPools()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pools).To(Equal([]resources.StoragePool{
+				{Name: "gpfs0", TotalBytes: 1000, UsedBytes: 600, FreeBytes: 400, BlockSize: 4096, UsedInodes: 10, FreeInodes: 90, MaxInodes: 100},
+				{Name: "gpfs1", Err: "error in mmdf"},
+			}))
+		})
+	})
+
 	Context(".RemoveVolume", func() {
 		BeforeEach(func() {
 			removeVolumeRequest = resources.RemoveVolumeRequest{Name: "fake-volume"}
@@ -400,7 +494,7 @@ var _ = Describe("local-client", func() {
 		Context("When forcedelete is set to true", func() {
 			BeforeEach(func() {
 				fakeConfig = resources.SpectrumScaleConfig{ForceDelete: true}
-				client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(logger, fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+				client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
 				Expect(err).ToNot(HaveOccurred())
 
 			})
@@ -413,7 +507,7 @@ var _ = Describe("local-client", func() {
 				Expect(removeVolumeResponse.Error).To(HaveOccurred())
 				Expect(removeVolumeResponse.Error.Error()).To(Equal("error getting fs mountpoint"))
 				Expect(fakeSpectrumDataModel.GetVolumeCallCount()).To(Equal(1))
-				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(0))
 				Expect(fakeSpectrumScaleConnector.GetFilesystemMountpointCallCount()).To(Equal(1))
 				Expect(fakeExec.RemoveAllCallCount()).To(Equal(0))
 			})
@@ -428,7 +522,7 @@ var _ = Describe("local-client", func() {
 				Expect(removeVolumeResponse.Error).To(HaveOccurred())
 				Expect(removeVolumeResponse.Error.Error()).To(Equal("error removing path"))
 				Expect(fakeSpectrumDataModel.GetVolumeCallCount()).To(Equal(1))
-				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(0))
 				Expect(fakeSpectrumScaleConnector.GetFilesystemMountpointCallCount()).To(Equal(1))
 				Expect(fakeExec.RemoveAllCallCount()).To(Equal(1))
 			})
@@ -458,8 +552,26 @@ var _ = Describe("local-client", func() {
 				Expect(removeVolumeResponse.Error).To(HaveOccurred())
 				Expect(removeVolumeResponse.Error.Error()).To(Equal("error deleting fileset"))
 				Expect(fakeSpectrumDataModel.GetVolumeCallCount()).To(Equal(1))
-				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(0))
 				Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(2))
+				_, state := fakeSpectrumDataModel.UpdateVolumeStateArgsForCall(1)
+				Expect(state).To(Equal(resources.VolumeStateAvailable))
+			})
+
+			It("should leave the volume in the deleting state when dbClient fails to delete it after its fileset", func() {
+				volume := spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "fake-volume"}, FileSystem: "fake-filesystem", Type: 0}
+				fakeSpectrumDataModel.GetVolumeReturns(volume, true, nil)
+				fakeSpectrumScaleConnector.IsFilesetLinkedReturns(false, nil)
+				fakeSpectrumScaleConnector.DeleteFilesetReturns(nil)
+				fakeSpectrumDataModel.DeleteVolumeReturns(fmt.Errorf("error deleting volume"))
+				removeVolumeResponse := client.RemoveVolume(removeVolumeRequest)
+				Expect(removeVolumeResponse.Error).To(HaveOccurred())
+				Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+				Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(1))
+				_, state := fakeSpectrumDataModel.UpdateVolumeStateArgsForCall(0)
+				Expect(state).To(Equal(resources.VolumeStateDeleting))
 			})
 
 			It("should succeed when type is fileset and forceDelete is true", func() {
@@ -775,4 +887,217 @@ var _ = Describe("local-client", func() {
 
 	})
 
+	Context(".Reconcile", func() {
+		var reconciler resources.BackendReconciler
+		BeforeEach(func() {
+			fakeConfig = resources.SpectrumScaleConfig{DefaultFilesystemName: "gpfs"}
+			client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+			Expect(err).ToNot(HaveOccurred())
+			reconciler = client.(resources.BackendReconciler)
+		})
+		It("should fail when ListFilesets errors", func() {
+			fakeSpectrumScaleConnector.ListFilesetsReturns(nil, fmt.Errorf("error listing filesets"))
+			_, err = reconciler.Reconcile()
+			Expect(err).To(HaveOccurred())
+		})
+		It("should report missing filesets, orphan filesets and attached volumes on unlinked filesets", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1"}, {Name: "vol2"}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturnsOnCall(0, spectrumscale.SpectrumScaleVolume{FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumDataModel.GetVolumeReturnsOnCall(1, spectrumscale.SpectrumScaleVolume{FileSystem: "gpfs", Fileset: "vol2", Volume: resources.Volume{Mountpoint: "/gpfs/vol2"}}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "root"}, {Name: "vol2"}, {Name: "orphan"}}, nil)
+			fakeSpectrumScaleConnector.IsFilesetLinkedReturns(false, nil)
+
+			drifts, err := reconciler.Reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drifts).To(ConsistOf(
+				resources.Drift{Kind: resources.DriftMissingStorage, Backend: resources.SpectrumScale, Volume: "vol1", Resource: "gpfs/vol1"},
+				resources.Drift{Kind: resources.DriftAttachMismatch, Backend: resources.SpectrumScale, Volume: "vol2", Resource: "gpfs/vol2", InDB: "/gpfs/vol2"},
+				resources.Drift{Kind: resources.DriftOrphanStorage, Backend: resources.SpectrumScale, Resource: "gpfs/orphan"},
+			))
+		})
+		It("should clear the mountpoint when repairing an attach mismatch", func() {
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{FileSystem: "gpfs", Fileset: "vol2", Volume: resources.Volume{Mountpoint: "/gpfs/vol2"}}, true, nil)
+			fakeSpectrumScaleConnector.IsFilesetLinkedReturns(false, nil)
+			err = reconciler.RepairDrift(resources.Drift{Kind: resources.DriftAttachMismatch, Volume: "vol2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeSpectrumDataModel.UpdateVolumeMountpointCallCount()).To(Equal(1))
+			name, mountpoint := fakeSpectrumDataModel.UpdateVolumeMountpointArgsForCall(0)
+			Expect(name).To(Equal("vol2"))
+			Expect(mountpoint).To(Equal(""))
+		})
+		It("should not remove orphan filesets", func() {
+			err = reconciler.RepairDrift(resources.Drift{Kind: resources.DriftOrphanStorage, Resource: "gpfs/orphan"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(0))
+		})
+	})
+	Context(".RecoverVolumes", func() {
+		var (
+			recoverer resources.VolumeRecoverer
+			locker    *fakes.FakeLocker
+		)
+		BeforeEach(func() {
+			locker = new(fakes.FakeLocker)
+			fakeConfig = resources.SpectrumScaleConfig{DefaultFilesystemName: "gpfs", ForceDelete: true}
+			client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+			Expect(err).ToNot(HaveOccurred())
+			recoverer = client.(resources.VolumeRecoverer)
+		})
+		It("should skip the volumes that are not in a transitional state", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1", State: resources.VolumeStateAvailable}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeSpectrumDataModel.GetVolumeCallCount()).To(Equal(0))
+			Expect(locker.WriteLockCallCount()).To(Equal(0))
+		})
+		It("should make a created volume available if its fileset exists and delete it otherwise", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{
+				{Name: "vol1", State: resources.VolumeStateCreating},
+				{Name: "vol2", State: resources.VolumeStateCreating},
+			}, nil)
+			fakeSpectrumDataModel.GetVolumeReturnsOnCall(0, spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateCreating}, FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumDataModel.GetVolumeReturnsOnCall(1, spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol2", State: resources.VolumeStateCreating}, FileSystem: "gpfs", Fileset: "vol2"}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "root"}, {Name: "vol1"}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(1))
+			name, state := fakeSpectrumDataModel.UpdateVolumeStateArgsForCall(0)
+			Expect(name).To(Equal("vol1"))
+			Expect(state).To(Equal(resources.VolumeStateAvailable))
+			Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeSpectrumDataModel.DeleteVolumeArgsForCall(0)).To(Equal("vol2"))
+		})
+		It("should resume the removal of a deleting volume", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1", State: resources.VolumeStateDeleting}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDeleting}, FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "vol1"}}, nil)
+			fakeSpectrumScaleConnector.IsFilesetLinkedReturns(true, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeSpectrumScaleConnector.UnlinkFilesetCallCount()).To(Equal(1))
+			Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+			Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(1))
+		})
+		It("should keep a deleting volume whose fileset fails to be deleted", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1", State: resources.VolumeStateDeleting}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDeleting}, FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "vol1"}}, nil)
+			fakeSpectrumScaleConnector.IsFilesetLinkedReturns(false, nil)
+			fakeSpectrumScaleConnector.DeleteFilesetReturns(fmt.Errorf("error deleting fileset"))
+			Expect(recoverer.RecoverVolumes(locker)).ToNot(Succeed())
+			Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(1))
+			Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(0))
+		})
+		It("should delete a deleting volume whose fileset is already deleted", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1", State: resources.VolumeStateDeleting}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateDeleting}, FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "root"}}, nil)
+			Expect(recoverer.RecoverVolumes(locker)).To(Succeed())
+			Expect(fakeSpectrumScaleConnector.DeleteFilesetCallCount()).To(Equal(0))
+			Expect(fakeSpectrumDataModel.DeleteVolumeCallCount()).To(Equal(1))
+		})
+		It("should move a volume that cannot be recovered to the error state", func() {
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "vol1", State: resources.VolumeStateAttaching}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{Volume: resources.Volume{Name: "vol1", State: resources.VolumeStateAttaching}, FileSystem: "gpfs", Fileset: "vol1"}, true, nil)
+			fakeSpectrumScaleConnector.IsFilesetLinkedReturns(false, fmt.Errorf("error in IsFilesetLinked"))
+			Expect(recoverer.RecoverVolumes(locker)).ToNot(Succeed())
+			Expect(fakeSpectrumDataModel.UpdateVolumeStateCallCount()).To(Equal(1))
+			_, state := fakeSpectrumDataModel.UpdateVolumeStateArgsForCall(0)
+			Expect(state).To(Equal(resources.VolumeStateError))
+		})
+	})
+	Context(".Adopt", func() {
+		var adopter resources.BackendAdopter
+		BeforeEach(func() {
+			fakeConfig = resources.SpectrumScaleConfig{DefaultFilesystemName: "gpfs"}
+			client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, fakeSpectrumDataModel)
+			Expect(err).ToNot(HaveOccurred())
+			adopter = client.(resources.BackendAdopter)
+			fakeSpectrumDataModel.ListVolumesReturns([]resources.Volume{{Name: "legacy1"}}, nil)
+			fakeSpectrumDataModel.GetVolumeReturns(spectrumscale.SpectrumScaleVolume{FileSystem: "gpfs", Fileset: "legacy1"}, true, nil)
+			fakeSpectrumScaleConnector.ListFilesetsReturns([]resources.Volume{{Name: "root"}, {Name: "legacy1"}, {Name: "legacy2"}, {Name: "legacy3"}, {Name: "other"}}, nil)
+		})
+		It("should fail when the pattern is empty", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{})
+			Expect(adoptResponse.Error).To(HaveOccurred())
+			Expect(fakeSpectrumScaleConnector.ListFilesetsCallCount()).To(Equal(0))
+		})
+		It("should adopt the unused filesets matching the pattern in one insert", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "legacy*"})
+			Expect(adoptResponse.Error).ToNot(HaveOccurred())
+			Expect(len(adoptResponse.Volumes)).To(Equal(2))
+			Expect(fakeSpectrumScaleConnector.ListFilesetsArgsForCall(0)).To(Equal("gpfs"))
+			Expect(fakeSpectrumDataModel.InsertPreexistingFilesetVolumesCallCount()).To(Equal(1))
+			filesets, filesystem, _ := fakeSpectrumDataModel.InsertPreexistingFilesetVolumesArgsForCall(0)
+			Expect(filesets).To(Equal([]string{"legacy2", "legacy3"}))
+			Expect(filesystem).To(Equal("gpfs"))
+		})
+		It("should not insert anything on dry run", func() {
+			adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "legacy*", DryRun: true})
+			Expect(adoptResponse.Error).ToNot(HaveOccurred())
+			Expect(len(adoptResponse.Volumes)).To(Equal(2))
+			Expect(fakeSpectrumDataModel.InsertPreexistingFilesetVolumesCallCount()).To(Equal(0))
+		})
+		Context("with a database", func() {
+			var (
+				dbDir     string
+				db        *gorm.DB
+				dataModel spectrumscale.SpectrumDataModel
+			)
+			BeforeEach(func() {
+				dbDir, err = ioutil.TempDir("", "ubiquity-spectrum")
+				Expect(err).ToNot(HaveOccurred())
+				db, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = model.NewMigrator(db).Up(false)
+				Expect(err).ToNot(HaveOccurred())
+				dataModel = spectrumscale.NewSpectrumDataModel(db, resources.SpectrumScale)
+				client, err = spectrumscale.NewSpectrumLocalClientWithConnectors(fakeSpectrumScaleConnector, fakeExec, fakeConfig, dataModel)
+				Expect(err).ToNot(HaveOccurred())
+				adopter = client.(resources.BackendAdopter)
+			})
+			AfterEach(func() {
+				db.Close()
+				os.RemoveAll(dbDir)
+			})
+			It("should attach an adopted fileset to several hosts", func() {
+				adoptResponse := adopter.Adopt(resources.AdoptRequest{Pattern: "legacy*"})
+				Expect(adoptResponse.Error).ToNot(HaveOccurred())
+				Expect(adoptResponse.Volumes[0].AccessMode).To(Equal(resources.AccessModeMultiWriter))
+				fakeSpectrumScaleConnector.IsFilesetLinkedReturns(true, nil)
+				Expect(client.Attach(resources.AttachRequest{Name: "legacy2", Host: "host1"}).Error).ToNot(HaveOccurred())
+				Expect(client.Attach(resources.AttachRequest{Name: "legacy2", Host: "host2"}).Error).ToNot(HaveOccurred())
+				attachments, err := dataModel.GetVolumeAttachments("legacy2")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(attachments)).To(Equal(2))
+			})
+		})
+	})
+	Context(".ValidateConfig", func() {
+		BeforeEach(func() {
+			fakeConfig = resources.SpectrumScaleConfig{DefaultFilesystemName: "gold"}
+		})
+		It("should fail if the cluster is not reachable", func() {
+			fakeSpectrumScaleConnector.GetClusterIdReturns("", fmt.Errorf("connection refused"))
+			err = spectrumscale.ValidateConfigWithConnector(fakeConfig, fakeSpectrumScaleConnector)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("mmcli connector: connection refused"))
+			Expect(fakeSpectrumScaleConnector.GetFilesystemMountpointCallCount()).To(Equal(0))
+		})
+		It("should fail if the default filesystem is not found", func() {
+			fakeConfig.RestConfig.Endpoint = "https://gui:443/"
+			fakeSpectrumScaleConnector.GetFilesystemMountpointReturns("", fmt.Errorf("filesystem not found"))
+			err = spectrumscale.ValidateConfigWithConnector(fakeConfig, fakeSpectrumScaleConnector)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("[gold] is not found with the rest connector"))
+			Expect(fakeSpectrumScaleConnector.GetFilesystemMountpointArgsForCall(0)).To(Equal("gold"))
+		})
+		It("should succeed if the default filesystem is found", func() {
+			fakeSpectrumScaleConnector.GetFilesystemMountpointReturns("/gpfs/gold", nil)
+			err = spectrumscale.ValidateConfigWithConnector(fakeConfig, fakeSpectrumScaleConnector)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should fail without a default filesystem", func() {
+			err = spectrumscale.ValidateConfig(resources.UbiquityServerConfig{ConfigPath: "/tmp"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeSpectrumScaleConnector.GetClusterIdCallCount()).To(Equal(0))
+		})
+	})
 })
@@ -0,0 +1,128 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// ConfigValidation is the result of the validation of one section of the config
+type ConfigValidation struct {
+	Section    string  // "server" or a backend name
+	Configured bool    // false for a backend without parameters, it is not served
+	Errors     []error // empty if the section is valid
+}
+
+func (v ConfigValidation) Passed() bool {
+	return len(v.Errors) == 0
+}
+
+// ValidateConfig checks the server parameters and every backend section of config, the backends are checked like
+// GetLocalClients creates them and must answer, but the database is not opened.
+// The config is valid if every configured section passed and at least one backend is configured.
+func ValidateConfig(config resources.UbiquityServerConfig) ([]ConfigValidation, bool) {
+	server := validateServerConfig(config)
+	var validations []ConfigValidation
+	served := make(map[string]bool)
+	backends, err := GetBackendConfigs(config)
+	if err != nil {
+		// the backends cannot be listed, the checks below would only repeat it
+		server.Errors = append(server.Errors, err)
+		return []ConfigValidation{server}, false
+	}
+	for _, backend := range backends {
+		validation := validateBackendConfig(config, backend)
+		served[backend.Name] = validation.Configured && validation.Passed()
+		validations = append(validations, validation)
+	}
+	if config.DefaultBackend != "" && !served[config.DefaultBackend] {
+		server.Errors = append(server.Errors, fmt.Errorf("the default backend [%s] is not served", config.DefaultBackend))
+	}
+	var servedNames []string
+	for name, ok := range served {
+		if ok {
+			servedNames = append(servedNames, name)
+		}
+	}
+	if err := model.ValidateStorageClasses(config.StorageClasses, servedNames); err != nil {
+		server.Errors = append(server.Errors, err)
+	}
+	validations = append([]ConfigValidation{server}, validations...)
+
+	valid, configured := true, false
+	for _, validation := range validations[1:] {
+		configured = configured || validation.Configured
+	}
+	if !configured {
+		validations[0].Errors = append(validations[0].Errors, fmt.Errorf("no backend is configured"))
+	}
+	for _, validation := range validations {
+		valid = valid && validation.Passed()
+	}
+	return validations, valid
+}
+
+func validateServerConfig(config resources.UbiquityServerConfig) ConfigValidation {
+	validation := ConfigValidation{Section: "server", Configured: true}
+	if config.Port <= 0 {
+		validation.Errors = append(validation.Errors, fmt.Errorf("missing required parameter 'port'"))
+	}
+	if config.LogLevel != "" {
+		if _, err := logs.ParseLogLevel(config.LogLevel); err != nil {
+			validation.Errors = append(validation.Errors, err)
+		}
+	}
+	for component, level := range config.LogComponentLevels {
+		if _, err := logs.ParseLogLevel(level); err != nil {
+			validation.Errors = append(validation.Errors, fmt.Errorf("component [%s]: %s", component, err.Error()))
+		}
+	}
+	if _, _, err := model.GetDatabaseDialectAndDSN(config.DatabaseConfig, config.ConfigPath); err != nil {
+		validation.Errors = append(validation.Errors, err)
+	}
+	if err := model.ValidateLockConfig(config.LockConfig); err != nil {
+		validation.Errors = append(validation.Errors, err)
+	}
+	if err := model.ValidateLeaseConfig(config.LeaseConfig); err != nil {
+		validation.Errors = append(validation.Errors, err)
+	}
+	return validation
+}
+
+// validateBackendConfig checks the backend instance like GetLocalClients creates its client
+func validateBackendConfig(config resources.UbiquityServerConfig, backend resources.BackendConfig) ConfigValidation {
+	validation := ConfigValidation{Section: backend.Name}
+	plugin, _ := resources.GetBackend(backend.Type)
+	settings, configured, err := decodeBackendConfig(plugin, backend)
+	if err != nil {
+		validation.Configured = true
+		validation.Errors = append(validation.Errors, err)
+		return validation
+	}
+	validation.Configured = configured
+	if !configured || plugin.ValidateConfig == nil {
+		return validation
+	}
+	if err := plugin.ValidateConfig(settings, config); err != nil {
+		validation.Errors = append(validation.Errors, err)
+	}
+	return validation
+}
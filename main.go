@@ -17,22 +17,22 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io/ioutil"
 	"os"
 	"path"
 
 	"flag"
 
-	"time"
-
 	"github.com/BurntSushi/toml"
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/midoblgsm/ubiquity/local"
+	"github.com/midoblgsm/ubiquity/model"
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
 	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 	"github.com/midoblgsm/ubiquity/web_server"
 )
 
@@ -42,15 +42,57 @@ var configFile = flag.String(
 	"config file with ubiquity server configuration params",
 )
 
-const (
-	HeartbeatInterval = 5 //seconds
+var migrate = flag.Bool(
+	"migrate",
+	false,
+	"apply the pending database schema migrations and exit",
+)
+
+var migrateDryRun = flag.Bool(
+	"migrate-dry-run",
+	false,
+	"print the pending database schema migrations (or the ones --migrate-down-to would roll back) and exit",
+)
+
+var migrateDownTo = flag.Int64(
+	"migrate-down-to",
+	-1,
+	"roll back the database schema migrations newer than the given version and exit",
+)
+
+var force = flag.Bool(
+	"force",
+	false,
+	"with --migrate-down-to, also roll back the migrations that drop tables holding volume data",
+)
+
+var exportInventory = flag.String(
+	"export-inventory",
+	"",
+	"write the volume inventory to the given JSON file and exit",
+)
+
+var importInventory = flag.String(
+	"import-inventory",
+	"",
+	"load the volume inventory from the given JSON file into an empty database and exit",
+)
+
+var validateConfig = flag.Bool(
+	"validate-config",
+	false,
+	"check the config file and every configured backend, print a report and exit without starting the server",
 )
 
 func main() {
 	flag.Parse()
 	var config resources.UbiquityServerConfig
 
-	fmt.Printf("Starting Ubiquity Storage API server with %s config file\n", *configFile)
+	if *validateConfig {
+		fmt.Printf("Validating %s config file\n", *configFile)
+	} else {
+		fmt.Printf("Starting Ubiquity Storage API server with %s config file\n", *configFile)
+	}
 
 	if _, err := os.Stat(*configFile); os.IsNotExist(err) {
 		panic(fmt.Sprintf("Cannot open config file: %s, aborting...", *configFile))
@@ -60,10 +102,28 @@ func main() {
 		fmt.Println(err)
 		return
 	}
+	if err := utils.ResolveServerConfigSecrets(&config); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	defer logs.InitFileLogger(logs.GetLogLevelFromString(config.LogLevel), path.Join(config.LogPath, "ubiquity.log"))()
-	logger, logFile := utils.SetupLogger(config.LogPath, "ubiquity")
-	defer utils.CloseLogs(logFile)
+	logConfig := utils.NewFileLoggerConfig(config.LogFormat, config.LogRotationConfig)
+	defer logs.InitRotatingFileLogger(logs.GetLogLevelFromString(config.LogLevel), path.Join(config.LogPath, "ubiquity.log"), logConfig)()
+	if err := utils.SetComponentLogLevels(config.LogComponentLevels); err != nil {
+		panic(err.Error())
+	}
+	logger := logs.GetLogger()
+	if *validateConfig {
+		if !runValidateConfig(config) {
+			os.Exit(1)
+		}
+		return
+	}
+	closeTracing, err := tracing.Init(utils.NewTracingConfig(config.TracingConfig))
+	if err != nil {
+		panic(err.Error())
+	}
+	defer closeTracing()
 
 	spectrumExecutor := utils.NewExecutor()
 	ubiquityConfigPath, err := utils.SetupConfigDirectory(logger, spectrumExecutor, config.ConfigPath)
@@ -71,72 +131,196 @@ func main() {
 		panic(err.Error())
 	}
 
-	//check if lock exists -- peer ubiquity server(s)
-	heartbeat := utils.NewHeartbeat(logger, ubiquityConfigPath)
-
-	logger.Println("Checking for heartbeat....")
-	probeHeartbeatUntilFree(heartbeat)
-
-	err = heartbeat.Create()
+	logger.Info("Obtaining handle to DB")
+	db, err := model.OpenDatabase(config.DatabaseConfig, ubiquityConfigPath)
 	if err != nil {
-		panic("failed to initialize heartbeat")
+		panic(fmt.Sprintf("failed to connect database: %s", err.Error()))
+	}
+	defer db.Close()
+
+	// the dry run and the export only read the database, so they do not wait for the lease of a running server
+	migrator := model.NewMigrator(db)
+	if *migrateDryRun || *exportInventory != "" {
+		if *migrateDryRun {
+			err = runMigrations(migrator)
+		} else {
+			err = runInventory(db)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	}
-	logger.Println("Heartbeat acquired")
-	go keepAlive(heartbeat)
 
-	logger.Println("Obtaining handle to DB")
-	db, err := gorm.Open("sqlite3", path.Join(ubiquityConfigPath, "ubiquity.db"))
+	// with the local locks only one server at a time works on the database, the peer ubiquity server(s) wait for the lease.
+	// with the database locks all the servers serve the API, the lease only serializes their migrations and recovery.
+	activeActive := model.IsDistributedLocker(config.LockConfig)
+	leaseDuration, leaseRenewInterval := model.LeaseDurations(config.LeaseConfig)
+	elector, err := model.NewLeaderElector(db, model.ServerLeaseName, leaseDuration, leaseRenewInterval)
 	if err != nil {
-		panic("failed to connect database")
+		panic(err)
 	}
-	defer db.Close()
+	logger.Info("Acquiring lease", logs.Args{{"lease", model.ServerLeaseName}, {"holderID", elector.HolderID()}})
+	if err = elector.Acquire(); err != nil {
+		panic(fmt.Sprintf("failed to acquire lease: %s", err.Error()))
+	}
+	logger.Info("Lease acquired", logs.Args{{"lease", model.ServerLeaseName}, {"fencingToken", elector.FencingToken()}})
+	if !activeActive {
+		elector.RegisterFencingCallbacks(db)
+	}
+	stopKeepAlive := make(chan struct{})
+	go elector.KeepAlive(leaseRenewInterval, stopKeepAlive, func(err error) {
+		// another server may already be serving, stop before handling one more request
+		logger.Error("Lost lease, aborting", logs.Args{{"lease", model.ServerLeaseName}, {"error", err}})
+		os.Exit(1)
+	})
 
-	if err := db.AutoMigrate(&resources.Volume{}).Error; err != nil {
+	if *migrate || *migrateDownTo >= 0 {
+		if err := runMigrations(migrator); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if _, err := migrator.Up(false); err != nil {
 		panic(err)
 	}
+	if *importInventory != "" {
+		if err := runInventory(db); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
+	volumeLocker, err := model.NewLocker(config.LockConfig, db, "volume")
+	if err != nil {
+		panic(err)
+	}
 	clients, err := local.GetLocalClients(logger, config, db)
 	if err != nil {
 		panic(err)
 	}
+	local.RecoverVolumes(logger, clients, volumeLocker)
+	// with the database locks, the running records may be the operations in progress on the other servers
+	if !activeActive {
+		if err = model.FailInterruptedVolumeOperations(db); err != nil {
+			logger.Error("Error closing interrupted volume operations", logs.Args{{"error", err}})
+		}
+	}
 
-	server, err := web_server.NewStorageApiServer(logger, clients, config, db)
+	if activeActive {
+		close(stopKeepAlive)
+		if err = elector.Release(); err != nil {
+			logger.Error("Error releasing lease", logs.Args{{"lease", model.ServerLeaseName}, {"error", err}})
+		}
+		logger.Info("Lease released, serving along with the other servers")
+	}
+
+	server, err := web_server.NewStorageApiServer(clients, config, db, volumeLocker)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("Error creating Storage API server [%s]...", err.Error()))
+		logger.Error("Error creating Storage API server", logs.Args{{"error", err}})
+		os.Exit(1)
 	}
 
-	log.Fatal(server.Start(config.Port))
+	err = server.Start(config.Port)
+	logger.Error("Storage API server stopped", logs.Args{{"error", err}})
+	os.Exit(1)
 }
 
-func keepAlive(heartbeat utils.Heartbeat) {
-	for {
-		err := heartbeat.Update()
-		if err != nil {
-			panic("Failed updating heartbeat...aborting")
+func runValidateConfig(config resources.UbiquityServerConfig) bool {
+	validations, valid := local.ValidateConfig(config)
+	for _, validation := range validations {
+		switch {
+		case !validation.Configured:
+			fmt.Printf("%-16s SKIP  not configured\n", validation.Section)
+		case validation.Passed():
+			fmt.Printf("%-16s PASS\n", validation.Section)
+		default:
+			for i, err := range validation.Errors {
+				section := validation.Section
+				if i > 0 {
+					section = ""
+				}
+				fmt.Printf("%-16s FAIL  %s\n", section, err.Error())
+			}
 		}
-		time.Sleep(HeartbeatInterval * time.Second)
 	}
+	if !valid {
+		fmt.Printf("%s is not valid\n", *configFile)
+		return false
+	}
+	fmt.Printf("%s is valid\n", *configFile)
+	return true
 }
-func probeHeartbeatUntilFree(heartbeat utils.Heartbeat) {
-	exists, err := heartbeat.Exists()
+
+func runMigrations(migrator *model.Migrator) error {
+	currentVersion, err := migrator.CurrentVersion()
 	if err != nil {
-		panic("failed to initialize heartbeat")
+		return err
 	}
-	if !exists {
-		fmt.Println("Probing...1.1")
-		return
+	fmt.Printf("Current schema version: %d\n", currentVersion)
+
+	var migrations []model.Migration
+	action := "Applied"
+	if *migrateDownTo >= 0 {
+		action = "Rolled back"
+		migrations, err = migrator.DownTo(*migrateDownTo, *migrateDryRun, *force)
+	} else {
+		migrations, err = migrator.Up(*migrateDryRun)
 	}
-	for {
-		// check timestamp
-		currentTime := time.Now()
-		lastUpdateTimestamp, err := heartbeat.GetLastUpdateTimestamp()
-		if err != nil {
-			panic("Unable to determine state of heartbeat...aborting")
+	if *migrateDryRun {
+		action = "Would apply"
+		if *migrateDownTo >= 0 {
+			action = "Would roll back"
+		}
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s migration %d: %s", action, migration.Version, migration.Description)
+		if *migrateDownTo >= 0 && migration.DropsData {
+			if *force {
+				fmt.Print(" (drops the table and its data)")
+			} else {
+				fmt.Print(" (drops the table and its data, refused without --force)")
+			}
 		}
+		fmt.Println()
+	}
+	if len(migrations) == 0 {
+		fmt.Println("Nothing to do")
+	}
+	return err
+}
 
-		if currentTime.Sub(lastUpdateTimestamp).Seconds() > HeartbeatInterval {
-			break
+func runInventory(db *gorm.DB) error {
+	if *exportInventory != "" {
+		inventory, err := model.ExportInventory(db)
+		if err != nil {
+			return err
 		}
-		time.Sleep(HeartbeatInterval * time.Second)
+		data, err := json.MarshalIndent(inventory, "", " ")
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(*exportInventory, data, 0600); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d volumes to %s\n", len(inventory.Volumes), *exportInventory)
+		return nil
+	}
+
+	data, err := utils.ReadFile(*importInventory)
+	if err != nil {
+		return err
+	}
+	var inventory model.Inventory
+	if err = json.Unmarshal(data, &inventory); err != nil {
+		return err
+	}
+	if err = model.ImportInventory(db, inventory); err != nil {
+		return err
 	}
+	fmt.Printf("Imported %d volumes from %s\n", len(inventory.Volumes), *importInventory)
+	return nil
 }
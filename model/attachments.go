@@ -0,0 +1,216 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+)
+
+// The volume_attachments table holds the hosts a volume is attached to. The backends add and remove the rows in their
+// Attach and Detach, after checking the access mode of the volume with CheckVolumeAttach.
+
+func ValidateAccessMode(accessMode string) error {
+	switch accessMode {
+	case "", resources.AccessModeSingleWriter, resources.AccessModeMultiReader, resources.AccessModeMultiWriter:
+		return nil
+	}
+	return &invalidAccessModeError{accessMode}
+}
+
+// IsMultiAttach returns true if the access mode allows more than one host to attach the volume
+func IsMultiAttach(accessMode string) bool {
+	return accessMode == resources.AccessModeMultiReader || accessMode == resources.AccessModeMultiWriter
+}
+
+// FindVolumeAttachment returns the attachment of the host, or nil if the host is not attached
+func FindVolumeAttachment(attachments []resources.VolumeAttachment, host string) *resources.VolumeAttachment {
+	for i := range attachments {
+		if attachments[i].Host == host {
+			return &attachments[i]
+		}
+	}
+	return nil
+}
+
+// CheckVolumeAttach checks that one more host can attach the volume next to its current attachments
+func CheckVolumeAttach(volume resources.Volume, attachments []resources.VolumeAttachment, host string, readOnly bool) error {
+	var others []resources.VolumeAttachment
+	for _, attachment := range attachments {
+		if attachment.Host != host {
+			others = append(others, attachment)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	switch volume.AccessMode {
+	case resources.AccessModeMultiWriter:
+		return nil
+	case resources.AccessModeMultiReader:
+		if readOnly {
+			return nil
+		}
+		for _, attachment := range others {
+			if !attachment.ReadOnly {
+				return &accessModeConflictError{volume.Name, volume.AccessMode, attachmentHosts(others)}
+			}
+		}
+		return nil
+	}
+	return &accessModeConflictError{volume.Name, resources.AccessModeSingleWriter, attachmentHosts(others)}
+}
+
+func GetVolumeAttachments(db *gorm.DB, volumeID uint) ([]resources.VolumeAttachment, error) {
+	var attachments []resources.VolumeAttachment
+	err := db.Where("volume_id = ?", volumeID).Order("attached_at, id").Find(&attachments).Error
+	return attachments, err
+}
+
+// AddVolumeAttachment records that the host attached the volume, the host is not added twice
+func AddVolumeAttachment(db *gorm.DB, volumeID uint, host string, readOnly bool) error {
+	var count int
+	if err := db.Model(&resources.VolumeAttachment{}).Where("volume_id = ? AND host = ?", volumeID, host).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != 0 {
+		return nil
+	}
+	return db.Create(&resources.VolumeAttachment{VolumeID: volumeID, Host: host, ReadOnly: readOnly, AttachedAt: time.Now()}).Error
+}
+
+func RemoveVolumeAttachment(db *gorm.DB, volumeID uint, host string) error {
+	return db.Where("volume_id = ? AND host = ?", volumeID, host).Delete(resources.VolumeAttachment{}).Error
+}
+
+// SetVolumeAttachmentHosts makes the attachments match the given hosts, the hosts that stay attached keep their record
+func SetVolumeAttachmentHosts(db *gorm.DB, volumeID uint, hosts []string) error {
+	attachments, err := GetVolumeAttachments(db, volumeID)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, host := range hosts {
+		if host != "" {
+			wanted[host] = true
+		}
+	}
+	for _, attachment := range attachments {
+		if !wanted[attachment.Host] {
+			if err = RemoveVolumeAttachment(db, volumeID, attachment.Host); err != nil {
+				return err
+			}
+		}
+	}
+	for host := range wanted {
+		if err = AddVolumeAttachment(db, volumeID, host, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func UpdateVolumeAccessMode(db *gorm.DB, volume *resources.Volume, accessMode string) error {
+	return db.Model(&resources.Volume{}).Where("id = ?", volume.ID).Update("access_mode", accessMode).Error
+}
+
+func attachmentHosts(attachments []resources.VolumeAttachment) []string {
+	hosts := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		hosts = append(hosts, attachment.Host)
+	}
+	return hosts
+}
+
+func init() {
+	RegisterMigrations(Migration{
+		Version:     9,
+		Description: "add volumes access_mode column and create volume_attachments table",
+		Up: func(tx *gorm.DB) error {
+			type volumeAttachment struct {
+				ID         uint `gorm:"primary_key"`
+				VolumeID   uint `gorm:"index"`
+				Host       string
+				ReadOnly   bool
+				AttachedAt time.Time
+			}
+			if err := tx.Table("volumes").AutoMigrate(&struct{ AccessMode string }{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Table("volume_attachments").AutoMigrate(&volumeAttachment{}).Error; err != nil {
+				return err
+			}
+			// scbe volumes could only be mapped to one host, the filesystem volumes could be attached by any number of hosts
+			if err := tx.Exec("UPDATE volumes SET access_mode = ? WHERE (access_mode IS NULL OR access_mode = '') AND backend = ?", resources.AccessModeSingleWriter, resources.SCBE).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE volumes SET access_mode = ? WHERE access_mode IS NULL OR access_mode = ''", resources.AccessModeMultiWriter).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the access_mode column is kept, older versions ignore it
+			return tx.DropTableIfExists("volume_attachments").Error
+		},
+		DropsData: true,
+	})
+	RegisterInventoryTable(InventoryTable{
+		Name: "volume_attachments",
+		Export: func(db *gorm.DB) (interface{}, error) {
+			var attachments []resources.VolumeAttachment
+			if err := db.Order("id").Find(&attachments).Error; err != nil {
+				return nil, err
+			}
+			return attachments, nil
+		},
+		Import: func(tx *gorm.DB, data json.RawMessage) error {
+			var attachments []resources.VolumeAttachment
+			if err := json.Unmarshal(data, &attachments); err != nil {
+				return err
+			}
+			for _, attachment := range attachments {
+				attachment := attachment
+				if err := tx.Create(&attachment).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+type invalidAccessModeError struct {
+	accessMode string
+}
+
+func (e *invalidAccessModeError) Error() string {
+	return fmt.Sprintf("Invalid access mode [%s], it must be one of [%s, %s, %s]", e.accessMode,
+		resources.AccessModeSingleWriter, resources.AccessModeMultiReader, resources.AccessModeMultiWriter)
+}
+
+type accessModeConflictError struct {
+	volName    string
+	accessMode string
+	hosts      []string
+}
+
+func (e *accessModeConflictError) Error() string {
+	return fmt.Sprintf("Volume [%s] with access mode [%s] is already attached to [%s]", e.volName, e.accessMode, strings.Join(e.hosts, ","))
+}
@@ -0,0 +1,93 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Attachments", func() {
+	Context(".CheckVolumeAttach", func() {
+		var (
+			writer = resources.VolumeAttachment{Host: "host1"}
+			reader = resources.VolumeAttachment{Host: "host2", ReadOnly: true}
+		)
+		It("should allow a single-writer volume on one host only", func() {
+			volume := resources.Volume{Name: "vol1", AccessMode: resources.AccessModeSingleWriter}
+			Expect(model.CheckVolumeAttach(volume, nil, "host1", false)).To(Succeed())
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{writer}, "host1", false)).To(Succeed())
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{writer}, "host3", true)).ToNot(Succeed())
+		})
+		It("should allow a multi-reader volume on read only hosts and one writer", func() {
+			volume := resources.Volume{Name: "vol1", AccessMode: resources.AccessModeMultiReader}
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{writer, reader}, "host3", true)).To(Succeed())
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{reader}, "host3", false)).To(Succeed())
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{writer, reader}, "host3", false)).ToNot(Succeed())
+		})
+		It("should allow a multi-writer volume on any number of hosts", func() {
+			volume := resources.Volume{Name: "vol1", AccessMode: resources.AccessModeMultiWriter}
+			Expect(model.CheckVolumeAttach(volume, []resources.VolumeAttachment{writer, reader}, "host3", false)).To(Succeed())
+		})
+		It("should treat a volume without access mode as single-writer", func() {
+			Expect(model.CheckVolumeAttach(resources.Volume{Name: "vol1"}, []resources.VolumeAttachment{reader}, "host3", true)).ToNot(Succeed())
+		})
+	})
+	Context("volume attachments table", func() {
+		var (
+			dbDir string
+			db    *gorm.DB
+			err   error
+		)
+		BeforeEach(func() {
+			dbDir, err = ioutil.TempDir("", "ubiquity-model")
+			Expect(err).ToNot(HaveOccurred())
+			db, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = model.NewMigrator(db).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			db.Close()
+			os.RemoveAll(dbDir)
+		})
+		It("should add each host once and keep the read only flag of the hosts that stay attached", func() {
+			Expect(model.AddVolumeAttachment(db, 1, "host1", true)).To(Succeed())
+			Expect(model.AddVolumeAttachment(db, 1, "host1", true)).To(Succeed())
+			Expect(model.AddVolumeAttachment(db, 1, "host2", false)).To(Succeed())
+
+			Expect(model.SetVolumeAttachmentHosts(db, 1, []string{"host1", "host3"})).To(Succeed())
+			attachments, err := model.GetVolumeAttachments(db, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(attachments)).To(Equal(2))
+			Expect(model.FindVolumeAttachment(attachments, "host1").ReadOnly).To(BeTrue())
+			Expect(model.FindVolumeAttachment(attachments, "host2")).To(BeNil())
+			Expect(model.FindVolumeAttachment(attachments, "host3")).ToNot(BeNil())
+
+			Expect(model.RemoveVolumeAttachment(db, 1, "host1")).To(Succeed())
+			attachments, err = model.GetVolumeAttachments(db, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(attachments)).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,131 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
+)
+
+// OpenDatabase opens the database selected by config.
+// An empty dialect means SQLite, and an empty SQLite DSN means the ubiquity.db file in ubiquityConfigPath.
+func OpenDatabase(config resources.DatabaseConfig, ubiquityConfigPath string) (*gorm.DB, error) {
+	dialect, dsn, err := GetDatabaseDialectAndDSN(config, ubiquityConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	RegisterTracingCallbacks(db)
+	return db, nil
+}
+
+// RegisterTracingCallbacks traces the queries of db that run below a span, as children of that span
+func RegisterTracingCallbacks(db *gorm.DB) {
+	db.Callback().Create().Before("gorm:create").Register("ubiquity:trace_create_start", startQuerySpan("create"))
+	db.Callback().Create().After("gorm:create").Register("ubiquity:trace_create_end", endQuerySpan)
+	db.Callback().Query().Before("gorm:query").Register("ubiquity:trace_query_start", startQuerySpan("query"))
+	db.Callback().Query().After("gorm:query").Register("ubiquity:trace_query_end", endQuerySpan)
+	db.Callback().Update().Before("gorm:update").Register("ubiquity:trace_update_start", startQuerySpan("update"))
+	db.Callback().Update().After("gorm:update").Register("ubiquity:trace_update_end", endQuerySpan)
+	db.Callback().Delete().Before("gorm:delete").Register("ubiquity:trace_delete_start", startQuerySpan("delete"))
+	db.Callback().Delete().After("gorm:delete").Register("ubiquity:trace_delete_end", endQuerySpan)
+	db.Callback().RowQuery().Before("gorm:row_query").Register("ubiquity:trace_row_query_start", startQuerySpan("row_query"))
+	db.Callback().RowQuery().After("gorm:row_query").Register("ubiquity:trace_row_query_end", endQuerySpan)
+}
+
+const querySpanKey = "ubiquity:span"
+
+func startQuerySpan(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		span := tracing.StartChildSpan("db."+operation, tracing.KindClient, logs.Args{
+			{"db.system", scope.Dialect().GetName()},
+			{"db.table", scope.TableName()},
+		})
+		if span != nil {
+			scope.InstanceSet(querySpanKey, span)
+		}
+	}
+}
+
+func endQuerySpan(scope *gorm.Scope) {
+	value, ok := scope.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span := value.(*tracing.Span)
+	span.SetAttribute("db.statement", scope.SQL)
+	if err := scope.DB().Error; err != nil && err != gorm.ErrRecordNotFound {
+		span.SetError(err)
+	}
+	span.End()
+}
+
+// GetDatabaseDialectAndDSN validates config and returns the dialect and the DSN to pass to gorm.Open
+func GetDatabaseDialectAndDSN(config resources.DatabaseConfig, ubiquityConfigPath string) (string, string, error) {
+	dialect := strings.ToLower(config.Dialect)
+	switch dialect {
+	case "", resources.DatabaseDialectSqlite, "sqlite":
+		dsn := config.DSN
+		if dsn == "" {
+			dsn = path.Join(ubiquityConfigPath, resources.DefaultSqliteDBFileName)
+		}
+		return resources.DatabaseDialectSqlite, dsn, nil
+	case resources.DatabaseDialectPostgres, "postgresql":
+		if config.DSN == "" {
+			return "", "", fmt.Errorf("Error in config file. The parameter [DatabaseConfig.DSN] is required for dialect [%s]", dialect)
+		}
+		return resources.DatabaseDialectPostgres, config.DSN, nil
+	case resources.DatabaseDialectMysql:
+		if config.DSN == "" {
+			return "", "", fmt.Errorf("Error in config file. The parameter [DatabaseConfig.DSN] is required for dialect [%s]", dialect)
+		}
+		return resources.DatabaseDialectMysql, mysqlDSNWithParseTime(config.DSN), nil
+	default:
+		return "", "", fmt.Errorf("Error in config file. The parameter [DatabaseConfig.Dialect] can be the following values [%s,%s,%s] (given [%s])",
+			resources.DatabaseDialectSqlite, resources.DatabaseDialectPostgres, resources.DatabaseDialectMysql, config.Dialect)
+	}
+}
+
+// mysqlDSNWithParseTime makes the mysql driver scan DATETIME columns into time.Time, which gorm.Model requires,
+// so a parseTime given in the DSN is replaced
+func mysqlDSNWithParseTime(dsn string) string {
+	base, query := dsn, ""
+	if i := strings.Index(dsn, "?"); i >= 0 {
+		base, query = dsn[:i], dsn[i+1:]
+	}
+	params := []string{}
+	for _, param := range strings.Split(query, "&") {
+		if param == "" || strings.HasPrefix(strings.ToLower(param), "parsetime=") {
+			continue
+		}
+		params = append(params, param)
+	}
+	params = append(params, "parseTime=True")
+	return base + "?" + strings.Join(params, "&")
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Database", func() {
+	Context(".GetDatabaseDialectAndDSN", func() {
+		cases := []struct {
+			description string
+			config      resources.DatabaseConfig
+			dialect     string
+			dsn         string
+		}{
+			{"should default to the sqlite file in the config path", resources.DatabaseConfig{}, resources.DatabaseDialectSqlite, "/opt/ubiquity/.config/ubiquity.db"},
+			{"should keep the given sqlite DSN", resources.DatabaseConfig{Dialect: "sqlite", DSN: "/tmp/u.db"}, resources.DatabaseDialectSqlite, "/tmp/u.db"},
+			{"should pass the postgres DSN as is", resources.DatabaseConfig{Dialect: "PostgreSQL", DSN: "host=db user=u dbname=ubiquity"}, resources.DatabaseDialectPostgres, "host=db user=u dbname=ubiquity"},
+			{"should add parseTime to a mysql DSN without parameters", resources.DatabaseConfig{Dialect: "mysql", DSN: "u:p@tcp(db:3306)/ubiquity"}, resources.DatabaseDialectMysql, "u:p@tcp(db:3306)/ubiquity?parseTime=True"},
+			{"should add parseTime to the parameters of a mysql DSN", resources.DatabaseConfig{Dialect: "mysql", DSN: "u:p@tcp(db:3306)/ubiquity?charset=utf8"}, resources.DatabaseDialectMysql, "u:p@tcp(db:3306)/ubiquity?charset=utf8&parseTime=True"},
+			{"should replace a disabled parseTime of a mysql DSN", resources.DatabaseConfig{Dialect: "mysql", DSN: "u:p@tcp(db:3306)/ubiquity?parseTime=false&charset=utf8"}, resources.DatabaseDialectMysql, "u:p@tcp(db:3306)/ubiquity?charset=utf8&parseTime=True"},
+			{"should keep an enabled parseTime of a mysql DSN once", resources.DatabaseConfig{Dialect: "mysql", DSN: "u:p@tcp(db:3306)/ubiquity?parsetime=true"}, resources.DatabaseDialectMysql, "u:p@tcp(db:3306)/ubiquity?parseTime=True"},
+		}
+		for _, c := range cases {
+			c := c
+			It(c.description, func() {
+				dialect, dsn, err := model.GetDatabaseDialectAndDSN(c.config, "/opt/ubiquity/.config")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(dialect).To(Equal(c.dialect))
+				Expect(dsn).To(Equal(c.dsn))
+			})
+		}
+
+		invalidCases := []struct {
+			description string
+			config      resources.DatabaseConfig
+		}{
+			{"should fail for an unknown dialect", resources.DatabaseConfig{Dialect: "oracle", DSN: "dsn"}},
+			{"should fail for postgres without a DSN", resources.DatabaseConfig{Dialect: "postgres"}},
+			{"should fail for mysql without a DSN", resources.DatabaseConfig{Dialect: "mysql"}},
+		}
+		for _, c := range invalidCases {
+			c := c
+			It(c.description, func() {
+				_, _, err := model.GetDatabaseDialectAndDSN(c.config, "/opt/ubiquity/.config")
+				Expect(err).To(HaveOccurred())
+			})
+		}
+	})
+})
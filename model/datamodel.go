@@ -18,9 +18,9 @@ package model
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/midoblgsm/ubiquity/resources"
 )
 
@@ -37,10 +37,18 @@ func GetBackendForVolume(db *gorm.DB, name string) (string, error) {
 	}
 	return volume.Backend, err
 }
+func GetVolumeByName(db *gorm.DB, name string) (resources.Volume, error) {
+	var volume resources.Volume
+	err := db.Where("name = ? ", name).First(&volume).Error
+	return volume, err
+}
 func VolumeExists(db *gorm.DB, name string) (bool, error) {
 	var volume resources.Volume
 	err := db.Where("name = ? ", name).First(&volume).Error
 	if err != nil {
+		if err.Error() == "record not found" {
+			return false, nil
+		}
 		return false, err
 	}
 	return true, err
@@ -49,7 +57,42 @@ func DeleteVolume(db *gorm.DB, volume *resources.Volume) *gorm.DB {
 	return db.Delete(volume)
 }
 
+func UpdateVolumeState(db *gorm.DB, volume *resources.Volume, state string) error {
+	return db.Model(&resources.Volume{}).Where("id = ?", volume.ID).Update("state", state).Error
+}
+
+// IsVolumeStateTransitional return true for the states a volume is in only while an operation is running on it
+func IsVolumeStateTransitional(state string) bool {
+	switch state {
+	case resources.VolumeStateCreating, resources.VolumeStateAttaching, resources.VolumeStateDetaching, resources.VolumeStateDeleting:
+		return true
+	}
+	return false
+}
+
+// UpdateVolumeMountpoint set the volume mountpoint, and its state to attached (or available if the mountpoint is empty)
 func UpdateVolumeMountpoint(db *gorm.DB, volume *resources.Volume, mountpoint string) error {
-	err := db.Model(volume).Update("mountpoint", mountpoint).Error
+	state := resources.VolumeStateAttached
+	if mountpoint == "" {
+		state = resources.VolumeStateAvailable
+	}
+	err := db.Model(volume).Updates(map[string]interface{}{"mountpoint": mountpoint, "state": state}).Error
 	return err
 }
+
+// SoftDeleteVolume move the volume to the deleted state, its row and storage are kept until it is purged
+func SoftDeleteVolume(db *gorm.DB, volume *resources.Volume, removedAt time.Time) error {
+	return db.Model(&resources.Volume{}).Where("id = ?", volume.ID).Updates(map[string]interface{}{"state": resources.VolumeStateDeleted, "removed_at": removedAt}).Error
+}
+
+// UndeleteVolume move a soft deleted volume back to the available state
+func UndeleteVolume(db *gorm.DB, volume *resources.Volume) error {
+	return db.Model(&resources.Volume{}).Where("id = ?", volume.ID).Updates(map[string]interface{}{"state": resources.VolumeStateAvailable, "removed_at": nil}).Error
+}
+
+// ListVolumesRemovedBefore return the soft deleted volumes that were removed before the given time
+func ListVolumesRemovedBefore(db *gorm.DB, before time.Time) ([]resources.Volume, error) {
+	var volumes []resources.Volume
+	err := db.Where("state = ? AND removed_at < ?", resources.VolumeStateDeleted, before).Order("removed_at").Find(&volumes).Error
+	return volumes, err
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Datamodel", func() {
+	var (
+		dbDir string
+		db    *gorm.DB
+		err   error
+	)
+	BeforeEach(func() {
+		dbDir, err = ioutil.TempDir("", "ubiquity-model")
+		Expect(err).ToNot(HaveOccurred())
+		db, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = model.NewMigrator(db).Up(false)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		db.Close()
+		os.RemoveAll(dbDir)
+	})
+
+	Context("soft delete", func() {
+		It("should list only the volumes removed before the given time and undelete them", func() {
+			now := time.Now()
+			for _, name := range []string{"vol1", "vol2", "vol3"} {
+				Expect(db.Create(&resources.Volume{Name: name, Backend: resources.LocalHost, State: resources.VolumeStateAvailable}).Error).ToNot(HaveOccurred())
+			}
+			vol1, err := model.GetVolumeByName(db, "vol1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.SoftDeleteVolume(db, &vol1, now.Add(-2*time.Hour))).To(Succeed())
+			vol2, err := model.GetVolumeByName(db, "vol2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.SoftDeleteVolume(db, &vol2, now)).To(Succeed())
+
+			expired, err := model.ListVolumesRemovedBefore(db, now.Add(-time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(expired)).To(Equal(1))
+			Expect(expired[0].Name).To(Equal("vol1"))
+			Expect(expired[0].State).To(Equal(resources.VolumeStateDeleted))
+
+			Expect(model.UndeleteVolume(db, &vol1)).To(Succeed())
+			vol1, err = model.GetVolumeByName(db, "vol1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vol1.State).To(Equal(resources.VolumeStateAvailable))
+			Expect(vol1.RemovedAt).To(BeNil())
+		})
+	})
+
+	Context("volume operations", func() {
+		It("should record the attach and detach of a volume and fail the interrupted ones", func() {
+			Expect(db.Create(&resources.Volume{Name: "vol1", Backend: resources.LocalHost, State: resources.VolumeStateAvailable}).Error).ToNot(HaveOccurred())
+			vol1, err := model.GetVolumeByName(db, "vol1")
+			Expect(err).ToNot(HaveOccurred())
+
+			attach, err := model.StartVolumeOperation(db, vol1, resources.VolumeOperationAttach, "host1", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.FinishVolumeOperation(db, attach, "/mnt/vol1", nil)).To(Succeed())
+			detach, err := model.StartVolumeOperation(db, vol1, resources.VolumeOperationDetach, "host1", "/mnt/vol1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.FinishVolumeOperation(db, detach, "", fmt.Errorf("device busy"))).To(Succeed())
+			_, err = model.StartVolumeOperation(db, vol1, resources.VolumeOperationDetach, "host1", "/mnt/vol1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(model.FailInterruptedVolumeOperations(db)).To(Succeed())
+
+			records, err := model.ListVolumeOperations(db, "vol1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(records)).To(Equal(3))
+			Expect(records[0].Operation).To(Equal(resources.VolumeOperationAttach))
+			Expect(records[0].Host).To(Equal("host1"))
+			Expect(records[0].Mountpoint).To(Equal("/mnt/vol1"))
+			Expect(records[0].Outcome).To(Equal(resources.VolumeOperationSucceeded))
+			Expect(records[0].EndedAt).ToNot(BeNil())
+			Expect(records[1].Outcome).To(Equal(resources.VolumeOperationFailed))
+			Expect(records[1].Error).To(Equal("device busy"))
+			Expect(records[2].Outcome).To(Equal(resources.VolumeOperationFailed))
+			Expect(records[2].EndedAt).ToNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,119 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+)
+
+// StartVolumeOperation inserts a running attach or detach record, call FinishVolumeOperation with it once the backend returns
+func StartVolumeOperation(db *gorm.DB, volume resources.Volume, operation, host, mountpoint string) (*resources.VolumeOperationRecord, error) {
+	record := &resources.VolumeOperationRecord{
+		VolumeID:   volume.ID,
+		VolumeName: volume.Name,
+		Backend:    volume.Backend,
+		Operation:  operation,
+		Host:       host,
+		Mountpoint: mountpoint,
+		StartedAt:  time.Now(),
+		Outcome:    resources.VolumeOperationRunning,
+	}
+	err := db.Create(record).Error
+	return record, err
+}
+
+// FinishVolumeOperation sets the end time and outcome of the record, and its mountpoint if one is given
+func FinishVolumeOperation(db *gorm.DB, record *resources.VolumeOperationRecord, mountpoint string, opErr error) error {
+	endedAt := time.Now()
+	updates := map[string]interface{}{"ended_at": endedAt, "outcome": resources.VolumeOperationSucceeded}
+	if opErr != nil {
+		updates["outcome"] = resources.VolumeOperationFailed
+		updates["error"] = opErr.Error()
+	}
+	if mountpoint != "" {
+		updates["mountpoint"] = mountpoint
+	}
+	return db.Model(&resources.VolumeOperationRecord{}).Where("id = ?", record.ID).Updates(updates).Error
+}
+
+// FailInterruptedVolumeOperations closes the records left running by a server that stopped during the operation
+func FailInterruptedVolumeOperations(db *gorm.DB) error {
+	return db.Model(&resources.VolumeOperationRecord{}).Where("outcome = ?", resources.VolumeOperationRunning).
+		Updates(map[string]interface{}{"ended_at": time.Now(), "outcome": resources.VolumeOperationFailed, "error": "interrupted by a server restart"}).Error
+}
+
+// ListVolumeOperations returns the attach and detach records of a volume name, oldest first.
+// Records of removed volumes that had the same name are included.
+func ListVolumeOperations(db *gorm.DB, name string) ([]resources.VolumeOperationRecord, error) {
+	var records []resources.VolumeOperationRecord
+	err := db.Where("volume_name = ?", name).Order("started_at, id").Find(&records).Error
+	return records, err
+}
+
+func init() {
+	RegisterMigrations(Migration{
+		Version:     8,
+		Description: "create volume_operations table",
+		Up: func(tx *gorm.DB) error {
+			type volumeOperation struct {
+				ID         uint   `gorm:"primary_key"`
+				VolumeID   uint   `gorm:"index"`
+				VolumeName string `gorm:"index"`
+				Backend    string
+				Operation  string
+				Host       string
+				Mountpoint string
+				StartedAt  time.Time
+				EndedAt    *time.Time
+				Outcome    string
+				Error      string
+			}
+			return tx.Table("volume_operations").AutoMigrate(&volumeOperation{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("volume_operations").Error
+		},
+		DropsData: true,
+	})
+	RegisterInventoryTable(InventoryTable{
+		Name: "volume_operations",
+		Export: func(db *gorm.DB) (interface{}, error) {
+			var records []resources.VolumeOperationRecord
+			if err := db.Order("id").Find(&records).Error; err != nil {
+				return nil, err
+			}
+			return records, nil
+		},
+		Import: func(tx *gorm.DB, data json.RawMessage) error {
+			var records []resources.VolumeOperationRecord
+			if err := json.Unmarshal(data, &records); err != nil {
+				return err
+			}
+			for _, record := range records {
+				record := record
+				if err := tx.Create(&record).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,293 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// InventoryFormatVersion is the version of the Inventory JSON document, bump it on incompatible changes
+const InventoryFormatVersion = 1
+
+// Inventory is a full dump of the volumes known to the server, used to move the server state to another database
+type Inventory struct {
+	FormatVersion int                        `json:"format_version"`
+	SchemaVersion int64                      `json:"schema_version"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	Volumes       []InventoryVolume          `json:"volumes"`
+	Tables        map[string]json.RawMessage `json:"tables"` // backend specific tables, keyed by table name
+}
+
+// InventoryVolume is the exported form of a resources.Volume row
+type InventoryVolume struct {
+	ID            uint       `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	Backend       string     `json:"backend"`
+	BackendType   string     `json:"backend_type,omitempty"`
+	CapacityBytes uint64     `json:"capacity_bytes"`
+	Mountpoint    string     `json:"mountpoint"`
+	State         string     `json:"state"`
+	RemovedAt     *time.Time `json:"removed_at,omitempty"`
+	AccessMode    string     `json:"access_mode,omitempty"`
+}
+
+// InventoryTable lets a backend add its own table to the inventory.
+// Rows must keep their ID and VolumeID so they still point to the right volume after import.
+type InventoryTable struct {
+	Name   string
+	Export func(db *gorm.DB) (interface{}, error)
+	Import func(tx *gorm.DB, data json.RawMessage) error
+}
+
+var (
+	inventoryTables     = make(map[string]InventoryTable)
+	inventoryTablesLock = &sync.Mutex{}
+)
+
+// RegisterInventoryTable adds a backend table to the inventory. Backends call it from init().
+func RegisterInventoryTable(table InventoryTable) {
+	inventoryTablesLock.Lock()
+	defer inventoryTablesLock.Unlock()
+	if _, exists := inventoryTables[table.Name]; exists {
+		panic(fmt.Sprintf("inventory table %s registered twice", table.Name))
+	}
+	inventoryTables[table.Name] = table
+}
+
+func getInventoryTables() []InventoryTable {
+	inventoryTablesLock.Lock()
+	defer inventoryTablesLock.Unlock()
+	tables := make([]InventoryTable, 0, len(inventoryTables))
+	for _, table := range inventoryTables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// ExportInventory dumps the volumes table and all the registered backend tables
+func ExportInventory(db *gorm.DB) (Inventory, error) {
+	logger := logs.GetComponentLogger(logs.ComponentModel)
+	defer logger.Trace(logs.DEBUG)()
+
+	migrator := NewMigrator(db)
+	schemaVersion, err := migrator.CurrentVersion()
+	if err != nil {
+		return Inventory{}, err
+	}
+	// the export only reads the database, it does not migrate it
+	pending, err := migrator.Pending()
+	if err != nil {
+		return Inventory{}, err
+	}
+	if len(pending) > 0 {
+		return Inventory{}, logger.ErrorRet(&inventorySchemaNotCurrentError{schemaVersion, len(pending)}, "failed")
+	}
+
+	var volumes []resources.Volume
+	if err := db.Order("id").Find(&volumes).Error; err != nil {
+		return Inventory{}, logger.ErrorRet(err, "failed to read volumes")
+	}
+	inventory := Inventory{
+		FormatVersion: InventoryFormatVersion,
+		SchemaVersion: schemaVersion,
+		ExportedAt:    time.Now(),
+		Volumes:       make([]InventoryVolume, 0, len(volumes)),
+		Tables:        make(map[string]json.RawMessage),
+	}
+	for _, volume := range volumes {
+		inventory.Volumes = append(inventory.Volumes, InventoryVolume{
+			ID:            volume.ID,
+			CreatedAt:     volume.CreatedAt,
+			UpdatedAt:     volume.UpdatedAt,
+			Name:          volume.Name,
+			Backend:       volume.Backend,
+			BackendType:   volume.BackendType,
+			CapacityBytes: volume.CapacityBytes,
+			Mountpoint:    volume.Mountpoint,
+			State:         volume.State,
+			RemovedAt:     volume.RemovedAt,
+			AccessMode:    volume.AccessMode,
+		})
+	}
+
+	for _, table := range getInventoryTables() {
+		if !db.HasTable(table.Name) {
+			continue
+		}
+		rows, err := table.Export(db)
+		if err != nil {
+			return Inventory{}, logger.ErrorRet(err, "failed to export table", logs.Args{{"table", table.Name}})
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return Inventory{}, logger.ErrorRet(err, "json.Marshal failed", logs.Args{{"table", table.Name}})
+		}
+		inventory.Tables[table.Name] = data
+	}
+	logger.Info("inventory exported", logs.Args{{"volumes", len(inventory.Volumes)}, {"schemaVersion", schemaVersion}})
+	return inventory, nil
+}
+
+// ImportInventory loads an inventory into an empty database in a single transaction.
+// The schema must be migrated to at least the inventory schema version before the import.
+func ImportInventory(db *gorm.DB, inventory Inventory) error {
+	logger := logs.GetComponentLogger(logs.ComponentModel)
+	defer logger.Trace(logs.DEBUG)()
+
+	if inventory.FormatVersion != InventoryFormatVersion {
+		return logger.ErrorRet(&inventoryFormatVersionError{inventory.FormatVersion}, "failed")
+	}
+	schemaVersion, err := NewMigrator(db).CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if inventory.SchemaVersion > schemaVersion {
+		return logger.ErrorRet(&inventorySchemaTooNewError{inventory.SchemaVersion, schemaVersion}, "failed")
+	}
+	var existing int
+	if err := db.Model(&resources.Volume{}).Count(&existing).Error; err != nil {
+		return logger.ErrorRet(err, "failed to count volumes")
+	}
+	if existing != 0 {
+		return logger.ErrorRet(&inventoryTargetNotEmptyError{existing}, "failed")
+	}
+
+	tables := getInventoryTables()
+	knownTables := make(map[string]bool)
+	for _, table := range tables {
+		knownTables[table.Name] = true
+	}
+	for name := range inventory.Tables {
+		if !knownTables[name] {
+			return logger.ErrorRet(fmt.Errorf("Inventory contains unknown table [%s]", name), "failed")
+		}
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return logger.ErrorRet(tx.Error, "failed to begin transaction")
+	}
+	for _, inventoryVolume := range inventory.Volumes {
+		volume := resources.Volume{
+			Name:          inventoryVolume.Name,
+			Backend:       inventoryVolume.Backend,
+			BackendType:   inventoryVolume.BackendType,
+			CapacityBytes: inventoryVolume.CapacityBytes,
+			Mountpoint:    inventoryVolume.Mountpoint,
+			State:         inventoryVolume.State,
+			RemovedAt:     inventoryVolume.RemovedAt,
+			AccessMode:    inventoryVolume.AccessMode,
+		}
+		if volume.State == "" {
+			// exported before volumes had a state
+			volume.State = resources.VolumeStateAvailable
+		}
+		if volume.AccessMode == "" {
+			volume.AccessMode = resources.AccessModeSingleWriter
+		}
+		if volume.BackendType == "" {
+			// exported before the named backends
+			volume.BackendType = volume.Backend
+		}
+		volume.ID = inventoryVolume.ID
+		volume.CreatedAt = inventoryVolume.CreatedAt
+		volume.UpdatedAt = inventoryVolume.UpdatedAt
+		if err := tx.Create(&volume).Error; err != nil {
+			tx.Rollback()
+			return logger.ErrorRet(err, "failed to insert volume", logs.Args{{"volume", inventoryVolume.Name}})
+		}
+	}
+	if err := ResetIDSequence(tx, "volumes"); err != nil {
+		tx.Rollback()
+		return logger.ErrorRet(err, "failed to reset id sequence", logs.Args{{"table", "volumes"}})
+	}
+	for _, table := range tables {
+		data, exists := inventory.Tables[table.Name]
+		if !exists {
+			continue
+		}
+		if err := table.Import(tx, data); err != nil {
+			tx.Rollback()
+			return logger.ErrorRet(err, "failed to import table", logs.Args{{"table", table.Name}})
+		}
+		if err := ResetIDSequence(tx, table.Name); err != nil {
+			tx.Rollback()
+			return logger.ErrorRet(err, "failed to reset id sequence", logs.Args{{"table", table.Name}})
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return logger.ErrorRet(err, "failed to commit")
+	}
+	logger.Info("inventory imported", logs.Args{{"volumes", len(inventory.Volumes)}, {"schemaVersion", inventory.SchemaVersion}})
+	return nil
+}
+
+// ResetIDSequence moves the id sequence of table past the highest id.
+// Only PostgreSQL needs it after rows were inserted with explicit ids, SQLite and MySQL track it by themselves.
+func ResetIDSequence(tx *gorm.DB, table string) error {
+	if tx.Dialect().GetName() != resources.DatabaseDialectPostgres {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %s", table, table)).Error
+}
+
+type inventoryFormatVersionError struct {
+	formatVersion int
+}
+
+func (e *inventoryFormatVersionError) Error() string {
+	return fmt.Sprintf("Inventory format version [%d] is not supported (expected [%d])", e.formatVersion, InventoryFormatVersion)
+}
+
+type inventorySchemaTooNewError struct {
+	inventorySchemaVersion int64
+	schemaVersion          int64
+}
+
+func (e *inventorySchemaTooNewError) Error() string {
+	return fmt.Sprintf("Inventory was exported from schema version [%d] but the database is at version [%d], upgrade the server first",
+		e.inventorySchemaVersion, e.schemaVersion)
+}
+
+type inventorySchemaNotCurrentError struct {
+	schemaVersion int64
+	pending       int
+}
+
+func (e *inventorySchemaNotCurrentError) Error() string {
+	return fmt.Sprintf("Database schema is at version [%d] with [%d] pending migrations, apply them with --migrate before exporting the inventory",
+		e.schemaVersion, e.pending)
+}
+
+type inventoryTargetNotEmptyError struct {
+	volumes int
+}
+
+func (e *inventoryTargetNotEmptyError) Error() string {
+	return fmt.Sprintf("Inventory can be imported only into an empty database, but it already has [%d] volumes", e.volumes)
+}
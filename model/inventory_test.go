@@ -0,0 +1,112 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inventory", func() {
+	var (
+		sourceDir string
+		targetDir string
+		source    *gorm.DB
+		target    *gorm.DB
+		err       error
+	)
+	BeforeEach(func() {
+		sourceDir, err = ioutil.TempDir("", "ubiquity-inventory-source")
+		Expect(err).ToNot(HaveOccurred())
+		targetDir, err = ioutil.TempDir("", "ubiquity-inventory-target")
+		Expect(err).ToNot(HaveOccurred())
+		source, err = model.OpenDatabase(resources.DatabaseConfig{}, sourceDir)
+		Expect(err).ToNot(HaveOccurred())
+		target, err = model.OpenDatabase(resources.DatabaseConfig{}, targetDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = model.NewMigrator(source).Up(false)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = model.NewMigrator(target).Up(false)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		source.Close()
+		target.Close()
+		os.RemoveAll(sourceDir)
+		os.RemoveAll(targetDir)
+	})
+
+	It("should export the volumes and import them into an empty database with the same ids", func() {
+		Expect(source.Create(&resources.Volume{Name: "vol1", Backend: resources.LocalHost}).Error).ToNot(HaveOccurred())
+		Expect(source.Create(&resources.Volume{Name: "vol2", Backend: resources.LocalHost, Mountpoint: "/mnt/vol2"}).Error).ToNot(HaveOccurred())
+		Expect(model.DeleteVolume(source, &resources.Volume{Model: gorm.Model{ID: 1}}).Error).ToNot(HaveOccurred())
+
+		inventory, err := model.ExportInventory(source)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inventory.FormatVersion).To(Equal(model.InventoryFormatVersion))
+		Expect(len(inventory.Volumes)).To(Equal(1))
+
+		Expect(model.ImportInventory(target, inventory)).To(Succeed())
+		volume, err := model.GetVolume(target, "vol2", resources.LocalHost)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(volume.ID).To(Equal(uint(2)))
+		Expect(volume.Mountpoint).To(Equal("/mnt/vol2"))
+	})
+
+	It("should refuse to import into a database that already has volumes", func() {
+		inventory, err := model.ExportInventory(source)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Create(&resources.Volume{Name: "vol1", Backend: resources.LocalHost}).Error).ToNot(HaveOccurred())
+		Expect(model.ImportInventory(target, inventory)).ToNot(Succeed())
+	})
+
+	It("should set the backend type of the volumes exported before the named backends", func() {
+		inventory, err := model.ExportInventory(source)
+		Expect(err).ToNot(HaveOccurred())
+		inventory.Volumes = []model.InventoryVolume{{ID: 1, Name: "vol1", Backend: resources.SCBE}, {ID: 2, Name: "vol2", Backend: "scbe-dc2", BackendType: resources.SCBE}}
+		Expect(model.ImportInventory(target, inventory)).To(Succeed())
+		volume, err := model.GetVolume(target, "vol1", resources.SCBE)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(volume.BackendType).To(Equal(resources.SCBE))
+		volume, err = model.GetVolume(target, "vol2", "scbe-dc2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(volume.BackendType).To(Equal(resources.SCBE))
+	})
+
+	It("should refuse to export a database that is not migrated", func() {
+		otherDir, err := ioutil.TempDir("", "ubiquity-inventory-other")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(otherDir)
+		other, err := model.OpenDatabase(resources.DatabaseConfig{}, otherDir)
+		Expect(err).ToNot(HaveOccurred())
+		defer other.Close()
+		_, err = model.ExportInventory(other)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("pending migrations"))
+		Expect(other.HasTable(&resources.Volume{})).To(BeFalse())
+	})
+
+	It("should refuse an unknown format version", func() {
+		Expect(model.ImportInventory(target, model.Inventory{FormatVersion: model.InventoryFormatVersion + 1})).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,291 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Labels are user key/value pairs attached to a volume. They are stored in their own table and never passed to the backends,
+// unlike the volume Metadata (profile, size, fileset, quota...) which are creation options.
+
+// VolumeLabel is a row in the volume_labels table, one per label of a volume
+type VolumeLabel struct {
+	ID       uint   `gorm:"primary_key" json:"id"`
+	VolumeID uint   `gorm:"index" json:"volume_id"`
+	Key      string `gorm:"column:label_key;index:idx_volume_labels_key_value" json:"key"` // key is reserved in mysql
+	Value    string `gorm:"column:label_value;index:idx_volume_labels_key_value" json:"value"`
+}
+
+func (VolumeLabel) TableName() string {
+	return "volume_labels"
+}
+
+const (
+	maxLabelNameLength   = 63
+	maxLabelPrefixLength = 253
+	maxLabelValueLength  = 63
+)
+
+var (
+	labelNameRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	labelPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateLabelKey checks a label key with the kubernetes rules: an optional DNS subdomain prefix and a '/', then a name of up to 63 characters
+func ValidateLabelKey(key string) error {
+	name := key
+	if i := strings.Index(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) == 0 || len(prefix) > maxLabelPrefixLength || !labelPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("invalid label key %q: the prefix must be a DNS subdomain", key)
+		}
+	}
+	if len(name) == 0 || len(name) > maxLabelNameLength || !labelNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid label key %q: the name must be up to %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", key, maxLabelNameLength)
+	}
+	return nil
+}
+
+// ValidateLabelValue checks a label value, it can be empty
+func ValidateLabelValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxLabelValueLength || !labelNameRegexp.MatchString(value) {
+		return fmt.Errorf("invalid label value %q: it must be up to %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", value, maxLabelValueLength)
+	}
+	return nil
+}
+
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidateLabelKey(key); err != nil {
+			return err
+		}
+		if err := ValidateLabelValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	selectorEquals       = "="
+	selectorNotEquals    = "!="
+	selectorExists       = "exists"
+	selectorDoesNotExist = "!"
+)
+
+type selectorRequirement struct {
+	key      string
+	operator string
+	value    string
+}
+
+// LabelSelector is a parsed label selector, all its requirements must match
+type LabelSelector struct {
+	requirements []selectorRequirement
+}
+
+// ParseLabelSelector parses a comma separated list of requirements, each one of `key=value`, `key==value`, `key!=value`, `key` or `!key`.
+// Set based requirements (`key in (a,b)`) are not supported. An empty selector matches everything.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var parsed LabelSelector
+	if strings.TrimSpace(selector) == "" {
+		return parsed, nil
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		var requirement selectorRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			requirement = selectorRequirement{strings.TrimSpace(parts[0]), selectorNotEquals, strings.TrimSpace(parts[1])}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			requirement = selectorRequirement{strings.TrimSpace(parts[0]), selectorEquals, strings.TrimSpace(parts[1])}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			requirement = selectorRequirement{strings.TrimSpace(parts[0]), selectorEquals, strings.TrimSpace(parts[1])}
+		case strings.HasPrefix(term, "!"):
+			requirement = selectorRequirement{key: strings.TrimSpace(term[1:]), operator: selectorDoesNotExist}
+		default:
+			requirement = selectorRequirement{key: term, operator: selectorExists}
+		}
+		if err := ValidateLabelKey(requirement.key); err != nil {
+			return LabelSelector{}, fmt.Errorf("invalid label selector %q: %s", selector, err.Error())
+		}
+		if err := ValidateLabelValue(requirement.value); err != nil {
+			return LabelSelector{}, fmt.Errorf("invalid label selector %q: %s", selector, err.Error())
+		}
+		parsed.requirements = append(parsed.requirements, requirement)
+	}
+	return parsed, nil
+}
+
+// Matches returns true if the labels satisfy all the requirements of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, requirement := range s.requirements {
+		value, exists := labels[requirement.key]
+		switch requirement.operator {
+		case selectorEquals:
+			if !exists || value != requirement.value {
+				return false
+			}
+		case selectorNotEquals:
+			if exists && value == requirement.value {
+				return false
+			}
+		case selectorExists:
+			if !exists {
+				return false
+			}
+		case selectorDoesNotExist:
+			if exists {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s LabelSelector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// GetVolumeLabels returns the labels of one volume
+func GetVolumeLabels(db *gorm.DB, volumeID uint) (map[string]string, error) {
+	labels, err := GetLabelsForVolumes(db, []uint{volumeID})
+	if err != nil {
+		return nil, err
+	}
+	return labels[volumeID], nil
+}
+
+// GetLabelsForVolumes returns the labels of the given volumes keyed by volume ID, volumes without labels are not in the map
+func GetLabelsForVolumes(db *gorm.DB, volumeIDs []uint) (map[uint]map[string]string, error) {
+	labels := make(map[uint]map[string]string)
+	if len(volumeIDs) == 0 {
+		return labels, nil
+	}
+	var rows []VolumeLabel
+	if err := db.Where("volume_id IN (?)", volumeIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if labels[row.VolumeID] == nil {
+			labels[row.VolumeID] = make(map[string]string)
+		}
+		labels[row.VolumeID][row.Key] = row.Value
+	}
+	return labels, nil
+}
+
+// SetVolumeLabels replaces all the labels of a volume
+func SetVolumeLabels(db *gorm.DB, volumeID uint, labels map[string]string) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := tx.Where("volume_id = ?", volumeID).Delete(VolumeLabel{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for key, value := range labels {
+		if err := tx.Create(&VolumeLabel{VolumeID: volumeID, Key: key, Value: value}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// PatchVolumeLabels applies a JSON merge patch to the labels of a volume: a nil value removes the label, any other value sets it
+func PatchVolumeLabels(db *gorm.DB, volumeID uint, patch map[string]*string) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	for key, value := range patch {
+		if err := tx.Where("volume_id = ? AND label_key = ?", volumeID, key).Delete(VolumeLabel{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if err := tx.Create(&VolumeLabel{VolumeID: volumeID, Key: key, Value: *value}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func DeleteVolumeLabels(db *gorm.DB, volumeID uint) error {
+	return db.Where("volume_id = ?", volumeID).Delete(VolumeLabel{}).Error
+}
+
+func init() {
+	RegisterMigrations(Migration{
+		Version:     7,
+		Description: "create volume_labels table",
+		Up: func(tx *gorm.DB) error {
+			type volumeLabel struct {
+				ID       uint   `gorm:"primary_key"`
+				VolumeID uint   `gorm:"index"`
+				Key      string `gorm:"column:label_key;index:idx_volume_labels_key_value"`
+				Value    string `gorm:"column:label_value;index:idx_volume_labels_key_value"`
+			}
+			return tx.Table("volume_labels").AutoMigrate(&volumeLabel{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("volume_labels").Error
+		},
+		DropsData: true,
+	})
+	RegisterInventoryTable(InventoryTable{
+		Name: "volume_labels",
+		Export: func(db *gorm.DB) (interface{}, error) {
+			var rows []VolumeLabel
+			if err := db.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			return rows, nil
+		},
+		Import: func(tx *gorm.DB, data json.RawMessage) error {
+			var rows []VolumeLabel
+			if err := json.Unmarshal(data, &rows); err != nil {
+				return err
+			}
+			for _, row := range rows {
+				row := row
+				if err := tx.Create(&row).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
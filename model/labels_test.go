@@ -0,0 +1,110 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Labels", func() {
+	Context(".ParseLabelSelector", func() {
+		It("should match the equality, inequality and existence requirements", func() {
+			selector, err := model.ParseLabelSelector("team=data, env!=prod,tier, !legacy")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selector.Matches(map[string]string{"team": "data", "env": "dev", "tier": "gold"})).To(BeTrue())
+			Expect(selector.Matches(map[string]string{"team": "data", "tier": "gold"})).To(BeTrue())
+			Expect(selector.Matches(map[string]string{"team": "data", "env": "prod", "tier": "gold"})).To(BeFalse())
+			Expect(selector.Matches(map[string]string{"team": "web", "tier": "gold"})).To(BeFalse())
+			Expect(selector.Matches(map[string]string{"team": "data"})).To(BeFalse())
+			Expect(selector.Matches(map[string]string{"team": "data", "tier": "gold", "legacy": ""})).To(BeFalse())
+		})
+		It("should accept == and match everything with an empty selector", func() {
+			selector, err := model.ParseLabelSelector("example.com/team==data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selector.Matches(map[string]string{"example.com/team": "data"})).To(BeTrue())
+			selector, err = model.ParseLabelSelector("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selector.Matches(nil)).To(BeTrue())
+		})
+		It("should fail on invalid keys and values", func() {
+			_, err := model.ParseLabelSelector("team in (data,web)")
+			Expect(err).To(HaveOccurred())
+			_, err = model.ParseLabelSelector("=data")
+			Expect(err).To(HaveOccurred())
+			_, err = model.ParseLabelSelector("team=da ta")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context(".ValidateLabels", func() {
+		It("should accept kubernetes style labels and reject the others", func() {
+			Expect(model.ValidateLabels(map[string]string{"team": "data", "example.com/env": "", "a_b.c-d": "x.y_z"})).To(Succeed())
+			Expect(model.ValidateLabels(map[string]string{"-team": "data"})).ToNot(Succeed())
+			Expect(model.ValidateLabels(map[string]string{"team": "data/"})).ToNot(Succeed())
+			Expect(model.ValidateLabels(map[string]string{"Example.com/team": "data"})).ToNot(Succeed())
+		})
+	})
+	Context("volume labels table", func() {
+		var (
+			dbDir string
+			db    *gorm.DB
+			err   error
+		)
+		BeforeEach(func() {
+			dbDir, err = ioutil.TempDir("", "ubiquity-model")
+			Expect(err).ToNot(HaveOccurred())
+			db, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = model.NewMigrator(db).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			db.Close()
+			os.RemoveAll(dbDir)
+		})
+		It("should set, patch and delete the labels of a volume only", func() {
+			Expect(model.SetVolumeLabels(db, 1, map[string]string{"team": "data", "env": "prod"})).To(Succeed())
+			Expect(model.SetVolumeLabels(db, 2, map[string]string{"team": "web"})).To(Succeed())
+
+			dev := "dev"
+			Expect(model.PatchVolumeLabels(db, 1, map[string]*string{"env": &dev, "team": nil, "tier": &dev})).To(Succeed())
+			labels, err := model.GetLabelsForVolumes(db, []uint{1, 2, 3})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(Equal(map[uint]map[string]string{1: {"env": "dev", "tier": "dev"}, 2: {"team": "web"}}))
+
+			Expect(model.DeleteVolumeLabels(db, 1)).To(Succeed())
+			volumeLabels, err := model.GetVolumeLabels(db, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volumeLabels).To(BeEmpty())
+			volumeLabels, err = model.GetVolumeLabels(db, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volumeLabels).To(Equal(map[string]string{"team": "web"}))
+		})
+		It("should fail to set or patch the labels when the transaction cannot begin", func() {
+			db.Close()
+			dev := "dev"
+			Expect(model.SetVolumeLabels(db, 1, map[string]string{"team": "data"})).ToNot(Succeed())
+			Expect(model.PatchVolumeLabels(db, 1, map[string]*string{"env": &dev})).ToNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,330 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// The ubiquity servers that share a database elect a leader with a lease row in the leases table.
+// Every acquisition of the lease increments its fencing token, and the leader checks its token before each write,
+// so a leader that was paused past the lease expiry cannot overwrite the state of the new leader.
+// The expiry written by the leader is compared with the clock of the other servers, keep the clocks in sync.
+
+const ServerLeaseName = "ubiquity-server"
+
+// ReconcileLeaseName is the lease held by the server running the background reconcile, so that the servers serving
+// together do not repair the same drifts at the same time
+const ReconcileLeaseName = "ubiquity-reconcile"
+
+// Lease is the row of the leases table, one per lease name
+type Lease struct {
+	Name         string `gorm:"primary_key"`
+	HolderID     string
+	Hostname     string
+	PID          int `gorm:"column:pid"`
+	FencingToken int64
+	RenewedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func (Lease) TableName() string {
+	return "leases"
+}
+
+type LeaderElector struct {
+	logger        logs.Logger
+	database      *gorm.DB
+	name          string
+	holderID      string
+	hostname      string
+	pid           int
+	duration      time.Duration
+	retryInterval time.Duration
+	lock          sync.Mutex
+	fencingToken  int64
+	deadline      time.Time // local monotonic time after which the lease may belong to another server
+}
+
+// NewLeaderElector creates the leases table if needed, before the migrations, which must only run on the leader.
+// The holder ID is unique per process, so a restarted server acquires the lease with a new fencing token.
+func NewLeaderElector(db *gorm.DB, name string, duration, retryInterval time.Duration) (*LeaderElector, error) {
+	logger := logs.GetComponentLogger(logs.ComponentModel)
+	if duration <= 0 || retryInterval <= 0 || retryInterval >= duration {
+		return nil, logger.ErrorRet(&invalidLeaseConfigError{duration, retryInterval}, "failed")
+	}
+	if err := db.AutoMigrate(&Lease{}).Error; err != nil {
+		return nil, logger.ErrorRet(err, "failed to create leases table")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	pid := os.Getpid()
+	return &LeaderElector{
+		logger:        logger,
+		database:      db,
+		name:          name,
+		holderID:      fmt.Sprintf("%s-%d-%d", hostname, pid, time.Now().UnixNano()),
+		hostname:      hostname,
+		pid:           pid,
+		duration:      duration,
+		retryInterval: retryInterval,
+	}, nil
+}
+
+func (e *LeaderElector) HolderID() string {
+	return e.holderID
+}
+
+// FencingToken returns the token of the last acquisition, 0 if the lease was never acquired
+func (e *LeaderElector) FencingToken() int64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.fencingToken
+}
+
+// Acquire blocks until the lease is acquired
+func (e *LeaderElector) Acquire() error {
+	defer e.logger.Trace(logs.DEBUG, logs.Args{{"name", e.name}, {"holderID", e.holderID}})()
+	for {
+		acquired, current, err := e.TryAcquire()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		e.logger.Info("waiting for the lease", logs.Args{{"name", e.name}, {"holderID", current.HolderID}, {"hostname", current.Hostname}, {"pid", current.PID}, {"expiresAt", current.ExpiresAt}})
+		time.Sleep(e.retryInterval)
+	}
+}
+
+// TryAcquire takes the lease if it is free, expired or already held by this elector.
+// If the lease is held by another server it returns false and the current lease.
+func (e *LeaderElector) TryAcquire() (bool, Lease, error) {
+	defer e.logger.Trace(logs.DEBUG, logs.Args{{"name", e.name}})()
+	current, err := e.readOrCreateLease()
+	if err != nil {
+		return false, current, e.logger.ErrorRet(err, "failed to read lease")
+	}
+	start := time.Now()
+	if current.HolderID != e.holderID && current.HolderID != "" && current.ExpiresAt.After(start.UTC()) {
+		return false, current, nil
+	}
+
+	// the update only succeeds if nobody took the lease since it was read
+	token := current.FencingToken + 1
+	result := e.database.Model(&Lease{}).Where("name = ? AND fencing_token = ?", e.name, current.FencingToken).Updates(map[string]interface{}{
+		"holder_id":     e.holderID,
+		"hostname":      e.hostname,
+		"pid":           e.pid,
+		"fencing_token": token,
+		"renewed_at":    start.UTC(),
+		"expires_at":    start.Add(e.duration).UTC(),
+	})
+	if result.Error != nil {
+		return false, current, e.logger.ErrorRet(result.Error, "failed to acquire lease")
+	}
+	if result.RowsAffected != 1 {
+		current, err = e.readOrCreateLease()
+		return false, current, err
+	}
+
+	e.lock.Lock()
+	e.fencingToken = token
+	e.deadline = start.Add(e.duration)
+	e.lock.Unlock()
+	e.logger.Info("lease acquired", logs.Args{{"name", e.name}, {"holderID", e.holderID}, {"fencingToken", token}, {"previousHolderID", current.HolderID}})
+	return true, current, nil
+}
+
+// Renew extends the lease, it fails with a lease lost error if another server acquired the lease
+func (e *LeaderElector) Renew() error {
+	token := e.FencingToken()
+	start := time.Now()
+	result := e.database.Model(&Lease{}).Where("name = ? AND holder_id = ? AND fencing_token = ?", e.name, e.holderID, token).Updates(map[string]interface{}{
+		"renewed_at": start.UTC(),
+		"expires_at": start.Add(e.duration).UTC(),
+	})
+	if result.Error != nil {
+		return e.logger.ErrorRet(result.Error, "failed to renew lease")
+	}
+	if token == 0 || result.RowsAffected != 1 {
+		e.expire()
+		return e.logger.ErrorRet(&leaseLostError{e.name, e.holderID, token}, "failed")
+	}
+	e.lock.Lock()
+	e.deadline = start.Add(e.duration)
+	e.lock.Unlock()
+	return nil
+}
+
+// KeepAlive renews the lease every interval until stop is closed, and calls onLost once the lease is lost or could not be
+// renewed before it expired
+func (e *LeaderElector) KeepAlive(interval time.Duration, stop <-chan struct{}, onLost func(error)) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		err := e.Renew()
+		if err == nil {
+			continue
+		}
+		if _, lost := err.(*leaseLostError); lost {
+			onLost(err)
+			return
+		}
+		if err = e.checkDeadline(); err != nil {
+			onLost(err)
+			return
+		}
+	}
+}
+
+// Release gives up the lease, so another server can acquire it without waiting for the expiry
+func (e *LeaderElector) Release() error {
+	defer e.logger.Trace(logs.DEBUG, logs.Args{{"name", e.name}})()
+	token := e.FencingToken()
+	e.expire()
+	return e.database.Model(&Lease{}).Where("name = ? AND holder_id = ? AND fencing_token = ?", e.name, e.holderID, token).
+		Update("expires_at", time.Now().UTC()).Error
+}
+
+// CheckFencingToken fails if the lease expired locally or if another server acquired it since
+func (e *LeaderElector) CheckFencingToken(db *gorm.DB) error {
+	if err := e.checkDeadline(); err != nil {
+		return err
+	}
+	token := e.FencingToken()
+	var current Lease
+	if err := db.Where("name = ?", e.name).First(&current).Error; err != nil {
+		return err
+	}
+	if current.HolderID != e.holderID || current.FencingToken != token {
+		e.expire()
+		return &leaseLostError{e.name, e.holderID, token}
+	}
+	return nil
+}
+
+// RegisterFencingCallbacks makes every create, update and delete of db check the fencing token first.
+// The check reads the lease in the same transaction as the write when the write is part of one.
+func (e *LeaderElector) RegisterFencingCallbacks(db *gorm.DB) {
+	db.Callback().Create().Before("gorm:create").Register("ubiquity:fencing_create", e.fence)
+	db.Callback().Update().Before("gorm:update").Register("ubiquity:fencing_update", e.fence)
+	db.Callback().Delete().Before("gorm:delete").Register("ubiquity:fencing_delete", e.fence)
+}
+
+func (e *LeaderElector) fence(scope *gorm.Scope) {
+	if scope.HasError() || scope.TableName() == (Lease{}).TableName() {
+		return
+	}
+	if err := e.CheckFencingToken(scope.NewDB()); err != nil {
+		e.logger.Error("write rejected", logs.Args{{"table", scope.TableName()}, {"error", err}})
+		scope.Err(err)
+	}
+}
+
+func (e *LeaderElector) checkDeadline() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.fencingToken == 0 || !time.Now().Before(e.deadline) {
+		return &leaseExpiredError{e.name, e.holderID, e.fencingToken}
+	}
+	return nil
+}
+
+func (e *LeaderElector) expire() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.deadline = time.Time{}
+}
+
+func (e *LeaderElector) readOrCreateLease() (Lease, error) {
+	var current Lease
+	err := e.database.Where("name = ?", e.name).First(&current).Error
+	if err != gorm.ErrRecordNotFound {
+		return current, err
+	}
+	// another server may insert the row at the same time, read it again if the insert fails
+	if err = e.database.Create(&Lease{Name: e.name, RenewedAt: time.Unix(0, 0).UTC(), ExpiresAt: time.Unix(0, 0).UTC()}).Error; err != nil {
+		e.logger.Debug("failed to insert lease", logs.Args{{"error", err}})
+	}
+	err = e.database.Where("name = ?", e.name).First(&current).Error
+	return current, err
+}
+
+// LeaseDurations returns the lease duration and renew interval of the config, with the defaults for the missing values
+func LeaseDurations(config resources.LeaseConfig) (time.Duration, time.Duration) {
+	duration := config.Duration
+	if duration == 0 {
+		duration = resources.DefaultLeaseDuration
+	}
+	renewInterval := config.RenewInterval
+	if renewInterval == 0 {
+		renewInterval = resources.DefaultLeaseRenewInterval
+	}
+	return time.Duration(duration) * time.Second, time.Duration(renewInterval) * time.Second
+}
+
+// ValidateLeaseConfig returns the error NewLeaderElector returns for the durations of config
+func ValidateLeaseConfig(config resources.LeaseConfig) error {
+	duration, renewInterval := LeaseDurations(config)
+	if duration <= 0 || renewInterval <= 0 || renewInterval >= duration {
+		return &invalidLeaseConfigError{duration, renewInterval}
+	}
+	return nil
+}
+
+type invalidLeaseConfigError struct {
+	duration      time.Duration
+	renewInterval time.Duration
+}
+
+func (e *invalidLeaseConfigError) Error() string {
+	return fmt.Sprintf("Error in config file. The lease renew interval [%s] must be positive and shorter than the lease duration [%s]", e.renewInterval, e.duration)
+}
+
+type leaseLostError struct {
+	name         string
+	holderID     string
+	fencingToken int64
+}
+
+func (e *leaseLostError) Error() string {
+	return fmt.Sprintf("Lease [%s] with fencing token [%d] is no longer held by [%s]", e.name, e.fencingToken, e.holderID)
+}
+
+type leaseExpiredError struct {
+	name         string
+	holderID     string
+	fencingToken int64
+}
+
+func (e *leaseExpiredError) Error() string {
+	return fmt.Sprintf("Lease [%s] with fencing token [%d] held by [%s] expired before it was renewed", e.name, e.fencingToken, e.holderID)
+}
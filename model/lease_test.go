@@ -0,0 +1,98 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lease", func() {
+	var (
+		dbDir    string
+		db1      *gorm.DB
+		db2      *gorm.DB
+		elector1 *model.LeaderElector
+		elector2 *model.LeaderElector
+		err      error
+	)
+	BeforeEach(func() {
+		dbDir, err = ioutil.TempDir("", "ubiquity-model")
+		Expect(err).ToNot(HaveOccurred())
+		db1, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = model.NewMigrator(db1).Up(false)
+		Expect(err).ToNot(HaveOccurred())
+		db2, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		elector1, err = model.NewLeaderElector(db1, model.ServerLeaseName, time.Second, 100*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		elector2, err = model.NewLeaderElector(db2, model.ServerLeaseName, time.Second, 100*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		db1.Close()
+		db2.Close()
+		os.RemoveAll(dbDir)
+	})
+	It("should fail if the renew interval is not shorter than the duration", func() {
+		_, err = model.NewLeaderElector(db1, model.ServerLeaseName, time.Second, time.Second)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should let one holder acquire the lease until it expires", func() {
+		acquired, _, err := elector1.TryAcquire()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(elector1.FencingToken()).To(Equal(int64(1)))
+
+		acquired, current, err := elector2.TryAcquire()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeFalse())
+		Expect(current.HolderID).To(Equal(elector1.HolderID()))
+		Expect(current.PID).To(Equal(os.Getpid()))
+		Expect(elector1.Renew()).To(Succeed())
+	})
+	It("should increment the fencing token on takeover and fence the writes of the previous holder", func() {
+		elector1.RegisterFencingCallbacks(db1)
+		Expect(elector1.Acquire()).To(Succeed())
+		Expect(db1.Create(&resources.Volume{Name: "vol1", Backend: resources.LocalHost}).Error).ToNot(HaveOccurred())
+
+		time.Sleep(1100 * time.Millisecond)
+		Expect(elector2.Acquire()).To(Succeed())
+		Expect(elector2.FencingToken()).To(Equal(int64(2)))
+
+		Expect(elector1.Renew()).ToNot(Succeed())
+		Expect(db1.Create(&resources.Volume{Name: "vol2", Backend: resources.LocalHost}).Error).To(HaveOccurred())
+		Expect(db1.Where("name = ?", "vol1").Delete(resources.Volume{}).Error).To(HaveOccurred())
+		Expect(model.VolumeExists(db2, "vol1")).To(BeTrue())
+	})
+	It("should let the next holder acquire a released lease right away", func() {
+		Expect(elector1.Acquire()).To(Succeed())
+		Expect(elector1.Release()).To(Succeed())
+		acquired, _, err := elector2.TryAcquire()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acquired).To(BeTrue())
+		Expect(elector1.CheckFencingToken(db1)).ToNot(Succeed())
+	})
+})
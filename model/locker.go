@@ -0,0 +1,471 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// The database locker shares the volume and host locks between the servers that use the same database.
+// Each lock is a row of the distributed_locks table, taken and released with conditional updates, so no row locks are held
+// between two statements. The holders refresh the expiry of their locks in the background, and the locks of a server that
+// stopped expire after LockConfig.Expiry seconds.
+// A server holding a read lock also owns a row of the distributed_lock_readers table, which expires on its own, so the
+// read lock of a server that stopped is released while the other servers still read.
+
+// DistributedLock is the row of a lock name, the row is kept when the lock is released
+type DistributedLock struct {
+	Name      string `gorm:"primary_key"`
+	LockMode  string // empty when the lock is free
+	Readers   int    // number of servers holding the read lock, each owns a DistributedLockReader row
+	Owner     string // holder of the write lock
+	ExpiresAt time.Time
+}
+
+func (DistributedLock) TableName() string {
+	return "distributed_locks"
+}
+
+// DistributedLockReader is the row of a server holding the read lock of a name, however many of its callers hold it
+type DistributedLockReader struct {
+	Name      string `gorm:"primary_key"`
+	Owner     string `gorm:"primary_key"`
+	ExpiresAt time.Time
+}
+
+func (DistributedLockReader) TableName() string {
+	return "distributed_lock_readers"
+}
+
+const (
+	LockModeRead  = "read"
+	LockModeWrite = "write"
+)
+
+// NewLocker returns the locker selected by config, names are prefixed by namespace in the database so the volume locks and
+// the host locks of a backend do not collide
+func NewLocker(config resources.LockConfig, db *gorm.DB, namespace string) (utils.Locker, error) {
+	switch strings.ToLower(config.Type) {
+	case "", resources.LockTypeLocal:
+		return utils.NewLocker(), nil
+	case resources.LockTypeDatabase:
+		expiry := config.Expiry
+		if expiry == 0 {
+			expiry = resources.DefaultLockExpiry
+		}
+		return NewDatabaseLocker(db, namespace, time.Duration(expiry)*time.Second, resources.DefaultLockRetryInterval*time.Millisecond)
+	}
+	return nil, &invalidLockTypeError{config.Type}
+}
+
+// ValidateLockConfig returns the error NewLocker returns for an invalid config
+func ValidateLockConfig(config resources.LockConfig) error {
+	switch strings.ToLower(config.Type) {
+	case "", resources.LockTypeLocal, resources.LockTypeDatabase:
+		return nil
+	}
+	return &invalidLockTypeError{config.Type}
+}
+
+// IsDistributedLocker returns true if the locks of config are shared between the servers
+func IsDistributedLocker(config resources.LockConfig) bool {
+	return strings.ToLower(config.Type) == resources.LockTypeDatabase
+}
+
+type databaseLocker struct {
+	logger        logs.Logger
+	database      *gorm.DB
+	namespace     string
+	owner         string
+	expiry        time.Duration
+	retryInterval time.Duration
+	local         utils.Locker // serializes the callers of this server before they poll the database
+	heldLock      *sync.Mutex
+	held          map[string]int // lock rows held by this server, with the number of holders
+	known         map[string]bool
+	readersLock   *sync.Mutex    // serializes taking and releasing the reader row of this server
+	readers       map[string]int // read locks held by this server, with the number of holders
+}
+
+func NewDatabaseLocker(db *gorm.DB, namespace string, expiry, retryInterval time.Duration) (utils.Locker, error) {
+	logger := logs.GetComponentLogger(logs.ComponentLocker)
+	if err := db.AutoMigrate(&DistributedLock{}, &DistributedLockReader{}).Error; err != nil {
+		return nil, logger.ErrorRet(err, "failed to create distributed_locks table")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	l := &databaseLocker{
+		logger:        logger,
+		database:      db,
+		namespace:     namespace,
+		owner:         fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		expiry:        expiry,
+		retryInterval: retryInterval,
+		local:         utils.NewLocker(),
+		heldLock:      &sync.Mutex{},
+		held:          make(map[string]int),
+		known:         make(map[string]bool),
+		readersLock:   &sync.Mutex{},
+		readers:       make(map[string]int),
+	}
+	go l.refreshHeldLocks()
+	return l, nil
+}
+
+func (l *databaseLocker) WriteLock(name string) {
+	l.WriteLockContext(context.Background(), name)
+}
+
+func (l *databaseLocker) WriteUnlock(name string) {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	key := l.key(name)
+	l.release(key)
+	err := l.database.Exec("UPDATE distributed_locks SET lock_mode = '', owner = '' WHERE name = ? AND lock_mode = ? AND owner = ?",
+		key, LockModeWrite, l.owner).Error
+	if err != nil {
+		l.logger.Error("failed to release lock, it is released when it expires", logs.Args{{"lockName", key}, {"error", err}})
+	}
+	l.local.WriteUnlock(name)
+}
+
+func (l *databaseLocker) ReadLock(name string) {
+	l.ReadLockContext(context.Background(), name)
+}
+
+func (l *databaseLocker) ReadUnlock(name string) {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	key := l.key(name)
+	l.release(key)
+	l.readersLock.Lock()
+	if l.readers[key] > 1 {
+		l.readers[key]--
+	} else {
+		delete(l.readers, key)
+		if err := l.releaseReadLock(key); err != nil {
+			l.logger.Error("failed to release lock, it is released when it expires", logs.Args{{"lockName", key}, {"error", err}})
+		}
+	}
+	l.readersLock.Unlock()
+	l.local.ReadUnlock(name)
+}
+
+func (l *databaseLocker) WriteLockContext(ctx context.Context, name string) error {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	if err := l.local.WriteLockContext(ctx, name); err != nil {
+		return err
+	}
+	key := l.key(name)
+	if err := l.waitFor(ctx, key, l.takeWriteLock); err != nil {
+		l.local.WriteUnlock(name)
+		return err
+	}
+	l.hold(key)
+	return nil
+}
+
+func (l *databaseLocker) ReadLockContext(ctx context.Context, name string) error {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	if err := l.local.ReadLockContext(ctx, name); err != nil {
+		return err
+	}
+	key := l.key(name)
+	if err := l.waitFor(ctx, key, l.takeReadLock); err != nil {
+		l.local.ReadUnlock(name)
+		return err
+	}
+	l.hold(key)
+	return nil
+}
+
+func (l *databaseLocker) TryWriteLock(name string) bool {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	if !l.local.TryWriteLock(name) {
+		return false
+	}
+	key := l.key(name)
+	if !l.tryTake(key, l.takeWriteLock) {
+		l.local.WriteUnlock(name)
+		return false
+	}
+	l.hold(key)
+	return true
+}
+
+func (l *databaseLocker) TryReadLock(name string) bool {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	if !l.local.TryReadLock(name) {
+		return false
+	}
+	key := l.key(name)
+	if !l.tryTake(key, l.takeReadLock) {
+		l.local.ReadUnlock(name)
+		return false
+	}
+	l.hold(key)
+	return true
+}
+
+func (l *databaseLocker) takeWriteLock(key string, now time.Time) (bool, error) {
+	result := l.database.Exec("UPDATE distributed_locks SET lock_mode = ?, owner = ?, expires_at = ? WHERE name = ? AND lock_mode = ''",
+		LockModeWrite, l.owner, now.Add(l.expiry), key)
+	return result.RowsAffected == 1, result.Error
+}
+
+// takeReadLock counts this server once in the readers of the lock and inserts its reader row, the other callers of this
+// server that read the same name share them
+func (l *databaseLocker) takeReadLock(key string, now time.Time) (bool, error) {
+	l.readersLock.Lock()
+	defer l.readersLock.Unlock()
+	if l.readers[key] > 0 {
+		l.readers[key]++
+		return true, nil
+	}
+	tx := l.database.Begin()
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	result := tx.Exec("UPDATE distributed_locks SET readers = readers + 1, lock_mode = ?, expires_at = ? WHERE name = ? AND lock_mode IN ('', ?)",
+		LockModeRead, now.Add(l.expiry), key, LockModeRead)
+	if result.Error != nil || result.RowsAffected != 1 {
+		tx.Rollback()
+		return false, result.Error
+	}
+	if err := tx.Create(&DistributedLockReader{Name: key, Owner: l.owner, ExpiresAt: now.Add(l.expiry)}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+	l.readers[key] = 1
+	return true, nil
+}
+
+// releaseReadLock deletes the reader row of this server and frees the lock after the last reader. A row that expired
+// meanwhile was already removed from the readers, so it is not counted twice
+func (l *databaseLocker) releaseReadLock(key string) error {
+	tx := l.database.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	result := tx.Where("name = ? AND owner = ?", key, l.owner).Delete(DistributedLockReader{})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if err := l.removeReaders(tx, key, result.RowsAffected); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// expireLock frees the lock rows whose holders all stopped refreshing them, and removes the reader rows of the servers that stopped
+func (l *databaseLocker) expireLock(key string, now time.Time) error {
+	tx := l.database.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	result := tx.Exec("UPDATE distributed_locks SET lock_mode = '', readers = 0, owner = '' WHERE name = ? AND lock_mode <> '' AND expires_at < ?", key, now)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		result = tx.Where("name = ?", key).Delete(DistributedLockReader{})
+	} else {
+		result = tx.Where("name = ? AND expires_at < ?", key, now).Delete(DistributedLockReader{})
+		if result.Error == nil {
+			result.Error = l.removeReaders(tx, key, result.RowsAffected)
+		}
+	}
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	return tx.Commit().Error
+}
+
+// removeReaders subtracts count servers from the readers of the lock, in the transaction that deleted their reader rows
+func (l *databaseLocker) removeReaders(tx *gorm.DB, key string, count int64) error {
+	if count == 0 {
+		return nil
+	}
+	err := tx.Exec("UPDATE distributed_locks SET readers = readers - ? WHERE name = ? AND lock_mode = ?", count, key, LockModeRead).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE distributed_locks SET lock_mode = '', readers = 0 WHERE name = ? AND lock_mode = ? AND readers <= 0", key, LockModeRead).Error
+}
+
+// waitFor retries tryTake until it takes the lock or the context is done
+func (l *databaseLocker) waitFor(ctx context.Context, key string, take func(key string, now time.Time) (bool, error)) error {
+	for {
+		if l.tryTake(key, take) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return utils.NewLockBusyError(key, ctx.Err())
+		case <-time.After(l.retryInterval):
+		}
+	}
+}
+
+// tryTake runs the conditional update that takes the lock once, the database errors are logged and count as a busy lock
+func (l *databaseLocker) tryTake(key string, take func(key string, now time.Time) (bool, error)) bool {
+	now := time.Now().UTC()
+	err := l.ensureLockRow(key, now)
+	if err == nil {
+		// a lock left by a server that stopped is free once it expired
+		err = l.expireLock(key, now)
+	}
+	if err == nil {
+		var taken bool
+		if taken, err = take(key, now); taken {
+			return true
+		}
+	}
+	if err != nil {
+		l.logger.Error("failed to take lock", logs.Args{{"lockName", key}, {"error", err}})
+	}
+	return false
+}
+
+func (l *databaseLocker) ensureLockRow(key string, now time.Time) error {
+	l.heldLock.Lock()
+	known := l.known[key]
+	l.heldLock.Unlock()
+	if known {
+		return nil
+	}
+	var count int
+	if err := l.database.Model(&DistributedLock{}).Where("name = ?", key).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		// another server may insert the same row first, the next count finds it
+		if err := l.database.Create(&DistributedLock{Name: key, ExpiresAt: now}).Error; err != nil {
+			return err
+		}
+	}
+	l.heldLock.Lock()
+	l.known[key] = true
+	l.heldLock.Unlock()
+	return nil
+}
+
+func (l *databaseLocker) hold(key string) {
+	l.heldLock.Lock()
+	defer l.heldLock.Unlock()
+	l.held[key]++
+}
+
+func (l *databaseLocker) release(key string) {
+	l.heldLock.Lock()
+	defer l.heldLock.Unlock()
+	if l.held[key] <= 1 {
+		delete(l.held, key)
+		return
+	}
+	l.held[key]--
+}
+
+// refreshHeldLocks extends the expiry of the locks held by this server, so long backend operations keep their locks
+func (l *databaseLocker) refreshHeldLocks() {
+	for {
+		time.Sleep(l.expiry / 3)
+		l.heldLock.Lock()
+		keys := make([]string, 0, len(l.held))
+		for key := range l.held {
+			keys = append(keys, key)
+		}
+		l.heldLock.Unlock()
+
+		expiresAt := time.Now().UTC().Add(l.expiry)
+		for _, key := range keys {
+			err := l.database.Exec("UPDATE distributed_locks SET expires_at = ? WHERE name = ? AND (lock_mode = ? OR (lock_mode = ? AND owner = ?))",
+				expiresAt, key, LockModeRead, LockModeWrite, l.owner).Error
+			if err == nil {
+				err = l.database.Exec("UPDATE distributed_lock_readers SET expires_at = ? WHERE name = ? AND owner = ?", expiresAt, key, l.owner).Error
+			}
+			if err != nil {
+				l.logger.Error("failed to refresh lock", logs.Args{{"lockName", key}, {"error", err}})
+			}
+		}
+	}
+}
+
+func (l *databaseLocker) key(name string) string {
+	return l.namespace + "/" + name
+}
+
+func init() {
+	RegisterMigrations(Migration{
+		Version:     11,
+		Description: "create distributed_locks table",
+		Up: func(tx *gorm.DB) error {
+			type distributedLock struct {
+				Name      string `gorm:"primary_key"`
+				LockMode  string
+				Readers   int
+				Owner     string
+				ExpiresAt time.Time
+			}
+			return tx.Table("distributed_locks").AutoMigrate(&distributedLock{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the locks are only held while the servers run, nothing is lost
+			return tx.DropTableIfExists("distributed_locks").Error
+		},
+	}, Migration{
+		Version:     14,
+		Description: "create distributed_lock_readers table",
+		Up: func(tx *gorm.DB) error {
+			type distributedLockReader struct {
+				Name      string `gorm:"primary_key"`
+				Owner     string `gorm:"primary_key"`
+				ExpiresAt time.Time
+			}
+			return tx.Table("distributed_lock_readers").AutoMigrate(&distributedLockReader{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the read locks are only held while the servers run, nothing is lost
+			return tx.DropTableIfExists("distributed_lock_readers").Error
+		},
+	})
+}
+
+type invalidLockTypeError struct {
+	lockType string
+}
+
+func (e *invalidLockTypeError) Error() string {
+	return fmt.Sprintf("Error in config file. The parameter [LockConfig.Type] is [%s], it must be one of [%s, %s]", e.lockType, resources.LockTypeLocal, resources.LockTypeDatabase)
+}
@@ -0,0 +1,150 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Database locker", func() {
+	var (
+		dbDir   string
+		db1     *gorm.DB
+		db2     *gorm.DB
+		locker1 utils.Locker
+		locker2 utils.Locker
+		err     error
+	)
+	BeforeEach(func() {
+		dbDir, err = ioutil.TempDir("", "ubiquity-model")
+		Expect(err).ToNot(HaveOccurred())
+		db1, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = model.NewMigrator(db1).Up(false)
+		Expect(err).ToNot(HaveOccurred())
+		db2, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		locker1, err = model.NewDatabaseLocker(db1, "volume", 3*time.Second, 10*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		locker2, err = model.NewDatabaseLocker(db2, "volume", 3*time.Second, 10*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		db1.Close()
+		db2.Close()
+		os.RemoveAll(dbDir)
+	})
+	It("should make the write lock of one server wait for the other server", func() {
+		locker1.WriteLock("vol1")
+		locked := make(chan bool)
+		go func() {
+			defer GinkgoRecover()
+			locker2.WriteLock("vol1")
+			locked <- true
+			locker2.WriteUnlock("vol1")
+		}()
+		Consistently(locked, "200ms").ShouldNot(Receive())
+
+		// another name is not blocked
+		locker2.WriteLock("vol2")
+		locker2.WriteUnlock("vol2")
+
+		locker1.WriteUnlock("vol1")
+		Eventually(locked, "2s").Should(Receive())
+	})
+	It("should share the read lock between the servers and block the write lock", func() {
+		locker1.ReadLock("vol1")
+		locker2.ReadLock("vol1")
+		locked := make(chan bool)
+		go func() {
+			defer GinkgoRecover()
+			locker1.WriteLock("vol1")
+			locked <- true
+			locker1.WriteUnlock("vol1")
+		}()
+		locker1.ReadUnlock("vol1")
+		Consistently(locked, "200ms").ShouldNot(Receive())
+		locker2.ReadUnlock("vol1")
+		Eventually(locked, "2s").Should(Receive())
+	})
+	It("should release the lock of a stopped server when it expires", func() {
+		stopped, err := model.NewDatabaseLocker(db1, "volume", 300*time.Millisecond, 10*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		stopped.WriteLock("vol1")
+		db1.Close()
+		locked := make(chan bool)
+		go func() {
+			defer GinkgoRecover()
+			locker2.WriteLock("vol1")
+			locked <- true
+		}()
+		Eventually(locked, "2s").Should(Receive())
+	})
+	It("should release the read lock of a stopped server while another server still reads", func() {
+		db3, err := model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		stopped, err := model.NewDatabaseLocker(db3, "volume", 300*time.Millisecond, 10*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		stopped.ReadLock("vol1")
+		db3.Close()
+		locker2.ReadLock("vol1")
+
+		locked := make(chan bool)
+		go func() {
+			defer GinkgoRecover()
+			locker1.WriteLock("vol1")
+			locked <- true
+			locker1.WriteUnlock("vol1")
+		}()
+		// the read lock of locker2 is refreshed, the one of the stopped server expires
+		Consistently(locked, "600ms").ShouldNot(Receive())
+		var readers []model.DistributedLockReader
+		Expect(db1.Find(&readers).Error).To(Succeed())
+		Expect(readers).To(HaveLen(1))
+
+		locker2.ReadUnlock("vol1")
+		Eventually(locked, "2s").Should(Receive())
+	})
+	It("should count the readers of each server once", func() {
+		locker1.ReadLock("vol1")
+		locker1.ReadLock("vol1")
+		locker2.ReadLock("vol1")
+		var lock model.DistributedLock
+		Expect(db1.Where("name = ?", "volume/vol1").First(&lock).Error).To(Succeed())
+		Expect(lock.Readers).To(Equal(2))
+
+		locker1.ReadUnlock("vol1")
+		locker2.ReadUnlock("vol1")
+		Expect(locker2.TryWriteLock("vol1")).To(BeFalse())
+		locker1.ReadUnlock("vol1")
+		Expect(locker2.TryWriteLock("vol1")).To(BeTrue())
+		locker2.WriteUnlock("vol1")
+	})
+	It("should fail on an unknown lock type", func() {
+		_, err = model.NewLocker(resources.LockConfig{Type: "zookeeper"}, db1, "volume")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,337 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Migration is one numbered schema change.
+// Up and Down get a transaction, so a failing migration leaves neither the schema change nor its schema_version row behind
+// (except on MySQL, where DDL statements commit implicitly).
+// A migration declares the columns of its own version instead of migrating the current structs, so that a version
+// means the same schema whether it runs on a fresh install or on an old database.
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+	DropsData   bool // Down drops a table holding volume data, DownTo rolls it back only when forced
+}
+
+// SchemaVersion is a row in the schema_version table, one per applied migration
+type SchemaVersion struct {
+	Version     int64 `gorm:"primary_key;auto_increment:false"`
+	Description string
+	AppliedAt   time.Time
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+var (
+	registeredMigrations     = make(map[int64]Migration)
+	registeredMigrationsLock = &sync.Mutex{}
+)
+
+// RegisterMigrations adds migrations to the global list applied by the Migrator.
+// Backends call it from init() for their own tables.
+// It panics if a version is registered twice, so collisions show up at startup.
+func RegisterMigrations(migrations ...Migration) {
+	registeredMigrationsLock.Lock()
+	defer registeredMigrationsLock.Unlock()
+	for _, migration := range migrations {
+		if existing, exists := registeredMigrations[migration.Version]; exists {
+			panic(fmt.Sprintf("migration version %d registered twice (%s, %s)", migration.Version, existing.Description, migration.Description))
+		}
+		registeredMigrations[migration.Version] = migration
+	}
+}
+
+// GetRegisteredMigrations returns all the registered migrations sorted by version
+func GetRegisteredMigrations() []Migration {
+	registeredMigrationsLock.Lock()
+	defer registeredMigrationsLock.Unlock()
+	migrations := make([]Migration, 0, len(registeredMigrations))
+	for _, migration := range registeredMigrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+func init() {
+	RegisterMigrations(Migration{
+		Version:     1,
+		Description: "create volumes table",
+		Up: func(tx *gorm.DB) error {
+			type volume struct {
+				gorm.Model
+				Name          string
+				CapacityBytes uint64
+				Backend       string
+				Mountpoint    string
+			}
+			return tx.Table("volumes").AutoMigrate(&volume{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("volumes").Error
+		},
+		DropsData: true,
+	}, Migration{
+		Version:     4,
+		Description: "add volumes state column",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Table("volumes").AutoMigrate(&struct{ State string }{}).Error; err != nil {
+				return err
+			}
+			// volumes with a mountpoint were attached by spectrum-scale or localhost, scbe attachments are set by the scbe migration
+			if err := tx.Exec("UPDATE volumes SET state = ? WHERE (state IS NULL OR state = '') AND mountpoint <> ''", resources.VolumeStateAttached).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE volumes SET state = ? WHERE state IS NULL OR state = ''", resources.VolumeStateAvailable).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the column is kept, since SQLite cannot drop columns, older versions ignore it
+			return nil
+		},
+	}, Migration{
+		Version:     6,
+		Description: "add volumes removed_at column",
+		Up: func(tx *gorm.DB) error {
+			return tx.Table("volumes").AutoMigrate(&struct{ RemovedAt *time.Time }{}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// deleted volumes become visible again to versions without retention, the column itself is kept
+			return tx.Exec("UPDATE volumes SET state = ? WHERE state = ?", resources.VolumeStateAvailable, resources.VolumeStateDeleted).Error
+		},
+	}, Migration{
+		Version:     12,
+		Description: "add volumes backend_type column",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Table("volumes").AutoMigrate(&struct{ BackendType string }{}).Error; err != nil {
+				return err
+			}
+			// the volumes created before the named backends belong to the backend named after its type
+			return tx.Exec("UPDATE volumes SET backend_type = backend WHERE backend_type IS NULL OR backend_type = ''").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// the column is kept, older versions ignore it
+			return nil
+		},
+	})
+}
+
+type Migrator struct {
+	logger     logs.Logger
+	database   *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator over all the registered migrations
+func NewMigrator(db *gorm.DB) *Migrator {
+	return NewMigratorWithMigrations(db, GetRegisteredMigrations())
+}
+
+func NewMigratorWithMigrations(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{logger: logs.GetComponentLogger(logs.ComponentModel), database: db, migrations: migrations}
+}
+
+// CurrentVersion returns the highest applied migration version, or 0 if none was applied
+func (m *Migrator) CurrentVersion() (int64, error) {
+	defer m.logger.Trace(logs.DEBUG)()
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Pending returns the migrations that were not applied yet, in the order Up applies them
+func (m *Migrator) Pending() ([]Migration, error) {
+	defer m.logger.Trace(logs.DEBUG)()
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, exists := applied[migration.Version]; !exists {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in version order and returns them.
+// With dryRun it only returns what would be applied.
+// It stops at the first failing migration, the already applied ones stay applied.
+func (m *Migrator) Up(dryRun bool) ([]Migration, error) {
+	defer m.logger.Trace(logs.DEBUG)()
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return pending, nil
+	}
+	var applied []Migration
+	for _, migration := range pending {
+		m.logger.Info("applying migration", logs.Args{{"version", migration.Version}, {"description", migration.Description}})
+		err := m.inTransaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, m.logger.ErrorRet(&migrationFailedError{migration.Version, migration.Description, "up", err}, "failed")
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// DownTo rolls back, in reverse version order, every applied migration newer than targetVersion and returns them.
+// With dryRun it only returns what would be rolled back.
+// Unless force is set, it rolls back nothing if one of them drops a table holding volume data.
+func (m *Migrator) DownTo(targetVersion int64, dryRun bool, force bool) ([]Migration, error) {
+	defer m.logger.Trace(logs.DEBUG, logs.Args{{"targetVersion", targetVersion}, {"force", force}})()
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	var toRollback []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, exists := applied[migration.Version]; exists && migration.Version > targetVersion {
+			toRollback = append(toRollback, migration)
+		}
+	}
+	if dryRun {
+		return toRollback, nil
+	}
+	if !force {
+		for _, migration := range toRollback {
+			if migration.DropsData {
+				return nil, m.logger.ErrorRet(&migrationDropsDataError{migration.Version, migration.Description}, "failed")
+			}
+		}
+	}
+	var rolledBack []Migration
+	for _, migration := range toRollback {
+		if migration.Down == nil {
+			return rolledBack, m.logger.ErrorRet(&migrationIrreversibleError{migration.Version, migration.Description}, "failed")
+		}
+		m.logger.Info("rolling back migration", logs.Args{{"version", migration.Version}, {"description", migration.Description}})
+		err := m.inTransaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("version = ?", migration.Version).Delete(&SchemaVersion{}).Error
+		})
+		if err != nil {
+			return rolledBack, m.logger.ErrorRet(&migrationFailedError{migration.Version, migration.Description, "down", err}, "failed")
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+func (m *Migrator) appliedVersions() (map[int64]SchemaVersion, error) {
+	if err := m.database.AutoMigrate(&SchemaVersion{}).Error; err != nil {
+		return nil, m.logger.ErrorRet(err, "failed to create schema_version table")
+	}
+	var rows []SchemaVersion
+	if err := m.database.Find(&rows).Error; err != nil {
+		return nil, m.logger.ErrorRet(err, "failed to read schema_version table")
+	}
+	applied := make(map[int64]SchemaVersion)
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) inTransaction(action func(tx *gorm.DB) error) error {
+	tx := m.database.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := action(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// RenameColumn is a helper for migrations that renames a column without losing data on any dialect.
+// value must already declare the new column, which is added and then filled from the old column.
+// The old column is kept, since SQLite cannot drop columns, and is simply ignored by gorm from then on.
+func RenameColumn(tx *gorm.DB, value interface{}, oldColumn string, newColumn string) error {
+	if err := tx.AutoMigrate(value).Error; err != nil {
+		return err
+	}
+	tableName := tx.NewScope(value).TableName()
+	if !tx.Dialect().HasColumn(tableName, oldColumn) {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL", tableName, newColumn, oldColumn, newColumn)).Error
+}
+
+type migrationFailedError struct {
+	version     int64
+	description string
+	direction   string
+	err         error
+}
+
+func (e *migrationFailedError) Error() string {
+	return fmt.Sprintf("Migration %d (%s) %s failed: %s", e.version, e.description, e.direction, e.err.Error())
+}
+
+type migrationDropsDataError struct {
+	version     int64
+	description string
+}
+
+func (e *migrationDropsDataError) Error() string {
+	return fmt.Sprintf("Migration %d (%s) drops a table and its data, it is rolled back only when forced", e.version, e.description)
+}
+
+type migrationIrreversibleError struct {
+	version     int64
+	description string
+}
+
+func (e *migrationIrreversibleError) Error() string {
+	return fmt.Sprintf("Migration %d (%s) cannot be rolled back", e.version, e.description)
+}
@@ -0,0 +1,209 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type migrationTestRow struct {
+	ID       uint
+	OldName  string
+	NewName  string
+	Backfill string
+}
+
+var _ = Describe("Migrator", func() {
+	var (
+		dbDir      string
+		db         *gorm.DB
+		migrations []model.Migration
+		migrator   *model.Migrator
+		err        error
+	)
+	BeforeEach(func() {
+		dbDir, err = ioutil.TempDir("", "ubiquity-model")
+		Expect(err).ToNot(HaveOccurred())
+		db, err = model.OpenDatabase(resources.DatabaseConfig{}, dbDir)
+		Expect(err).ToNot(HaveOccurred())
+		migrations = []model.Migration{
+			{
+				Version:     1,
+				Description: "create table",
+				Up: func(tx *gorm.DB) error {
+					return tx.AutoMigrate(&migrationTestRow{}).Error
+				},
+				Down: func(tx *gorm.DB) error {
+					return tx.DropTableIfExists(&migrationTestRow{}).Error
+				},
+			},
+			{
+				Version:     2,
+				Description: "backfill",
+				Up: func(tx *gorm.DB) error {
+					return tx.Model(&migrationTestRow{}).Update("backfill", "done").Error
+				},
+				Down: func(tx *gorm.DB) error {
+					return tx.Model(&migrationTestRow{}).Update("backfill", "").Error
+				},
+			},
+		}
+		migrator = model.NewMigratorWithMigrations(db, migrations)
+	})
+	AfterEach(func() {
+		db.Close()
+		os.RemoveAll(dbDir)
+	})
+
+	Context(".Up", func() {
+		It("should apply all migrations in order and record their versions", func() {
+			applied, err := migrator.Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(applied)).To(Equal(2))
+			version, err := migrator.CurrentVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(int64(2)))
+			pending, err := migrator.Pending()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pending).To(BeEmpty())
+		})
+		It("should not change anything on dry run", func() {
+			pending, err := migrator.Up(true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(pending)).To(Equal(2))
+			Expect(db.HasTable(&migrationTestRow{})).To(Equal(false))
+			version, err := migrator.CurrentVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(int64(0)))
+		})
+		It("should not record a failing migration and stop there", func() {
+			migrations = append(migrations, model.Migration{
+				Version:     3,
+				Description: "fail",
+				Up:          func(tx *gorm.DB) error { return fmt.Errorf("boom") },
+			})
+			migrator = model.NewMigratorWithMigrations(db, migrations)
+			applied, err := migrator.Up(false)
+			Expect(err).To(HaveOccurred())
+			Expect(len(applied)).To(Equal(2))
+			version, err := migrator.CurrentVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(int64(2)))
+		})
+	})
+
+	Context(".DownTo", func() {
+		It("should roll back the migrations newer than the target in reverse order", func() {
+			_, err := migrator.Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			rolledBack, err := migrator.DownTo(0, false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rolledBack)).To(Equal(2))
+			Expect(rolledBack[0].Version).To(Equal(int64(2)))
+			Expect(db.HasTable(&migrationTestRow{})).To(Equal(false))
+			version, err := migrator.CurrentVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(int64(0)))
+		})
+		It("should roll back nothing without force if a migration drops data", func() {
+			migrations[0].DropsData = true
+			migrator = model.NewMigratorWithMigrations(db, migrations)
+			_, err := migrator.Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			toRollback, err := migrator.DownTo(0, true, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(toRollback)).To(Equal(2))
+			Expect(toRollback[1].DropsData).To(Equal(true))
+
+			rolledBack, err := migrator.DownTo(0, false, false)
+			Expect(err).To(HaveOccurred())
+			Expect(rolledBack).To(BeEmpty())
+			Expect(db.HasTable(&migrationTestRow{})).To(Equal(true))
+			version, err := migrator.CurrentVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(int64(2)))
+
+			rolledBack, err = migrator.DownTo(0, false, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rolledBack)).To(Equal(2))
+			Expect(db.HasTable(&migrationTestRow{})).To(Equal(false))
+		})
+	})
+
+	Context(".RenameColumn", func() {
+		It("should copy the data of the old column into the new one", func() {
+			Expect(db.Exec("CREATE TABLE migration_test_rows (id integer primary key, old_name varchar(255))").Error).ToNot(HaveOccurred())
+			Expect(db.Exec("INSERT INTO migration_test_rows (id, old_name) VALUES (1, 'vol1')").Error).ToNot(HaveOccurred())
+			Expect(model.RenameColumn(db, &migrationTestRow{}, "old_name", "new_name")).To(Succeed())
+			var row migrationTestRow
+			Expect(db.First(&row, 1).Error).ToNot(HaveOccurred())
+			Expect(row.NewName).To(Equal("vol1"))
+		})
+	})
+
+	Context("registered migrations", func() {
+		It("should create the volumes table with the columns of each version", func() {
+			var first []model.Migration
+			for _, migration := range model.GetRegisteredMigrations() {
+				if migration.Version <= 1 {
+					first = append(first, migration)
+				}
+			}
+			_, err := model.NewMigratorWithMigrations(db, first).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Dialect().HasColumn("volumes", "mountpoint")).To(Equal(true))
+			for _, column := range []string{"state", "removed_at", "access_mode", "backend_type"} {
+				Expect(db.Dialect().HasColumn("volumes", column)).To(Equal(false), column)
+			}
+
+			_, err = model.NewMigrator(db).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			for _, column := range []string{"state", "removed_at", "access_mode", "backend_type"} {
+				Expect(db.Dialect().HasColumn("volumes", column)).To(Equal(true), column)
+			}
+		})
+		It("should set the state of the volumes that existed before the state column", func() {
+			Expect(db.Exec("CREATE TABLE volumes (id integer primary key, created_at datetime, updated_at datetime, deleted_at datetime, name varchar(255), capacity_bytes bigint, backend varchar(255), mountpoint varchar(255))").Error).ToNot(HaveOccurred())
+			Expect(db.Exec("INSERT INTO volumes (id, name, backend, mountpoint) VALUES (1, 'vol1', 'localhost', ''), (2, 'vol2', 'localhost', '/mnt/vol2')").Error).ToNot(HaveOccurred())
+			_, err := model.NewMigrator(db).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			volume, err := model.GetVolume(db, "vol1", resources.LocalHost)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volume.State).To(Equal(resources.VolumeStateAvailable))
+			volume, err = model.GetVolume(db, "vol2", resources.LocalHost)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volume.State).To(Equal(resources.VolumeStateAttached))
+		})
+		It("should set the backend type of the volumes that existed before the named backends", func() {
+			Expect(db.Exec("CREATE TABLE volumes (id integer primary key, created_at datetime, updated_at datetime, deleted_at datetime, name varchar(255), capacity_bytes bigint, backend varchar(255), mountpoint varchar(255))").Error).ToNot(HaveOccurred())
+			Expect(db.Exec("INSERT INTO volumes (id, name, backend, mountpoint) VALUES (1, 'vol1', 'scbe', '')").Error).ToNot(HaveOccurred())
+			_, err := model.NewMigrator(db).Up(false)
+			Expect(err).ToNot(HaveOccurred())
+			volume, err := model.GetVolume(db, "vol1", resources.SCBE)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volume.BackendType).To(Equal(resources.SCBE))
+		})
+	})
+})
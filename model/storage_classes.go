@@ -0,0 +1,91 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+)
+
+// ApplyStorageClass expands the storage class of a create request, given by StorageClass or by the storage-class
+// option, into its Backend and Metadata: the options of the class, then the options of the request it allows.
+// A request without a storage class is not changed.
+func ApplyStorageClass(classes map[string]resources.StorageClassConfig, request *resources.CreateVolumeRequest) error {
+	name := request.StorageClass
+	if option, ok := request.Metadata[resources.OptionNameForStorageClass]; ok {
+		if name != "" && name != option {
+			return fmt.Errorf("the storage class [%s] and the option %s=%s do not match", name, resources.OptionNameForStorageClass, option)
+		}
+		name = option
+	}
+	if name == "" {
+		return nil
+	}
+	class, ok := classes[name]
+	if !ok {
+		return fmt.Errorf("storage class [%s] not found", name)
+	}
+	if request.Backend != "" && request.Backend != class.Backend {
+		return fmt.Errorf("the backend [%s] is not the backend [%s] of the storage class [%s]", request.Backend, class.Backend, name)
+	}
+
+	metadata := make(map[string]string)
+	for option, value := range class.Options {
+		metadata[option] = value
+	}
+	var refused []string
+	for option, value := range request.Metadata {
+		if option == resources.OptionNameForStorageClass {
+			continue
+		}
+		if !utils.StringInSlice(option, class.AllowedOverrides) {
+			refused = append(refused, option)
+			continue
+		}
+		metadata[option] = value
+	}
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return fmt.Errorf("the storage class [%s] does not allow the options [%s]", name, strings.Join(refused, ","))
+	}
+	request.StorageClass = name
+	request.Backend = class.Backend
+	request.Metadata = metadata
+	return nil
+}
+
+// ValidateStorageClasses checks that the storage classes use a backend of backends
+func ValidateStorageClasses(classes map[string]resources.StorageClassConfig, backends []string) error {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if classes[name].Backend == "" {
+			return fmt.Errorf("Error in config file. The storage class [%s] has no Backend", name)
+		}
+		if !utils.StringInSlice(classes[name].Backend, backends) {
+			return fmt.Errorf("Error in config file. The backend [%s] of the storage class [%s] is not served", classes[name].Backend, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model_test
+
+import (
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StorageClasses", func() {
+	var (
+		classes map[string]resources.StorageClassConfig
+		request resources.CreateVolumeRequest
+	)
+	BeforeEach(func() {
+		classes = map[string]resources.StorageClassConfig{
+			"gold": {
+				Backend:          "scbe-dc1",
+				Options:          map[string]string{"profile": "gold", "fstype": "xfs"},
+				AllowedOverrides: []string{"size", "fstype"},
+			},
+		}
+		request = resources.CreateVolumeRequest{Name: "vol1", StorageClass: "gold", Metadata: map[string]string{"size": "10"}}
+	})
+	Context(".ApplyStorageClass", func() {
+		It("should set the backend and the options of the class with the allowed options of the request", func() {
+			request.Metadata["fstype"] = "ext4"
+			Expect(model.ApplyStorageClass(classes, &request)).To(Succeed())
+			Expect(request.Backend).To(Equal("scbe-dc1"))
+			Expect(request.Metadata).To(Equal(map[string]string{"profile": "gold", "fstype": "ext4", "size": "10"}))
+			Expect(classes["gold"].Options["fstype"]).To(Equal("xfs"))
+		})
+		It("should take the class from the storage-class option", func() {
+			request.StorageClass = ""
+			request.Metadata[resources.OptionNameForStorageClass] = "gold"
+			Expect(model.ApplyStorageClass(classes, &request)).To(Succeed())
+			Expect(request.StorageClass).To(Equal("gold"))
+			Expect(request.Metadata).To(Equal(map[string]string{"profile": "gold", "fstype": "xfs", "size": "10"}))
+		})
+		It("should not change a request without a class", func() {
+			request.StorageClass = ""
+			request.Metadata["profile"] = "silver"
+			Expect(model.ApplyStorageClass(classes, &request)).To(Succeed())
+			Expect(request.Backend).To(BeEmpty())
+			Expect(request.Metadata).To(Equal(map[string]string{"profile": "silver", "size": "10"}))
+		})
+		It("should refuse the options the class does not allow", func() {
+			request.Metadata["profile"] = "silver"
+			request.Metadata["quota"] = "1G"
+			err := model.ApplyStorageClass(classes, &request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("[profile,quota]"))
+		})
+		It("should refuse an unknown class, another backend or two different classes", func() {
+			Expect(model.ApplyStorageClass(classes, &resources.CreateVolumeRequest{StorageClass: "platinum"})).ToNot(Succeed())
+			Expect(model.ApplyStorageClass(classes, &resources.CreateVolumeRequest{StorageClass: "gold", Backend: "scbe-dc2"})).ToNot(Succeed())
+			Expect(model.ApplyStorageClass(classes, &resources.CreateVolumeRequest{StorageClass: "gold",
+				Metadata: map[string]string{resources.OptionNameForStorageClass: "silver"}})).ToNot(Succeed())
+		})
+	})
+	Context(".ValidateStorageClasses", func() {
+		It("should fail if the backend of a class is not served", func() {
+			Expect(model.ValidateStorageClasses(classes, []string{"scbe-dc1"})).To(Succeed())
+			err := model.ValidateStorageClasses(classes, []string{resources.SCBE})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("gold"))
+			Expect(model.ValidateStorageClasses(map[string]resources.StorageClassConfig{"empty": {}}, []string{resources.SCBE})).ToNot(Succeed())
+		})
+	})
+})
@@ -18,7 +18,6 @@ package remote
 
 import (
 	"fmt"
-	"log"
 
 	"net/http"
 
@@ -26,12 +25,14 @@ import (
 
 	"reflect"
 
-	"github.com/midoblgsm/ubiquity/remote/mounter"
+	_ "github.com/midoblgsm/ubiquity/remote/mounter" // the mounters register themselves, see resources.RegisterMounter
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 )
 
 type remoteClient struct {
-	logger            *log.Logger
+	logger            logs.Logger
 	isActivated       bool
 	isMounted         bool
 	httpClient        *http.Client
@@ -40,25 +41,27 @@ type remoteClient struct {
 	mounterPerBackend map[string]resources.Mounter
 }
 
-func NewRemoteClient(logger *log.Logger, storageApiURL string, config resources.UbiquityPluginConfig) (resources.StorageClient, error) {
-	return &remoteClient{logger: logger, storageApiURL: storageApiURL, httpClient: &http.Client{}, config: config, mounterPerBackend: make(map[string]resources.Mounter)}, nil
+func NewRemoteClient(storageApiURL string, config resources.UbiquityPluginConfig) (resources.StorageClient, error) {
+	return &remoteClient{logger: logs.GetComponentLogger(logs.ComponentRemote), storageApiURL: storageApiURL, httpClient: &http.Client{}, config: config, mounterPerBackend: make(map[string]resources.Mounter)}, nil
 }
 
 func (s *remoteClient) Activate(activateRequest resources.ActivateRequest) resources.ActivateResponse {
-	s.logger.Println("remoteClient: Activate start")
-	defer s.logger.Println("remoteClient: Activate end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.Activate", tracing.KindInternal).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	if s.isActivated {
 		return resources.ActivateResponse{}
 	}
-	s.logger.Println("remoteClient: Activate success")
+	s.logger.Info("remoteClient: Activate success")
 	s.isActivated = true
 	return resources.ActivateResponse{}
 }
 
 func (s *remoteClient) CreateVolume(createVolumeRequest resources.CreateVolumeRequest) resources.CreateVolumeResponse {
-	s.logger.Println("remoteClient: create start")
-	defer s.logger.Println("remoteClient: create end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.CreateVolume", tracing.KindInternal, logs.Args{{"volume", createVolumeRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	createRemoteURL := utils.FormatURL(s.storageApiURL, "volumes")
 
@@ -68,12 +71,12 @@ func (s *remoteClient) CreateVolume(createVolumeRequest resources.CreateVolumeRe
 
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "POST", createRemoteURL, createVolumeRequest)
 	if err != nil {
-		s.logger.Printf("Error in create volume remote call %s", err.Error())
+		s.logger.Error("Error in create volume remote call", logs.Args{{"error", err}})
 		return resources.CreateVolumeResponse{Error: fmt.Errorf("Error in create volume remote call(http error)")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in create volume remote call %#v", response)
+		s.logger.Error("Error in create volume remote call", logs.Args{{"response", response}})
 		return resources.CreateVolumeResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
@@ -81,19 +84,20 @@ func (s *remoteClient) CreateVolume(createVolumeRequest resources.CreateVolumeRe
 }
 
 func (s *remoteClient) RemoveVolume(removeVolumeRequest resources.RemoveVolumeRequest) resources.RemoveVolumeResponse {
-	s.logger.Println("remoteClient: remove start")
-	defer s.logger.Println("remoteClient: remove end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.RemoveVolume", tracing.KindInternal, logs.Args{{"volume", removeVolumeRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	removeRemoteURL := utils.FormatURL(s.storageApiURL, "volumes", removeVolumeRequest.Name)
 
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "DELETE", removeRemoteURL, removeVolumeRequest)
 	if err != nil {
-		s.logger.Printf("Error in remove volume remote call %#v", err)
+		s.logger.Error("Error in remove volume remote call", logs.Args{{"error", err}})
 		return resources.RemoveVolumeResponse{Error: fmt.Errorf("Error in remove volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in remove volume remote call %#v", response)
+		s.logger.Error("Error in remove volume remote call", logs.Args{{"response", response}})
 		return resources.RemoveVolumeResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
@@ -101,25 +105,26 @@ func (s *remoteClient) RemoveVolume(removeVolumeRequest resources.RemoveVolumeRe
 }
 
 func (s *remoteClient) GetVolume(getVolumeRequest resources.GetVolumeRequest) resources.GetVolumeResponse {
-	s.logger.Println("remoteClient: get start")
-	defer s.logger.Println("remoteClient: get finish")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.GetVolume", tracing.KindInternal, logs.Args{{"volume", getVolumeRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getRemoteURL := utils.FormatURL(s.storageApiURL, "volumes", getVolumeRequest.Name)
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "GET", getRemoteURL, getVolumeRequest)
 	if err != nil {
-		s.logger.Printf("Error in get volume remote call %#v", err)
+		s.logger.Error("Error in get volume remote call", logs.Args{{"error", err}})
 		return resources.GetVolumeResponse{Error: fmt.Errorf("Error in get volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in get volume remote call %#v", response)
+		s.logger.Error("Error in get volume remote call", logs.Args{{"response", response}})
 		return resources.GetVolumeResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
 	getResponse := resources.GetVolumeResponse{}
 	err = utils.UnmarshalResponse(response, &getResponse)
 	if err != nil {
-		s.logger.Printf("Error in unmarshalling response for get remote call %#v for response %#v", err, response)
+		s.logger.Error("Error in unmarshalling response for get remote call", logs.Args{{"response", response}, {"error", err}})
 		return resources.GetVolumeResponse{Error: fmt.Errorf("Error in unmarshalling response for get remote call")}
 	}
 
@@ -127,25 +132,26 @@ func (s *remoteClient) GetVolume(getVolumeRequest resources.GetVolumeRequest) re
 }
 
 func (s *remoteClient) GetVolumeConfig(getVolumeConfigRequest resources.GetVolumeConfigRequest) resources.GetVolumeConfigResponse {
-	s.logger.Println("remoteClient: GetVolumeConfig start")
-	defer s.logger.Println("remoteClient: GetVolumeConfig finish")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.GetVolumeConfig", tracing.KindInternal, logs.Args{{"volume", getVolumeConfigRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getRemoteURL := utils.FormatURL(s.storageApiURL, "volumes", getVolumeConfigRequest.Name, "config")
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "GET", getRemoteURL, getVolumeConfigRequest)
 	if err != nil {
-		s.logger.Printf("Error in get volume remote call %#v", err)
+		s.logger.Error("Error in get volume remote call", logs.Args{{"error", err}})
 		return resources.GetVolumeConfigResponse{Error: fmt.Errorf("Error in get volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in get volume remote call %#v", response)
+		s.logger.Error("Error in get volume remote call", logs.Args{{"response", response}})
 		return resources.GetVolumeConfigResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
 	getVolumeConfigResponse := resources.GetVolumeConfigResponse{}
 	err = utils.UnmarshalResponse(response, &getVolumeConfigResponse)
 	if err != nil {
-		s.logger.Printf("Error in unmarshalling response for get remote call %#v for response %#v", err, response)
+		s.logger.Error("Error in unmarshalling response for get remote call", logs.Args{{"response", response}, {"error", err}})
 		return resources.GetVolumeConfigResponse{Error: fmt.Errorf("Error in unmarshalling response for get remote call")}
 	}
 
@@ -153,18 +159,19 @@ func (s *remoteClient) GetVolumeConfig(getVolumeConfigRequest resources.GetVolum
 }
 
 func (s *remoteClient) Attach(attachRequest resources.AttachRequest) resources.AttachResponse {
-	s.logger.Println("remoteClient: attach start")
-	defer s.logger.Println("remoteClient: attach end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.Attach", tracing.KindInternal, logs.Args{{"volume", attachRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	attachRemoteURL := utils.FormatURL(s.storageApiURL, "volumes", attachRequest.Name, "attach")
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "PUT", attachRemoteURL, attachRequest)
 	if err != nil {
-		s.logger.Printf("Error in attach volume remote call %#v", err)
+		s.logger.Error("Error in attach volume remote call", logs.Args{{"error", err}})
 		return resources.AttachResponse{Error: fmt.Errorf("Error in attach volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in attach volume remote call %#v", response)
+		s.logger.Error("Error in attach volume remote call", logs.Args{{"response", response}})
 
 		return resources.AttachResponse{Error: utils.ExtractErrorResponse(response)}
 	}
@@ -185,12 +192,15 @@ func (s *remoteClient) Attach(attachRequest resources.AttachRequest) resources.A
 		return resources.AttachResponse{Error: getVolumeConfigResponse.Error}
 	}
 
-	mounter, err := s.getMounterForBackend(getVolumeResponse.Volume.Backend)
+	mounter, err := s.getMounterForBackend(volumeBackendType(getVolumeResponse.Volume))
 	if err != nil {
 		return resources.AttachResponse{Error: fmt.Errorf("Error determining mounter for volume: %s", err.Error())}
 	}
 	mountRequest := resources.MountRequest{Mountpoint: attachResponse.Mountpoint, VolumeConfig: getVolumeConfigResponse.VolumeConfig}
+	mountSpan := tracing.StartSpan("mount", tracing.KindInternal, logs.Args{{"backend", getVolumeResponse.Volume.Backend}, {"mountpoint", attachResponse.Mountpoint}})
 	mountResponse := mounter.Mount(mountRequest)
+	mountSpan.SetError(mountResponse.Error)
+	mountSpan.End()
 	if mountResponse.Error != nil {
 		return resources.AttachResponse{Error: mountResponse.Error}
 	}
@@ -199,15 +209,16 @@ func (s *remoteClient) Attach(attachRequest resources.AttachRequest) resources.A
 }
 
 func (s *remoteClient) Detach(detachRequest resources.DetachRequest) resources.DetachResponse {
-	s.logger.Println("remoteClient: detach start")
-	defer s.logger.Println("remoteClient: detach end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.Detach", tracing.KindInternal, logs.Args{{"volume", detachRequest.Name}}).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	getVolumeRequest := resources.GetVolumeRequest{Name: detachRequest.Name}
 	getVolumeResponse := s.GetVolume(getVolumeRequest)
 	if getVolumeResponse.Error != nil {
 		return resources.DetachResponse{Error: getVolumeResponse.Error}
 	}
-	mounter, err := s.getMounterForBackend(getVolumeResponse.Volume.Backend)
+	mounter, err := s.getMounterForBackend(volumeBackendType(getVolumeResponse.Volume))
 	if err != nil {
 		return resources.DetachResponse{Error: fmt.Errorf("Volume not found")}
 	}
@@ -218,7 +229,10 @@ func (s *remoteClient) Detach(detachRequest resources.DetachRequest) resources.D
 		return resources.DetachResponse{Error: getVolumeConfigResponse.Error}
 	}
 	unmountRequest := resources.UnmountRequest{VolumeConfig: getVolumeConfigResponse.VolumeConfig}
+	unmountSpan := tracing.StartSpan("unmount", tracing.KindInternal, logs.Args{{"backend", getVolumeResponse.Volume.Backend}})
 	unmountResponse := mounter.Unmount(unmountRequest)
+	unmountSpan.SetError(unmountResponse.Error)
+	unmountSpan.End()
 	if unmountResponse.Error != nil {
 		return resources.DetachResponse{Error: unmountResponse.Error}
 	}
@@ -226,18 +240,18 @@ func (s *remoteClient) Detach(detachRequest resources.DetachRequest) resources.D
 	detachRemoteURL := utils.FormatURL(s.storageApiURL, "volumes", detachRequest.Name, "detach")
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "PUT", detachRemoteURL, detachRequest)
 	if err != nil {
-		s.logger.Printf("Error in detach volume remote call %#v", err)
+		s.logger.Error("Error in detach volume remote call", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: fmt.Errorf("Error in detach volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in detach volume remote call %#v", response)
+		s.logger.Error("Error in detach volume remote call", logs.Args{{"response", response}})
 		return resources.DetachResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
 	afterDetachRequest := resources.AfterDetachRequest{VolumeConfig: getVolumeConfigResponse.VolumeConfig}
 	if afterDetachResponse := mounter.ActionAfterDetach(afterDetachRequest); afterDetachResponse.Error != nil {
-		s.logger.Printf(fmt.Sprintf("Error execute action after detaching the volume : %#v", err))
+		s.logger.Error("Error execute action after detaching the volume", logs.Args{{"error", err}})
 		return resources.DetachResponse{Error: err}
 	}
 	return resources.DetachResponse{}
@@ -245,25 +259,26 @@ func (s *remoteClient) Detach(detachRequest resources.DetachRequest) resources.D
 }
 
 func (s *remoteClient) ListVolumes(listVolumesRequest resources.ListVolumesRequest) resources.ListVolumesResponse {
-	s.logger.Println("remoteClient: list start")
-	defer s.logger.Println("remoteClient: list end")
+	defer bindRequestID()()
+	defer tracing.StartSpan("remote.ListVolumes", tracing.KindInternal).End()
+	defer s.logger.Trace(logs.DEBUG)()
 
 	listRemoteURL := utils.FormatURL(s.storageApiURL, "volumes")
 	response, err := utils.HttpExecute(s.httpClient, s.logger, "GET", listRemoteURL, listVolumesRequest)
 	if err != nil {
-		s.logger.Printf("Error in list volume remote call %#v", err)
+		s.logger.Error("Error in list volume remote call", logs.Args{{"error", err}})
 		return resources.ListVolumesResponse{Error: fmt.Errorf("Error in list volume remote call")}
 	}
 
 	if response.StatusCode != http.StatusOK {
-		s.logger.Printf("Error in list volume remote call %#v", err)
+		s.logger.Error("Error in list volume remote call", logs.Args{{"error", err}})
 		return resources.ListVolumesResponse{Error: utils.ExtractErrorResponse(response)}
 	}
 
 	listVolumesResponse := resources.ListVolumesResponse{}
 	err = utils.UnmarshalResponse(response, &listVolumesResponse)
 	if err != nil {
-		s.logger.Printf("Error in unmarshalling response for get remote call %#v for response %#v", err, response)
+		s.logger.Error("Error in unmarshalling response for get remote call", logs.Args{{"response", response}, {"error", err}})
 		return resources.ListVolumesResponse{Error: err}
 	}
 
@@ -271,24 +286,36 @@ func (s *remoteClient) ListVolumes(listVolumesRequest resources.ListVolumesReque
 
 }
 
-// Return the mounter object. If mounter object already used(in the map mounterPerBackend) then just reuse it
+// bindRequestID keeps the request ID already bound by the caller, or binds a new one, so the logs of the call and the
+// server requests it sends share one ID
+func bindRequestID() func() {
+	if logs.GetRequestID() != "" {
+		return func() {}
+	}
+	return logs.BindRequestID(logs.NewRequestID())
+}
+
+// volumeBackendType returns the type of the backend instance of volume, the servers that do not know the named backend
+// instances only send the backend, which is also its type
+func volumeBackendType(volume resources.Volume) string {
+	if volume.BackendType != "" {
+		return volume.BackendType
+	}
+	return volume.Backend
+}
+
+// Return the mounter object of a backend type. If mounter object already used(in the map mounterPerBackend) then just reuse it
 func (s *remoteClient) getMounterForBackend(backend string) (resources.Mounter, error) {
-	s.logger.Println("remoteClient: getMounterForVolume start")
-	defer s.logger.Println("remoteClient: getMounterForVolume end")
+	defer s.logger.Trace(logs.DEBUG)()
 	mounterInst, ok := s.mounterPerBackend[backend]
 	if ok {
-		s.logger.Printf("getMounterForVolume reuse existing mounter for backend " + backend)
+		s.logger.Debug("getMounterForVolume reuse existing mounter", logs.Args{{"backend", backend}})
 		return mounterInst, nil
-	} else if backend == resources.SpectrumScale {
-		s.mounterPerBackend[backend] = mounter.NewSpectrumScaleMounter(s.logger)
-	} else if backend == resources.SoftlayerNFS || backend == resources.SpectrumScaleNFS {
-		s.mounterPerBackend[backend] = mounter.NewNfsMounter(s.logger)
-	} else if backend == resources.SCBE {
-		s.mounterPerBackend[backend] = mounter.NewScbeMounter(s.config.ScbeRemoteConfig)
-	} else if backend == resources.LocalHost {
-		s.mounterPerBackend[backend] = mounter.NewLocalHostMounter(s.logger, s.config.LocalHostConfig)
-	} else {
+	}
+	newMounter, ok := resources.GetMounterFactory(backend)
+	if !ok {
 		return nil, fmt.Errorf("Mounter not found for backend: %s", backend)
 	}
+	s.mounterPerBackend[backend] = newMounter(s.config)
 	return s.mounterPerBackend[backend], nil
 }
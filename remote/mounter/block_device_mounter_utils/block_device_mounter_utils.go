@@ -19,6 +19,7 @@ package block_device_mounter_utils
 import (
 	"github.com/midoblgsm/ubiquity/remote/mounter/block_device_utils"
 	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 	"sync"
 )
 
@@ -38,7 +39,7 @@ func NewBlockDeviceMounterUtils() BlockDeviceMounterUtils {
 }
 
 func newBlockDeviceMounterUtils(blockDeviceUtils block_device_utils.BlockDeviceUtils) BlockDeviceMounterUtils {
-	return &blockDeviceMounterUtils{logger: logs.GetLogger(),
+	return &blockDeviceMounterUtils{logger: logs.GetComponentLogger(logs.ComponentMounter),
 		blockDeviceUtils:  blockDeviceUtils,
 		rescanLock:        &sync.RWMutex{},
 		cleanMPDeviceLock: &sync.RWMutex{},
@@ -46,10 +47,13 @@ func newBlockDeviceMounterUtils(blockDeviceUtils block_device_utils.BlockDeviceU
 }
 
 // MountDeviceFlow create filesystem on the device (if needed) and then mount it on a given mountpoint
-func (b *blockDeviceMounterUtils) MountDeviceFlow(devicePath string, fsType string, mountPoint string) error {
+func (b *blockDeviceMounterUtils) MountDeviceFlow(devicePath string, fsType string, mountPoint string) (err error) {
+	span := tracing.StartSpan("mount.device", tracing.KindInternal, logs.Args{{"device", devicePath}, {"fs.type", fsType}})
+	defer span.EndWithError(&err)
 	defer b.logger.Trace(logs.INFO, logs.Args{{"devicePath", devicePath}, {"fsType", fsType}, {"mountPoint", mountPoint}})()
 
-	needToCreateFS, err := b.blockDeviceUtils.CheckFs(devicePath)
+	var needToCreateFS bool
+	needToCreateFS, err = b.blockDeviceUtils.CheckFs(devicePath)
 	if err != nil {
 		return b.logger.ErrorRet(err, "CheckFs failed")
 	}
@@ -66,10 +70,12 @@ func (b *blockDeviceMounterUtils) MountDeviceFlow(devicePath string, fsType stri
 }
 
 // UnmountDeviceFlow umount device, clean device and remove mountpoint folder
-func (b *blockDeviceMounterUtils) UnmountDeviceFlow(devicePath string) error {
+func (b *blockDeviceMounterUtils) UnmountDeviceFlow(devicePath string) (err error) {
 	defer b.logger.Trace(logs.INFO, logs.Args{{"devicePath", devicePath}})
+	span := tracing.StartSpan("unmount.device", tracing.KindInternal, logs.Args{{"device", devicePath}})
+	defer span.EndWithError(&err)
 
-	err := b.blockDeviceUtils.UmountFs(devicePath)
+	err = b.blockDeviceUtils.UmountFs(devicePath)
 	if err != nil {
 		return b.logger.ErrorRet(err, "UmountFs failed")
 	}
@@ -94,12 +100,16 @@ func (b *blockDeviceMounterUtils) UnmountDeviceFlow(devicePath string) error {
 // 2. SCSI rescan
 // 3. multipathing rescan
 // return error if one of the steps fail
-func (b *blockDeviceMounterUtils) RescanAll(withISCSI bool, wwn string, rescanForCleanUp bool) error {
+func (b *blockDeviceMounterUtils) RescanAll(withISCSI bool, wwn string, rescanForCleanUp bool) (err error) {
 	defer b.logger.Trace(logs.INFO, logs.Args{{"withISCSI", withISCSI}})
+	span := tracing.StartSpan("rescan", tracing.KindInternal, logs.Args{{"volume.wwn", wwn}, {"iscsi", withISCSI}, {"cleanup", rescanForCleanUp}})
+	defer span.EndWithError(&err)
 
 	// locking for concurrent rescans and reduce rescans if no need
 	b.logger.Debug("Ask for rescanLock for volumeWWN", logs.Args{{"volumeWWN", wwn}})
+	lockSpan := tracing.StartSpan("rescan.lock.wait", tracing.KindInternal)
 	b.rescanLock.Lock() // Prevent rescan in parallel
+	lockSpan.End()
 	b.logger.Debug("Recived rescanLock for volumeWWN", logs.Args{{"volumeWWN", wwn}})
 	defer b.rescanLock.Unlock()
 	defer b.logger.Debug("Released rescanLock for volumeWWN", logs.Args{{"volumeWWN", wwn}})
@@ -131,6 +141,8 @@ func (b *blockDeviceMounterUtils) RescanAll(withISCSI bool, wwn string, rescanFo
 	return nil
 }
 
-func (b *blockDeviceMounterUtils) Discover(volumeWwn string) (string, error) {
+func (b *blockDeviceMounterUtils) Discover(volumeWwn string) (device string, err error) {
+	span := tracing.StartSpan("multipath.discover", tracing.KindInternal, logs.Args{{"volume.wwn", volumeWwn}})
+	defer span.EndWithError(&err)
 	return b.blockDeviceUtils.Discover(volumeWwn)
 }
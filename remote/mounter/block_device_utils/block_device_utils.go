@@ -19,5 +19,5 @@ func NewBlockDeviceUtilsWithExecutor(executor utils.Executor) BlockDeviceUtils {
 }
 
 func newBlockDeviceUtils(executor utils.Executor) BlockDeviceUtils {
-	return &blockDeviceUtils{logger: logs.GetLogger(), exec: executor}
+	return &blockDeviceUtils{logger: logs.GetComponentLogger(logs.ComponentMounter), exec: executor}
 }
@@ -18,30 +18,35 @@ package mounter
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 	"os"
 	"path"
 )
 
 type localhostMounter struct {
-	logger *log.Logger
+	logger logs.Logger
 	config resources.LocalHostConfig
 }
 
-func NewLocalHostMounter(logger *log.Logger, config resources.LocalHostConfig) resources.Mounter {
-	return &localhostMounter{logger: logger, config: config}
+func init() {
+	resources.RegisterMounter(resources.LocalHost, func(config resources.UbiquityPluginConfig) resources.Mounter {
+		return NewLocalHostMounter(config.LocalHostConfig)
+	})
+}
+
+func NewLocalHostMounter(config resources.LocalHostConfig) resources.Mounter {
+	return &localhostMounter{logger: logs.GetComponentLogger(logs.ComponentMounter), config: config}
 }
 
 func (s *localhostMounter) Mount(mountRequest resources.MountRequest) resources.MountResponse {
-	s.logger.Println("localhostMounter: Mount start")
-	defer s.logger.Println("localhostMounter: Mount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	volumeName, ok := mountRequest.VolumeConfig["volumeName"]
 
 	if !ok {
-		s.logger.Printf("volumeName should be specified %#v \n", mountRequest)
+		s.logger.Info("volumeName should be specified", logs.Args{{"mountRequest", mountRequest}})
 		return resources.MountResponse{Error: fmt.Errorf("volumeName not specified")}
 	}
 
@@ -49,7 +54,7 @@ func (s *localhostMounter) Mount(mountRequest resources.MountRequest) resources.
 	err := os.Link(volumePath, mountRequest.Mountpoint)
 
 	if err != nil {
-		s.logger.Printf("Error removing mountpoint %#v \n", err)
+		s.logger.Error("Error removing mountpoint", logs.Args{{"error", err}})
 		return resources.MountResponse{Error: err}
 	}
 
@@ -57,8 +62,7 @@ func (s *localhostMounter) Mount(mountRequest resources.MountRequest) resources.
 }
 
 func (s *localhostMounter) Unmount(unmountRequest resources.UnmountRequest) resources.UnmountResponse {
-	s.logger.Println("spectrumScaleMounter: Unmount start")
-	defer s.logger.Println("spectrumScaleMounter: Unmount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	// for spectrum-scale native: No Op for now
 	return resources.UnmountResponse{}
@@ -18,34 +18,39 @@ package mounter
 
 import (
 	"fmt"
-	"log"
 	"path"
 	"strings"
 
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type nfsMounter struct {
-	logger   *log.Logger
+	logger   logs.Logger
 	executor utils.Executor
 }
 
-func NewNfsMounter(logger *log.Logger) resources.Mounter {
-	return &nfsMounter{logger: logger, executor: utils.NewExecutor()}
+func init() {
+	newNfsMounter := func(config resources.UbiquityPluginConfig) resources.Mounter { return NewNfsMounter() }
+	resources.RegisterMounter(resources.SoftlayerNFS, newNfsMounter)
+	resources.RegisterMounter(resources.SpectrumScaleNFS, newNfsMounter)
+}
+
+func NewNfsMounter() resources.Mounter {
+	return &nfsMounter{logger: logs.GetComponentLogger(logs.ComponentMounter), executor: utils.NewExecutor()}
 }
 
 func (s *nfsMounter) Mount(mountRequest resources.MountRequest) resources.MountResponse {
-	s.logger.Println("nfsMounter: Mount start")
-	defer s.logger.Println("nfsMounter: Mount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	remoteMountpoint := path.Join("/mnt/", strings.Split(mountRequest.Mountpoint, ":")[1])
 	if s.isMounted(mountRequest.Mountpoint, remoteMountpoint) {
-		s.logger.Printf("nfsMounter: - mount: %s is already mounted at %s\n", mountRequest.Mountpoint, remoteMountpoint)
+		s.logger.Info("Already mounted", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"remoteMountpoint", remoteMountpoint}})
 		return resources.MountResponse{Mountpoint: remoteMountpoint}
 	}
 
-	s.logger.Printf("nfsMounter: mkdir -p %s\n", remoteMountpoint)
+	s.logger.Debug("nfsMounter: mkdir -p", logs.Args{{"remoteMountpoint", remoteMountpoint}})
 	args := []string{"mkdir", "-p", remoteMountpoint}
 
 	_, err := s.executor.Execute("sudo", args)
@@ -61,14 +66,14 @@ func (s *nfsMounter) Mount(mountRequest resources.MountRequest) resources.MountR
 			args := []string{"chown", fmt.Sprintf("%s:%s", uid, gid), remoteMountpoint}
 			_, err = s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to change permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to change permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 			//set permissions to specific user
 			args = []string{"chmod", "og-rw", remoteMountpoint}
 			_, err = s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to set user permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to set user permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 
@@ -77,7 +82,7 @@ func (s *nfsMounter) Mount(mountRequest resources.MountRequest) resources.MountR
 			args := []string{"chmod", "777", remoteMountpoint}
 			_, err = s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to change permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to change permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 		}
@@ -87,8 +92,7 @@ func (s *nfsMounter) Mount(mountRequest resources.MountRequest) resources.MountR
 }
 
 func (s *nfsMounter) Unmount(unmountRequest resources.UnmountRequest) resources.UnmountResponse {
-	s.logger.Println("nfsMounter: Unmount start")
-	defer s.logger.Println("nfsMounter: Unmount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	nfs_share := unmountRequest.VolumeConfig["nfs_share"].(string)
 
@@ -100,44 +104,40 @@ func (s *nfsMounter) Unmount(unmountRequest resources.UnmountRequest) resources.
 }
 
 func (s *nfsMounter) mount(nfsShare, remoteMountpoint string) resources.MountResponse {
-	s.logger.Printf("nfsMounter: - mount start nfsShare=%s\n", nfsShare)
-	defer s.logger.Printf("nfsMounter: - mount end nfsShare=%s\n", nfsShare)
+	defer s.logger.Trace(logs.DEBUG, logs.Args{{"nfsShare", nfsShare}})()
 
 	args := []string{"mount", "-t", "nfs", nfsShare, remoteMountpoint}
 	output, err := s.executor.Execute("sudo", args)
 	if err != nil {
 		return resources.MountResponse{Error: fmt.Errorf("nfsMounter: Failed to mount share %s to remote mountpoint %s (error '%s', output '%s')\n", nfsShare, remoteMountpoint, err.Error(), output)}
 	}
-	s.logger.Printf("nfsMounter:  mount output: %s\n", string(output))
+	s.logger.Debug("mount output", logs.Args{{"output", string(output)}})
 
 	return resources.MountResponse{Mountpoint: remoteMountpoint}
 }
 
 func (s *nfsMounter) isMounted(nfsShare, remoteMountpoint string) bool {
-	s.logger.Printf("nfsMounter: - isMounted start nfsShare=%s\n", nfsShare)
-	defer s.logger.Printf("nfsMounter: - isMounted end nfsShare=%s\n", nfsShare)
+	defer s.logger.Trace(logs.DEBUG, logs.Args{{"nfsShare", nfsShare}})()
 
 	command := "grep"
 	args := []string{"-qs", fmt.Sprintf("%s\\s%s", nfsShare, remoteMountpoint), "/proc/mounts"}
 	output, err := s.executor.Execute(command, args)
 	if err != nil {
-		s.logger.Printf("nfsMounter: failed to check if share %s is mounted at remote mountpoint %s (error '%s', output '%s')\n",
-			nfsShare, remoteMountpoint, err.Error(), output)
+		s.logger.Error("nfsMounter: failed to check if share is mounted", logs.Args{{"nfsShare", nfsShare}, {"remoteMountpoint", remoteMountpoint}, {"error", err}, {"output", string(output)}})
 		return false
 	}
 	return true
 }
 
 func (s *nfsMounter) unmount(remoteMountpoint string) resources.UnmountResponse {
-	s.logger.Printf("nfsMounter: - unmount start remoteMountpoint=%s\n", remoteMountpoint)
-	defer s.logger.Printf("nfsMounter: - unmount end remoteMountpoint=%s\n", remoteMountpoint)
+	defer s.logger.Trace(logs.DEBUG, logs.Args{{"remoteMountpoint", remoteMountpoint}})()
 
 	args := []string{"umount", remoteMountpoint}
 	output, err := s.executor.Execute("sudo", args)
 	if err != nil {
 		return resources.UnmountResponse{Error: fmt.Errorf("Failed to unmount remote mountpoint %s (error '%s', output '%s')\n", remoteMountpoint, err.Error(), output)}
 	}
-	s.logger.Printf("nfsMounter: umount output: %s\n", string(output))
+	s.logger.Debug("umount output", logs.Args{{"output", string(output)}})
 
 	return resources.UnmountResponse{}
 }
@@ -31,10 +31,16 @@ type scbeMounter struct {
 	config                  resources.ScbeRemoteConfig
 }
 
+func init() {
+	resources.RegisterMounter(resources.SCBE, func(config resources.UbiquityPluginConfig) resources.Mounter {
+		return NewScbeMounter(config.ScbeRemoteConfig)
+	})
+}
+
 func NewScbeMounter(scbeRemoteConfig resources.ScbeRemoteConfig) resources.Mounter {
 	blockDeviceMounterUtils := block_device_mounter_utils.NewBlockDeviceMounterUtils()
 	return &scbeMounter{
-		logger:                  logs.GetLogger(),
+		logger:                  logs.GetComponentLogger(logs.ComponentMounter),
 		blockDeviceMounterUtils: blockDeviceMounterUtils,
 		exec:   utils.NewExecutor(),
 		config: scbeRemoteConfig,
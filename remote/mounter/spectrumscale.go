@@ -18,24 +18,29 @@ package mounter
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/midoblgsm/ubiquity/resources"
 	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type spectrumScaleMounter struct {
-	logger   *log.Logger
+	logger   logs.Logger
 	executor utils.Executor
 }
 
-func NewSpectrumScaleMounter(logger *log.Logger) resources.Mounter {
-	return &spectrumScaleMounter{logger: logger, executor: utils.NewExecutor()}
+func init() {
+	resources.RegisterMounter(resources.SpectrumScale, func(config resources.UbiquityPluginConfig) resources.Mounter {
+		return NewSpectrumScaleMounter()
+	})
+}
+
+func NewSpectrumScaleMounter() resources.Mounter {
+	return &spectrumScaleMounter{logger: logs.GetComponentLogger(logs.ComponentMounter), executor: utils.NewExecutor()}
 }
 
 func (s *spectrumScaleMounter) Mount(mountRequest resources.MountRequest) resources.MountResponse {
-	s.logger.Println("spectrumScaleMounter: Mount start")
-	defer s.logger.Println("spectrumScaleMounter: Mount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	isPreexisting, isPreexistingSpecified := mountRequest.VolumeConfig["isPreexisting"]
 	if isPreexistingSpecified && isPreexisting.(bool) == false {
@@ -46,14 +51,14 @@ func (s *spectrumScaleMounter) Mount(mountRequest resources.MountRequest) resour
 			args := []string{"chown", fmt.Sprintf("%s:%s", uid, gid), mountRequest.Mountpoint}
 			_, err := s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to change permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to change permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 			//set permissions to specific user
 			args = []string{"chmod", "og-rw", mountRequest.Mountpoint}
 			_, err = s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to set user permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to set user permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 		} else {
@@ -61,7 +66,7 @@ func (s *spectrumScaleMounter) Mount(mountRequest resources.MountRequest) resour
 			args := []string{"chmod", "777", mountRequest.Mountpoint}
 			_, err := s.executor.Execute("sudo", args)
 			if err != nil {
-				s.logger.Printf("Failed to change permissions of mountpoint %s: %s", mountRequest.Mountpoint, err.Error())
+				s.logger.Error("Failed to change permissions of mountpoint", logs.Args{{"mountpoint", mountRequest.Mountpoint}, {"error", err}})
 				return resources.MountResponse{Error: err}
 			}
 		}
@@ -71,8 +76,7 @@ func (s *spectrumScaleMounter) Mount(mountRequest resources.MountRequest) resour
 }
 
 func (s *spectrumScaleMounter) Unmount(unmountRequest resources.UnmountRequest) resources.UnmountResponse {
-	s.logger.Println("spectrumScaleMounter: Unmount start")
-	defer s.logger.Println("spectrumScaleMounter: Unmount end")
+	defer s.logger.Trace(logs.DEBUG)()
 
 	// for spectrum-scale native: No Op for now
 	return resources.UnmountResponse{}
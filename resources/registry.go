@@ -0,0 +1,122 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jinzhu/gorm"
+)
+
+// BackendPlugin is the server side of a backend type, the server builds the clients of the configured instances of the
+// registered types
+type BackendPlugin struct {
+	Type string
+	// DecodeConfig returns the settings of the instance from its section of the config, and false if the section is
+	// empty (an instance of the ScbeConfig, SpectrumScaleConfig or LocalHostConfig sections is then not served)
+	DecodeConfig func(backend BackendConfig) (settings interface{}, configured bool, err error)
+	// NewClient creates the client of the instance named backend, config holds the server parameters
+	NewClient func(backend string, settings interface{}, config UbiquityServerConfig, database *gorm.DB) (StorageClient, error)
+	// ValidateConfig checks the settings like NewClient uses them, without the database (optional)
+	ValidateConfig func(settings interface{}, config UbiquityServerConfig) error
+	// DefaultAccessMode of the volumes created without one, AccessModeMultiWriter if empty
+	DefaultAccessMode string
+}
+
+// MounterFactory creates the mounter of a backend type on the plugin side
+type MounterFactory func(config UbiquityPluginConfig) Mounter
+
+var (
+	backendPlugins   = make(map[string]BackendPlugin)
+	mounterFactories = make(map[string]MounterFactory)
+	registryLock     = &sync.Mutex{}
+)
+
+// RegisterBackend adds a backend type to the server. Backends call it from init().
+func RegisterBackend(plugin BackendPlugin) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, exists := backendPlugins[plugin.Type]; exists {
+		panic(fmt.Sprintf("backend %s registered twice", plugin.Type))
+	}
+	backendPlugins[plugin.Type] = plugin
+}
+
+// GetBackend returns the registered backend type
+func GetBackend(backendType string) (BackendPlugin, bool) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	plugin, ok := backendPlugins[backendType]
+	return plugin, ok
+}
+
+// GetDefaultAccessMode returns the access mode of the volumes of backendType created or adopted without one. It keeps the
+// behavior from before the access modes: an scbe volume is mapped to one host, a filesystem volume can be attached by any
+// number of hosts (see BackendPlugin.DefaultAccessMode)
+func GetDefaultAccessMode(backendType string) string {
+	if plugin, ok := GetBackend(backendType); ok && plugin.DefaultAccessMode != "" {
+		return plugin.DefaultAccessMode
+	}
+	return AccessModeMultiWriter
+}
+
+// GetBackendTypes returns the registered backend types, sorted
+func GetBackendTypes() []string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	types := make([]string, 0, len(backendPlugins))
+	for backendType := range backendPlugins {
+		types = append(types, backendType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RegisterMounter adds the mounter of a backend type to the plugins. Mounters call it from init().
+func RegisterMounter(backendType string, factory MounterFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, exists := mounterFactories[backendType]; exists {
+		panic(fmt.Sprintf("mounter %s registered twice", backendType))
+	}
+	mounterFactories[backendType] = factory
+}
+
+// GetMounterFactory returns the mounter factory of a backend type
+func GetMounterFactory(backendType string) (MounterFactory, bool) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	factory, ok := mounterFactories[backendType]
+	return factory, ok
+}
+
+// DecodeBackendSettings decodes the Settings of a backend instance into v, with the same rules as the config file
+func DecodeBackendSettings(settings map[string]interface{}, v interface{}) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	var buffer bytes.Buffer
+	if err := toml.NewEncoder(&buffer).Encode(settings); err != nil {
+		return err
+	}
+	_, err := toml.Decode(buffer.String(), v)
+	return err
+}
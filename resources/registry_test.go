@@ -0,0 +1,95 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resources_test
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	Context(".RegisterBackend", func() {
+		It("should register a backend type once", func() {
+			plugin := resources.BackendPlugin{
+				Type: "registry-test",
+				DecodeConfig: func(backend resources.BackendConfig) (interface{}, bool, error) {
+					return nil, len(backend.Settings) > 0, nil
+				},
+				NewClient: func(backend string, settings interface{}, config resources.UbiquityServerConfig, database *gorm.DB) (resources.StorageClient, error) {
+					return nil, nil
+				},
+			}
+			resources.RegisterBackend(plugin)
+			registered, ok := resources.GetBackend("registry-test")
+			Expect(ok).To(BeTrue())
+			Expect(registered.Type).To(Equal("registry-test"))
+			Expect(resources.GetBackendTypes()).To(ContainElement("registry-test"))
+			Expect(func() { resources.RegisterBackend(plugin) }).To(Panic())
+
+			_, ok = resources.GetBackend("registry-missing")
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context(".RegisterMounter", func() {
+		It("should register a mounter once", func() {
+			factory := func(config resources.UbiquityPluginConfig) resources.Mounter { return nil }
+			resources.RegisterMounter("registry-test", factory)
+			_, ok := resources.GetMounterFactory("registry-test")
+			Expect(ok).To(BeTrue())
+			Expect(func() { resources.RegisterMounter("registry-test", factory) }).To(Panic())
+			_, ok = resources.GetMounterFactory("registry-missing")
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context(".DecodeBackendSettings", func() {
+		type settings struct {
+			Endpoint     string
+			Port         int
+			PasswordFile string `toml:"password_file"`
+			Pools        []string
+			Tls          struct {
+				SkipVerify bool
+			}
+		}
+		It("should decode the settings like the config file", func() {
+			var decoded settings
+			Expect(resources.DecodeBackendSettings(map[string]interface{}{
+				"endpoint":      "https://acme.example.com",
+				"Port":          int64(8443),
+				"password_file": "/run/secrets/acme",
+				"pools":         []interface{}{"gold", "silver"},
+				"tls":           map[string]interface{}{"skipVerify": true},
+			}, &decoded)).To(Succeed())
+			Expect(decoded.Endpoint).To(Equal("https://acme.example.com"))
+			Expect(decoded.Port).To(Equal(8443))
+			Expect(decoded.PasswordFile).To(Equal("/run/secrets/acme"))
+			Expect(decoded.Pools).To(Equal([]string{"gold", "silver"}))
+			Expect(decoded.Tls.SkipVerify).To(BeTrue())
+		})
+		It("should fail on a type mismatch", func() {
+			var decoded settings
+			Expect(resources.DecodeBackendSettings(map[string]interface{}{"port": "https"}, &decoded)).ToNot(Succeed())
+		})
+		It("should leave v unchanged without settings", func() {
+			decoded := settings{Endpoint: "default"}
+			Expect(resources.DecodeBackendSettings(nil, &decoded)).To(Succeed())
+			Expect(decoded.Endpoint).To(Equal("default"))
+		})
+	})
+})
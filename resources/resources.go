@@ -17,6 +17,9 @@
 package resources
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/jinzhu/gorm"
 )
 
@@ -36,10 +39,141 @@ type UbiquityServerConfig struct {
 	ScbeConfig          ScbeConfig
 	LocalHostConfig     LocalHostConfig
 	BrokerConfig        BrokerConfig
+	DatabaseConfig      DatabaseConfig
+	ReconcileConfig     ReconcileConfig
+	RetentionConfig     RetentionConfig
+	LeaseConfig         LeaseConfig
+	LockConfig          LockConfig
 	DefaultBackend      string
 	LogLevel            string
+	LogFormat           string // text (the default) or json
+	LogRotationConfig   LogRotationConfig
+	LogComponentLevels  map[string]string // level of some component loggers, the others log at LogLevel
+	TracingConfig       TracingConfig
+	Backends            []BackendConfig               // named backend instances, in addition to the ScbeConfig, SpectrumScaleConfig and LocalHostConfig sections
+	StorageClasses      map[string]StorageClassConfig // keyed by the class name of the create requests
+}
+
+// StorageClassConfig maps a storage class, an abstract tier such as gold, to a backend instance and the creation options
+// of its volumes, so the users do not need to know the SCBE services or the Spectrum Scale filesystems
+type StorageClassConfig struct {
+	Backend          string            // the backend instance of the volumes of the class
+	Options          map[string]string // the default creation options, e.g. profile = "gold" and fstype = "xfs"
+	AllowedOverrides []string          // the options a create request can set, e.g. size; the request cannot set the others
+}
+
+// BackendConfig declares a named instance of a backend type, so one server can reach several SCBE servers or Spectrum
+// Scale clusters. Only the section of its type is used.
+type BackendConfig struct {
+	Name                string // the backend name in the API and in the volumes, e.g. scbe-dc1
+	Type                string // scbe, spectrum-scale, localhost or the type of another registered backend
+	ScbeConfig          ScbeConfig
+	SpectrumScaleConfig SpectrumScaleConfig
+	LocalHostConfig     LocalHostConfig
+	Settings            map[string]interface{} // the section of the other backend types, see DecodeBackendSettings
+}
+
+// BackendType returns the type of the backend instance name, the backends of the ScbeConfig, SpectrumScaleConfig and
+// LocalHostConfig sections are named after their type
+func (c UbiquityServerConfig) BackendType(name string) string {
+	for _, backend := range c.Backends {
+		if backend.Name == name {
+			return backend.Type
+		}
+	}
+	return name
+}
+
+// DatabaseConfig selects the SQL database that holds the server state.
+// If Dialect is empty, a SQLite file named ubiquity.db in the ConfigPath directory is used.
+type DatabaseConfig struct {
+	Dialect string // one of sqlite3, postgres or mysql
+	DSN     string // connection string in the format expected by the dialect driver
+	DSNFile string `toml:"dsn_file"` // file holding the DSN instead of DSN, e.g. a Kubernetes or Docker secret
+}
+
+func (c DatabaseConfig) String() string {
+	return fmt.Sprintf("{Dialect:%s DSN:%s DSNFile:%s}", c.Dialect, RedactSecret(c.DSN), c.DSNFile)
+}
+
+const (
+	DatabaseDialectSqlite   = "sqlite3"
+	DatabaseDialectPostgres = "postgres"
+	DatabaseDialectMysql    = "mysql"
+	DefaultSqliteDBFileName = "ubiquity.db"
+)
+
+// ReconcileConfig controls the background comparison of the volumes in the database with the volumes on the storage
+type ReconcileConfig struct {
+	Interval int  // seconds between two background runs, 0 disables the background runs
+	Repair   bool // repair the drifts found by the background runs instead of only reporting them
+}
+
+// RetentionConfig enables the soft delete of volumes.
+// While Period is 0, removing a volume deletes its storage right away.
+type RetentionConfig struct {
+	Period        int // seconds a removed volume is kept before it is purged
+	PurgeInterval int // seconds between two background purges, defaults to DefaultPurgeInterval
+}
+
+const DefaultPurgeInterval = 3600
+
+// LeaseConfig controls the leader lease of the servers that share a database.
+// A server that cannot renew the lease within Duration stops, and another server takes over.
+type LeaseConfig struct {
+	Duration      int // seconds the lease is valid after each renewal, defaults to DefaultLeaseDuration
+	RenewInterval int // seconds between two renewals, must be shorter than Duration, defaults to DefaultLeaseRenewInterval
+}
+
+const (
+	DefaultLeaseDuration      = 15
+	DefaultLeaseRenewInterval = 5
+)
+
+// LockConfig selects where the volume and host locks are kept.
+// With the database locks, all the servers that share the database serve the API at the same time,
+// and the lease is only held while a server starts.
+type LockConfig struct {
+	Type   string // local (the default) or database
+	Expiry int    // seconds after which the database locks of a stopped server are released, defaults to DefaultLockExpiry
+
+	// seconds a request waits for a volume used by another operation before it fails with 423 (Locked),
+	// defaults to DefaultLockWaitTimeout, a negative value waits forever
+	WaitTimeout int
+}
+
+const (
+	LockTypeLocal            = "local"
+	LockTypeDatabase         = "database"
+	DefaultLockExpiry        = 30
+	DefaultLockRetryInterval = 200 // milliseconds between two attempts to take a busy database lock
+	DefaultLockWaitTimeout   = 30
+)
+
+// LogRotationConfig sets when the log files in LogPath are rotated and how many rotated files are kept.
+// MaxSize, MaxBackups and MaxAge get their default when 0, a negative value disables the rule.
+type LogRotationConfig struct {
+	MaxSize     int  // megabytes written to a log file before it is rotated, defaults to DefaultLogMaxSize
+	RotateEvery int  // hours after which a log file is rotated even if it is small, 0 disables it
+	MaxBackups  int  // number of rotated files kept per log file, defaults to DefaultLogMaxBackups
+	MaxAge      int  // days a rotated file is kept, defaults to DefaultLogMaxAge
+	Compress    bool // gzip the rotated files
 }
 
+// TracingConfig selects where the trace spans are exported, tracing is disabled if both File and Endpoint are empty
+type TracingConfig struct {
+	ServiceName   string // service.name of the spans, defaults to ubiquity
+	File          string // OTLP-JSON lines are appended to this file
+	Endpoint      string // OTLP/HTTP traces endpoint, e.g. http://localhost:4318/v1/traces
+	FlushInterval int    // seconds between two exports of the queued spans, defaults to 5
+}
+
+const (
+	DefaultLogMaxSize    = 100
+	DefaultLogMaxBackups = 5
+	DefaultLogMaxAge     = 30
+)
+
 // TODO we should consider to move dedicated backend structs to the backend resource file instead of this one.
 type SpectrumScaleConfig struct {
 	DefaultFilesystemName string
@@ -47,12 +181,19 @@ type SpectrumScaleConfig struct {
 	SshConfig             SshConfig
 	RestConfig            RestConfig
 	ForceDelete           bool
+	PlacementPolicy       string // most-free, first-fit or weighted to choose the filesystem of the volumes without one, empty always uses DefaultFilesystemName
 }
 
 type CredentialInfo struct {
-	UserName string `json:"username"`
-	Password string `json:"password"`
-	Group    string `json:"group"`
+	UserName     string `json:"username"`
+	UserNameFile string `json:"-" toml:"username_file"` // file holding the user name instead of UserName
+	Password     string `json:"password"`
+	PasswordFile string `json:"-" toml:"password_file"` // file holding the password instead of Password
+	Group        string `json:"group"`
+}
+
+func (c CredentialInfo) String() string {
+	return fmt.Sprintf("{UserName:%s UserNameFile:%s Password:%s PasswordFile:%s Group:%s}", c.UserName, c.UserNameFile, RedactSecret(c.Password), c.PasswordFile, c.Group)
 }
 
 type ConnectionInfo struct {
@@ -70,6 +211,7 @@ type ScbeConfig struct {
 	UbiquityInstanceName string // Prefix for the volume name in the storage side (max length 15 char)
 
 	DefaultFilesystemType string // The default filesystem type to create on new provisioned volume during attachment to the host
+	PlacementPolicy       string // most-free, first-fit or weighted to choose the service of the volumes without a profile, empty always uses DefaultService
 }
 
 const UbiquityInstanceNameMaxSize = 15
@@ -77,18 +219,38 @@ const DefaultForScbeConfigParamDefaultVolumeSize = "1"    // if customer don't m
 const DefaultForScbeConfigParamDefaultFilesystem = "ext4" // if customer don't mention fstype, then the default is ext4
 const PathToMountUbiquityBlockDevices = "/ubiquity/%s"    // %s is the WWN of the volume # TODO this should be moved to docker plugin side
 const OptionNameForVolumeFsType = "fstype"                // the option name of the fstype and also the key in the volumeConfig
+const OptionNameForStorageClass = "storage-class"         // the option name of the storage class, for the clients that only send options
 
 type SshConfig struct {
-	User string
-	Host string
-	Port string
+	User     string
+	UserFile string `toml:"user_file"` // file holding the user instead of User
+	Host     string
+	Port     string
 }
 
 type RestConfig struct {
-	Endpoint string
-	User     string
-	Password string
-	Hostname string
+	Endpoint     string
+	User         string
+	UserFile     string `toml:"user_file"` // file holding the user instead of User
+	Password     string
+	PasswordFile string `toml:"password_file"` // file holding the password instead of Password
+	Hostname     string
+}
+
+func (c RestConfig) String() string {
+	return fmt.Sprintf("{Endpoint:%s User:%s UserFile:%s Password:%s PasswordFile:%s Hostname:%s}", c.Endpoint, c.User, c.UserFile, RedactSecret(c.Password), c.PasswordFile, c.Hostname)
+}
+
+// RedactedSecret replaces the passwords and the other secrets in the logs, the configs are logged with %v so their
+// String methods redact the secrets they hold
+const RedactedSecret = "<redacted>"
+
+// RedactSecret returns RedactedSecret, or the empty string for an empty secret so a missing secret shows in the logs
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return RedactedSecret
 }
 
 type SpectrumNfsRemoteConfig struct {
@@ -109,6 +271,9 @@ type UbiquityPluginConfig struct {
 	LocalHostConfig         LocalHostConfig
 	Backends                []string
 	LogLevel                string
+	LogFormat               string // text (the default) or json
+	LogRotationConfig       LogRotationConfig
+	TracingConfig           TracingConfig
 }
 
 type UbiquityDockerPluginConfig struct {
@@ -143,6 +308,150 @@ type StorageClient interface {
 	Detach(detachRequest DetachRequest) DetachResponse
 }
 
+// BackendReconciler is implemented by the StorageClients that can compare their database records with the storage.
+// Reconcile only reports, RepairDrift checks the storage again before changing anything.
+type BackendReconciler interface {
+	Reconcile() ([]Drift, error)
+	RepairDrift(drift Drift) error
+}
+
+const (
+	DriftMissingStorage = "missing-storage" // the volume is in the database but not on the storage
+	DriftOrphanStorage  = "orphan-storage"  // the storage has a volume that is not in the database
+	DriftAttachMismatch = "attach-mismatch" // the database and the storage disagree on where the volume is attached
+)
+
+type Drift struct {
+	Kind      string
+	Backend   string
+	Volume    string // empty for orphan storage
+	Resource  string // the storage side identifier (WWN, fileset or directory)
+	InDB      string // attachment recorded in the database, for attach mismatches
+	OnStorage string // attachment found on the storage, for attach mismatches
+}
+
+type ReconcileRequest struct {
+	Backends []string // empty means all the backends
+	Repair   bool
+}
+
+type ReconcileReport struct {
+	Backend  string
+	Drifts   []Drift
+	Repaired []Drift
+	Errors   []string
+}
+
+type ReconcileResponse struct {
+	Reports []ReconcileReport
+	Err     string
+}
+
+// LogLevelsResponse is the default log level and the levels of the component loggers
+type LogLevelsResponse struct {
+	Default    string
+	Components map[string]string
+	Err        string
+}
+
+// SetLogLevelRequest sets the level of a component logger, the empty Component sets the default level and the empty
+// Level makes the component log at the default level again
+type SetLogLevelRequest struct {
+	Component string
+	Level     string
+}
+
+// BackendAdopter is implemented by the StorageClients that can register storage created outside ubiquity as volumes
+type BackendAdopter interface {
+	Adopt(adoptRequest AdoptRequest) AdoptResponse
+}
+
+type AdoptRequest struct {
+	Backend    string
+	Pattern    string            // shell pattern (see path.Match) of the fileset or SCBE volume names to adopt
+	Filesystem string            // Spectrum Scale filesystem to scan, the default filesystem if empty
+	Service    string            // SCBE service to scan, the default service if empty
+	Opts       map[string]string // options of all the adopted volumes (e.g uid and gid, fstype)
+	DryRun     bool              // only return the volumes that would be adopted
+}
+
+type AdoptResponse struct {
+	Volumes []Volume
+	Error   error
+}
+
+// BackendDescriber is implemented by the StorageClients that report their activation state and their own
+// capabilities, the server adds the capabilities of the optional interfaces they implement
+type BackendDescriber interface {
+	IsActivated() bool
+	Capabilities() []string
+}
+
+// PoolLister is implemented by the StorageClients that create their volumes in pools with their own capacity
+type PoolLister interface {
+	ListPools() ([]StoragePool, error)
+}
+
+// VolumeQosUpdater is implemented by the StorageClients that can change the QoS limits of an existing volume
+type VolumeQosUpdater interface {
+	UpdateVolumeQos(updateVolumeQosRequest UpdateVolumeQosRequest) error
+}
+
+type UpdateVolumeQosRequest struct {
+	Name    string
+	MaxIops int // 0 removes the limit
+	MaxMbps int // 0 removes the limit
+}
+
+// Capabilities of the backends, listed by GET /ubiquity_storage/backends
+const (
+	CapabilityAdopt       = "adopt"        // registers storage created outside ubiquity as volumes
+	CapabilityReconcile   = "reconcile"    // detects the drift between the database and the storage
+	CapabilityRecover     = "recover"      // finishes the volume transitions interrupted by a crash
+	CapabilityPools       = "pools"        // lists its pools and their capacity
+	CapabilityPlacement   = "placement"    // places the volumes that do not pin a pool by free capacity
+	CapabilityMultiWriter = "multi-writer" // creates volumes that many hosts can attach read write
+	CapabilityQuota       = "quota"        // limits the size of the volumes of a shared filesystem
+	CapabilityQos         = "qos"          // limits the IOPS and bandwidth of each volume
+)
+
+// BackendInfo describes a backend instance served by the server
+type BackendInfo struct {
+	Name         string
+	Type         string
+	Default      bool // the backend of the requests that do not name one
+	Activated    bool
+	Capabilities []string
+}
+
+type ListBackendsResponse struct {
+	Backends []BackendInfo
+	Err      string
+}
+
+// StoragePool is where a backend creates volumes, e.g. an SCBE service or a Spectrum Scale filesystem. The fields
+// the backend does not report are 0.
+type StoragePool struct {
+	Name       string
+	TotalBytes uint64
+	UsedBytes  uint64
+	FreeBytes  uint64 // the largest volume that can be created in the pool
+	Volumes    int    // the number of volumes of the pool, including the ones not created by ubiquity
+	MaxIops    int    // QoS limits of the pool, 0 if unlimited
+	MaxMbps    int
+	BlockSize  uint64
+	UsedInodes uint64
+	FreeInodes uint64
+	MaxInodes  uint64
+	Err        string // why the capacity of the pool is unknown
+}
+
+type ListPoolsResponse struct {
+	Backend string
+	Pools   []StoragePool
+	Err     string
+}
+
 //go:generate counterfeiter -o ../fakes/fake_mounter.go . Mounter
 
 type Mounter interface {
@@ -161,6 +470,9 @@ type CreateVolumeRequest struct {
 	Backend       string
 	CapacityBytes uint64
 	Metadata      map[string]string
+	Labels        map[string]string // user labels, stored by the server and never passed to the backend
+	AccessMode    string            // one of the AccessMode constants, the default of the backend if empty (see GetDefaultAccessMode)
+	StorageClass  string            // a storage class of the server config, it sets Backend and Metadata
 }
 
 type RemoveVolumeRequest struct {
@@ -170,11 +482,29 @@ type RemoveVolumeRequest struct {
 type ListVolumesRequest struct {
 	//TODO add filter
 	Backends []string
+	Deleted  bool // list only the volumes that were removed and are kept by the retention
+	// LabelSelector filters the volumes by labels, e.g. `team=data,env!=prod`
+	LabelSelector string
 }
 
-type AttachRequest struct {
+// PatchVolumeLabelsRequest is a JSON merge patch of the volume labels, a null value removes the label
+type PatchVolumeLabelsRequest struct {
+	Name   string
+	Labels map[string]*string
+}
+
+type UndeleteVolumeRequest struct {
+	Name string
+}
+
+type PurgeVolumeRequest struct {
 	Name string
-	Host string
+}
+
+type AttachRequest struct {
+	Name     string
+	Host     string
+	ReadOnly bool // the host mounts the volume read only, required by multi-reader volumes except for their single writer
 }
 
 type DetachRequest struct {
@@ -245,6 +575,14 @@ type AfterDetachResponse struct {
 	Error error
 }
 
+type PatchVolumeLabelsResponse struct {
+	Labels map[string]string
+}
+
+type VolumeHistoryResponse struct {
+	History []VolumeOperationRecord
+}
+
 type GetVolumeResponse struct {
 	Volume Volume
 	Error  error
@@ -264,8 +602,89 @@ type Volume struct {
 	Name          string
 	CapacityBytes uint64
 	Metadata      VolumeMetadata
-	Backend       string
+	Backend       string // name of the backend instance
+	BackendType   string // type of the backend instance, it selects the mounter
 	Mountpoint    string
+	State         string
+	RemovedAt     *time.Time         // set while the volume is in the deleted state, the purger deletes it once the retention period is over
+	Labels        map[string]string  `gorm:"-"` // stored in the volume_labels table, filled by the server
+	AccessMode    string             // declared at create time, see the AccessMode constants
+	Attachments   []VolumeAttachment `gorm:"-"` // stored in the volume_attachments table, filled by the server
+}
+
+// Access modes of a volume, they decide how many hosts can attach it at once
+const (
+	AccessModeSingleWriter = "single-writer" // one host at a time
+	AccessModeMultiReader  = "multi-reader"  // any number of read only hosts and at most one read write host
+	AccessModeMultiWriter  = "multi-writer"  // any number of read write hosts, the filesystem must support it
+)
+
+// VolumeAttachment is a row of the volume_attachments table, one per host a volume is currently attached to
+type VolumeAttachment struct {
+	ID         uint `gorm:"primary_key"`
+	VolumeID   uint `gorm:"index"`
+	Host       string
+	ReadOnly   bool
+	AttachedAt time.Time
+}
+
+func (VolumeAttachment) TableName() string {
+	return "volume_attachments"
+}
+
+// VolumeOperationRecord is a row of the volume_operations table, the server adds one for every attach and detach.
+// Rows are kept after the volume is removed, so they tell which hosts had the volume over time.
+type VolumeOperationRecord struct {
+	ID         uint   `gorm:"primary_key"`
+	VolumeID   uint   `gorm:"index"`
+	VolumeName string `gorm:"index"`
+	Backend    string
+	Operation  string // attach or detach
+	Host       string
+	Mountpoint string
+	StartedAt  time.Time
+	EndedAt    *time.Time // nil while the operation is running, or if the server stopped during it
+	Outcome    string
+	Error      string
+}
+
+func (VolumeOperationRecord) TableName() string {
+	return "volume_operations"
+}
+
+const (
+	VolumeOperationAttach = "attach"
+	VolumeOperationDetach = "detach"
+
+	VolumeOperationRunning   = "running"
+	VolumeOperationSucceeded = "succeeded"
+	VolumeOperationFailed    = "failed"
+)
+
+// Volume states. The backends record the transitional state (creating, attaching, detaching, deleting) before they act on the storage,
+// so a transition interrupted by a crash is found and finished or rolled back by the startup recovery.
+const (
+	VolumeStateCreating  = "creating"
+	VolumeStateAvailable = "available"
+	VolumeStateAttaching = "attaching"
+	VolumeStateAttached  = "attached"
+	VolumeStateDetaching = "detaching"
+	VolumeStateDeleting  = "deleting"
+	VolumeStateError     = "error"   // the recovery could not finish nor roll back the transition, needs manual handling
+	VolumeStateDeleted   = "deleted" // removed while retention is enabled, the storage is kept until the volume is purged
+)
+
+// VolumeRecoverer is implemented by the StorageClients that can finish or roll back the volume transitions interrupted by a crash.
+// The recovery takes the write lock of each volume it recovers, so it waits for the operations still running on the other servers
+// and skips the volumes they completed.
+type VolumeRecoverer interface {
+	RecoverVolumes(locker VolumeLocker) error
+}
+
+// VolumeLocker is the part of utils.Locker used by the recovery
+type VolumeLocker interface {
+	WriteLock(name string)
+	WriteUnlock(name string)
 }
 
 type VolumeMetadata struct {
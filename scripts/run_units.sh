@@ -9,3 +9,6 @@ echo "Run unit tests"
 ginkgo -r --skip vendor -ldflags -s
 
 
+
+echo "Run the unit tests of the concurrently used packages with the race detector"
+ginkgo -race local/placement
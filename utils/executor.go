@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -47,6 +48,8 @@ func NewExecutor() Executor {
 }
 
 func (e *executor) Execute(command string, args []string) ([]byte, error) {
+	span := tracing.StartChildSpan("exec "+filepath.Base(command), tracing.KindInternal, logs.Args{{"exec.command", command}, {"exec.args", args}})
+	defer span.End()
 	cmd := exec.Command(command, args...)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -66,6 +69,7 @@ func (e *executor) Execute(command string, args []string) ([]byte, error) {
 		})
 
 	if err != nil {
+		span.SetError(err)
 		return nil, fmt.Errorf("Error %#v", stdOut)
 	}
 	return stdOut, err
@@ -21,13 +21,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"path"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 )
 
 func ExtractErrorResponse(response *http.Response) error {
@@ -51,10 +52,10 @@ func FormatURL(url string, entries ...string) string {
 	return fmt.Sprintf("%s%s", base, suffix)
 }
 
-func HttpExecuteUserAuth(httpClient *http.Client, logger *log.Logger, requestType string, requestURL string, user string, password string, rawPayload interface{}) (*http.Response, error) {
+func HttpExecuteUserAuth(httpClient *http.Client, logger logs.Logger, requestType string, requestURL string, user string, password string, rawPayload interface{}) (*http.Response, error) {
 	payload, err := json.MarshalIndent(rawPayload, "", " ")
 	if err != nil {
-		logger.Printf("Internal error marshalling params %#v", err)
+		logger.Error("Internal error marshalling params", logs.Args{{"error", err}})
 		return nil, fmt.Errorf("Internal error marshalling params")
 	}
 
@@ -64,7 +65,7 @@ func HttpExecuteUserAuth(httpClient *http.Client, logger *log.Logger, requestTyp
 
 	request, err := http.NewRequest(requestType, requestURL, bytes.NewBuffer(payload))
 	if err != nil {
-		logger.Printf("Error in creating request %#v", err)
+		logger.Error("Error in creating request", logs.Args{{"error", err}})
 		return nil, fmt.Errorf("Error in creating request")
 	}
 
@@ -72,24 +73,69 @@ func HttpExecuteUserAuth(httpClient *http.Client, logger *log.Logger, requestTyp
 	request.Header.Add("Accept", "application/json")
 
 	request.SetBasicAuth(user, password)
-	return httpClient.Do(request)
+	return HttpDo(httpClient, request)
 
 }
 
-func HttpExecute(httpClient *http.Client, logger *log.Logger, requestType string, requestURL string, rawPayload interface{}) (*http.Response, error) {
+func HttpExecute(httpClient *http.Client, logger logs.Logger, requestType string, requestURL string, rawPayload interface{}) (*http.Response, error) {
 	payload, err := json.MarshalIndent(rawPayload, "", " ")
 	if err != nil {
-		logger.Printf("Internal error marshalling params %#v", err)
+		logger.Error("Internal error marshalling params", logs.Args{{"error", err}})
 		return nil, fmt.Errorf("Internal error marshalling params")
 	}
 
 	request, err := http.NewRequest(requestType, requestURL, bytes.NewBuffer(payload))
 	if err != nil {
-		logger.Printf("Error in creating request %#v", err)
+		logger.Error("Error in creating request", logs.Args{{"error", err}})
 		return nil, fmt.Errorf("Error in creating request")
 	}
 
-	return httpClient.Do(request)
+	return HttpDo(httpClient, request)
+}
+
+// HttpDo sends request in a client span, with the request ID and the trace context of the current goroutine
+func HttpDo(httpClient *http.Client, request *http.Request) (*http.Response, error) {
+	SetRequestIDHeader(request)
+	span := tracing.StartSpan("HTTP "+request.Method, tracing.KindClient, logs.Args{
+		{"http.method", request.Method},
+		{"http.url", request.URL.String()},
+	})
+	defer span.End()
+	tracing.Inject(request.Header)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", response.StatusCode)
+	if response.StatusCode >= http.StatusInternalServerError {
+		span.SetError(fmt.Errorf("%s", response.Status))
+	}
+	return response, nil
+}
+
+// SetRequestIDHeader sends the request ID bound to the current goroutine, or a new one if none is bound,
+// so the receiver logs the call under the same ID as the caller
+func SetRequestIDHeader(request *http.Request) {
+	id := logs.GetRequestID()
+	if id == "" {
+		id = logs.NewRequestID()
+	}
+	request.Header.Set(logs.RequestIDHeader, id)
+}
+
+// RequestIDHandler binds the request ID of the X-Request-ID header to the goroutine that serves the request, or a new ID
+// if the header is missing or invalid, and returns it in the response header
+func RequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(logs.RequestIDHeader)
+		if !logs.IsValidRequestID(id) {
+			id = logs.NewRequestID()
+		}
+		w.Header().Set(logs.RequestIDHeader, id)
+		defer logs.BindRequestID(id)()
+		next.ServeHTTP(w, req)
+	})
 }
 
 func WriteResponse(w http.ResponseWriter, code int, object interface{}) {
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request ID", func() {
+	var (
+		server     *httptest.Server
+		receivedID string
+		boundID    string
+	)
+	BeforeEach(func() {
+		receivedID, boundID = "", ""
+		server = httptest.NewServer(utils.RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			receivedID = req.Header.Get(logs.RequestIDHeader)
+			boundID = logs.GetRequestID()
+		})))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("should send the bound request ID and bind it on the server", func() {
+		defer logs.BindRequestID("abc-123")()
+		response, err := utils.HttpExecute(&http.Client{}, logs.GetLogger(), "GET", server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receivedID).To(Equal("abc-123"))
+		Expect(boundID).To(Equal("abc-123"))
+		Expect(response.Header.Get(logs.RequestIDHeader)).To(Equal("abc-123"))
+	})
+	It("should send a new request ID if none is bound", func() {
+		_, err := utils.HttpExecute(&http.Client{}, logs.GetLogger(), "GET", server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receivedID).ToNot(BeEmpty())
+		Expect(logs.GetRequestID()).To(BeEmpty())
+	})
+	It("should replace an invalid request ID on the server", func() {
+		request, err := http.NewRequest("GET", server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		request.Header.Set(logs.RequestIDHeader, "bad id with spaces")
+		response, err := http.DefaultClient.Do(request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(boundID).ToNot(BeEmpty())
+		Expect(boundID).ToNot(ContainSubstring(" "))
+		Expect(response.Header.Get(logs.RequestIDHeader)).To(Equal(boundID))
+	})
+	It("should restore the previous request ID when unbound", func() {
+		unbindOuter := logs.BindRequestID("outer")
+		unbindInner := logs.BindRequestID("inner")
+		Expect(logs.GetRequestID()).To(Equal("inner"))
+		unbindInner()
+		Expect(logs.GetRequestID()).To(Equal("outer"))
+		unbindOuter()
+		Expect(logs.GetRequestID()).To(BeEmpty())
+	})
+	It("should add the bound request ID to the lines of a *log.Logger", func() {
+		buf := &bytes.Buffer{}
+		logger := log.New(logs.NewRequestIDWriter(buf), "test: ", 0)
+		logger.Println("without id")
+		unbind := logs.BindRequestID("abc-123")
+		logger.Println("with id")
+		unbind()
+		Expect(buf.String()).To(Equal("test: without id\ntest: with id {request-id=abc-123}\n"))
+	})
+})
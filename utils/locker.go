@@ -17,119 +17,219 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"github.com/midoblgsm/ubiquity/utils/logs"
 	"sync"
 	"time"
+
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 )
 
 //go:generate counterfeiter -o ../fakes/fake_locker.go . Locker
+
+// Locker holds named read/write locks.
+// The context variants give up with a LockBusyError when the context is done, use a context with a deadline to bound the wait.
 type Locker interface {
 	WriteLock(name string)
 	WriteUnlock(name string)
 	ReadLock(name string)
 	ReadUnlock(name string)
+	WriteLockContext(ctx context.Context, name string) error
+	ReadLockContext(ctx context.Context, name string) error
+	TryWriteLock(name string) bool
+	TryReadLock(name string) bool
 }
 
 func NewLocker() Locker {
-	return &locker{locks: make(map[string]*sync.RWMutex), accessLock: &sync.Mutex{}, statsLock: &sync.Mutex{}, cleanupLock: &sync.Mutex{}, stats: make(map[string]time.Time), logger: logs.GetLogger()}
+	return &locker{locks: make(map[string]*namedLock), accessLock: &sync.Mutex{}, logger: logs.GetComponentLogger(logs.ComponentLocker)}
 }
 
-const (
-	STALE_LOCK_TIMEOUT = 600 //in seconds
-)
+// LockWithTimeout takes the write or read lock of name, waiting at most timeout (forever if timeout is 0)
+func LockWithTimeout(ctx context.Context, l Locker, name string, write bool, timeout time.Duration) (err error) {
+	span := tracing.StartChildSpan("lock.wait", tracing.KindInternal, logs.Args{{"lock.name", name}, {"lock.write", write}})
+	defer span.EndWithError(&err)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if write {
+		err = l.WriteLockContext(ctx, name)
+	} else {
+		err = l.ReadLockContext(ctx, name)
+	}
+	return err
+}
+
+// namedLock is a read/write lock whose waiters can give up.
+// New readers wait while a writer waits, so a stream of readers cannot starve the writers.
+type namedLock struct {
+	readers        int
+	writer         bool
+	writersWaiting int
+	refs           int           // holders and waiters, the lock is removed from the map when there are none
+	released       chan struct{} // closed and replaced on every release, to wake up the waiters
+}
 
 type locker struct {
-	accessLock  *sync.Mutex
-	locks       map[string]*sync.RWMutex
-	statsLock   *sync.Mutex
-	stats       map[string]time.Time
-	cleanupLock *sync.Mutex
-	logger      logs.Logger
+	accessLock *sync.Mutex
+	locks      map[string]*namedLock
+	logger     logs.Logger
 }
 
 func (l *locker) WriteLock(name string) {
 	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
-
-	defer l.updateStats(name)
-	l.accessLock.Lock()
-	if lock, exists := l.locks[name]; exists {
-		l.accessLock.Unlock()
-		lock.Lock()
-		return
-	}
-
-	lock := &sync.RWMutex{}
-	lock.Lock()
-	l.locks[name] = lock
-	l.accessLock.Unlock()
+	l.acquire(context.Background(), name, true)
 }
 func (l *locker) WriteUnlock(name string) {
 	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
-	defer l.updateStats(name)
-	l.accessLock.Lock()
-	defer l.accessLock.Unlock()
-	if lock, exists := l.locks[name]; exists {
-		lock.Unlock()
-		return
-	}
-
+	l.release(name, true)
 }
 func (l *locker) ReadLock(name string) {
 	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
-	defer l.updateStats(name)
+	l.acquire(context.Background(), name, false)
+}
+func (l *locker) ReadUnlock(name string) {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	l.release(name, false)
+}
+func (l *locker) WriteLockContext(ctx context.Context, name string) error {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	return l.acquire(ctx, name, true)
+}
+func (l *locker) ReadLockContext(ctx context.Context, name string) error {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	return l.acquire(ctx, name, false)
+}
+func (l *locker) TryWriteLock(name string) bool {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	return l.tryAcquire(name, true)
+}
+func (l *locker) TryReadLock(name string) bool {
+	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
+	return l.tryAcquire(name, false)
+}
+
+func (l *locker) acquire(ctx context.Context, name string, write bool) error {
 	l.accessLock.Lock()
-	if lock, exists := l.locks[name]; exists {
+	lock := l.getLock(name)
+	lock.refs++
+	if write {
+		lock.writersWaiting++
+	}
+	for {
+		if lock.free(write) {
+			lock.take(write)
+			l.accessLock.Unlock()
+			return nil
+		}
+		released := lock.released
 		l.accessLock.Unlock()
-		lock.RLock()
-		return
+		select {
+		case <-released:
+			l.accessLock.Lock()
+		case <-ctx.Done():
+			l.accessLock.Lock()
+			lock.refs--
+			if write {
+				lock.writersWaiting--
+				lock.notify() // the readers waiting behind this writer can go
+			}
+			l.removeUnused(name, lock)
+			l.accessLock.Unlock()
+			return NewLockBusyError(name, ctx.Err())
+		}
 	}
+}
 
-	lock := &sync.RWMutex{}
-	lock.RLock()
-	l.locks[name] = lock
-	l.accessLock.Unlock()
+func (l *locker) tryAcquire(name string, write bool) bool {
+	l.accessLock.Lock()
+	defer l.accessLock.Unlock()
+	lock := l.getLock(name)
+	if !lock.free(write) {
+		l.removeUnused(name, lock)
+		return false
+	}
+	lock.refs++
+	if write {
+		lock.writersWaiting++
+	}
+	lock.take(write)
+	return true
 }
-func (l *locker) ReadUnlock(name string) {
-	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
-	defer l.updateStats(name)
+
+func (l *locker) release(name string, write bool) {
 	l.accessLock.Lock()
 	defer l.accessLock.Unlock()
-	if lock, exists := l.locks[name]; exists {
-		lock.RUnlock()
+	lock, exists := l.locks[name]
+	if !exists || (write && !lock.writer) || (!write && lock.readers == 0) {
+		l.logger.Error("unlock of a lock that is not held", logs.Args{{"lockName", name}, {"write", write}})
 		return
 	}
+	if write {
+		lock.writer = false
+	} else {
+		lock.readers--
+	}
+	lock.refs--
+	lock.notify()
+	l.removeUnused(name, lock)
 }
-func (l *locker) updateStats(name string) {
-	defer l.logger.Trace(logs.DEBUG, logs.Args{{"lockName", name}})()
 
-	l.statsLock.Lock()
-	defer l.cleanup()
-	defer l.statsLock.Unlock()
-	if stat, exists := l.stats[name]; exists {
-		stat = time.Now()
-		l.stats[name] = stat
-		return
+func (l *locker) getLock(name string) *namedLock {
+	lock, exists := l.locks[name]
+	if !exists {
+		lock = &namedLock{released: make(chan struct{})}
+		l.locks[name] = lock
 	}
-	stat := time.Now()
-	l.stats[name] = stat
-}
-func (l *locker) cleanup() {
-	defer l.logger.Trace(logs.DEBUG)()
-
-	l.cleanupLock.Lock()
-	defer l.cleanupLock.Unlock()
-	currentTime := time.Now()
-	var statsToDelete []string
-	for name, stat := range l.stats {
-		if currentTime.Sub(stat).Seconds() > STALE_LOCK_TIMEOUT {
-			msg := fmt.Sprint("Removing stalelock '%s' as it has exceeded configured timeout ('%d seconds')\n", name, STALE_LOCK_TIMEOUT)
-			l.logger.Debug(msg)
-			delete(l.locks, name)
-			statsToDelete = append(statsToDelete, name)
-		}
+	return lock
+}
+
+// removeUnused drops the lock once nobody holds or waits for it, so the map does not grow with every volume name
+func (l *locker) removeUnused(name string, lock *namedLock) {
+	if lock.refs == 0 {
+		delete(l.locks, name)
 	}
-	for _, name := range statsToDelete {
-		delete(l.stats, name)
+}
+
+func (lock *namedLock) free(write bool) bool {
+	if write {
+		return !lock.writer && lock.readers == 0
 	}
+	return !lock.writer && lock.writersWaiting == 0
+}
+
+func (lock *namedLock) take(write bool) {
+	if write {
+		lock.writersWaiting--
+		lock.writer = true
+		return
+	}
+	lock.readers++
+}
+
+func (lock *namedLock) notify() {
+	close(lock.released)
+	lock.released = make(chan struct{})
+}
+
+// LockBusyError is returned when a lock was not acquired before the context was done
+type LockBusyError struct {
+	Name  string
+	Cause error
+}
+
+func NewLockBusyError(name string, cause error) *LockBusyError {
+	return &LockBusyError{Name: name, Cause: cause}
+}
+
+func (e *LockBusyError) Error() string {
+	return fmt.Sprintf("Lock [%s] is busy (%s)", e.Name, e.Cause)
+}
+
+// IsLockBusyError tells if err is a LockBusyError
+func IsLockBusyError(err error) bool {
+	_, ok := err.(*LockBusyError)
+	return ok
 }
@@ -17,9 +17,9 @@
 package utils_test
 
 import (
-	"log"
-	"os"
+	"context"
 	"reflect"
+	"time"
 
 	"fmt"
 
@@ -31,7 +31,6 @@ import (
 var _ = Describe("utils - locker", func() {
 	var (
 		locker         utils.Locker
-		logger         *log.Logger
 		sharedResource []string
 		c1             chan int
 		c2             chan int
@@ -41,7 +40,6 @@ var _ = Describe("utils - locker", func() {
 	)
 
 	BeforeEach(func() {
-		logger = log.New(os.Stdout, "locker: ", log.Lshortfile|log.LstdFlags)
 		locker = utils.NewLocker()
 		c1 = make(chan int)
 		c2 = make(chan int)
@@ -78,6 +76,61 @@ var _ = Describe("utils - locker", func() {
 
 		})
 	})
+	Context(".TryWriteLock", func() {
+		It("should fail while the lock is held and succeed once it is released", func() {
+			Expect(locker.TryWriteLock("tryLockTest")).To(BeTrue())
+			Expect(locker.TryWriteLock("tryLockTest")).To(BeFalse())
+			Expect(locker.TryReadLock("tryLockTest")).To(BeFalse())
+			locker.WriteUnlock("tryLockTest")
+			Expect(locker.TryReadLock("tryLockTest")).To(BeTrue())
+			Expect(locker.TryReadLock("tryLockTest")).To(BeTrue())
+			Expect(locker.TryWriteLock("tryLockTest")).To(BeFalse())
+			locker.ReadUnlock("tryLockTest")
+			locker.ReadUnlock("tryLockTest")
+			Expect(locker.TryWriteLock("tryLockTest")).To(BeTrue())
+		})
+	})
+	Context(".WriteLockContext", func() {
+		It("should give up with a busy error when the deadline passes", func() {
+			locker.WriteLock("contextLockTest")
+			err := utils.LockWithTimeout(context.Background(), locker, "contextLockTest", true, 50*time.Millisecond)
+			Expect(utils.IsLockBusyError(err)).To(BeTrue())
+			err = utils.LockWithTimeout(context.Background(), locker, "contextLockTest", false, 50*time.Millisecond)
+			Expect(utils.IsLockBusyError(err)).To(BeTrue())
+
+			// the waiters that gave up do not hold the lock
+			locker.WriteUnlock("contextLockTest")
+			Expect(locker.TryWriteLock("contextLockTest")).To(BeTrue())
+			locker.WriteUnlock("contextLockTest")
+		})
+		It("should get the lock when it is released before the deadline", func() {
+			locker.ReadLock("contextLockTest")
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				locker.ReadUnlock("contextLockTest")
+			}()
+			Expect(utils.LockWithTimeout(context.Background(), locker, "contextLockTest", true, time.Second)).To(Succeed())
+			locker.WriteUnlock("contextLockTest")
+		})
+		It("should make new readers wait behind a waiting writer", func() {
+			locker.ReadLock("contextLockTest")
+			writerDone := make(chan error)
+			go func() {
+				writerDone <- utils.LockWithTimeout(context.Background(), locker, "contextLockTest", true, time.Second)
+			}()
+			Eventually(func() bool {
+				// the reader is refused only once the writer waits
+				if locker.TryReadLock("contextLockTest") {
+					locker.ReadUnlock("contextLockTest")
+					return false
+				}
+				return true
+			}).Should(BeTrue())
+			locker.ReadUnlock("contextLockTest")
+			Expect(<-writerDone).ToNot(HaveOccurred())
+			locker.WriteUnlock("contextLockTest")
+		})
+	})
 	Context(".WriteLock", func() {
 		It("should succeed", func() {
 			//expectedResource1 := []string{"c", "c", "c", "d", "d", "d"}
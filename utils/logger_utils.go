@@ -22,6 +22,11 @@ import (
 	"log"
 	"os"
 	"path"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 )
 
 func SetupLogger(logPath string, loggerName string) (*log.Logger, *os.File) {
@@ -31,10 +36,56 @@ func SetupLogger(logPath string, loggerName string) (*log.Logger, *os.File) {
 		return nil, nil
 	}
 	log.SetOutput(logFile)
-	logger := log.New(io.MultiWriter(logFile), fmt.Sprintf("%s: ", loggerName), log.Lshortfile|log.LstdFlags)
+	logger := log.New(logs.NewRequestIDWriter(io.MultiWriter(logFile)), fmt.Sprintf("%s: ", loggerName), log.Lshortfile|log.LstdFlags)
 	return logger, logFile
 }
 
+// NewFileLoggerConfig returns the log file config of format and rotation, with the defaults for the missing values
+func NewFileLoggerConfig(format string, rotation resources.LogRotationConfig) logs.FileLoggerConfig {
+	return logs.FileLoggerConfig{
+		Format: logs.GetLogFormatFromString(format),
+		Rotation: logs.RotationConfig{
+			MaxSize:     logRotationValue(rotation.MaxSize, resources.DefaultLogMaxSize),
+			RotateEvery: logRotationValue(rotation.RotateEvery, 0),
+			MaxBackups:  logRotationValue(rotation.MaxBackups, resources.DefaultLogMaxBackups),
+			MaxAge:      logRotationValue(rotation.MaxAge, resources.DefaultLogMaxAge),
+			Compress:    rotation.Compress,
+		},
+	}
+}
+
+func logRotationValue(value int, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// NewTracingConfig returns the tracing config of config
+func NewTracingConfig(config resources.TracingConfig) tracing.Config {
+	return tracing.Config{
+		ServiceName:   config.ServiceName,
+		File:          config.File,
+		Endpoint:      config.Endpoint,
+		FlushInterval: time.Duration(config.FlushInterval) * time.Second,
+	}
+}
+
+// SetComponentLogLevels sets the level of each component in levels, it fails on the first invalid level
+func SetComponentLogLevels(levels map[string]string) error {
+	for component, levelName := range levels {
+		level, err := logs.ParseLogLevel(levelName)
+		if err != nil {
+			return fmt.Errorf("Error in config file. The level of the [%s] component logger: %s", component, err.Error())
+		}
+		logs.SetComponentLevel(component, level)
+	}
+	return nil
+}
+
 func CloseLogs(logFile *os.File) {
 	logFile.Sync()
 	logFile.Close()
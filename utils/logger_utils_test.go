@@ -0,0 +1,143 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger utils", func() {
+	var (
+		logDir string
+		err    error
+	)
+	BeforeEach(func() {
+		logDir, err = ioutil.TempDir("", "ubiquity-logs")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(logDir)
+	})
+	Context(".NewFileLoggerConfig", func() {
+		It("should set the defaults and disable the negative values", func() {
+			config := utils.NewFileLoggerConfig("JSON", resources.LogRotationConfig{MaxBackups: -1})
+			Expect(config.Format).To(Equal(logs.FormatJSON))
+			Expect(config.Rotation.MaxSize).To(Equal(resources.DefaultLogMaxSize))
+			Expect(config.Rotation.MaxBackups).To(Equal(0))
+			Expect(config.Rotation.MaxAge).To(Equal(resources.DefaultLogMaxAge))
+		})
+	})
+	Context("json format", func() {
+		It("should write the level, function, request ID and args as fields", func() {
+			buf := &bytes.Buffer{}
+			logger := logs.NewLogger(logs.DEBUG, logs.FormatJSON, buf)
+			unbind := logs.BindRequestID("abc-123")
+			logger.ErrorRet(fmt.Errorf("boom"), "failed", logs.Args{{"volume", "vol1"}})
+			unbind()
+
+			var entry map[string]interface{}
+			Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+			Expect(entry["level"]).To(Equal("ERROR"))
+			Expect(entry["msg"]).To(Equal("failed"))
+			Expect(entry["component"]).To(Equal("utils_test"))
+			Expect(entry["request-id"]).To(Equal("abc-123"))
+			Expect(entry["args"]).To(Equal(map[string]interface{}{"volume": "vol1", "error": "boom"}))
+		})
+	})
+	Context(".SetComponentLogLevels", func() {
+		// a component without a logger, GetLevels lists it only while it has its own level
+		const component = "test-component"
+		AfterEach(func() {
+			logs.ResetComponentLevel(component)
+		})
+		It("should set the level of the components and keep the default level", func() {
+			defaultLevel, _ := logs.GetLevels()
+			err := utils.SetComponentLogLevels(map[string]string{component: "Error"})
+			Expect(err).ToNot(HaveOccurred())
+			level, levels := logs.GetLevels()
+			Expect(level).To(Equal(defaultLevel))
+			Expect(levels[component]).To(Equal(logs.Level(logs.ERROR)))
+
+			logs.ResetComponentLevel(component)
+			_, levels = logs.GetLevels()
+			Expect(levels).ToNot(HaveKey(component))
+		})
+		It("should fail on an invalid level", func() {
+			err := utils.SetComponentLogLevels(map[string]string{component: "verbose"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("rotation", func() {
+		It("should rotate the file at MaxSize and keep MaxBackups compressed files", func() {
+			logFile, err := logs.OpenRotatingFile(path.Join(logDir, "test.log"), logs.RotationConfig{MaxSize: 1, MaxBackups: 2, Compress: true})
+			Expect(err).ToNot(HaveOccurred())
+			defer logFile.Close()
+			line := []byte(strings.Repeat("x", 400*1024) + "\n")
+			for i := 0; i < 12; i++ {
+				_, err = logFile.Write(line)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			backups := func() []string {
+				files, err := ioutil.ReadDir(logDir)
+				Expect(err).ToNot(HaveOccurred())
+				var names []string
+				for _, file := range files {
+					if file.Name() != "test.log" {
+						names = append(names, file.Name())
+					}
+				}
+				return names
+			}
+			Eventually(backups).Should(HaveLen(2))
+			Eventually(func() bool {
+				for _, name := range backups() {
+					if !strings.HasSuffix(name, ".log.gz") {
+						return false
+					}
+				}
+				return true
+			}).Should(BeTrue())
+		})
+		It("should share the file between the writers of the same path", func() {
+			first, err := logs.OpenRotatingFile(path.Join(logDir, "test.log"), logs.RotationConfig{})
+			Expect(err).ToNot(HaveOccurred())
+			second, err := logs.OpenRotatingFile(path.Join(logDir, "test.log"), logs.RotationConfig{})
+			Expect(err).ToNot(HaveOccurred())
+			first.Write([]byte("first\n"))
+			Expect(first.Close()).To(Succeed())
+			_, err = second.Write([]byte("second\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second.Close()).To(Succeed())
+
+			data, err := ioutil.ReadFile(path.Join(logDir, "test.log"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("first\nsecond\n"))
+		})
+	})
+})
@@ -20,16 +20,97 @@ import (
     "os"
     "fmt"
     "io"
+    "sort"
     "strings"
+    "sync"
 )
 
-var logger Logger = nil
+var (
+    logger Logger = nil
+    factory *loggerFactory = nil
+)
+
+const (
+    FormatText = "text"
+    FormatJSON = "json"
+)
+
+// FileLoggerConfig selects the format of a log file and when it is rotated
+type FileLoggerConfig struct {
+    Format   string // FormatText or FormatJSON, empty means FormatText
+    Rotation RotationConfig
+}
+
+// loggerFactory creates the component loggers of one writer, they share the writer and the levels
+type loggerFactory struct {
+    levels    *levelRegistry
+    newLogger func(component string) Logger
+    lock      sync.Mutex
+    loggers   map[string]Logger
+}
+
+func newLoggerFactory(level Level, format string, writer io.Writer) *loggerFactory {
+    f := &loggerFactory{levels: newLevelRegistry(level), loggers: make(map[string]Logger)}
+    if format == FormatJSON {
+        lock := &sync.Mutex{}
+        f.newLogger = func(component string) Logger { return newJsonLogger(component, f.levels, writer, lock) }
+    } else {
+        backend := newGoLoggingBackend(writer)
+        f.newLogger = func(component string) Logger { return newGoLoggingLogger(component, f.levels, backend) }
+    }
+    return f
+}
 
-func initLogger(level Level, writer io.Writer) {
+func (f *loggerFactory) get(component string) Logger {
+    f.lock.Lock()
+    defer f.lock.Unlock()
+    if l, ok := f.loggers[component]; ok {
+        return l
+    }
+    l := f.newLogger(component)
+    f.loggers[component] = l
+    return l
+}
+
+// components returns the names of the component loggers created so far
+func (f *loggerFactory) components() []string {
+    f.lock.Lock()
+    defer f.lock.Unlock()
+    var components []string
+    for component := range f.loggers {
+        if component != "" {
+            components = append(components, component)
+        }
+    }
+    sort.Strings(components)
+    return components
+}
+
+func initLogger(level Level, format string, writer io.Writer) {
     if logger != nil {
         panic("logger already initialized")
     }
-    logger = newGoLoggingLogger(level, writer)
+    factory = newLoggerFactory(level, format, writer)
+    logger = factory.get("")
+}
+
+func clearLogger() {
+    logger = nil
+    factory = nil
+}
+
+// NewLogger returns a Logger that writes to writer in format, FormatJSON or FormatText, outside of the global logger
+func NewLogger(level Level, format string, writer io.Writer) Logger {
+    return newLoggerFactory(level, format, writer).get("")
+}
+
+// GetLogFormatFromString translates a string log format to FormatText or FormatJSON
+// If there is no match, default is FormatText
+func GetLogFormatFromString(format string) string {
+    if strings.ToLower(format) == FormatJSON {
+        return FormatJSON
+    }
+    return FormatText
 }
 
 // GetLogLevelFromString translates string log level to Level type
@@ -56,16 +137,29 @@ func InitFileLogger(level Level, filePath string) func() {
     if err != nil {
         panic(fmt.Sprintf("failed to init logger %v", err))
     }
-    initLogger(level, io.MultiWriter(logFile))
-    return func() { logFile.Close(); logger = nil }
+    initLogger(level, FormatText, io.MultiWriter(logFile))
+    return func() { logFile.Close(); clearLogger() }
+}
+
+// InitRotatingFileLogger initializes the global logger with a file writer to filePath, in the format and with the
+// rotation of config, and set at level. Other writers opened with OpenRotatingFile on the same path share the file.
+// It returns a function that clears the global logger.
+// If the global logger is already initialized InitRotatingFileLogger panics.
+func InitRotatingFileLogger(level Level, filePath string, config FileLoggerConfig) func() {
+    logFile, err := OpenRotatingFile(filePath, config.Rotation)
+    if err != nil {
+        panic(fmt.Sprintf("failed to init logger %v", err))
+    }
+    initLogger(level, GetLogFormatFromString(config.Format), logFile)
+    return func() { logFile.Close(); clearLogger() }
 }
 
 // InitStdoutLogger initializes the global logger with stdout and set at level.
 // It returns a function that clears the global logger.
 // If the global logger is already initialized InitStdoutLogger panics.
 func InitStdoutLogger(level Level) func() {
-    initLogger(level, os.Stdout)
-    return func() { logger = nil }
+    initLogger(level, FormatText, os.Stdout)
+    return func() { clearLogger() }
 }
 
 // GetLogger returns the global logger.
@@ -75,4 +169,17 @@ func GetLogger() Logger {
         panic("logger not initialized")
     }
     return logger
-}
\ No newline at end of file
+}
+
+// GetComponentLogger returns the logger of a component, it writes like the global logger at the level of the component.
+// If the global logger is not initialized GetComponentLogger panics.
+func GetComponentLogger(component string) Logger {
+    return getFactory().get(component)
+}
+
+func getFactory() *loggerFactory {
+    if factory == nil {
+        panic("logger not initialized")
+    }
+    return factory
+}
@@ -27,39 +27,59 @@ const (
 )
 
 type goLoggingLogger struct {
-    logger *logging.Logger
+    logger    *logging.Logger
+    component string
+    levels    *levelRegistry
 }
 
-func newGoLoggingLogger(level Level, writer io.Writer) *goLoggingLogger {
-    newLogger := logging.MustGetLogger("")
-    newLogger.ExtraCalldepth = 1
+// newGoLoggingBackend returns the backend shared by the component loggers of one writer.
+// The levels are checked by the loggers, so the backend lets all the records through.
+func newGoLoggingBackend(writer io.Writer) logging.LeveledBackend {
     format := logging.MustStringFormatter("%{time:2006-01-02 15:04:05.999} %{level:.5s} %{pid} %{shortfile} %{shortpkg}::%{shortfunc} %{message}")
     backend := logging.NewLogBackend(writer, "", 0)
     backendFormatter := logging.NewBackendFormatter(backend, format)
     backendLeveled := logging.AddModuleLevel(backendFormatter)
-    backendLeveled.SetLevel(getLevel(level), "")
-    newLogger.SetBackend(backendLeveled)
-    return &goLoggingLogger{newLogger}
+    backendLeveled.SetLevel(logging.DEBUG, "")
+    return backendLeveled
+}
+
+func newGoLoggingLogger(component string, levels *levelRegistry, backend logging.LeveledBackend) *goLoggingLogger {
+    newLogger := logging.MustGetLogger(component)
+    newLogger.ExtraCalldepth = 1
+    newLogger.SetBackend(backend)
+    return &goLoggingLogger{logger: newLogger, component: component, levels: levels}
 }
 
 func (l *goLoggingLogger) Debug(str string, args ...Args) {
-    l.logger.Debugf(str + " %v", args)
+    if l.levels.enabled(l.component, DEBUG) {
+        l.logger.Debugf(str + " %v", withRequestID(args))
+    }
 }
 
 func (l *goLoggingLogger) Info(str string, args ...Args) {
-    l.logger.Infof(str + " %v", args)
+    if l.levels.enabled(l.component, INFO) {
+        l.logger.Infof(str + " %v", withRequestID(args))
+    }
 }
 
 func (l *goLoggingLogger) Error(str string, args ...Args) {
-    l.logger.Errorf(str + " %v", args)
+    if l.levels.enabled(l.component, ERROR) {
+        l.logger.Errorf(str + " %v", withRequestID(args))
+    }
 }
 
 func (l *goLoggingLogger) ErrorRet(err error, str string, args ...Args) error {
-    l.logger.Errorf(str + " %v ", append(args, Args{{"error", err}}))
+    if l.levels.enabled(l.component, ERROR) {
+        l.logger.Errorf(str + " %v ", withRequestID(append(args, Args{{"error", err}})))
+    }
     return err
 }
 
 func (l *goLoggingLogger) Trace(level Level, args ...Args) func() {
+    if !l.levels.enabled(l.component, level) {
+        return func() {}
+    }
+    args = withRequestID(args)
     switch level {
     case DEBUG:
         l.logger.Debug(traceEnter, args)
@@ -74,16 +94,3 @@ func (l *goLoggingLogger) Trace(level Level, args ...Args) func() {
         panic("unknown level")
     }
 }
-
-func getLevel(level Level) logging.Level {
-    switch level {
-    case DEBUG:
-        return logging.DEBUG
-    case INFO:
-        return logging.INFO
-    case ERROR:
-        return logging.ERROR
-    default:
-        panic("unknown level")
-    }
-}
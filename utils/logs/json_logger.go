@@ -0,0 +1,161 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "sync"
+    "time"
+)
+
+// The JSON format writes one object per line, so log shippers can read the fields without parsing the message:
+// {"time":"...","level":"INFO","pid":1,"component":"scbe","function":"(*scbeLocalClient).Attach","file":"scbe.go:120",
+//  "msg":"...","request-id":"...","args":{"volume":"vol1"}}
+// The component is the name of the component logger, or the package of the caller for GetLogger.
+
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+type jsonEntry struct {
+    Time      string                 `json:"time"`
+    Level     string                 `json:"level"`
+    PID       int                    `json:"pid"`
+    Component string                 `json:"component,omitempty"`
+    Function  string                 `json:"function,omitempty"`
+    File      string                 `json:"file,omitempty"`
+    Message   string                 `json:"msg"`
+    RequestID string                 `json:"request-id,omitempty"`
+    Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+type caller struct {
+    pkg      string
+    function string
+    file     string
+}
+
+type jsonLogger struct {
+    component string
+    levels    *levelRegistry
+    writer    io.Writer
+    lock      *sync.Mutex
+}
+
+func newJsonLogger(component string, levels *levelRegistry, writer io.Writer, lock *sync.Mutex) *jsonLogger {
+    return &jsonLogger{component: component, levels: levels, writer: writer, lock: lock}
+}
+
+func (l *jsonLogger) Debug(str string, args ...Args) {
+    l.log(DEBUG, getCaller(2), str, args)
+}
+
+func (l *jsonLogger) Info(str string, args ...Args) {
+    l.log(INFO, getCaller(2), str, args)
+}
+
+func (l *jsonLogger) Error(str string, args ...Args) {
+    l.log(ERROR, getCaller(2), str, args)
+}
+
+func (l *jsonLogger) ErrorRet(err error, str string, args ...Args) error {
+    l.log(ERROR, getCaller(2), str, append(args, Args{{"error", err}}))
+    return err
+}
+
+func (l *jsonLogger) Trace(level Level, args ...Args) func() {
+    // the EXIT line is written by a deferred call, keep the function that called Trace
+    c := getCaller(2)
+    l.log(level, c, traceEnter, args)
+    return func() { l.log(level, c, traceExit, args) }
+}
+
+func (l *jsonLogger) log(level Level, c caller, str string, args []Args) {
+    if !l.levels.enabled(l.component, level) {
+        return
+    }
+    entry := jsonEntry{
+        Time:      time.Now().Format(jsonTimeFormat),
+        Level:     strings.ToUpper(level.String()),
+        PID:       os.Getpid(),
+        Component: l.component,
+        Function:  c.function,
+        File:      c.file,
+        Message:   str,
+        RequestID: GetRequestID(),
+        Args:      argsMap(args),
+    }
+    if entry.Component == "" {
+        entry.Component = c.pkg
+    }
+    l.write(entry)
+}
+
+func (l *jsonLogger) write(entry jsonEntry) {
+    line, err := json.Marshal(entry)
+    if err != nil {
+        // an arg value that cannot be marshalled is written with its text form
+        for name, value := range entry.Args {
+            entry.Args[name] = fmt.Sprintf("%v", value)
+        }
+        if line, err = json.Marshal(entry); err != nil {
+            return
+        }
+    }
+    l.lock.Lock()
+    defer l.lock.Unlock()
+    l.writer.Write(append(line, '\n'))
+}
+
+func argsMap(args []Args) map[string]interface{} {
+    if len(args) == 0 {
+        return nil
+    }
+    fields := make(map[string]interface{})
+    for _, arg := range args {
+        for _, param := range arg {
+            if err, ok := param.Value.(error); ok && err != nil {
+                fields[param.Name] = err.Error()
+            } else {
+                fields[param.Name] = param.Value
+            }
+        }
+    }
+    return fields
+}
+
+// getCaller returns the package, function and file:line of the function skip frames above getCaller
+func getCaller(skip int) caller {
+    pc, file, line, ok := runtime.Caller(skip)
+    if !ok {
+        return caller{}
+    }
+    c := caller{file: fmt.Sprintf("%s:%d", filepath.Base(file), line)}
+    if fn := runtime.FuncForPC(pc); fn != nil {
+        // github.com/midoblgsm/ubiquity/local/scbe.(*scbeLocalClient).Attach
+        name := fn.Name()
+        name = name[strings.LastIndex(name, "/") + 1:]
+        if dot := strings.Index(name, "."); dot >= 0 {
+            c.pkg, c.function = name[:dot], name[dot + 1:]
+        }
+    }
+    return c
+}
@@ -0,0 +1,122 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+)
+
+// The components of ubiquity that log through their own component logger, each one can run at its own level.
+const (
+    ComponentScbe = "scbe"
+    ComponentSpectrum = "spectrum"
+    ComponentLocalhost = "localhost"
+    ComponentWebServer = "web_server"
+    ComponentMounter = "mounter"
+    ComponentRemote = "remote"
+    ComponentLocker = "locker"
+    ComponentModel = "model"
+)
+
+// levelRegistry holds the default level and the levels set for some components, the loggers read it on every call
+// so a level change applies at once
+type levelRegistry struct {
+    lock         sync.RWMutex
+    defaultLevel Level
+    components   map[string]Level
+}
+
+func newLevelRegistry(defaultLevel Level) *levelRegistry {
+    return &levelRegistry{defaultLevel: defaultLevel, components: make(map[string]Level)}
+}
+
+func (r *levelRegistry) level(component string) Level {
+    r.lock.RLock()
+    defer r.lock.RUnlock()
+    if level, ok := r.components[component]; ok {
+        return level
+    }
+    return r.defaultLevel
+}
+
+func (r *levelRegistry) enabled(component string, level Level) bool {
+    return level >= r.level(component)
+}
+
+func (r *levelRegistry) setLevel(component string, level Level) {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+    if component == "" {
+        r.defaultLevel = level
+        return
+    }
+    r.components[component] = level
+}
+
+func (r *levelRegistry) resetLevel(component string) {
+    r.lock.Lock()
+    defer r.lock.Unlock()
+    delete(r.components, component)
+}
+
+func (r *levelRegistry) componentLevels() map[string]Level {
+    r.lock.RLock()
+    defer r.lock.RUnlock()
+    levels := make(map[string]Level)
+    for component, level := range r.components {
+        levels[component] = level
+    }
+    return levels
+}
+
+// ParseLogLevel translates one of "debug" / "info" / "error" to its Level, unlike GetLogLevelFromString it fails on
+// any other string
+func ParseLogLevel(level string) (Level, error) {
+    switch strings.ToLower(level) {
+    case "debug":
+        return DEBUG, nil
+    case "info":
+        return INFO, nil
+    case "error":
+        return ERROR, nil
+    }
+    return INFO, fmt.Errorf("Invalid log level [%s], it must be one of [debug, info, error]", level)
+}
+
+// SetComponentLevel changes the level of a component logger, the empty component sets the default level of the
+// components without their own level.
+// If the global logger is not initialized SetComponentLevel panics.
+func SetComponentLevel(component string, level Level) {
+    getFactory().levels.setLevel(component, level)
+}
+
+// ResetComponentLevel makes a component log at the default level again
+func ResetComponentLevel(component string) {
+    getFactory().levels.resetLevel(component)
+}
+
+// GetLevels returns the default level and the level of each component logger created or set so far
+func GetLevels() (Level, map[string]Level) {
+    f := getFactory()
+    levels := f.levels.componentLevels()
+    for _, component := range f.components() {
+        levels[component] = f.levels.level(component)
+    }
+    return f.levels.level(""), levels
+}
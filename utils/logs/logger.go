@@ -55,6 +55,19 @@ type Logger interface {
     Trace(level Level, args ...Args) func()
 }
 
+func (level Level) String() string {
+    switch level {
+    case DEBUG:
+        return "debug"
+    case INFO:
+        return "info"
+    case ERROR:
+        return "error"
+    default:
+        return fmt.Sprintf("level(%d)", int(level))
+    }
+}
+
 func (param nameValue) String() string {
     return fmt.Sprintf("{" + param.Name + "=%v}", param.Value)
 }
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+    "bytes"
+    "crypto/rand"
+    "encoding/hex"
+    "io"
+    "regexp"
+    "runtime"
+    "strconv"
+    "sync"
+)
+
+// The request ID of a call is bound to the goroutine that serves it, so the loggers, the backends and the REST clients
+// running on that goroutine find it without passing it through every function.
+// Work handed to another goroutine must bind the ID again with BindRequestID, otherwise its log lines and REST calls
+// have no ID (or a new one): the background loops (reconcile, purge, lock and lease renewals, trace export) do not have one.
+//
+// Finding the goroutine parses the first line of its stack in each log line and each outgoing REST call. It costs a few
+// microseconds, about as much as formatting the rest of the line, while any ID is bound, and a map length check when none is
+// (see the benchmarks in request_id_test.go).
+
+const (
+    // RequestIDHeader is the HTTP header that carries the request ID between the plugin, the server and the storage
+    RequestIDHeader = "X-Request-ID"
+    // RequestIDKey is the name of the request ID in the log lines of both logger kinds
+    RequestIDKey = "request-id"
+)
+
+var (
+    requestIDs = make(map[int64]string)
+    requestIDsLock = &sync.RWMutex{}
+    validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+)
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+    id := make([]byte, 8)
+    if _, err := rand.Read(id); err != nil {
+        return strconv.FormatInt(GoroutineID(), 16)
+    }
+    return hex.EncodeToString(id)
+}
+
+// IsValidRequestID returns false for the IDs that are too long or could break a log line
+func IsValidRequestID(id string) bool {
+    return validRequestID.MatchString(id)
+}
+
+// BindRequestID binds id to the current goroutine, and returns a function that restores the previous ID,
+// so it can be used with defer in 1 line
+func BindRequestID(id string) func() {
+    gid := GoroutineID()
+    requestIDsLock.Lock()
+    previous, hadPrevious := requestIDs[gid]
+    requestIDs[gid] = id
+    requestIDsLock.Unlock()
+    return func() {
+        requestIDsLock.Lock()
+        defer requestIDsLock.Unlock()
+        if hadPrevious {
+            requestIDs[gid] = previous
+        } else {
+            delete(requestIDs, gid)
+        }
+    }
+}
+
+// GetRequestID returns the request ID bound to the current goroutine, or an empty string
+func GetRequestID() string {
+    requestIDsLock.RLock()
+    empty := len(requestIDs) == 0
+    requestIDsLock.RUnlock()
+    if empty {
+        return ""
+    }
+    gid := GoroutineID()
+    requestIDsLock.RLock()
+    defer requestIDsLock.RUnlock()
+    return requestIDs[gid]
+}
+
+// withRequestID adds the request ID of the current goroutine to args
+func withRequestID(args []Args) []Args {
+    if id := GetRequestID(); id != "" {
+        return append(args, Args{{RequestIDKey, id}})
+    }
+    return args
+}
+
+type requestIDWriter struct {
+    writer io.Writer
+}
+
+// NewRequestIDWriter returns a writer that adds the request ID of the writing goroutine to each line,
+// in the same {request-id=...} form as the Args of a Logger. It is meant for the output of a *log.Logger,
+// which writes each line with a single Write on the goroutine of the caller.
+func NewRequestIDWriter(writer io.Writer) io.Writer {
+    return &requestIDWriter{writer}
+}
+
+func (w *requestIDWriter) Write(p []byte) (int, error) {
+    id := GetRequestID()
+    if id == "" {
+        return w.writer.Write(p)
+    }
+    line := bytes.TrimSuffix(p, []byte("\n"))
+    buf := make([]byte, 0, len(p) + len(id) + len(RequestIDKey) + 5)
+    buf = append(buf, line...)
+    buf = append(buf, " {" + RequestIDKey + "=" + id + "}\n"...)
+    if _, err := w.writer.Write(buf); err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
+
+// GoroutineID parses the ID of the current goroutine from the first line of its stack, "goroutine 18 [running]:".
+// It is the costly part of GetRequestID, see BenchmarkGoroutineID.
+func GoroutineID() int64 {
+    buf := make([]byte, 64)
+    buf = buf[:runtime.Stack(buf, false)]
+    fields := bytes.Fields(buf)
+    if len(fields) < 2 {
+        return 0
+    }
+    id, _ := strconv.ParseInt(string(fields[1]), 10, 64)
+    return id
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package logs_test
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// The request ID of a log line is found by parsing the stack of the goroutine, the benchmarks below measure that cost
+// with and without a bound ID
+
+func BenchmarkGoroutineID(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        logs.GoroutineID()
+    }
+}
+
+func BenchmarkGetRequestIDNoneBound(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        logs.GetRequestID()
+    }
+}
+
+func BenchmarkGetRequestIDBound(b *testing.B) {
+    defer logs.BindRequestID(logs.NewRequestID())()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        logs.GetRequestID()
+    }
+}
+
+func BenchmarkLogLineNoneBound(b *testing.B) {
+    benchmarkLogLine(b)
+}
+
+func BenchmarkLogLineBound(b *testing.B) {
+    defer logs.BindRequestID(logs.NewRequestID())()
+    benchmarkLogLine(b)
+}
+
+func benchmarkLogLine(b *testing.B) {
+    dir, err := ioutil.TempDir("", "request_id_bench")
+    if err != nil {
+        b.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    defer logs.InitFileLogger(logs.DEBUG, filepath.Join(dir, "ubiquity.log"))()
+    logger := logs.GetLogger()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        logger.Info("benchmark", logs.Args{{"key", "value"}})
+    }
+}
@@ -0,0 +1,269 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logs
+
+import (
+    "compress/gzip"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// RotationConfig sets when a log file is rotated and how long the rotated files are kept.
+// A zero value disables the matching rule, so the zero config appends to the file forever.
+type RotationConfig struct {
+    MaxSize     int  // megabytes written to the file before it is rotated
+    RotateEvery int  // hours after which the file is rotated, counted from the time it was opened
+    MaxBackups  int  // number of rotated files kept
+    MaxAge      int  // days a rotated file is kept
+    Compress    bool // gzip the rotated files
+}
+
+const (
+    backupTimeFormat = "2006-01-02T15-04-05.000"
+    compressSuffix = ".gz"
+    megabyte = 1024 * 1024
+)
+
+// The loggers of a process that write to the same path share one rotatingFile, so a rotation is seen by all of them.
+var (
+    rotatingFiles = make(map[string]*rotatingFile)
+    rotatingFilesLock = &sync.Mutex{}
+)
+
+type rotatingFile struct {
+    path     string
+    config   RotationConfig
+    lock     sync.Mutex
+    file     *os.File
+    size     int64
+    openedAt time.Time
+    refs     int
+    millLock sync.Mutex
+}
+
+// OpenRotatingFile opens filePath for append, the file is rotated to <name>-<time><ext> when it reaches the limits of config.
+// A second open of the same path returns the same file, the file is closed when all the returned writers are closed.
+func OpenRotatingFile(filePath string, config RotationConfig) (io.WriteCloser, error) {
+    absPath, err := filepath.Abs(filePath)
+    if err != nil {
+        return nil, err
+    }
+    rotatingFilesLock.Lock()
+    defer rotatingFilesLock.Unlock()
+    if f, ok := rotatingFiles[absPath]; ok {
+        f.refs++
+        return &rotatingFileRef{file: f}, nil
+    }
+    f := &rotatingFile{path: absPath, config: config, refs: 1}
+    if err := f.open(); err != nil {
+        return nil, err
+    }
+    rotatingFiles[absPath] = f
+    go f.mill()
+    return &rotatingFileRef{file: f}, nil
+}
+
+// rotatingFileRef is one open of a shared rotatingFile, it is closed once
+type rotatingFileRef struct {
+    file *rotatingFile
+    once sync.Once
+}
+
+func (r *rotatingFileRef) Write(p []byte) (int, error) {
+    return r.file.Write(p)
+}
+
+func (r *rotatingFileRef) Close() error {
+    var err error
+    r.once.Do(func() {
+        rotatingFilesLock.Lock()
+        defer rotatingFilesLock.Unlock()
+        r.file.refs--
+        if r.file.refs > 0 {
+            return
+        }
+        delete(rotatingFiles, r.file.path)
+        err = r.file.close()
+    })
+    return err
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+    f.lock.Lock()
+    defer f.lock.Unlock()
+    if f.file == nil {
+        return 0, fmt.Errorf("log file %s is closed", f.path)
+    }
+    if f.shouldRotate(int64(len(p))) {
+        if err := f.rotate(); err != nil {
+            return 0, err
+        }
+    }
+    n, err := f.file.Write(p)
+    f.size += int64(n)
+    return n, err
+}
+
+func (f *rotatingFile) shouldRotate(writeSize int64) bool {
+    if f.size == 0 {
+        return false
+    }
+    if f.config.MaxSize > 0 && f.size + writeSize > int64(f.config.MaxSize) * megabyte {
+        return true
+    }
+    return f.config.RotateEvery > 0 && time.Since(f.openedAt) >= time.Duration(f.config.RotateEvery) * time.Hour
+}
+
+func (f *rotatingFile) open() error {
+    if err := os.MkdirAll(filepath.Dir(f.path), 0750); err != nil {
+        return err
+    }
+    file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+    if err != nil {
+        return err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return err
+    }
+    f.file = file
+    f.size = info.Size()
+    f.openedAt = time.Now()
+    return nil
+}
+
+func (f *rotatingFile) close() error {
+    f.lock.Lock()
+    defer f.lock.Unlock()
+    if f.file == nil {
+        return nil
+    }
+    err := f.file.Close()
+    f.file = nil
+    return err
+}
+
+// rotate renames the current file with the rotation time and opens a new one, the backups are compressed and removed
+// in the background
+func (f *rotatingFile) rotate() error {
+    if err := f.file.Close(); err != nil {
+        return err
+    }
+    f.file = nil
+    if err := os.Rename(f.path, f.backupName(time.Now().UTC())); err != nil {
+        // keep writing to the current file rather than losing the logs
+        return f.open()
+    }
+    if err := f.open(); err != nil {
+        return err
+    }
+    go f.mill()
+    return nil
+}
+
+func (f *rotatingFile) backupName(t time.Time) string {
+    ext := filepath.Ext(f.path)
+    return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), t.Format(backupTimeFormat), ext)
+}
+
+type backupFile struct {
+    path      string
+    timestamp time.Time
+}
+
+// backups returns the rotated files of f, newest first
+func (f *rotatingFile) backups() ([]backupFile, error) {
+    ext := filepath.Ext(f.path)
+    prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+    entries, err := ioutil.ReadDir(filepath.Dir(f.path))
+    if err != nil {
+        return nil, err
+    }
+    var backups []backupFile
+    for _, entry := range entries {
+        name := entry.Name()
+        if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+            continue
+        }
+        stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix), ext)
+        timestamp, err := time.Parse(backupTimeFormat, stamp)
+        if err != nil {
+            continue
+        }
+        backups = append(backups, backupFile{filepath.Join(filepath.Dir(f.path), name), timestamp})
+    }
+    sort.Slice(backups, func(i, j int) bool { return backups[i].timestamp.After(backups[j].timestamp) })
+    return backups, nil
+}
+
+// mill compresses the rotated files and removes the ones beyond MaxBackups or older than MaxAge.
+// Errors are written to stderr, the log file itself may be the broken part.
+func (f *rotatingFile) mill() {
+    f.millLock.Lock()
+    defer f.millLock.Unlock()
+    backups, err := f.backups()
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "failed to list rotated log files of %s: %v\n", f.path, err)
+        return
+    }
+    cutoff := time.Now().Add(-time.Duration(f.config.MaxAge) * 24 * time.Hour)
+    for i, backup := range backups {
+        if (f.config.MaxBackups > 0 && i >= f.config.MaxBackups) || (f.config.MaxAge > 0 && backup.timestamp.Before(cutoff)) {
+            if err := os.Remove(backup.path); err != nil {
+                fmt.Fprintf(os.Stderr, "failed to remove rotated log file %s: %v\n", backup.path, err)
+            }
+            continue
+        }
+        if f.config.Compress && !strings.HasSuffix(backup.path, compressSuffix) {
+            if err := compressFile(backup.path); err != nil {
+                fmt.Fprintf(os.Stderr, "failed to compress rotated log file %s: %v\n", backup.path, err)
+            }
+        }
+    }
+}
+
+func compressFile(path string) error {
+    src, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer src.Close()
+    dst, err := os.OpenFile(path + compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+    if err != nil {
+        return err
+    }
+    gz := gzip.NewWriter(dst)
+    if _, err = io.Copy(gz, src); err == nil {
+        err = gz.Close()
+    }
+    if closeErr := dst.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        os.Remove(path + compressSuffix)
+        return err
+    }
+    return os.Remove(path)
+}
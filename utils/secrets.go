@@ -0,0 +1,113 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/resources"
+)
+
+var secretEnvPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// ExpandSecret returns the environment variable VAR if the whole value is ${VAR}, an unset variable is an error
+// so a typo does not become an empty password. Any other value is kept as is, so the existing passwords that
+// contain a ${...} still work.
+func ExpandSecret(value string) (string, error) {
+	match := secretEnvPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+	variable, ok := os.LookupEnv(match[1])
+	if !ok {
+		return "", fmt.Errorf("environment variable [%s] not set", match[1])
+	}
+	return variable, nil
+}
+
+// ResolveSecret returns the value of the credential parameter name: the content of file without the trailing newline
+// if file is set, value with a ${VAR} reference expanded otherwise
+func ResolveSecret(name string, value string, fileName string, file string) (string, error) {
+	if file == "" {
+		secret, err := ExpandSecret(value)
+		if err != nil {
+			return "", fmt.Errorf("Error in config file. The parameter [%s]: %s", name, err.Error())
+		}
+		return secret, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("Error in config file. Only one of the parameters [%s] and [%s] can be set", name, fileName)
+	}
+	file, err := ExpandSecret(file)
+	if err != nil {
+		return "", fmt.Errorf("Error in config file. The parameter [%s]: %s", fileName, err.Error())
+	}
+	content, err := ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("Error in config file. The parameter [%s]: %s", fileName, err.Error())
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// ResolveServerConfigSecrets sets every credential parameter of config from the environment variables and the files
+// it references, so the config file itself does not hold the secrets
+func ResolveServerConfigSecrets(config *resources.UbiquityServerConfig) error {
+	if err := resolveBackendSecrets("", &config.ScbeConfig, &config.SpectrumScaleConfig); err != nil {
+		return err
+	}
+	for i := range config.Backends {
+		backend := &config.Backends[i]
+		if err := resolveBackendSecrets(fmt.Sprintf("Backends[%s].", backend.Name), &backend.ScbeConfig, &backend.SpectrumScaleConfig); err != nil {
+			return err
+		}
+	}
+	secret, err := ResolveSecret("DatabaseConfig.DSN", config.DatabaseConfig.DSN, "DatabaseConfig.dsn_file", config.DatabaseConfig.DSNFile)
+	if err != nil {
+		return err
+	}
+	config.DatabaseConfig.DSN = secret
+	return nil
+}
+
+// resolveBackendSecrets resolves the credential parameters of a backend section, prefix is prepended to their names
+func resolveBackendSecrets(prefix string, scbeConfig *resources.ScbeConfig, spectrumConfig *resources.SpectrumScaleConfig) error {
+	credentialInfo := &scbeConfig.ConnectionInfo.CredentialInfo
+	restConfig := &spectrumConfig.RestConfig
+	credentials := []struct {
+		name     string
+		value    *string
+		fileName string
+		file     string
+	}{
+		{"ScbeConfig.ConnectionInfo.CredentialInfo.UserName", &credentialInfo.UserName, "ScbeConfig.ConnectionInfo.CredentialInfo.username_file", credentialInfo.UserNameFile},
+		{"ScbeConfig.ConnectionInfo.CredentialInfo.Password", &credentialInfo.Password, "ScbeConfig.ConnectionInfo.CredentialInfo.password_file", credentialInfo.PasswordFile},
+		{"SpectrumScaleConfig.RestConfig.User", &restConfig.User, "SpectrumScaleConfig.RestConfig.user_file", restConfig.UserFile},
+		{"SpectrumScaleConfig.RestConfig.Password", &restConfig.Password, "SpectrumScaleConfig.RestConfig.password_file", restConfig.PasswordFile},
+		{"SpectrumScaleConfig.SshConfig.User", &spectrumConfig.SshConfig.User, "SpectrumScaleConfig.SshConfig.user_file", spectrumConfig.SshConfig.UserFile},
+	}
+	for _, credential := range credentials {
+		secret, err := ResolveSecret(prefix+credential.name, *credential.value, prefix+credential.fileName, credential.file)
+		if err != nil {
+			return err
+		}
+		*credential.value = secret
+	}
+	return nil
+}
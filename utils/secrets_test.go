@@ -0,0 +1,130 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secrets", func() {
+	var (
+		tmpDir string
+		config resources.UbiquityServerConfig
+	)
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "secrets")
+		Expect(err).ToNot(HaveOccurred())
+		os.Setenv("UBIQUITY_TEST_PASSWORD", "s3cr$t")
+		config = resources.UbiquityServerConfig{}
+	})
+	AfterEach(func() {
+		os.Unsetenv("UBIQUITY_TEST_PASSWORD")
+		os.RemoveAll(tmpDir)
+	})
+	Context(".ExpandSecret", func() {
+		It("should replace a value that is an environment variable", func() {
+			secret, err := utils.ExpandSecret("${UBIQUITY_TEST_PASSWORD}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret).To(Equal("s3cr$t"))
+		})
+		It("should keep the values that only contain a ${...}", func() {
+			for _, value := range []string{"pre-${UBIQUITY_TEST_PASSWORD}", "${UBIQUITY_TEST_MISSING}-post", "$HOME", "pa$${word}"} {
+				secret, err := utils.ExpandSecret(value)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secret).To(Equal(value))
+			}
+		})
+		It("should fail if a variable is not set", func() {
+			_, err := utils.ExpandSecret("${UBIQUITY_TEST_MISSING}")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("UBIQUITY_TEST_MISSING"))
+		})
+	})
+	Context(".ResolveServerConfigSecrets", func() {
+		It("should read the password files and expand the environment variables", func() {
+			passwordFile := path.Join(tmpDir, "scbe-password")
+			Expect(ioutil.WriteFile(passwordFile, []byte("from-file\n"), 0600)).To(Succeed())
+			config.ScbeConfig.ConnectionInfo.CredentialInfo.PasswordFile = passwordFile
+			config.SpectrumScaleConfig.RestConfig.Password = "${UBIQUITY_TEST_PASSWORD}"
+			config.DatabaseConfig.DSN = "${UBIQUITY_TEST_PASSWORD}"
+			Expect(utils.ResolveServerConfigSecrets(&config)).To(Succeed())
+			Expect(config.ScbeConfig.ConnectionInfo.CredentialInfo.Password).To(Equal("from-file"))
+			Expect(config.SpectrumScaleConfig.RestConfig.Password).To(Equal("s3cr$t"))
+			Expect(config.DatabaseConfig.DSN).To(Equal("s3cr$t"))
+		})
+		It("should read the user files", func() {
+			userFile := path.Join(tmpDir, "user")
+			Expect(ioutil.WriteFile(userFile, []byte("admin\n"), 0600)).To(Succeed())
+			config.ScbeConfig.ConnectionInfo.CredentialInfo.UserNameFile = userFile
+			config.SpectrumScaleConfig.RestConfig.UserFile = userFile
+			config.SpectrumScaleConfig.SshConfig.UserFile = userFile
+			Expect(utils.ResolveServerConfigSecrets(&config)).To(Succeed())
+			Expect(config.ScbeConfig.ConnectionInfo.CredentialInfo.UserName).To(Equal("admin"))
+			Expect(config.SpectrumScaleConfig.RestConfig.User).To(Equal("admin"))
+			Expect(config.SpectrumScaleConfig.SshConfig.User).To(Equal("admin"))
+
+			config = resources.UbiquityServerConfig{}
+			config.SpectrumScaleConfig.SshConfig = resources.SshConfig{User: "root", UserFile: userFile}
+			err := utils.ResolveServerConfigSecrets(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SpectrumScaleConfig.SshConfig.user_file"))
+		})
+		It("should resolve the credentials of the named backends", func() {
+			config.Backends = []resources.BackendConfig{{Name: "scbe-dc2", Type: resources.SCBE}}
+			config.Backends[0].ScbeConfig.ConnectionInfo.CredentialInfo.Password = "${UBIQUITY_TEST_PASSWORD}"
+			Expect(utils.ResolveServerConfigSecrets(&config)).To(Succeed())
+			Expect(config.Backends[0].ScbeConfig.ConnectionInfo.CredentialInfo.Password).To(Equal("s3cr$t"))
+
+			config.Backends[0].ScbeConfig.ConnectionInfo.CredentialInfo.Password = "${UBIQUITY_TEST_MISSING}"
+			err := utils.ResolveServerConfigSecrets(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Backends[scbe-dc2].ScbeConfig.ConnectionInfo.CredentialInfo.Password"))
+		})
+		It("should fail if both the password and the password file are set", func() {
+			config.SpectrumScaleConfig.RestConfig.Password = "plain"
+			config.SpectrumScaleConfig.RestConfig.PasswordFile = path.Join(tmpDir, "spectrum-password")
+			err := utils.ResolveServerConfigSecrets(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SpectrumScaleConfig.RestConfig.password_file"))
+		})
+		It("should fail if the password file is missing", func() {
+			config.DatabaseConfig.DSNFile = path.Join(tmpDir, "missing")
+			err := utils.ResolveServerConfigSecrets(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("DatabaseConfig.dsn_file"))
+		})
+	})
+	It("should redact the secrets when the config is logged", func() {
+		config.ScbeConfig.ConnectionInfo.CredentialInfo = resources.CredentialInfo{UserName: "admin", Password: "s3cr$t"}
+		config.SpectrumScaleConfig.RestConfig = resources.RestConfig{User: "admin", Password: "s3cr$t"}
+		config.DatabaseConfig.DSN = "user=ubiquity password=s3cr$t"
+		logged := fmt.Sprintf("%v %+v", config, logs.Args{{"config", config}})
+		Expect(logged).ToNot(ContainSubstring("s3cr$t"))
+		Expect(logged).To(ContainSubstring("UserName:admin"))
+		Expect(logged).To(ContainSubstring(resources.RedactedSecret))
+	})
+})
@@ -0,0 +1,374 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// The ended spans are queued and exported in batches, in the OTLP/HTTP JSON encoding: one ExportTraceServiceRequest
+// per line of the file, and one per POST to the endpoint, so the file can be replayed to any OTLP collector.
+
+// Config selects where the spans are exported, tracing is disabled if both File and Endpoint are empty
+type Config struct {
+	ServiceName   string
+	File          string        // OTLP-JSON lines are appended to this file
+	Endpoint      string        // OTLP/HTTP traces endpoint of a local collector, e.g. http://localhost:4318/v1/traces
+	FlushInterval time.Duration // the queued spans are exported at least this often, DefaultFlushInterval if 0
+}
+
+const (
+	DefaultServiceName   = "ubiquity"
+	DefaultFlushInterval = 5 * time.Second
+	maxQueuedSpans       = 4096
+	maxBatchSpans        = 512
+	instrumentationScope = "github.com/midoblgsm/ubiquity/utils/tracing"
+)
+
+type exporter interface {
+	export(request []byte) error
+	close() error
+}
+
+type tracer struct {
+	logger    logs.Logger
+	resource  otlpResource
+	exporters []exporter
+	spans     chan *Span
+	flush     chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	dropped   int
+	lock      sync.Mutex
+}
+
+// Init starts exporting the spans as set in config, and returns a function that exports the queued spans and stops
+// tracing. With an empty config Init does nothing.
+// If tracing is already initialized Init fails.
+func Init(config Config) (func(), error) {
+	if config.File == "" && config.Endpoint == "" {
+		return func() {}, nil
+	}
+	globalTracerLock.Lock()
+	defer globalTracerLock.Unlock()
+	if globalTracer != nil {
+		return nil, fmt.Errorf("tracing already initialized")
+	}
+	t, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	globalTracer = t
+	return func() {
+		globalTracerLock.Lock()
+		globalTracer = nil
+		globalTracerLock.Unlock()
+		t.stop()
+	}, nil
+}
+
+func newTracer(config Config) (*tracer, error) {
+	t := &tracer{
+		logger:  logs.GetLogger(),
+		spans:   make(chan *Span, maxQueuedSpans),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if config.File != "" {
+		if err := os.MkdirAll(filepath.Dir(config.File), 0750); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(config.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		if err != nil {
+			return nil, err
+		}
+		t.exporters = append(t.exporters, &fileExporter{file: file})
+	}
+	if config.Endpoint != "" {
+		t.exporters = append(t.exporters, &httpExporter{endpoint: config.Endpoint, httpClient: &http.Client{Timeout: 10 * time.Second}})
+	}
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	hostname, _ := os.Hostname()
+	t.resource = otlpResource{Attributes: otlpAttributes(logs.Args{
+		{"service.name", serviceName},
+		{"host.name", hostname},
+		{"process.pid", os.Getpid()},
+	})}
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+	go t.run(flushInterval)
+	return t, nil
+}
+
+// queue never blocks the traced call, the spans are dropped when the exporters fall behind
+func (t *tracer) queue(span *Span) {
+	select {
+	case t.spans <- span:
+	default:
+		t.lock.Lock()
+		t.dropped++
+		t.lock.Unlock()
+	}
+}
+
+// Flush exports the spans queued so far, for the short lived processes and the tests
+func Flush() {
+	if t := getTracer(); t != nil {
+		flushed := make(chan struct{})
+		select {
+		case t.flush <- flushed:
+			<-flushed
+		case <-t.stopped:
+		}
+	}
+}
+
+func (t *tracer) run(flushInterval time.Duration) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) >= maxBatchSpans {
+				batch = t.export(batch)
+			}
+		case <-ticker.C:
+			batch = t.export(batch)
+		case flushed := <-t.flush:
+			batch = t.export(t.drain(batch))
+			close(flushed)
+		case <-t.done:
+			t.export(t.drain(batch))
+			for _, e := range t.exporters {
+				e.close()
+			}
+			close(t.stopped)
+			return
+		}
+	}
+}
+
+func (t *tracer) drain(batch []*Span) []*Span {
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+		default:
+			return batch
+		}
+	}
+}
+
+func (t *tracer) stop() {
+	close(t.done)
+	<-t.stopped
+}
+
+// export sends batch to the exporters and returns the batch to fill next
+func (t *tracer) export(batch []*Span) []*Span {
+	t.lock.Lock()
+	dropped := t.dropped
+	t.dropped = 0
+	t.lock.Unlock()
+	if dropped > 0 {
+		t.logger.Error("tracing queue full, spans dropped", logs.Args{{"dropped", dropped}})
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+	request, err := json.Marshal(t.otlpRequest(batch))
+	if err != nil {
+		t.logger.Error("failed to encode spans", logs.Args{{"error", err}})
+		return batch[:0]
+	}
+	for _, e := range t.exporters {
+		if err := e.export(request); err != nil {
+			t.logger.Error("failed to export spans", logs.Args{{"spans", len(batch)}, {"error", err}})
+		}
+	}
+	return batch[:0]
+}
+
+type fileExporter struct {
+	file *os.File
+}
+
+func (e *fileExporter) export(request []byte) error {
+	_, err := e.file.Write(append(request, '\n'))
+	return err
+}
+
+func (e *fileExporter) close() error {
+	return e.file.Close()
+}
+
+type httpExporter struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+func (e *httpExporter) export(request []byte) error {
+	response, err := e.httpClient.Post(e.endpoint, "application/json", bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", e.endpoint, response.Status)
+	}
+	return nil
+}
+
+func (e *httpExporter) close() error {
+	return nil
+}
+
+// The OTLP JSON encoding, with the trace and span IDs in hex and the 64 bit integers as strings
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 unset, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+const otlpStatusError = 2
+
+func (t *tracer) otlpRequest(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		span.lock.Lock()
+		s := otlpSpan{
+			TraceID:           span.context.TraceID,
+			SpanID:            span.context.SpanID,
+			ParentSpanID:      span.parentSpanID,
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.attributes),
+		}
+		if span.err != nil {
+			s.Status = otlpStatus{Code: otlpStatusError, Message: span.err.Error()}
+		}
+		span.lock.Unlock()
+		spans = append(spans, s)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   t.resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: spans}},
+	}}}
+}
+
+func otlpAttributes(args logs.Args) []otlpKeyValue {
+	attributes := make([]otlpKeyValue, 0, len(args))
+	for _, arg := range args {
+		attributes = append(attributes, otlpKeyValue{Key: arg.Name, Value: otlpValue(arg.Value)})
+	}
+	return attributes
+}
+
+func otlpValue(value interface{}) otlpAnyValue {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := strconv.FormatInt(v.Int(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := strconv.FormatUint(v.Uint(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return otlpAnyValue{DoubleValue: &f}
+	case reflect.String:
+		s := v.String()
+		return otlpAnyValue{StringValue: &s}
+	}
+	var s string
+	if err, ok := value.(error); ok {
+		s = err.Error()
+	} else {
+		s = fmt.Sprintf("%v", value)
+	}
+	return otlpAnyValue{StringValue: &s}
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// TraceparentHeader is the W3C Trace Context header, "00-<trace-id>-<parent-id>-<flags>"
+const TraceparentHeader = "traceparent"
+
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// Inject sets the traceparent header to the span bound to the current goroutine, if any
+func Inject(header http.Header) {
+	c := CurrentSpanContext()
+	if !c.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, fmt.Sprintf("00-%s-%s-01", c.TraceID, c.SpanID))
+}
+
+// Extract returns the span context of the traceparent header, it returns false if the header is missing or invalid
+func Extract(header http.Header) (SpanContext, bool) {
+	match := traceparentPattern.FindStringSubmatch(strings.TrimSpace(header.Get(TraceparentHeader)))
+	if match == nil || strings.Trim(match[1], "0") == "" || strings.Trim(match[2], "0") == "" {
+		return SpanContext{}, false
+	}
+	return SpanContext{TraceID: match[1], SpanID: match[2]}, true
+}
+
+// HTTPHandler serves each request in a server span named by spanName, child of the span of the traceparent header
+func HTTPHandler(spanName func(req *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if getTracer() == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if parent, ok := Extract(req.Header); ok {
+			defer BindSpanContext(parent)()
+		}
+		span := StartSpan(spanName(req), KindServer, logs.Args{
+			{"http.method", req.Method},
+			{"http.target", req.URL.Path},
+			{"request.id", logs.GetRequestID()},
+		})
+		defer span.End()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		span.SetAttribute("http.status_code", recorder.status)
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("%d %s", recorder.status, http.StatusText(recorder.status)))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
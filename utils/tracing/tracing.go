@@ -0,0 +1,205 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// A span is bound to the goroutine that started it until it ends, like the request IDs of the logs package,
+// so the spans started below it on the same goroutine become its children without passing it around.
+// Work handed to another goroutine must bind the parent again with BindSpanContext.
+// When tracing is not initialized StartSpan returns nil, and the methods of a nil *Span do nothing.
+
+type SpanKind int
+
+// The span kinds of OTLP
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// SpanContext identifies a span across processes, it is what the traceparent header carries
+type SpanContext struct {
+	TraceID string // 32 lower case hex digits
+	SpanID  string // 16 lower case hex digits
+}
+
+func (c SpanContext) IsValid() bool {
+	return c.TraceID != "" && c.SpanID != ""
+}
+
+type Span struct {
+	name         string
+	kind         SpanKind
+	context      SpanContext
+	parentSpanID string
+	start        time.Time
+	end          time.Time
+	lock         sync.Mutex
+	attributes   logs.Args
+	err          error
+	unbind       func()
+	tracer       *tracer
+}
+
+var (
+	globalTracer     *tracer
+	globalTracerLock = &sync.RWMutex{}
+	spanContexts     = make(map[int64]SpanContext)
+	spanContextsLock = &sync.RWMutex{}
+)
+
+// StartSpan starts a span, child of the span bound to the current goroutine if any, and binds it to the goroutine
+// until End
+func StartSpan(name string, kind SpanKind, attributes ...logs.Args) *Span {
+	t := getTracer()
+	if t == nil {
+		return nil
+	}
+	parent := CurrentSpanContext()
+	span := &Span{name: name, kind: kind, start: time.Now(), tracer: t, parentSpanID: parent.SpanID}
+	span.context = SpanContext{TraceID: parent.TraceID, SpanID: newID(8)}
+	if span.context.TraceID == "" {
+		span.context.TraceID = newID(16)
+	}
+	for _, args := range attributes {
+		span.attributes = append(span.attributes, args...)
+	}
+	span.unbind = BindSpanContext(span.context)
+	return span
+}
+
+// StartChildSpan starts a span like StartSpan, but only below a span bound to the current goroutine, for the calls
+// that run too often to be traced on their own, like the database queries of the background jobs
+func StartChildSpan(name string, kind SpanKind, attributes ...logs.Args) *Span {
+	if !CurrentSpanContext().IsValid() {
+		return nil
+	}
+	return StartSpan(name, kind, attributes...)
+}
+
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+func (s *Span) SetAttribute(name string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.attributes = append(s.attributes, logs.Args{{name, value}}...)
+}
+
+// SetError marks the span as failed with err, a nil err leaves the span as it is
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+}
+
+// End binds the parent span to the goroutine again and queues the span for export, it must run on the goroutine that
+// started the span
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if !s.end.IsZero() {
+		s.lock.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.lock.Unlock()
+	s.unbind()
+	s.tracer.queue(s)
+}
+
+// EndWithError sets the error *err then ends the span, for "defer span.EndWithError(&err)" in the functions with a
+// named error result
+func (s *Span) EndWithError(err *error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.SetError(*err)
+	}
+	s.End()
+}
+
+// BindSpanContext binds c to the current goroutine as the parent of the next spans, and returns a function that
+// restores the previous one, so it can be used with defer in 1 line
+func BindSpanContext(c SpanContext) func() {
+	gid := logs.GoroutineID()
+	spanContextsLock.Lock()
+	previous, hadPrevious := spanContexts[gid]
+	spanContexts[gid] = c
+	spanContextsLock.Unlock()
+	return func() {
+		spanContextsLock.Lock()
+		defer spanContextsLock.Unlock()
+		if hadPrevious {
+			spanContexts[gid] = previous
+		} else {
+			delete(spanContexts, gid)
+		}
+	}
+}
+
+// CurrentSpanContext returns the span context bound to the current goroutine, or an invalid one
+func CurrentSpanContext() SpanContext {
+	spanContextsLock.RLock()
+	empty := len(spanContexts) == 0
+	spanContextsLock.RUnlock()
+	if empty {
+		return SpanContext{}
+	}
+	gid := logs.GoroutineID()
+	spanContextsLock.RLock()
+	defer spanContextsLock.RUnlock()
+	return spanContexts[gid]
+}
+
+func getTracer() *tracer {
+	globalTracerLock.RLock()
+	defer globalTracerLock.RUnlock()
+	return globalTracer
+}
+
+// newID returns size random bytes in hex, never all zeros since W3C reserves that value for invalid IDs
+func newID(size int) string {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%0*x", size*2, time.Now().UnixNano())
+	}
+	id[0] |= 1
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,161 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Status       struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+func readExportedSpans(file string) map[string]exportedSpan {
+	f, err := os.Open(file)
+	Expect(err).ToNot(HaveOccurred())
+	defer f.Close()
+	spans := make(map[string]exportedSpan)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var request struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		Expect(json.Unmarshal(scanner.Bytes(), &request)).To(Succeed())
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				for _, span := range scopeSpans.Spans {
+					spans[span.Name] = span
+				}
+			}
+		}
+	}
+	Expect(scanner.Err()).ToNot(HaveOccurred())
+	return spans
+}
+
+var _ = Describe("Tracing", func() {
+	var (
+		tmpDir       string
+		traceFile    string
+		closeTracing func()
+		server       *httptest.Server
+		traceparent  string
+	)
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "tracing")
+		Expect(err).ToNot(HaveOccurred())
+		traceFile = path.Join(tmpDir, "traces.json")
+		closeTracing, err = tracing.Init(tracing.Config{File: traceFile})
+		Expect(err).ToNot(HaveOccurred())
+		traceparent = ""
+		server = httptest.NewServer(utils.RequestIDHandler(tracing.HTTPHandler(
+			func(req *http.Request) string { return "GET /test" },
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				traceparent = req.Header.Get(tracing.TraceparentHeader)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))))
+	})
+	AfterEach(func() {
+		server.Close()
+		closeTracing()
+		os.RemoveAll(tmpDir)
+	})
+	It("should not start spans when tracing is not initialized", func() {
+		closeTracing()
+		closeTracing = func() {}
+		span := tracing.StartSpan("ignored", tracing.KindInternal)
+		Expect(span).To(BeNil())
+		span.SetAttribute("ignored", true)
+		span.End()
+		Expect(tracing.CurrentSpanContext().IsValid()).To(BeFalse())
+	})
+	It("should fail to initialize tracing twice", func() {
+		_, err := tracing.Init(tracing.Config{File: traceFile})
+		Expect(err).To(HaveOccurred())
+	})
+	It("should propagate the trace to the server and export the spans", func() {
+		span := tracing.StartSpan("attach", tracing.KindInternal, logs.Args{{"volume", "vol1"}})
+		Expect(tracing.CurrentSpanContext()).To(Equal(span.Context()))
+		response, err := utils.HttpExecute(&http.Client{}, logs.GetLogger(), "GET", server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(http.StatusInternalServerError))
+		span.End()
+		Expect(tracing.CurrentSpanContext().IsValid()).To(BeFalse())
+
+		received, ok := tracing.Extract(http.Header{"Traceparent": []string{traceparent}})
+		Expect(ok).To(BeTrue())
+		Expect(received.TraceID).To(Equal(span.Context().TraceID))
+
+		tracing.Flush()
+		spans := readExportedSpans(traceFile)
+		Expect(spans).To(HaveKey("attach"))
+		Expect(spans).To(HaveKey("HTTP GET"))
+		Expect(spans).To(HaveKey("GET /test"))
+		Expect(spans["attach"].ParentSpanID).To(BeEmpty())
+		Expect(spans["HTTP GET"].ParentSpanID).To(Equal(spans["attach"].SpanID))
+		Expect(spans["HTTP GET"].SpanID).To(Equal(received.SpanID))
+		Expect(spans["GET /test"].ParentSpanID).To(Equal(spans["HTTP GET"].SpanID))
+		Expect(spans["GET /test"].TraceID).To(Equal(spans["attach"].TraceID))
+		Expect(spans["GET /test"].Kind).To(Equal(int(tracing.KindServer)))
+		Expect(spans["GET /test"].Status.Code).To(Equal(2))
+		Expect(spans["attach"].Status.Code).To(Equal(0))
+	})
+	It("should only start child spans below a span", func() {
+		Expect(tracing.StartChildSpan("db.query", tracing.KindClient)).To(BeNil())
+		span := tracing.StartSpan("parent", tracing.KindInternal)
+		child := tracing.StartChildSpan("db.query", tracing.KindClient)
+		Expect(child).ToNot(BeNil())
+		Expect(child.Context().TraceID).To(Equal(span.Context().TraceID))
+		child.End()
+		Expect(tracing.CurrentSpanContext()).To(Equal(span.Context()))
+		span.End()
+	})
+	It("should reject an invalid traceparent header", func() {
+		for _, header := range []string{"", "00-abc-def-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+			_, ok := tracing.Extract(http.Header{"Traceparent": []string{header}})
+			Expect(ok).To(BeFalse(), header)
+		}
+		c, ok := tracing.Extract(http.Header{"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}})
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(tracing.SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}))
+	})
+})
@@ -25,9 +25,10 @@ import (
 
 	"path"
 
-	"log"
 	"strconv"
 	"strings"
+
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 func ReadAndUnmarshal(object interface{}, dir string, fileName string) error {
@@ -119,32 +120,31 @@ func MkDir(path string) error {
 	return err
 }
 
-func SetupConfigDirectory(logger *log.Logger, executor Executor, configPath string) (string, error) {
-	logger.Println("setupConfigPath start")
-	defer logger.Println("setupConfigPath end")
+func SetupConfigDirectory(logger logs.Logger, executor Executor, configPath string) (string, error) {
+	defer logger.Trace(logs.DEBUG)()
 
 	ubiquityConfigPath := path.Join(configPath, ".config")
-	logger.Printf("User specified config path: %s", configPath)
+	logger.Info("User specified config path", logs.Args{{"configPath", configPath}})
 
 	if _, err := executor.Stat(ubiquityConfigPath); os.IsNotExist(err) {
 		args := []string{"mkdir", ubiquityConfigPath}
 		_, err := executor.Execute("sudo", args)
 		if err != nil {
-			logger.Printf("Error creating directory %s", err.Error())
+			logger.Error("Error creating directory", logs.Args{{"error", err}})
 			return "", err
 		}
 
 	}
 	currentUser, err := user.Current()
 	if err != nil {
-		logger.Printf("Error determining current user: %s", err.Error())
+		logger.Error("Error determining current user", logs.Args{{"error", err}})
 		return "", err
 	}
 
 	args := []string{"chown", "-R", fmt.Sprintf("%s:%s", currentUser.Uid, currentUser.Gid), ubiquityConfigPath}
 	_, err = executor.Execute("sudo", args)
 	if err != nil {
-		logger.Printf("Error setting permissions on config directory %s", ubiquityConfigPath)
+		logger.Error("Error setting permissions on config directory", logs.Args{{"ubiquityConfigPath", ubiquityConfigPath}})
 		return "", err
 	}
 	return ubiquityConfigPath, nil
@@ -159,7 +159,7 @@ func StringInSlice(a string, list []string) bool {
 	return false
 }
 
-func ConvertToBytes(logger *log.Logger, inputStr string) (uint64, error) {
+func ConvertToBytes(logger logs.Logger, inputStr string) (uint64, error) {
 	var Iter int
 	var byteSlice []byte
 	var retValue uint64
@@ -188,7 +188,7 @@ func ConvertToBytes(logger *log.Logger, inputStr string) (uint64, error) {
 	}
 
 	if Iter == len(inputStr) {
-		logger.Printf("Input string has no Unit, returning %v\n", retValue)
+		logger.Debug("Input string has no Unit, returning", logs.Args{{"retValue", retValue}})
 		return retValue, nil
 	}
 
@@ -0,0 +1,253 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Admin operations on the whole server state, they are not used by the plugins
+
+func (h *StorageApiHandler) ExportInventory() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		inventory, err := model.ExportInventory(h.database)
+		if err != nil {
+			h.logger.Error("Error exporting inventory", logs.Args{{"error", err}})
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		utils.WriteResponse(w, http.StatusOK, inventory)
+	}
+}
+
+func (h *StorageApiHandler) ImportInventory() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		inventory := model.Inventory{}
+		err := utils.UnmarshalDataFromRequest(req, &inventory)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = model.ImportInventory(h.database, inventory); err != nil {
+			h.logger.Error("Error importing inventory", logs.Args{{"error", err}})
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		utils.WriteResponse(w, http.StatusOK, nil)
+	}
+}
+
+// GetLogLevels returns the level of each component logger, the components not listed log at the default level
+func (h *StorageApiHandler) GetLogLevels() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		utils.WriteResponse(w, http.StatusOK, logLevelsResponse())
+	}
+}
+
+// SetLogLevel changes the level of one component logger, or the default level, until the server restarts
+func (h *StorageApiHandler) SetLogLevel() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		setLogLevelRequest := resources.SetLogLevelRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &setLogLevelRequest)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if setLogLevelRequest.Level == "" {
+			if setLogLevelRequest.Component == "" {
+				utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: "the default log level cannot be reset"})
+				return
+			}
+			logs.ResetComponentLevel(setLogLevelRequest.Component)
+		} else {
+			level, err := logs.ParseLogLevel(setLogLevelRequest.Level)
+			if err != nil {
+				utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+				return
+			}
+			logs.SetComponentLevel(setLogLevelRequest.Component, level)
+		}
+		h.logger.Info("Log level changed", logs.Args{{"component", setLogLevelRequest.Component}, {"level", setLogLevelRequest.Level}})
+		utils.WriteResponse(w, http.StatusOK, logLevelsResponse())
+	}
+}
+
+func logLevelsResponse() resources.LogLevelsResponse {
+	defaultLevel, levels := logs.GetLevels()
+	response := resources.LogLevelsResponse{Default: defaultLevel.String(), Components: make(map[string]string)}
+	for component, level := range levels {
+		response.Components[component] = level.String()
+	}
+	return response
+}
+
+// Adopt registers storage that was created outside ubiquity as volumes of one backend
+func (h *StorageApiHandler) Adopt() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		adoptRequest := resources.AdoptRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &adoptRequest)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if len(adoptRequest.Backend) == 0 {
+			adoptRequest.Backend = h.config.DefaultBackend
+		}
+		backend, ok := h.backends[adoptRequest.Backend]
+		if !ok {
+			h.logger.Error("error-backend-not-found", logs.Args{{"backend", adoptRequest.Backend}})
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
+			return
+		}
+		adopter, ok := backend.(resources.BackendAdopter)
+		if !ok {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: fmt.Sprintf("backend %s does not support adopt", adoptRequest.Backend)})
+			return
+		}
+		adoptResponse := adopter.Adopt(adoptRequest)
+		if adoptResponse.Error != nil {
+			h.logger.Error("Error adopting volumes", logs.Args{{"error", adoptResponse.Error}})
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: adoptResponse.Error.Error()})
+			return
+		}
+		utils.WriteResponse(w, http.StatusOK, resources.ListResponse{Volumes: adoptResponse.Volumes})
+	}
+}
+
+func (h *StorageApiHandler) Reconcile() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reconcileRequest := resources.ReconcileRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &reconcileRequest)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		backends := reconcileRequest.Backends
+		if len(backends) == 0 {
+			for name := range h.backends {
+				backends = append(backends, name)
+			}
+		}
+		for _, name := range backends {
+			if _, ok := h.backends[name]; !ok {
+				utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
+				return
+			}
+		}
+		utils.WriteResponse(w, http.StatusOK, h.reconcileBackends(backends, reconcileRequest.Repair))
+	}
+}
+
+// RunPeriodicReconcile reconciles all the backends every interval, until the server exits.
+// Each run holds the reconcile lease, so only one of the servers sharing the database runs at a time.
+func (h *StorageApiHandler) RunPeriodicReconcile(interval time.Duration, repair bool) {
+	leaseDuration, leaseRenewInterval := model.LeaseDurations(h.config.LeaseConfig)
+	elector, err := model.NewLeaderElector(h.database, model.ReconcileLeaseName, leaseDuration, leaseRenewInterval)
+	if err != nil {
+		h.logger.Error("Error creating reconcile lease, background reconcile disabled", logs.Args{{"error", err}})
+		return
+	}
+	for {
+		time.Sleep(interval)
+		acquired, current, err := elector.TryAcquire()
+		if err != nil {
+			h.logger.Error("Error acquiring reconcile lease", logs.Args{{"error", err}})
+			continue
+		}
+		if !acquired {
+			h.logger.Info("Reconcile is running on another server, skipping", logs.Args{{"holderID", current.HolderID}, {"hostname", current.Hostname}})
+			continue
+		}
+		h.runLeasedReconcile(elector, leaseRenewInterval, repair)
+	}
+}
+
+func (h *StorageApiHandler) runLeasedReconcile(elector *model.LeaderElector, leaseRenewInterval time.Duration, repair bool) {
+	stopKeepAlive := make(chan struct{})
+	go elector.KeepAlive(leaseRenewInterval, stopKeepAlive, func(err error) {
+		h.logger.Error("Lost reconcile lease, another server may reconcile at the same time", logs.Args{{"error", err}})
+	})
+	defer func() {
+		close(stopKeepAlive)
+		if err := elector.Release(); err != nil {
+			h.logger.Error("Error releasing reconcile lease", logs.Args{{"error", err}})
+		}
+	}()
+
+	var backends []string
+	for name := range h.backends {
+		backends = append(backends, name)
+	}
+	response := h.reconcileBackends(backends, repair)
+	for _, report := range response.Reports {
+		for _, drift := range report.Drifts {
+			h.logger.Info("Reconcile drift", logs.Args{{"drift", drift}})
+		}
+		for _, reconcileError := range report.Errors {
+			h.logger.Error("Reconcile error on backend", logs.Args{{"backend", report.Backend}, {"error", reconcileError}})
+		}
+	}
+}
+
+func (h *StorageApiHandler) reconcileBackends(backends []string, repair bool) resources.ReconcileResponse {
+	response := resources.ReconcileResponse{}
+	for _, name := range backends {
+		report := resources.ReconcileReport{Backend: name}
+		reconciler, ok := h.backends[name].(resources.BackendReconciler)
+		if !ok {
+			report.Errors = append(report.Errors, "backend does not support reconcile")
+			response.Reports = append(response.Reports, report)
+			continue
+		}
+		drifts, err := reconciler.Reconcile()
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			response.Reports = append(response.Reports, report)
+			continue
+		}
+		report.Drifts = drifts
+		if repair {
+			for _, drift := range drifts {
+				if err := h.repairDrift(reconciler, drift); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+					continue
+				}
+				report.Repaired = append(report.Repaired, drift)
+			}
+		}
+		response.Reports = append(response.Reports, report)
+	}
+	return response
+}
+
+// repairDrift holds the volume lock, so the repair does not race with a request on the same volume
+func (h *StorageApiHandler) repairDrift(reconciler resources.BackendReconciler, drift resources.Drift) error {
+	if drift.Volume != "" {
+		if err := h.lockVolumeInBackground(drift.Volume); err != nil {
+			return err
+		}
+		defer h.locker.WriteUnlock(drift.Volume)
+	}
+	return reconciler.RepairDrift(drift)
+}
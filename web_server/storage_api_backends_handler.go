@@ -0,0 +1,95 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// ListBackends returns the served backend instances with their activation state and capabilities, sorted by name
+func (h *StorageApiHandler) ListBackends() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		response := resources.ListBackendsResponse{Backends: []resources.BackendInfo{}}
+		for name, backend := range h.backends {
+			response.Backends = append(response.Backends, h.describeBackend(name, backend))
+		}
+		sort.Slice(response.Backends, func(i, j int) bool { return response.Backends[i].Name < response.Backends[j].Name })
+		utils.WriteResponse(w, http.StatusOK, response)
+	}
+}
+
+// ListPools returns the pools of a backend (SCBE services, Spectrum Scale filesystems) with their capacity
+func (h *StorageApiHandler) ListPools() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := utils.ExtractVarsFromRequest(req, "name")
+		backend, ok := h.backends[name]
+		if !ok {
+			h.logger.Error("error-backend-not-found", logs.Args{{"backend", name}})
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
+			return
+		}
+		poolLister, ok := backend.(resources.PoolLister)
+		if !ok {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: fmt.Sprintf("backend %s does not support pools", name)})
+			return
+		}
+		pools, err := poolLister.ListPools()
+		if err != nil {
+			h.logger.Error("Error listing pools", logs.Args{{"backend", name}, {"error", err}})
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.ListPoolsResponse{Backend: name, Err: err.Error()})
+			return
+		}
+		utils.WriteResponse(w, http.StatusOK, resources.ListPoolsResponse{Backend: name, Pools: pools})
+	}
+}
+
+// describeBackend adds the capabilities of the optional interfaces the backend implements to its own ones
+func (h *StorageApiHandler) describeBackend(name string, backend resources.StorageClient) resources.BackendInfo {
+	info := resources.BackendInfo{
+		Name:         name,
+		Type:         h.config.BackendType(name),
+		Default:      name == h.config.DefaultBackend,
+		Capabilities: []string{},
+	}
+	if describer, ok := backend.(resources.BackendDescriber); ok {
+		info.Activated = describer.IsActivated()
+		info.Capabilities = append(info.Capabilities, describer.Capabilities()...)
+	}
+	if _, ok := backend.(resources.BackendAdopter); ok {
+		info.Capabilities = append(info.Capabilities, resources.CapabilityAdopt)
+	}
+	if _, ok := backend.(resources.BackendReconciler); ok {
+		info.Capabilities = append(info.Capabilities, resources.CapabilityReconcile)
+	}
+	if _, ok := backend.(resources.VolumeRecoverer); ok {
+		info.Capabilities = append(info.Capabilities, resources.CapabilityRecover)
+	}
+	if _, ok := backend.(resources.PoolLister); ok {
+		info.Capabilities = append(info.Capabilities, resources.CapabilityPools)
+	}
+	if _, ok := backend.(resources.VolumeQosUpdater); ok {
+		info.Capabilities = append(info.Capabilities, resources.CapabilityQos)
+	}
+	sort.Strings(info.Capabilities)
+	return info
+}
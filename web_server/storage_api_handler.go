@@ -17,7 +17,6 @@
 package web_server
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/midoblgsm/ubiquity/resources"
@@ -27,23 +26,24 @@ import (
 
 	"github.com/jinzhu/gorm"
 	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/utils/logs"
 )
 
 type StorageApiHandler struct {
-	logger   *log.Logger
+	logger   logs.Logger
 	backends map[string]resources.StorageClient
 	database *gorm.DB
 	config   resources.UbiquityServerConfig
 	locker   utils.Locker
 }
 
-func NewStorageApiHandler(logger *log.Logger, backends map[string]resources.StorageClient, database *gorm.DB, config resources.UbiquityServerConfig) *StorageApiHandler {
-	return &StorageApiHandler{logger: logger, backends: backends, database: database, config: config, locker: utils.NewLocker()}
+func NewStorageApiHandler(backends map[string]resources.StorageClient, database *gorm.DB, config resources.UbiquityServerConfig, locker utils.Locker) *StorageApiHandler {
+	return &StorageApiHandler{logger: logs.GetComponentLogger(logs.ComponentWebServer), backends: backends, database: database, config: config, locker: locker}
 }
 
 func (h *StorageApiHandler) Activate() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		h.logger.Println("start")
+		defer h.logger.Trace(logs.DEBUG)()
 		activateRequest := resources.ActivateRequest{}
 		err := utils.UnmarshalDataFromRequest(req, &activateRequest)
 		if err != nil {
@@ -52,28 +52,28 @@ func (h *StorageApiHandler) Activate() http.HandlerFunc {
 		}
 		if len(activateRequest.Backends) != 0 {
 			for _, b := range activateRequest.Backends {
-				h.logger.Printf("Activating just one backend %s", b)
+				h.logger.Info("Activating just one backend", logs.Args{{"backend", b}})
 				backend, ok := h.backends[b]
 				if !ok {
-					h.logger.Printf("error-activating-backend%s", b)
+					h.logger.Error("error-activating-backend", logs.Args{{"backend", b}})
 					utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
 					return
 				}
 				activateResponse := backend.Activate(activateRequest)
 				if activateResponse.Error != nil {
-					h.logger.Printf("Error activating %s", activateResponse.Error.Error())
+					h.logger.Error("Error activating", logs.Args{{"error", activateResponse.Error}})
 					utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: activateResponse.Error.Error()})
 					return
 				}
 			}
 		} else {
 			var errors string
-			h.logger.Printf("Activating all backends")
+			h.logger.Info("Activating all backends")
 			errors = ""
 			for name, backend := range h.backends {
 				activateResponse := backend.Activate(activateRequest)
 				if activateResponse.Error != nil {
-					h.logger.Printf(fmt.Sprintf("Error activating %s %s", name, activateResponse.Error.Error()))
+					h.logger.Error("Error activating", logs.Args{{"backend", name}, {"error", activateResponse.Error}})
 					errors = fmt.Sprintf("%s,%s", errors, name)
 				}
 			}
@@ -83,7 +83,7 @@ func (h *StorageApiHandler) Activate() http.HandlerFunc {
 			}
 		}
 
-		h.logger.Println("Activate success (on server)")
+		h.logger.Info("Activate success (on server)")
 		utils.WriteResponse(w, http.StatusOK, nil)
 	}
 }
@@ -97,18 +97,41 @@ func (h *StorageApiHandler) CreateVolume() http.HandlerFunc {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
+		if err = model.ValidateLabels(createVolumeRequest.Labels); err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = model.ValidateAccessMode(createVolumeRequest.AccessMode); err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = model.ApplyStorageClass(h.config.StorageClasses, &createVolumeRequest); err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
 		if len(createVolumeRequest.Backend) == 0 {
 			createVolumeRequest.Backend = h.config.DefaultBackend
 		}
+		if len(createVolumeRequest.AccessMode) == 0 {
+			createVolumeRequest.AccessMode = resources.GetDefaultAccessMode(h.config.BackendType(createVolumeRequest.Backend))
+		}
 		backend, ok := h.backends[createVolumeRequest.Backend]
 		if !ok {
-			h.logger.Printf("error-backend-not-found%s", createVolumeRequest.Backend)
+			h.logger.Error("error-backend-not-found", logs.Args{{"backend", createVolumeRequest.Backend}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
 			return
 		}
 
-		h.locker.ReadLock(createVolumeRequest.Name) // will block if another caller is already in process of creating volume with same name
+		if !h.lockVolume(w, req, createVolumeRequest.Name, false) { // will wait if another caller is already in process of creating volume with same name
+			return
+		}
 		//TODO: err needs to be check for db connection issues
+		existingVolume, err := model.GetVolumeByName(h.database, createVolumeRequest.Name)
+		if err == nil && existingVolume.State == resources.VolumeStateDeleted {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: fmt.Sprintf("Volume `%s` was removed and is kept by the retention, undelete or purge it first", createVolumeRequest.Name)})
+			h.locker.ReadUnlock(createVolumeRequest.Name)
+			return
+		}
 		exists, _ := model.VolumeExists(h.database, createVolumeRequest.Name)
 		if exists == true {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: fmt.Sprintf("Volume `%s` already exists", createVolumeRequest.Name)})
@@ -117,13 +140,27 @@ func (h *StorageApiHandler) CreateVolume() http.HandlerFunc {
 		}
 		h.locker.ReadUnlock(createVolumeRequest.Name)
 
-		h.locker.WriteLock(createVolumeRequest.Name) // will ensure no other caller can create volume with same name concurrently
+		if !h.lockVolume(w, req, createVolumeRequest.Name, true) { // will ensure no other caller can create volume with same name concurrently
+			return
+		}
 		defer h.locker.WriteUnlock(createVolumeRequest.Name)
 		createVolumeResponse := backend.CreateVolume(createVolumeRequest)
 		if createVolumeResponse.Error != nil {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: createVolumeResponse.Error.Error()})
 			return
 		}
+		if err = h.setCreatedVolumeAccessMode(createVolumeRequest.Name, createVolumeRequest.AccessMode); err != nil {
+			h.logger.Error("Error setting access mode of volume", logs.Args{{"name", createVolumeRequest.Name}, {"error", err}})
+			h.rollbackCreatedVolume(backend, createVolumeRequest.Name)
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = h.setCreatedVolumeLabels(createVolumeRequest.Name, createVolumeRequest.Labels); err != nil {
+			h.logger.Error("Error setting labels of volume", logs.Args{{"name", createVolumeRequest.Name}, {"error", err}})
+			h.rollbackCreatedVolume(backend, createVolumeRequest.Name)
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
 		utils.WriteResponse(w, http.StatusOK, createVolumeResponse)
 	}
 }
@@ -139,18 +176,30 @@ func (h *StorageApiHandler) RemoveVolume() http.HandlerFunc {
 
 		backend, err := h.getBackend(removeVolumeRequest.Name)
 		if err != nil {
-			h.logger.Printf("error-backend-not-found-for-volume:%s", removeVolumeRequest.Name)
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", removeVolumeRequest.Name}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
 
-		h.locker.WriteLock(removeVolumeRequest.Name)
+		if !h.lockVolume(w, req, removeVolumeRequest.Name, true) {
+			return
+		}
 		defer h.locker.WriteUnlock(removeVolumeRequest.Name)
+		if h.config.RetentionConfig.Period > 0 {
+			if err = h.softDeleteVolume(removeVolumeRequest.Name); err != nil {
+				utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+				return
+			}
+			utils.WriteResponse(w, http.StatusOK, resources.RemoveVolumeResponse{})
+			return
+		}
+		volume, _ := model.GetVolumeByName(h.database, removeVolumeRequest.Name)
 		removeVolumeResponse := backend.RemoveVolume(removeVolumeRequest)
 		if removeVolumeResponse.Error != nil {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: removeVolumeResponse.Error.Error()})
 			return
 		}
+		h.deleteRemovedVolumeLabels(volume)
 		utils.WriteResponse(w, http.StatusOK, removeVolumeResponse)
 	}
 }
@@ -166,14 +215,18 @@ func (h *StorageApiHandler) AttachVolume() http.HandlerFunc {
 
 		backend, err := h.getBackend(attachRequest.Name)
 		if err != nil {
-			h.logger.Printf("error-backend-not-found-for-volume:%s", attachRequest.Name)
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", attachRequest.Name}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
 
-		h.locker.WriteLock(attachRequest.Name)
+		if !h.lockVolume(w, req, attachRequest.Name, true) {
+			return
+		}
 		defer h.locker.WriteUnlock(attachRequest.Name)
+		record := h.startVolumeOperation(attachRequest.Name, resources.VolumeOperationAttach, attachRequest.Host)
 		attachVolumeResponse := backend.Attach(attachRequest)
+		h.finishVolumeOperation(record, attachVolumeResponse.Mountpoint, attachVolumeResponse.Error)
 		if attachVolumeResponse.Error != nil {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: attachVolumeResponse.Error.Error()})
 			return
@@ -194,14 +247,18 @@ func (h *StorageApiHandler) DetachVolume() http.HandlerFunc {
 
 		backend, err := h.getBackend(detachRequest.Name)
 		if err != nil {
-			h.logger.Printf("error-backend-not-found-for-volume:%s", detachRequest.Name)
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", detachRequest.Name}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
 
-		h.locker.WriteLock(detachRequest.Name)
+		if !h.lockVolume(w, req, detachRequest.Name, true) {
+			return
+		}
 		defer h.locker.WriteUnlock(detachRequest.Name)
+		record := h.startVolumeOperation(detachRequest.Name, resources.VolumeOperationDetach, detachRequest.Host)
 		detachResponse := backend.Detach(detachRequest)
+		h.finishVolumeOperation(record, "", detachResponse.Error)
 		if detachResponse.Error != nil {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: detachResponse.Error.Error()})
 			return
@@ -221,12 +278,14 @@ func (h *StorageApiHandler) GetVolumeConfig() http.HandlerFunc {
 
 		backend, err := h.getBackend(getVolumeConfigRequest.Name)
 		if err != nil {
-			h.logger.Printf("error-backend-not-found-for-volume:%s", getVolumeConfigRequest.Name)
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", getVolumeConfigRequest.Name}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
 
-		h.locker.WriteLock(getVolumeConfigRequest.Name)
+		if !h.lockVolume(w, req, getVolumeConfigRequest.Name, true) {
+			return
+		}
 		defer h.locker.WriteUnlock(getVolumeConfigRequest.Name)
 
 		getVolumeConfigResponse := backend.GetVolumeConfig(getVolumeConfigRequest)
@@ -251,12 +310,14 @@ func (h *StorageApiHandler) GetVolume() http.HandlerFunc {
 
 		backend, err := h.getBackend(getVolumeRequest.Name)
 		if err != nil {
-			h.logger.Printf("error-backend-not-found-for-volume:%s", getVolumeRequest.Name)
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", getVolumeRequest.Name}})
 			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
 
-		h.locker.WriteLock(getVolumeRequest.Name)
+		if !h.lockVolume(w, req, getVolumeRequest.Name, true) {
+			return
+		}
 		defer h.locker.WriteUnlock(getVolumeRequest.Name)
 
 		getVolumeResponse := backend.GetVolume(getVolumeRequest)
@@ -264,6 +325,11 @@ func (h *StorageApiHandler) GetVolume() http.HandlerFunc {
 			utils.WriteResponse(w, 409, getVolumeResponse.Error)
 			return
 		}
+		if volume, err := model.GetVolumeByName(h.database, getVolumeRequest.Name); err == nil {
+			getVolumeResponse.Volume.Labels, _ = model.GetVolumeLabels(h.database, volume.ID)
+			getVolumeResponse.Volume.AccessMode = volume.AccessMode
+			getVolumeResponse.Volume.Attachments, _ = model.GetVolumeAttachments(h.database, volume.ID)
+		}
 
 		utils.WriteResponse(w, http.StatusOK, getVolumeResponse)
 	}
@@ -278,6 +344,11 @@ func (h *StorageApiHandler) ListVolumes() http.HandlerFunc {
 			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
 			return
 		}
+		selector, err := model.ParseLabelSelector(listVolumesRequest.LabelSelector)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
 		var listVolumesResponse resources.ListVolumesResponse
 		var volumes []resources.Volume
 		if len(listVolumesRequest.Backends) != 0 {
@@ -285,21 +356,19 @@ func (h *StorageApiHandler) ListVolumes() http.HandlerFunc {
 			for _, b := range listVolumesRequest.Backends {
 				backend, ok := h.backends[b]
 				if !ok {
-					h.logger.Printf("error-backend-not-found%s", b)
+					h.logger.Error("error-backend-not-found", logs.Args{{"backend", b}})
 					utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "backend-not-found"})
 					return
 				}
 				listVolumesResponse = backend.ListVolumes(listVolumesRequest)
 				if listVolumesResponse.Error != nil {
-					h.logger.Printf("Error listing volume %s", listVolumesResponse.Error.Error())
+					h.logger.Error("Error listing volume", logs.Args{{"error", listVolumesResponse.Error}})
 					utils.WriteResponse(w, 409, &resources.GenericResponse{Err: listVolumesResponse.Error.Error()})
 					return
 				}
 				volumes = append(volumes, listVolumesResponse.Volumes...)
 			}
-			listResponse := resources.ListVolumesResponse{Volumes: volumes}
-			h.logger.Printf("List response: %#v\n", listResponse)
-			utils.WriteResponse(w, http.StatusOK, listResponse)
+			h.writeListVolumesResponse(w, volumes, listVolumesRequest.Deleted, selector)
 			return
 
 		}
@@ -307,7 +376,7 @@ func (h *StorageApiHandler) ListVolumes() http.HandlerFunc {
 		for _, backend := range h.backends {
 			listVolumesResponse := backend.ListVolumes(listVolumesRequest)
 			if listVolumesResponse.Error != nil {
-				h.logger.Printf("Error listing volume %s", listVolumesResponse.Error.Error())
+				h.logger.Error("Error listing volume", logs.Args{{"error", listVolumesResponse.Error}})
 				utils.WriteResponse(w, 409, &resources.GenericResponse{Err: listVolumesResponse.Error.Error()})
 				return
 			}
@@ -315,22 +384,50 @@ func (h *StorageApiHandler) ListVolumes() http.HandlerFunc {
 
 		}
 
-		listResponse := resources.ListVolumesResponse{Volumes: volumes}
-		h.logger.Printf("List response: %#v\n", listResponse)
-		utils.WriteResponse(w, http.StatusOK, listResponse)
+		h.writeListVolumesResponse(w, volumes, listVolumesRequest.Deleted, selector)
 	}
 }
 
-func (h *StorageApiHandler) getBackend(name string) (resources.StorageClient, error) {
+func (h *StorageApiHandler) writeListVolumesResponse(w http.ResponseWriter, volumes []resources.Volume, deleted bool, selector model.LabelSelector) {
+	selected, err := h.selectVolumesByLabels(filterDeletedVolumes(volumes, deleted), selector)
+	if err != nil {
+		h.logger.Error("Error reading volume labels", logs.Args{{"error", err}})
+		utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+		return
+	}
+	listResponse := resources.ListVolumesResponse{Volumes: selected}
+	h.logger.Debug("List response", logs.Args{{"listResponse", listResponse}})
+	utils.WriteResponse(w, http.StatusOK, listResponse)
+}
 
-	backendName, err := model.GetBackendForVolume(h.database, name)
+// setCreatedVolumeAccessMode must be called with the volume lock held, after the backend created the volume
+func (h *StorageApiHandler) setCreatedVolumeAccessMode(name string, accessMode string) error {
+	volume, err := model.GetVolumeByName(h.database, name)
 	if err != nil {
+		return err
+	}
+	return model.UpdateVolumeAccessMode(h.database, &volume, accessMode)
+}
+
+// rollbackCreatedVolume removes a volume the backend created when the rest of the create failed, so that the create can be retried.
+// It must be called with the volume write lock held, the failure is only logged
+func (h *StorageApiHandler) rollbackCreatedVolume(backend resources.StorageClient, name string) {
+	if removeVolumeResponse := backend.RemoveVolume(resources.RemoveVolumeRequest{Name: name}); removeVolumeResponse.Error != nil {
+		h.logger.Error("Error removing volume after failed create", logs.Args{{"name", name}, {"error", removeVolumeResponse.Error}})
+	}
+}
+
+func (h *StorageApiHandler) getBackend(name string) (resources.StorageClient, error) {
+
+	volume, err := model.GetVolumeByName(h.database, name)
+	if err != nil || volume.State == resources.VolumeStateDeleted {
 		return nil, fmt.Errorf("Volume not found")
 	}
+	backendName := volume.Backend
 
 	backend, exists := h.backends[backendName]
 	if !exists {
-		h.logger.Printf("Cannot find backend %s", backendName)
+		h.logger.Error("Cannot find backend", logs.Args{{"backendName", backendName}})
 		return nil, fmt.Errorf("Cannot find backend %s", backend)
 	}
 	return backend, nil
@@ -0,0 +1,71 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"net/http"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// VolumeHistory returns the attach and detach records of a volume, including the ones of removed volumes with the same name
+func (h *StorageApiHandler) VolumeHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := utils.ExtractVarsFromRequest(req, "volume")
+		records, err := model.ListVolumeOperations(h.database, name)
+		if err != nil {
+			h.logger.Error("Error reading history of volume", logs.Args{{"name", name}, {"error", err}})
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if len(records) == 0 {
+			if exists, _ := model.VolumeExists(h.database, name); !exists {
+				utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "Volume not found"})
+				return
+			}
+		}
+		utils.WriteResponse(w, http.StatusOK, resources.VolumeHistoryResponse{History: records})
+	}
+}
+
+// startVolumeOperation must be called with the volume lock held.
+// The history is informational, so failing to record it is logged and does not fail the operation.
+func (h *StorageApiHandler) startVolumeOperation(name, operation, host string) *resources.VolumeOperationRecord {
+	volume, err := model.GetVolumeByName(h.database, name)
+	if err != nil {
+		h.logger.Error("Error recording operation of volume", logs.Args{{"operation", operation}, {"volume", name}, {"error", err}})
+		return nil
+	}
+	record, err := model.StartVolumeOperation(h.database, volume, operation, host, volume.Mountpoint)
+	if err != nil {
+		h.logger.Error("Error recording operation of volume", logs.Args{{"operation", operation}, {"volume", name}, {"error", err}})
+		return nil
+	}
+	return record
+}
+
+func (h *StorageApiHandler) finishVolumeOperation(record *resources.VolumeOperationRecord, mountpoint string, opErr error) {
+	if record == nil {
+		return
+	}
+	if err := model.FinishVolumeOperation(h.database, record, mountpoint, opErr); err != nil {
+		h.logger.Error("Error recording the end of operation of volume", logs.Args{{"operation", record.Operation}, {"volume", record.VolumeName}, {"error", err}})
+	}
+}
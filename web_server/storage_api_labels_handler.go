@@ -0,0 +1,110 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"net/http"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// Labels are kept by the server in the volume_labels table, the backends never see them.
+
+// PatchVolumeLabels sets or removes labels of a volume, the request is a JSON merge patch of the labels
+func (h *StorageApiHandler) PatchVolumeLabels() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		patchRequest := resources.PatchVolumeLabelsRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &patchRequest)
+		if err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		for key, value := range patchRequest.Labels {
+			if err = model.ValidateLabelKey(key); err == nil && value != nil {
+				err = model.ValidateLabelValue(*value)
+			}
+			if err != nil {
+				utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: err.Error()})
+				return
+			}
+		}
+
+		if !h.lockVolume(w, req, patchRequest.Name, true) {
+			return
+		}
+		defer h.locker.WriteUnlock(patchRequest.Name)
+		volume, err := model.GetVolumeByName(h.database, patchRequest.Name)
+		if err != nil || volume.State == resources.VolumeStateDeleted {
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: "Volume not found"})
+			return
+		}
+		if err = model.PatchVolumeLabels(h.database, volume.ID, patchRequest.Labels); err != nil {
+			h.logger.Error("Error patching labels of volume", logs.Args{{"name", patchRequest.Name}, {"error", err}})
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		labels, err := model.GetVolumeLabels(h.database, volume.ID)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		h.logger.Info("Labels of volume patched", logs.Args{{"volume", patchRequest.Name}})
+		utils.WriteResponse(w, http.StatusOK, resources.PatchVolumeLabelsResponse{Labels: labels})
+	}
+}
+
+// setCreatedVolumeLabels must be called with the volume lock held, after the backend created the volume
+func (h *StorageApiHandler) setCreatedVolumeLabels(name string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	volume, err := model.GetVolumeByName(h.database, name)
+	if err != nil {
+		return err
+	}
+	return model.SetVolumeLabels(h.database, volume.ID, labels)
+}
+
+// deleteRemovedVolumeLabels drops the labels of a volume the backend removed
+func (h *StorageApiHandler) deleteRemovedVolumeLabels(volume resources.Volume) {
+	if err := model.DeleteVolumeLabels(h.database, volume.ID); err != nil {
+		h.logger.Error("Error deleting labels of volume", logs.Args{{"name", volume.Name}, {"error", err}})
+	}
+}
+
+// selectVolumesByLabels fills the labels of the volumes and keeps the ones matching the selector
+func (h *StorageApiHandler) selectVolumesByLabels(volumes []resources.Volume, selector model.LabelSelector) ([]resources.Volume, error) {
+	ids := make([]uint, 0, len(volumes))
+	for _, volume := range volumes {
+		ids = append(ids, volume.ID)
+	}
+	labels, err := model.GetLabelsForVolumes(h.database, ids)
+	if err != nil {
+		return nil, err
+	}
+	var selected []resources.Volume
+	for _, volume := range volumes {
+		volume.Labels = labels[volume.ID]
+		if selector.Matches(volume.Labels) {
+			selected = append(selected, volume)
+		}
+	}
+	return selected, nil
+}
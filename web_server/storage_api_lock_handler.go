@@ -0,0 +1,57 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// lockVolume takes the volume lock of a request, waiting at most LockConfig.WaitTimeout or until the client goes away.
+// If the volume stays busy it writes a 423 response and returns false, so the requests on a hung volume do not pile up.
+func (h *StorageApiHandler) lockVolume(w http.ResponseWriter, req *http.Request, name string, write bool) bool {
+	err := utils.LockWithTimeout(req.Context(), h.locker, name, write, h.lockWaitTimeout())
+	if err == nil {
+		return true
+	}
+	h.logger.Error("Volume is busy", logs.Args{{"volume", name}, {"error", err}})
+	utils.WriteResponse(w, http.StatusLocked, &resources.GenericResponse{Err: fmt.Sprintf("Volume `%s` is busy with another operation, try again later", name)})
+	return false
+}
+
+// lockVolumeInBackground takes the volume lock for the background jobs, which have no request to answer
+func (h *StorageApiHandler) lockVolumeInBackground(name string) error {
+	return utils.LockWithTimeout(context.Background(), h.locker, name, true, h.lockWaitTimeout())
+}
+
+// lockWaitTimeout returns how long to wait for a busy volume, 0 means forever
+func (h *StorageApiHandler) lockWaitTimeout() time.Duration {
+	waitTimeout := h.config.LockConfig.WaitTimeout
+	if waitTimeout == 0 {
+		waitTimeout = resources.DefaultLockWaitTimeout
+	}
+	if waitTimeout < 0 {
+		return 0
+	}
+	return time.Duration(waitTimeout) * time.Second
+}
@@ -0,0 +1,66 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// UpdateVolumeQos changes the QoS limits of a volume of a backend that implements resources.VolumeQosUpdater
+func (h *StorageApiHandler) UpdateVolumeQos() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		updateRequest := resources.UpdateVolumeQosRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &updateRequest)
+		if err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+
+		backend, err := h.getBackend(updateRequest.Name)
+		if err != nil {
+			h.logger.Error("error-backend-not-found-for-volume", logs.Args{{"name", updateRequest.Name}})
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		updater, ok := backend.(resources.VolumeQosUpdater)
+		if !ok {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: fmt.Sprintf("backend of volume %s does not support qos", updateRequest.Name)})
+			return
+		}
+		if updateRequest.MaxIops < 0 || updateRequest.MaxMbps < 0 {
+			utils.WriteResponse(w, http.StatusBadRequest, &resources.GenericResponse{Err: "the qos limits cannot be negative"})
+			return
+		}
+
+		if !h.lockVolume(w, req, updateRequest.Name, true) {
+			return
+		}
+		defer h.locker.WriteUnlock(updateRequest.Name)
+		if err = updater.UpdateVolumeQos(updateRequest); err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+
+		h.logger.Info("QoS of volume updated", logs.Args{{"volume", updateRequest.Name}, {"maxIops", updateRequest.MaxIops}, {"maxMbps", updateRequest.MaxMbps}})
+		utils.WriteResponse(w, http.StatusOK, &resources.GenericResponse{})
+	}
+}
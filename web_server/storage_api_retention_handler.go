@@ -0,0 +1,169 @@
+/**
+ * Copyright 2017 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web_server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/midoblgsm/ubiquity/model"
+	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+)
+
+// While retention is enabled, removing a volume only moves it to the deleted state and keeps its storage.
+// A deleted volume is hidden from the other requests until it is undeleted, or purged by the request or the background purger.
+
+func (h *StorageApiHandler) UndeleteVolume() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		undeleteRequest := resources.UndeleteVolumeRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &undeleteRequest)
+		if err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+
+		if !h.lockVolume(w, req, undeleteRequest.Name, true) {
+			return
+		}
+		defer h.locker.WriteUnlock(undeleteRequest.Name)
+		volume, err := h.getDeletedVolume(undeleteRequest.Name)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = model.UndeleteVolume(h.database, &volume); err != nil {
+			h.logger.Error("Error undeleting volume", logs.Args{{"name", undeleteRequest.Name}, {"error", err}})
+			utils.WriteResponse(w, http.StatusInternalServerError, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		h.logger.Info("Volume undeleted", logs.Args{{"volume", undeleteRequest.Name}})
+		utils.WriteResponse(w, http.StatusOK, nil)
+	}
+}
+
+// PurgeVolume deletes the storage of a deleted volume without waiting for the retention period to end
+func (h *StorageApiHandler) PurgeVolume() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		purgeRequest := resources.PurgeVolumeRequest{}
+		err := utils.UnmarshalDataFromRequest(req, &purgeRequest)
+		if err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+
+		if !h.lockVolume(w, req, purgeRequest.Name, true) {
+			return
+		}
+		defer h.locker.WriteUnlock(purgeRequest.Name)
+		volume, err := h.getDeletedVolume(purgeRequest.Name)
+		if err != nil {
+			utils.WriteResponse(w, http.StatusNotFound, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		if err = h.purgeVolume(volume); err != nil {
+			utils.WriteResponse(w, 409, &resources.GenericResponse{Err: err.Error()})
+			return
+		}
+		utils.WriteResponse(w, http.StatusOK, nil)
+	}
+}
+
+// RunPeriodicPurge purges every interval the volumes deleted more than period ago, until the server exits
+func (h *StorageApiHandler) RunPeriodicPurge(interval time.Duration, period time.Duration) {
+	for {
+		time.Sleep(interval)
+		volumes, err := model.ListVolumesRemovedBefore(h.database, time.Now().Add(-period))
+		if err != nil {
+			h.logger.Error("Error listing the volumes to purge", logs.Args{{"error", err}})
+			continue
+		}
+		for _, volume := range volumes {
+			h.purgeExpiredVolume(volume.Name)
+		}
+	}
+}
+
+func (h *StorageApiHandler) purgeExpiredVolume(name string) {
+	// a busy volume is purged by the next run
+	if !h.locker.TryWriteLock(name) {
+		h.logger.Info("Volume is busy, skipping its purge", logs.Args{{"volume", name}})
+		return
+	}
+	defer h.locker.WriteUnlock(name)
+	// check again under the lock, the volume may have been undeleted meanwhile
+	volume, err := h.getDeletedVolume(name)
+	if err != nil {
+		return
+	}
+	if err = h.purgeVolume(volume); err != nil {
+		h.logger.Error("Error purging volume", logs.Args{{"name", name}, {"error", err}})
+	}
+}
+
+// softDeleteVolume must be called with the volume lock held
+func (h *StorageApiHandler) softDeleteVolume(name string) error {
+	volume, err := model.GetVolumeByName(h.database, name)
+	if err != nil {
+		return err
+	}
+	if volume.State == resources.VolumeStateAttached || model.IsVolumeStateTransitional(volume.State) {
+		return fmt.Errorf("Volume %s is %s, it can be removed only when it is available", name, volume.State)
+	}
+	if err = model.SoftDeleteVolume(h.database, &volume, time.Now()); err != nil {
+		h.logger.Error("Error soft deleting volume", logs.Args{{"name", name}, {"error", err}})
+		return err
+	}
+	h.logger.Info("Volume deleted, it is kept for the retention period", logs.Args{{"volume", name}, {"retention", h.config.RetentionConfig.Period}})
+	return nil
+}
+
+// purgeVolume must be called with the volume lock held
+func (h *StorageApiHandler) purgeVolume(volume resources.Volume) error {
+	backend, exists := h.backends[volume.Backend]
+	if !exists {
+		return fmt.Errorf("Cannot find backend %s", volume.Backend)
+	}
+	removeVolumeResponse := backend.RemoveVolume(resources.RemoveVolumeRequest{Name: volume.Name})
+	if removeVolumeResponse.Error != nil {
+		return removeVolumeResponse.Error
+	}
+	h.deleteRemovedVolumeLabels(volume)
+	h.logger.Info("Volume purged", logs.Args{{"volume", volume.Name}})
+	return nil
+}
+
+func (h *StorageApiHandler) getDeletedVolume(name string) (resources.Volume, error) {
+	volume, err := model.GetVolumeByName(h.database, name)
+	if err != nil || volume.State != resources.VolumeStateDeleted {
+		return resources.Volume{}, fmt.Errorf("Deleted volume %s not found", name)
+	}
+	return volume, nil
+}
+
+// filterDeletedVolumes keep only the deleted volumes if deleted is true, or only the other volumes if it is false
+func filterDeletedVolumes(volumes []resources.Volume, deleted bool) []resources.Volume {
+	var filtered []resources.Volume
+	for _, volume := range volumes {
+		if (volume.State == resources.VolumeStateDeleted) == deleted {
+			filtered = append(filtered, volume)
+		}
+	}
+	return filtered
+}
@@ -18,22 +18,27 @@ package web_server
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"time"
 
 	"github.com/midoblgsm/ubiquity/resources"
+	"github.com/midoblgsm/ubiquity/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
+	"github.com/midoblgsm/ubiquity/utils/logs"
+	"github.com/midoblgsm/ubiquity/utils/tracing"
 )
 
 type StorageApiServer struct {
 	storageApiHandler *StorageApiHandler
-	logger            *log.Logger
+	logger            logs.Logger
+	config            resources.UbiquityServerConfig
 }
 
-func NewStorageApiServer(logger *log.Logger, backends map[string]resources.StorageClient, config resources.UbiquityServerConfig, database *gorm.DB) (*StorageApiServer, error) {
-	return &StorageApiServer{storageApiHandler: NewStorageApiHandler(logger, backends, database, config), logger: logger}, nil
+// NewStorageApiServer creates the server, locker holds the volume locks of the handlers
+func NewStorageApiServer(backends map[string]resources.StorageClient, config resources.UbiquityServerConfig, database *gorm.DB, locker utils.Locker) (*StorageApiServer, error) {
+	return &StorageApiServer{storageApiHandler: NewStorageApiHandler(backends, database, config, locker), logger: logs.GetComponentLogger(logs.ComponentWebServer), config: config}, nil
 }
 
 func (s *StorageApiServer) InitializeHandler() http.Handler {
@@ -46,13 +51,53 @@ func (s *StorageApiServer) InitializeHandler() http.Handler {
 	router.HandleFunc("/ubiquity_storage/volumes/{volume}/detach", s.storageApiHandler.DetachVolume()).Methods("PUT")
 	router.HandleFunc("/ubiquity_storage/volumes/{volume}", s.storageApiHandler.GetVolume()).Methods("GET")
 	router.HandleFunc("/ubiquity_storage/volumes/{volume}/config", s.storageApiHandler.GetVolumeConfig()).Methods("GET")
-	return router
+	router.HandleFunc("/ubiquity_storage/volumes/{volume}/qos", s.storageApiHandler.UpdateVolumeQos()).Methods("PUT")
+	router.HandleFunc("/ubiquity_storage/volumes/{volume}/undelete", s.storageApiHandler.UndeleteVolume()).Methods("PUT")
+	router.HandleFunc("/ubiquity_storage/volumes/{volume}/purge", s.storageApiHandler.PurgeVolume()).Methods("DELETE")
+	router.HandleFunc("/ubiquity_storage/volumes/{volume}/labels", s.storageApiHandler.PatchVolumeLabels()).Methods("PATCH")
+	router.HandleFunc("/ubiquity_storage/volumes/{volume}/history", s.storageApiHandler.VolumeHistory()).Methods("GET")
+	router.HandleFunc("/ubiquity_storage/backends", s.storageApiHandler.ListBackends()).Methods("GET")
+	router.HandleFunc("/ubiquity_storage/backends/{name}/pools", s.storageApiHandler.ListPools()).Methods("GET")
+	router.HandleFunc("/ubiquity_storage/admin/inventory", s.storageApiHandler.ExportInventory()).Methods("GET")
+	router.HandleFunc("/ubiquity_storage/admin/inventory", s.storageApiHandler.ImportInventory()).Methods("POST")
+	router.HandleFunc("/ubiquity_storage/admin/adopt", s.storageApiHandler.Adopt()).Methods("POST")
+	router.HandleFunc("/ubiquity_storage/admin/reconcile", s.storageApiHandler.Reconcile()).Methods("POST")
+	router.HandleFunc("/ubiquity_storage/admin/log-levels", s.storageApiHandler.GetLogLevels()).Methods("GET")
+	router.HandleFunc("/ubiquity_storage/admin/log-levels", s.storageApiHandler.SetLogLevel()).Methods("PUT")
+	return utils.RequestIDHandler(tracing.HTTPHandler(routeSpanName(router), router))
+}
+
+// routeSpanName names the server spans after the route template, so the spans of all the volumes group together
+func routeSpanName(router *mux.Router) func(req *http.Request) string {
+	return func(req *http.Request) string {
+		var match mux.RouteMatch
+		if router.Match(req, &match) && match.Route != nil {
+			if template, err := match.Route.GetPathTemplate(); err == nil {
+				return req.Method + " " + template
+			}
+		}
+		return req.Method
+	}
 }
 
 func (s *StorageApiServer) Start(port int) error {
 	router := s.InitializeHandler()
 	http.Handle("/", router)
 
+	if s.config.ReconcileConfig.Interval > 0 {
+		s.logger.Info("Starting background reconcile", logs.Args{{"interval", s.config.ReconcileConfig.Interval}, {"repair", s.config.ReconcileConfig.Repair}})
+		go s.storageApiHandler.RunPeriodicReconcile(time.Duration(s.config.ReconcileConfig.Interval)*time.Second, s.config.ReconcileConfig.Repair)
+	}
+
+	if s.config.RetentionConfig.Period > 0 {
+		purgeInterval := s.config.RetentionConfig.PurgeInterval
+		if purgeInterval <= 0 {
+			purgeInterval = resources.DefaultPurgeInterval
+		}
+		s.logger.Info("Starting background purge", logs.Args{{"interval", purgeInterval}, {"retention", s.config.RetentionConfig.Period}})
+		go s.storageApiHandler.RunPeriodicPurge(time.Duration(purgeInterval)*time.Second, time.Duration(s.config.RetentionConfig.Period)*time.Second)
+	}
+
 	fmt.Println(fmt.Sprintf("Starting Storage API server on port %d ....", port))
 	fmt.Println("CTL-C to exit/stop Storage API server service")
 	return http.ListenAndServe(fmt.Sprintf(":%d", port), nil)